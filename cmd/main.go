@@ -1,15 +1,24 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"os"
 
+	"github.com/EugeneKrivoshein/fin_service/config"
 	_ "github.com/EugeneKrivoshein/fin_service/docs"
+	"github.com/EugeneKrivoshein/fin_service/internal/admin"
 	route "github.com/EugeneKrivoshein/fin_service/internal/api"
+	"github.com/EugeneKrivoshein/fin_service/internal/auth"
+	"github.com/EugeneKrivoshein/fin_service/internal/fx"
 	handler "github.com/EugeneKrivoshein/fin_service/internal/handlers"
+	"github.com/EugeneKrivoshein/fin_service/internal/outbox"
 	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
 	repo "github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	"github.com/EugeneKrivoshein/fin_service/internal/ratelimit"
 	service "github.com/EugeneKrivoshein/fin_service/internal/services"
+	"github.com/EugeneKrivoshein/fin_service/internal/webhook"
 )
 
 // @title Финансовый сервис API
@@ -17,17 +26,81 @@ import (
 // @description API для управления балансом и переводами денег
 // @host localhost:8080
 // @BasePath /
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT в формате "Bearer <token>"; субъект токена (sub) — ID пользователя
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API-ключ сервиса; допускается только к роутам областей доступа ключа
 func main() {
+	cfg, err := config.LoadConfig("config.env")
+	if err != nil {
+		log.Fatalf("Ошибка загрузки конфигурации: %v", err)
+	}
+
 	pgxProvider, err := postgres.NewPGXProvider()
 	if err != nil {
 		log.Fatalf("Ошибка создания подключения к БД: %v", err)
 	}
 	defer pgxProvider.Close()
 
+	opts := []service.Option{service.WithWebhooks(webhook.NewSender(service.DefaultWebhookTimeout))}
+	if cfg.FXRatesFile != "" {
+		rates, err := fx.NewFileProvider(cfg.FXRatesFile)
+		if err != nil {
+			log.Fatalf("Ошибка загрузки курсов валют: %v", err)
+		}
+		opts = append(opts, service.WithFXProvider(rates, cfg.FXQuoteTTL))
+	}
+
+	// События outbox публикуются построчно в JSON в файл или stdout
+	outboxOut := os.Stdout
+	if cfg.OutboxFile != "" {
+		outboxOut, err = os.OpenFile(cfg.OutboxFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			log.Fatalf("Ошибка открытия файла событий outbox: %v", err)
+		}
+		defer outboxOut.Close()
+	}
+	opts = append(opts, service.WithOutboxPublisher(outbox.NewWriterPublisher(outboxOut)))
+
 	repository := repo.NewRepository(pgxProvider.Pool)
-	serviceLayer := service.NewService(repository)
+	serviceLayer := service.NewService(repository, opts...)
 	handlerLayer := handler.NewHandler(serviceLayer)
-	router := route.SetupRouter(handlerLayer)
+	adminLayer := admin.NewHandler(serviceLayer)
+
+	// Первый администратор назначается через ADMIN_USERNAMES; повторная выдача роли ничего не меняет
+	for _, name := range cfg.AdminUsernames {
+		u, err := serviceLayer.EnsureAdmin(context.Background(), name)
+		if err != nil {
+			log.Fatalf("Ошибка назначения администратора %q из ADMIN_USERNAMES: %v", name, err)
+		}
+		log.Printf("Пользователю %s (id %d) выдана роль admin (ADMIN_USERNAMES)", u.Username, u.ID)
+	}
+
+	// Фоновые задачи работают, пока работает сервер
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go serviceLayer.RunHoldExpiry(ctx, cfg.HoldExpiryInterval)
+	go serviceLayer.RunScheduler(ctx, cfg.SchedulerInterval)
+	go serviceLayer.RunWebhookWorker(ctx, cfg.WebhookInterval)
+	go serviceLayer.RunOutboxRelay(ctx, cfg.OutboxInterval)
+
+	verifier, err := auth.NewVerifier(cfg.JWTKeys, cfg.JWTIssuer, cfg.JWTAudience)
+	if err != nil {
+		log.Fatalf("Ошибка настройки аутентификации (JWT_SECRET или JWT_KEYS): %v", err)
+	}
+
+	// Корзины лимитов хранятся в памяти: при нескольких экземплярах сервиса лимиты действуют в каждом отдельно
+	limiter := handler.NewRateLimiter(ratelimit.NewMemoryStore(), cfg.RateLimits)
+
+	router := route.SetupRouter(handlerLayer, adminLayer, verifier, limiter)
+	// Адрес клиента для журнала аудита и лимитов по адресу берётся из X-Forwarded-For только от доверенных прокси
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Ошибка настройки доверенных прокси (TRUSTED_PROXIES): %v", err)
+	}
 
 	if err := http.ListenAndServe(":8080", router); err != nil {
 		log.Fatalf("Ошибка при запуске сервера: %v", err)
@@ -0,0 +1,85 @@
+// Команда reconcile пересчитывает балансы всех счетов пользователей по истории транзакций
+// (с учётом начальных остатков) и выводит расхождения с accounts.balance в JSON или CSV.
+//
+//	go run ./cmd/reconcile -format csv -output report.csv
+//	go run ./cmd/reconcile -fix
+//
+// Код возврата: 0 — расхождений нет (или все исправлены с -fix), 1 — найдены расхождения, 2 — ошибка.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	service "github.com/EugeneKrivoshein/fin_service/internal/services"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	format := flag.String("format", "json", "формат отчёта: json или csv")
+	output := flag.String("output", "", "файл для отчёта (по умолчанию stdout)")
+	fix := flag.Bool("fix", false, "исправить расхождения корректирующими проводками")
+	flag.Parse()
+
+	if *format != "json" && *format != "csv" {
+		log.Printf("Неизвестный формат отчёта %q", *format)
+		return 2
+	}
+
+	pgxProvider, err := postgres.NewPGXProvider()
+	if err != nil {
+		log.Printf("Ошибка создания подключения к БД: %v", err)
+		return 2
+	}
+	defer pgxProvider.Close()
+
+	serviceLayer := service.NewService(postgres.NewRepository(pgxProvider.Pool))
+
+	// При ошибке исправления отчёт всё равно выводится: в нём видно, что уже исправлено
+	report, reconcileErr := serviceLayer.Reconcile(context.Background(), *fix)
+	if report == nil {
+		log.Printf("Ошибка сверки: %v", reconcileErr)
+		return 2
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			log.Printf("Ошибка создания файла отчёта: %v", err)
+			return 2
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if *format == "csv" {
+		err = service.WriteReconciliationCSV(w, report)
+	} else {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	}
+	if err != nil {
+		log.Printf("Ошибка записи отчёта: %v", err)
+		return 2
+	}
+
+	log.Printf("Проверено счетов: %d, расхождений: %d", report.AccountsChecked, len(report.Mismatches))
+	switch {
+	case reconcileErr != nil:
+		log.Printf("Ошибка сверки: %v", reconcileErr)
+		return 2
+	case len(report.Mismatches) > 0 && !*fix:
+		return 1
+	}
+	return 0
+}
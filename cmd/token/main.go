@@ -0,0 +1,53 @@
+// Команда token выпускает JWT для обращения к API от имени пользователя — для разработки и отладки.
+// Ключ подписи, iss и aud берутся из той же конфигурации, что и у сервиса.
+//
+//	go run ./cmd/token -user 1 -ttl 24h
+//	curl -H "Authorization: Bearer $(go run ./cmd/token -user 1)" localhost:8080/users/1/balance
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/config"
+	"github.com/EugeneKrivoshein/fin_service/internal/auth"
+)
+
+func main() {
+	userID := flag.Int64("user", 0, "ID пользователя — субъект токена")
+	ttl := flag.Duration("ttl", time.Hour, "срок действия токена")
+	kid := flag.String("kid", "", "идентификатор ключа из JWT_KEYS; пусто — ключ JWT_SECRET")
+	flag.Parse()
+
+	if *userID <= 0 {
+		log.Fatal("Укажите ID пользователя: -user <id>")
+	}
+
+	cfg, err := config.LoadConfig("config.env")
+	if err != nil {
+		log.Fatalf("Ошибка загрузки конфигурации: %v", err)
+	}
+	key, ok := cfg.JWTKeys[*kid]
+	if !ok {
+		log.Fatalf("Ключ %q не задан в JWT_SECRET или JWT_KEYS", *kid)
+	}
+
+	now := time.Now()
+	claims := &auth.Claims{
+		Subject:   strconv.FormatInt(*userID, 10),
+		Issuer:    cfg.JWTIssuer,
+		ExpiresAt: now.Add(*ttl).Unix(),
+		IssuedAt:  now.Unix(),
+	}
+	if cfg.JWTAudience != "" {
+		claims.Audience = auth.Audience{cfg.JWTAudience}
+	}
+	token, err := auth.Sign(claims, *kid, key)
+	if err != nil {
+		log.Fatalf("Ошибка подписи токена: %v", err)
+	}
+	fmt.Println(token)
+}
@@ -1,9 +1,13 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/EugeneKrivoshein/fin_service/internal/ratelimit"
 	"github.com/joho/godotenv"
 )
 
@@ -14,6 +18,42 @@ type Config struct {
 	DBPass        string
 	DBName        string
 	ServerAddress string
+
+	// Файл с курсами валют для котировок; если не задан, переводы с конвертацией недоступны
+	FXRatesFile string
+	// Время жизни котировки; ноль — значение по умолчанию
+	FXQuoteTTL time.Duration
+
+	// Период фоновой проверки истёкших холдов
+	HoldExpiryInterval time.Duration
+	// Период проверки наступивших запланированных переводов
+	SchedulerInterval time.Duration
+	// Период отправки событий вебхуков, время доставки которых наступило
+	WebhookInterval time.Duration
+
+	// Файл, в который публикуются события outbox (JSON Lines); если не задан — stdout
+	OutboxFile string
+	// Период публикации событий outbox
+	OutboxInterval time.Duration
+
+	// Ключи подписи JWT (kid → секрет). JWT_SECRET задаёт ключ для токенов без kid, JWT_KEYS — ключи
+	// с идентификаторами в виде "kid1:secret1,kid2:secret2", чтобы менять секрет без отзыва всех токенов
+	JWTKeys map[string][]byte
+	// Ожидаемые утверждения iss и aud токенов; пустые значения не проверяются
+	JWTIssuer   string
+	JWTAudience string
+
+	// Адреса или подсети прокси, которым доверяется заголовок X-Forwarded-For при определении адреса клиента
+	// для журнала аудита и лимитов частоты запросов; если не заданы, используется адрес соединения
+	TrustedProxies []string
+
+	// Лимиты частоты запросов: RATE_LIMIT_IP — на адрес клиента, RATE_LIMIT_CLIENT — на пользователя или API-ключ,
+	// RATE_LIMIT_ROUTES — дополнительные лимиты клиента на роуты ("POST /transfer=10/1m,...")
+	RateLimits ratelimit.Policy
+
+	// Имена пользователей, которым при запуске выдаётся роль admin (ADMIN_USERNAMES="root,ops"); отсутствующие
+	// пользователи создаются. Так назначается первый администратор. Удаление имени из списка роль не отзывает
+	AdminUsernames []string
 }
 
 func LoadConfig(envPath string) (*Config, error) {
@@ -21,12 +61,103 @@ func LoadConfig(envPath string) (*Config, error) {
 		log.Printf("Не удалось загрузить .env: %v. Используются переменные окружения.", err)
 	}
 
-	return &Config{
+	cfg := &Config{
 		DBUser:        os.Getenv("DB_USER"),
 		DBPass:        os.Getenv("DB_PASSWORD"),
 		DBName:        os.Getenv("DB_NAME"),
 		DBHost:        os.Getenv("DB_HOST"),
 		DBPort:        os.Getenv("DB_PORT"),
 		ServerAddress: os.Getenv("SERVER_ADDRESS"),
-	}, nil
+		FXRatesFile:   os.Getenv("FX_RATES_FILE"),
+		OutboxFile:    os.Getenv("OUTBOX_FILE"),
+		JWTIssuer:     os.Getenv("JWT_ISSUER"),
+		JWTAudience:   os.Getenv("JWT_AUDIENCE"),
+		JWTKeys:       map[string][]byte{},
+
+		HoldExpiryInterval: time.Minute,
+		SchedulerInterval:  30 * time.Second,
+		WebhookInterval:    5 * time.Second,
+		OutboxInterval:     time.Second,
+
+		RateLimits: ratelimit.Policy{
+			IP:     ratelimit.Limit{Requests: 600, Period: time.Minute},
+			Client: ratelimit.Limit{Requests: 300, Period: time.Minute},
+			Routes: map[string]ratelimit.Limit{
+				"POST /transfer": {Requests: 30, Period: time.Minute},
+			},
+		},
+	}
+
+	if ttl := os.Getenv("FX_QUOTE_TTL"); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid FX_QUOTE_TTL: %w", err)
+		}
+		cfg.FXQuoteTTL = d
+	}
+
+	for name, dst := range map[string]*time.Duration{
+		"HOLD_EXPIRY_INTERVAL": &cfg.HoldExpiryInterval,
+		"SCHEDULER_INTERVAL":   &cfg.SchedulerInterval,
+		"WEBHOOK_INTERVAL":     &cfg.WebhookInterval,
+		"OUTBOX_INTERVAL":      &cfg.OutboxInterval,
+	} {
+		if interval := os.Getenv(name); interval != "" {
+			d, err := time.ParseDuration(interval)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid %s: %q", name, interval)
+			}
+			*dst = d
+		}
+	}
+
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		cfg.JWTKeys[""] = []byte(secret)
+	}
+	if keys := os.Getenv("JWT_KEYS"); keys != "" {
+		for _, pair := range strings.Split(keys, ",") {
+			kid, secret, ok := strings.Cut(strings.TrimSpace(pair), ":")
+			if !ok || kid == "" || secret == "" {
+				return nil, fmt.Errorf("invalid JWT_KEYS entry %q, expected kid:secret", pair)
+			}
+			cfg.JWTKeys[kid] = []byte(secret)
+		}
+	}
+
+	for name, dst := range map[string]*ratelimit.Limit{
+		"RATE_LIMIT_IP":     &cfg.RateLimits.IP,
+		"RATE_LIMIT_CLIENT": &cfg.RateLimits.Client,
+	} {
+		if limit := os.Getenv(name); limit != "" {
+			l, err := ratelimit.ParseLimit(limit)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", name, err)
+			}
+			*dst = l
+		}
+	}
+	if routes := os.Getenv("RATE_LIMIT_ROUTES"); routes != "" {
+		limits, err := ratelimit.ParseRouteLimits(routes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RATE_LIMIT_ROUTES: %w", err)
+		}
+		cfg.RateLimits.Routes = limits
+	}
+
+	if proxies := os.Getenv("TRUSTED_PROXIES"); proxies != "" {
+		for _, p := range strings.Split(proxies, ",") {
+			cfg.TrustedProxies = append(cfg.TrustedProxies, strings.TrimSpace(p))
+		}
+	}
+
+	if names := os.Getenv("ADMIN_USERNAMES"); names != "" {
+		for _, name := range strings.Split(names, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				return nil, fmt.Errorf("invalid ADMIN_USERNAMES: empty username")
+			}
+			cfg.AdminUsernames = append(cfg.AdminUsernames, name)
+		}
+	}
+
+	return cfg, nil
 }
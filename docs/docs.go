@@ -15,9 +15,52 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/deposit": {
+        "/admin/api-keys": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает все ключи, включая отозванные и истёкшие, без самих ключей: только видимую часть (prefix), области доступа и время последнего использования",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API-ключи"
+                ],
+                "summary": "API-ключи",
+                "responses": {
+                    "200": {
+                        "description": "Ключи",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/postgres.APIKey"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "Позволяет пользователю пополнить свой баланс",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Выпускает API-ключ для сервиса. Ключ передаётся в заголовке X-API-Key и открывает доступ только к роутам его областей: deposit:write, transfer:write, withdraw:write, holds:write, transactions:read, balance:read, users:write. Сам ключ возвращается только в ответе на этот запрос — в базе хранится его хеш",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,54 +68,114 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Баланс"
+                    "API-ключи"
                 ],
-                "summary": "Пополнение баланса",
+                "summary": "Создание API-ключа",
                 "parameters": [
                     {
-                        "description": "Данные для пополнения",
+                        "description": "Ключ",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.DepositRequest"
+                            "$ref": "#/definitions/handler.APIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Созданный ключ",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.APIKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации, неизвестная область доступа или срок действия в прошлом",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
                         }
                     }
+                }
+            }
+        },
+        "/admin/api-keys/{id}/revoke": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отзывает ключ: запросы с ним сразу отклоняются с 401. Повторный отзыв ничего не меняет",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API-ключи"
+                ],
+                "summary": "Отзыв API-ключа",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID ключа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Баланс успешно пополнен",
+                        "description": "Отозванный ключ",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/postgres.APIKey"
                         }
                     },
                     "400": {
-                        "description": "Ошибка валидации",
+                        "description": "Некорректный id",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Ключ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/transactions": {
-            "get": {
-                "description": "Возвращает список последних 10 транзакций пользователя",
+        "/admin/api-keys/{id}/rotate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Выпускает новый ключ с теми же названием и областями доступа. Старый ключ действует ещё grace_period_seconds (по умолчанию сутки), чтобы сервис успел перейти на новый. Новый ключ возвращается только в ответе на этот запрос",
                 "consumes": [
                     "application/json"
                 ],
@@ -80,52 +183,219 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Транзакции"
+                    "API-ключи"
                 ],
-                "summary": "Получение последних 10 транзакций",
+                "summary": "Ротация API-ключа",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID пользователя",
-                        "name": "user_id",
-                        "in": "query",
+                        "description": "ID ключа",
+                        "name": "id",
+                        "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Параметры ротации",
+                        "name": "input",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handler.RotateAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Новый ключ",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.APIKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный id или срок действия старого ключа",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Ключ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Ключ отозван или истёк",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/audit-log": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает записи журнала аудита от новых к старым: кто (пользователь или API-ключ) выполнил операцию, меняющую состояние, роут и путь, SHA-256 тела запроса, адрес клиента, идентификатор запроса (X-Request-ID) и результат. Для следующей страницы передайте next_before_id в before_id. Журнал нельзя изменить или очистить. Требуется роль admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование"
+                ],
+                "summary": "Журнал аудита",
+                "parameters": [
+                    {
+                        "enum": [
+                            "user",
+                            "api_key"
+                        ],
+                        "type": "string",
+                        "description": "Тип исполнителя",
+                        "name": "actor_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя или API-ключа",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Метод и шаблон роута, например POST /transfer",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Идентификатор запроса",
+                        "name": "request_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "success",
+                            "failure"
+                        ],
+                        "type": "string",
+                        "description": "Результат",
+                        "name": "result",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC 3339), включительно",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC 3339), не включительно",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Записи старше указанной",
+                        "name": "before_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы (по умолчанию 50, максимум 200)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Список транзакций",
+                        "description": "Страница журнала",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/postgres.Transaction"
-                            }
+                            "$ref": "#/definitions/postgres.AuditPage"
                         }
                     },
                     "400": {
                         "description": "Ошибка валидации",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/transfer": {
+        "/admin/fee-rules": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает все правила комиссий за переводы и снятия",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование"
+                ],
+                "summary": "Правила комиссий",
+                "responses": {
+                    "200": {
+                        "description": "Правила комиссий",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/postgres.FeeRule"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "Позволяет пользователю перевести деньги другому пользователю",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создаёт правило комиссии за переводы или снятия в валюте. Правило с тарифом действует для его пользователей вместо общего правила. Комиссия списывается с плательщика сверх суммы операции и зачисляется на системный счёт доходов fee_revenue",
                 "consumes": [
                     "application/json"
                 ],
@@ -133,84 +403,4313 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Транзакции"
+                    "Администрирование"
                 ],
-                "summary": "Перевод денег",
+                "summary": "Создание правила комиссии",
                 "parameters": [
                     {
-                        "description": "Данные для перевода",
+                        "description": "Правило комиссии",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.TransferRequest"
+                            "$ref": "#/definitions/handler.FeeRuleRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Перевод успешно выполнен",
+                    "201": {
+                        "description": "Созданное правило",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/postgres.FeeRule"
                         }
                     },
                     "400": {
-                        "description": "Ошибка валидации",
+                        "description": "Ошибка валидации, некорректное правило или неподдерживаемая валюта",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Правило для этой операции, валюты и тарифа уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handlers.DepositRequest": {
-            "type": "object",
-            "required": [
-                "amount",
-                "user_id"
-            ],
-            "properties": {
-                "amount": {
-                    "type": "number"
-                },
-                "user_id": {
-                    "type": "integer"
+        },
+        "/admin/fee-rules/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет правило комиссии; комиссии уже проведённых операций не меняются",
+                "tags": [
+                    "Администрирование"
+                ],
+                "summary": "Удаление правила комиссии",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID правила",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Правило удалено"
+                    },
+                    "400": {
+                        "description": "Некорректный id",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Правило не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
                 }
             }
         },
-        "handlers.TransferRequest": {
-            "type": "object",
-            "required": [
-                "amount",
-                "receiver_id",
-                "sender_id"
-            ],
-            "properties": {
-                "amount": {
-                    "type": "number"
+        "/admin/ledger/verify": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Проверяет, что все проводки главной книги сбалансированы, а баланс каждого счёта пользователя совпадает с остатком его счёта в книге",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Баланс"
+                ],
+                "summary": "Сверка балансов с главной книгой",
+                "responses": {
+                    "200": {
+                        "description": "Результат сверки",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.LedgerReport"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет разрешения settings:manage",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reconciliation": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Пересчитывает балансы всех пользователей по истории транзакций с учётом начальных остатков и возвращает расхождения с текущими балансами",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "Администрирование"
+                ],
+                "summary": "Сверка балансов с историей транзакций",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Формат отчёта",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Отчёт о сверке",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.ReconciliationReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Выполняет сверку и исправляет каждое расхождение корректирующей проводкой; в отчёте исправленные строки отмечены adjusted",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "Администрирование"
+                ],
+                "summary": "Исправление расхождений балансов",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Формат отчёта",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Отчёт о сверке",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.ReconciliationReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Баланс счёта по истории отрицательный",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/spending-limits/tiers/{tier}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает лимиты расходов тарифа во всех валютах, для которых они заданы",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование"
+                ],
+                "summary": "Лимиты тарифа",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Тариф",
+                        "name": "tier",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Лимиты тарифа",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/postgres.SpendingLimits"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректное имя тарифа",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Задаёт лимиты расходов тарифа в валюте, заменяя прежние. Лимиты действуют для всех пользователей тарифа, кроме тех, кому заданы собственные лимиты в этой валюте",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование"
+                ],
+                "summary": "Установка лимитов тарифа",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Тариф",
+                        "name": "tier",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Лимиты",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.SpendingLimitsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Лимиты тарифа",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.SpendingLimits"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации, некорректное имя тарифа или неподдерживаемая валюта",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Некорректная сумма лимита",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/transactions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Ищет транзакции всех пользователей по фильтрам; с user_id — только транзакции, в которых участвует пользователь. Результат упорядочен от новых к старым, для следующей страницы передайте next_cursor в том же параметре (before или after), что и текущий курсор. Требуется роль admin или support",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование"
+                ],
+                "summary": "Поиск транзакций",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы (по умолчанию 10, максимум 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор: транзакции старше указанной",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор: транзакции новее указанной",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "deposit",
+                            "transfer",
+                            "withdrawal",
+                            "reversal",
+                            "adjustment"
+                        ],
+                        "type": "string",
+                        "description": "Тип транзакции",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта транзакций (ISO 4217)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC 3339), включительно",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC 3339), не включительно",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Минимальная сумма",
+                        "name": "min_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Максимальная сумма",
+                        "name": "max_amount",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Страница транзакций",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.TransactionPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin или support",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает пользователей, упорядоченных по id, постранично. Требуется роль admin или support",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование"
+                ],
+                "summary": "Список пользователей",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы (по умолчанию 20, максимум 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Страница пользователей",
+                        "schema": {
+                            "$ref": "#/definitions/admin.UsersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin или support",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает любого пользователя со счетами. Требуется роль admin или support",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование"
+                ],
+                "summary": "Пользователь",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пользователь",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный id",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin или support",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/adjustments": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Зачисляет (положительная сумма) или списывает (отрицательная сумма) деньги по счёту пользователя в валюте и создаёт транзакцию типа adjustment с указанной причиной. Корректировка возможна и на замороженном счёте; списать можно только доступный остаток. Требуется роль admin",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование"
+                ],
+                "summary": "Ручная корректировка баланса",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности для безопасного повтора запроса",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Сумма, валюта и причина корректировки",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/admin.AdjustmentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Баланс скорректирован",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.OperationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации, неподдерживаемая валюта или некорректная причина",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь или счёт в валюте не найден",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Счёт закрыт либо ключ идемпотентности использован с другим запросом",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Недостаточно доступных средств для списания",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/freeze": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Запрещает пополнения, снятия и переводы по счёту до разморозки. Требуется роль admin или support",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование"
+                ],
+                "summary": "Заморозка счёта",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пользователь",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный id",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin или support",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Счёт закрыт",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/roles": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает роли пользователя в административном API: admin — полный доступ, support — просмотр пользователей и транзакций и заморозка счетов. Требуется роль admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование"
+                ],
+                "summary": "Роли пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Роли",
+                        "schema": {
+                            "$ref": "#/definitions/admin.RolesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный id",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/roles/{role}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Выдаёт пользователю роль admin или support; повторная выдача ничего не меняет. Требуется роль admin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование"
+                ],
+                "summary": "Выдача роли",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "admin",
+                            "support"
+                        ],
+                        "type": "string",
+                        "description": "Роль",
+                        "name": "role",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Роли после выдачи",
+                        "schema": {
+                            "$ref": "#/definitions/admin.RolesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный id или неизвестная роль",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отзывает роль у пользователя. Свою роль admin отозвать нельзя, чтобы не остаться без администратора. Требуется роль admin",
+                "tags": [
+                    "Администрирование"
+                ],
+                "summary": "Отзыв роли",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "admin",
+                            "support"
+                        ],
+                        "type": "string",
+                        "description": "Роль",
+                        "name": "role",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Роль отозвана"
+                    },
+                    "400": {
+                        "description": "Некорректный id или неизвестная роль",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "У пользователя нет этой роли",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Попытка отозвать свою роль admin",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/spending-limits": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Задаёт пользователю собственные лимиты расходов в валюте; они полностью заменяют лимиты его тарифа в этой валюте. Запрос без лимитов снимает с пользователя ограничения тарифа",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование"
+                ],
+                "summary": "Установка лимитов пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Лимиты",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.SpendingLimitsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Лимиты пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.SpendingLimits"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации или неподдерживаемая валюта",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Некорректная сумма лимита",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет собственные лимиты пользователя в валюте: снова действуют лимиты его тарифа",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование"
+                ],
+                "summary": "Удаление лимитов пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта (ISO 4217), по умолчанию RUB",
+                        "name": "currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Действующие лимиты пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.SpendingStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации или неподдерживаемая валюта",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/tier": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Переводит пользователя на тариф; к его расходам применяются лимиты нового тарифа, если пользователю не заданы собственные. Тариф без лимитов расходы не ограничивает",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование"
+                ],
+                "summary": "Смена тарифа пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Тариф",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.SetTierRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пользователь",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации или некорректное имя тарифа",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/transactions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает страницу транзакций любого пользователя от новых к старым с теми же фильтрами и курсорами, что и GET /transactions. Требуется роль admin или support",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование"
+                ],
+                "summary": "История транзакций пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы (по умолчанию 10, максимум 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор: транзакции старше указанной",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор: транзакции новее указанной",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "deposit",
+                            "transfer",
+                            "withdrawal",
+                            "reversal",
+                            "adjustment"
+                        ],
+                        "type": "string",
+                        "description": "Тип транзакции",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта транзакций (ISO 4217)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC 3339), включительно",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC 3339), не включительно",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Минимальная сумма",
+                        "name": "min_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Максимальная сумма",
+                        "name": "max_amount",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Страница транзакций",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.TransactionPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin или support",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/unfreeze": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Снова разрешает операции по замороженному счёту. Требуется роль admin или support",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование"
+                ],
+                "summary": "Разморозка счёта",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пользователь",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный id",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin или support",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Счёт закрыт",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/webhook-deliveries/{id}/redeliver": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Ставит доставленное или исчерпавшее попытки событие в очередь заново с полным набором попыток. Получатель увидит тот же id события, что и в прошлый раз",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Вебхуки"
+                ],
+                "summary": "Повторная доставка вебхука",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID доставки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Доставка поставлена в очередь",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.WebhookDelivery"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный id",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Доставка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Доставка ещё ожидает отправки",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает все подписки на события без секретов",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Вебхуки"
+                ],
+                "summary": "Подписки на вебхуки",
+                "responses": {
+                    "200": {
+                        "description": "Подписки",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/postgres.WebhookSubscription"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Подписывает адрес на события: deposit.completed, transfer.completed, withdrawal.completed, reversal.completed, adjustment.completed, deposit.failed, transfer.failed, withdrawal.failed, balance.updated. Каждый запрос подписан HMAC-SHA256 секретом подписки в заголовке X-Webhook-Signature. Секрет возвращается только в ответе на этот запрос",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Вебхуки"
+                ],
+                "summary": "Подписка на вебхуки",
+                "parameters": [
+                    {
+                        "description": "Подписка",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.WebhookSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Созданная подписка с секретом",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.WebhookSubscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации, некорректный адрес или неизвестный тип события",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/webhooks/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет подписку вместе с историей доставок; недоставленные события больше не отправляются",
+                "tags": [
+                    "Вебхуки"
+                ],
+                "summary": "Удаление подписки на вебхуки",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Подписка удалена"
+                    },
+                    "400": {
+                        "description": "Некорректный id",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/webhooks/{id}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает последние доставки событий подписчику от новых к старым: статус (pending — ждёт попытки, delivered — доставлено, dead — попытки исчерпаны), число попыток и результат последней",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Вебхуки"
+                ],
+                "summary": "Доставки вебхуков",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Статус доставки: pending, delivered или dead",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество записей (по умолчанию 50, максимум 200)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Доставки",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/postgres.WebhookDelivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли admin",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/deposit": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Пополняет счёт пользователя в указанной валюте; если счёта в этой валюте нет, он открывается. Доступно сервисам с областью deposit:write и пользователям с разрешением deposits:write",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Баланс"
+                ],
+                "summary": "Пополнение баланса",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности для безопасного повтора запроса",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Данные для пополнения",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.DepositRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Баланс успешно пополнен",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.OperationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации или неподдерживаемая валюта",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет разрешения deposits:write",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Счёт заморожен или закрыт либо ключ идемпотентности использован с другим запросом",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Некорректная сумма",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/fx/quotes": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Фиксирует текущий курс обмена from_currency на to_currency до expires_at. Идентификатор котировки передаётся в quote_id перевода; котировка используется один раз",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Транзакции"
+                ],
+                "summary": "Котировка курса валют",
+                "parameters": [
+                    {
+                        "description": "Валютная пара",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.FXQuoteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Котировка",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.FXQuote"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации или неподдерживаемая валюта",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Курс для пары недоступен или валюты совпадают",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/holds": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Резервирует сумму на счёте пользователя в валюте. Зарезервированная сумма не входит в доступный остаток, пока холд не списан, не освобождён и не истёк; баланс по главной книге не меняется",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Холды"
+                ],
+                "summary": "Создание холда",
+                "parameters": [
+                    {
+                        "description": "Данные холда",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateHoldRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Холд",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.Hold"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации, неподдерживаемая валюта или некорректный срок холда",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция со счётом другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Счёт заморожен или закрыт",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Недостаточно доступных средств или некорректная сумма",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/holds/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает холд по id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Холды"
+                ],
+                "summary": "Холд",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID холда",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Холд",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.Hold"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный id",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Холд не найден или принадлежит другому пользователю",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/holds/{id}/capture": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Списывает холд полностью или частично: с receiver_id сумма переводится получателю, без него — списывается со счёта. Остаток резерва при частичном списании освобождается",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Холды"
+                ],
+                "summary": "Списание холда",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID холда",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Сумма и получатель",
+                        "name": "input",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CaptureHoldRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности для безопасного повтора запроса",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Холд списан",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CaptureHoldResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Холд или получатель не найден либо холд принадлежит другому пользователю",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Холд уже списан, освобождён или истёк, счёт заморожен или закрыт либо ключ идемпотентности использован с другим запросом",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Получатель — владелец холда, сумма больше суммы холда, недостаточно средств, превышен лимит расходов или у получателя нет счёта в валюте холда",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/holds/{id}/release": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Снимает резерв без списания денег: сумма холда снова становится доступной",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Холды"
+                ],
+                "summary": "Освобождение холда",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID холда",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Холд освобождён",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.Hold"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный id",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Холд не найден или принадлежит другому пользователю",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Холд уже списан, освобождён или истёк",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scheduled-transfers": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Планирует перевод на start_at; с recurrence перевод повторяется по правилу (например, ежемесячная оплата аренды). Переводы выполняет фоновый планировщик сервиса, результат каждого выполнения сохраняется",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Запланированные переводы"
+                ],
+                "summary": "Запланированный перевод",
+                "parameters": [
+                    {
+                        "description": "Данные перевода",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.ScheduledTransferRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Запланированный перевод",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.ScheduledTransfer"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации, некорректное правило повторения или start_at в прошлом",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция со счётом другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Отправитель или получатель не найден",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Счёт заморожен или закрыт",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Некорректная сумма или перевод самому себе",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scheduled-transfers/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает запланированный перевод по id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Запланированные переводы"
+                ],
+                "summary": "Запланированный перевод",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID запланированного перевода",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Запланированный перевод",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.ScheduledTransfer"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный id",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Запланированный перевод не найден или принадлежит другому пользователю",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scheduled-transfers/{id}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отменяет перевод; отменённый перевод возобновить нельзя, история выполнений сохраняется",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Запланированные переводы"
+                ],
+                "summary": "Отмена запланированного перевода",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID запланированного перевода",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Перевод отменён",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.ScheduledTransfer"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный id",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Запланированный перевод не найден или принадлежит другому пользователю",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Перевод уже завершён или отменён",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scheduled-transfers/{id}/pause": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Приостанавливает выполнение перевода до возобновления",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Запланированные переводы"
+                ],
+                "summary": "Приостановка запланированного перевода",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID запланированного перевода",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Перевод приостановлен",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.ScheduledTransfer"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный id",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Запланированный перевод не найден или принадлежит другому пользователю",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Перевод уже завершён или отменён",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scheduled-transfers/{id}/resume": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возобновляет приостановленный перевод. Вхождения, пропущенные за время паузы, не выполняются; просроченный разовый перевод выполняется сразу",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Запланированные переводы"
+                ],
+                "summary": "Возобновление запланированного перевода",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID запланированного перевода",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Перевод возобновлён",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.ScheduledTransfer"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный id",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Запланированный перевод не найден или принадлежит другому пользователю",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Перевод уже завершён или отменён",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scheduled-transfers/{id}/runs": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает последние выполнения перевода от новых к старым: плановое время, результат, созданную транзакцию или текст ошибки",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Запланированные переводы"
+                ],
+                "summary": "История выполнения запланированного перевода",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID запланированного перевода",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество записей (по умолчанию 20, максимум 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Выполнения",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/postgres.ScheduledTransferRun"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Запланированный перевод не найден или принадлежит другому пользователю",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает страницу транзакций пользователя от новых к старым. Для следующей страницы передайте next_cursor в том же параметре (before или after), что и текущий курсор",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Транзакции"
+                ],
+                "summary": "История транзакций",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы (по умолчанию 10, максимум 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор: транзакции старше указанной",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор: транзакции новее указанной",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "deposit",
+                            "transfer",
+                            "withdrawal",
+                            "reversal",
+                            "adjustment"
+                        ],
+                        "type": "string",
+                        "description": "Тип транзакции",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта транзакций (ISO 4217)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC 3339), включительно",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC 3339), не включительно",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Минимальная сумма",
+                        "name": "min_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Максимальная сумма",
+                        "name": "max_amount",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Страница транзакций",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.TransactionPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция со счётом другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/transactions/{id}/reverse": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создаёт компенсирующую транзакцию типа reversal, которая возвращает отправителю всю сумму перевода или её часть. Исходная транзакция не меняется и получает ссылку reversed_by в истории; каждый перевод сторнируется один раз, перевод с конвертацией — только полностью",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Транзакции"
+                ],
+                "summary": "Сторно перевода",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID перевода",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Сумма сторно",
+                        "name": "input",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ReverseRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности для безопасного повтора запроса",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Перевод сторнирован",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.OperationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет разрешения transactions:reverse",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Транзакция не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Перевод уже сторнирован, счёт закрыт либо ключ идемпотентности использован с другим запросом",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Транзакция не является переводом, сумма больше суммы перевода или у получателя недостаточно средств",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/transfer": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Переводит деньги другому пользователю в одной валюте. Если у получателя нет счёта в валюте перевода, перевод отклоняется с кодом currency_mismatch.\nС quote_id выполняется перевод с конвертацией: отправитель платит amount в исходной валюте котировки, получатель получает сумму в валюте назначения по зафиксированному курсу.\nЕсли для перевода действует правило комиссии, комиссия (поле fee транзакции) списывается с отправителя сверх amount",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Транзакции"
+                ],
+                "summary": "Перевод денег",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности для безопасного повтора запроса",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Данные для перевода",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.TransferRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Перевод успешно выполнен",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.OperationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации или неподдерживаемая валюта",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция со счётом другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Отправитель, получатель или котировка не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Счёт заморожен или закрыт, котировка истекла или использована либо ключ идемпотентности использован с другим запросом",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Недостаточно средств, превышен лимит расходов, перевод самому себе или у получателя нет счёта в валюте перевода",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Создаёт пользователя с уникальным именем и нулевым счётом в RUB. Доступно сервисам с областью users:write и пользователям с разрешением users:create",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователи"
+                ],
+                "summary": "Создание пользователя",
+                "parameters": [
+                    {
+                        "description": "Данные пользователя",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Созданный пользователь",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет разрешения users:create",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Имя пользователя занято",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователи"
+                ],
+                "summary": "Получение пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пользователь",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция со счётом другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/accounts": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает счета пользователя во всех валютах",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователи"
+                ],
+                "summary": "Счета пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Счета пользователя",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/postgres.Account"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция со счётом другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Открывает пользователю счёт с нулевым балансом в новой валюте (ISO 4217)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователи"
+                ],
+                "summary": "Открытие счёта в валюте",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Валюта счёта",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.OpenAccountRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Открытый счёт",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.Account"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации или неподдерживаемая валюта",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция со счётом другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Счёт в этой валюте уже открыт, пользователь заморожен или закрыт",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/balance": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает текущий баланс пользователя в валюте и время последней операции в ней. balance — учётный баланс по главной книге, available_balance — доступный для списания остаток за вычетом действующих холдов (held). С параметром as_of — баланс на указанный момент, восстановленный по истории транзакций",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Баланс"
+                ],
+                "summary": "Баланс пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта (ISO 4217), по умолчанию RUB",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Момент времени в формате RFC 3339, например 2025-02-01T12:00:00Z",
+                        "name": "as_of",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Баланс пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.Balance"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации или неподдерживаемая валюта",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция со счётом другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/close": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Окончательно закрывает счёт; балансы во всех валютах должны быть нулевыми",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователи"
+                ],
+                "summary": "Закрытие счёта",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пользователь",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.User"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция со счётом другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Счёт уже закрыт или баланс не нулевой",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/scheduled-transfers": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает запланированные переводы, в которых пользователь — отправитель, от новых к старым",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Запланированные переводы"
+                ],
+                "summary": "Запланированные переводы пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Запланированные переводы",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/postgres.ScheduledTransfer"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный id",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция со счётом другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/spending-limits": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает действующие лимиты исходящих операций пользователя в валюте (собственные или лимиты тарифа), суммы, потраченные с начала суток и месяца (UTC), и наибольшую сумму, которую можно потратить одной операцией сейчас",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Лимиты"
+                ],
+                "summary": "Лимиты расходов пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта (ISO 4217), по умолчанию RUB",
+                        "name": "currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Лимиты и их использование",
+                        "schema": {
+                            "$ref": "#/definitions/postgres.SpendingStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации или неподдерживаемая валюта",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция со счётом другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/statement": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Выгружает все транзакции пользователя в валюте за период [from, to) с начальным балансом, балансом после каждой транзакции и конечным балансом. Без from выписка начинается с первой транзакции, без to — заканчивается текущим моментом. Форматы: csv (первая и последняя строки — opening_balance и closing_balance), jsonl (записи opening, transaction и closing) и pdf. Выписка передаётся потоком по мере чтения из базы",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "Баланс"
+                ],
+                "summary": "Выписка по счёту",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "jsonl",
+                            "pdf"
+                        ],
+                        "type": "string",
+                        "default": "csv",
+                        "description": "Формат выписки",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта (ISO 4217), по умолчанию RUB",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC 3339), включительно",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC 3339), не включительно",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Выписка",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации или неподдерживаемая валюта",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция со счётом другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/withdraw": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Списывает деньги со счёта пользователя в указанной валюте, если на нём достаточно средств. Комиссия по правилам комиссий (поле fee транзакции) списывается сверх суммы",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Баланс"
+                ],
+                "summary": "Снятие денег",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности для безопасного повтора запроса",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Данные для снятия",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.WithdrawRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Деньги успешно списаны",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.OperationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации или неподдерживаемая валюта",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Операция со счётом другого пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Счёт заморожен или закрыт либо ключ идемпотентности использован с другим запросом",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Недостаточно средств или превышен лимит расходов",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "admin.AdjustmentRequest": {
+            "type": "object",
+            "required": [
+                "amount",
+                "reason"
+            ],
+            "properties": {
+                "amount": {
+                    "description": "Сумма корректировки: положительная зачисляется на счёт, отрицательная списывается с него",
+                    "type": "number",
+                    "example": -25
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "reason": {
+                    "description": "Причина корректировки, сохраняется в транзакции",
+                    "type": "string",
+                    "example": "duplicate deposit #1234"
+                }
+            }
+        },
+        "admin.RolesResponse": {
+            "type": "object",
+            "properties": {
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "admin"
+                    ]
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "admin.UsersResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/postgres.User"
+                    }
+                }
+            }
+        },
+        "handler.APIKeyRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "billing"
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "deposit:write",
+                        "transactions:read"
+                    ]
+                }
+            }
+        },
+        "handler.CaptureHoldRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Сумма списания; если не указана, списывается вся сумма холда",
+                    "type": "number",
+                    "minimum": 0,
+                    "example": 450
+                },
+                "receiver_id": {
+                    "description": "Получатель перевода; если не указан, сумма списывается со счёта (withdrawal)",
+                    "type": "integer"
+                }
+            }
+        },
+        "handler.CaptureHoldResponse": {
+            "type": "object",
+            "properties": {
+                "hold": {
+                    "$ref": "#/definitions/postgres.Hold"
+                },
+                "transaction": {
+                    "$ref": "#/definitions/postgres.Transaction"
+                }
+            }
+        },
+        "handler.CreateHoldRequest": {
+            "type": "object",
+            "required": [
+                "amount",
+                "user_id"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 500
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "ttl_seconds": {
+                    "description": "Срок жизни холда в секундах; по умолчанию 7 дней, максимум 30 дней",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 3600
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handler.CreateUserRequest": {
+            "type": "object",
+            "required": [
+                "username"
+            ],
+            "properties": {
+                "username": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 3
+                }
+            }
+        },
+        "handler.DepositRequest": {
+            "type": "object",
+            "required": [
+                "amount",
+                "user_id"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 100.5
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handler.FXQuoteRequest": {
+            "type": "object",
+            "required": [
+                "from_currency",
+                "to_currency"
+            ],
+            "properties": {
+                "from_currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "to_currency": {
+                    "type": "string",
+                    "example": "USD"
+                }
+            }
+        },
+        "handler.FeeRuleRequest": {
+            "type": "object",
+            "required": [
+                "transaction_type"
+            ],
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "flat": {
+                    "type": "number",
+                    "example": 10
+                },
+                "max_fee": {
+                    "type": "number",
+                    "example": 3000
+                },
+                "min_fee": {
+                    "type": "number",
+                    "example": 30
+                },
+                "percent": {
+                    "type": "number",
+                    "example": 1.5
+                },
+                "tier": {
+                    "description": "Тариф пользователей, для которых действует правило; без него — для всех",
+                    "type": "string",
+                    "example": "standard"
+                },
+                "transaction_type": {
+                    "type": "string",
+                    "enum": [
+                        "transfer",
+                        "withdrawal"
+                    ],
+                    "example": "transfer"
+                }
+            }
+        },
+        "handler.OpenAccountRequest": {
+            "type": "object",
+            "required": [
+                "currency"
+            ],
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                }
+            }
+        },
+        "handler.ReverseRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Сумма сторно; если не указана, сторнируется вся сумма перевода",
+                    "type": "number",
+                    "minimum": 0,
+                    "example": 50
+                }
+            }
+        },
+        "handler.RotateAPIKeyRequest": {
+            "type": "object",
+            "properties": {
+                "grace_period_seconds": {
+                    "description": "Сколько секунд старый ключ действует после ротации; по умолчанию сутки, 0 — отозвать сразу, максимум 30 дней",
+                    "type": "integer",
+                    "example": 3600
+                }
+            }
+        },
+        "handler.ScheduledTransferRequest": {
+            "type": "object",
+            "required": [
+                "amount",
+                "receiver_id",
+                "sender_id",
+                "start_at"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 25000
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "receiver_id": {
+                    "type": "integer"
+                },
+                "recurrence": {
+                    "description": "Правило повторения RRULE: FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL, COUNT, UNTIL; без него перевод разовый",
+                    "type": "string",
+                    "example": "FREQ=MONTHLY;INTERVAL=1"
+                },
+                "sender_id": {
+                    "type": "integer"
+                },
+                "start_at": {
+                    "description": "Время первого выполнения (RFC 3339), должно быть в будущем",
+                    "type": "string",
+                    "example": "2025-04-01T09:00:00Z"
+                }
+            }
+        },
+        "handler.SetTierRequest": {
+            "type": "object",
+            "required": [
+                "tier"
+            ],
+            "properties": {
+                "tier": {
+                    "type": "string",
+                    "example": "premium"
+                }
+            }
+        },
+        "handler.SpendingLimitsRequest": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "daily": {
+                    "type": "number",
+                    "example": 300000
+                },
+                "monthly": {
+                    "type": "number",
+                    "example": 1000000
+                },
+                "per_transaction": {
+                    "type": "number",
+                    "example": 100000
+                }
+            }
+        },
+        "handler.TransferRequest": {
+            "type": "object",
+            "required": [
+                "amount",
+                "receiver_id",
+                "sender_id"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 100.5
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "quote_id": {
+                    "description": "Котировка для перевода с конвертацией (см. POST /fx/quotes); валюты задаёт котировка, поэтому currency не указывается",
+                    "type": "string"
+                },
+                "receiver_id": {
+                    "type": "integer"
+                },
+                "sender_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handler.WebhookSubscriptionRequest": {
+            "type": "object",
+            "required": [
+                "event_types",
+                "url"
+            ],
+            "properties": {
+                "event_types": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "transfer.completed",
+                        "transfer.failed"
+                    ]
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/hooks/fin"
+                }
+            }
+        },
+        "handler.WithdrawRequest": {
+            "type": "object",
+            "required": [
+                "amount",
+                "user_id"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 100.5
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "httpapi.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "insufficient_funds"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "insufficient funds"
+                },
+                "limit": {
+                    "description": "Превышенный лимит и остаток лимита; заполняется только для limit_exceeded",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/postgres.LimitExceededError"
+                        }
+                    ]
+                }
+            }
+        },
+        "httpapi.OperationResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "transaction": {
+                    "$ref": "#/definitions/postgres.Transaction"
+                }
+            }
+        },
+        "postgres.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "fsk_3f1c9a2b..."
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "billing"
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "fsk_3f1c9a2b"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "rotated_from": {
+                    "type": "integer"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "deposit:write",
+                        "transactions:read"
+                    ]
+                }
+            }
+        },
+        "postgres.Account": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "number",
+                    "example": 1500.5
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "postgres.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "POST /admin/users/:id/adjustments"
+                },
+                "actor_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "actor_type": {
+                    "type": "string",
+                    "example": "user"
+                },
+                "client_ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error_code": {
+                    "type": "string",
+                    "example": "insufficient_funds"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "path": {
+                    "type": "string",
+                    "example": "/admin/users/42/adjustments"
+                },
+                "payload_hash": {
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "4b1e0c2f9a7d3e85"
+                },
+                "result": {
+                    "type": "string",
+                    "example": "success"
+                },
+                "status_code": {
+                    "type": "integer",
+                    "example": 200
+                }
+            }
+        },
+        "postgres.AuditPage": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/postgres.AuditEntry"
+                    }
+                },
+                "next_before_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "postgres.Balance": {
+            "type": "object",
+            "properties": {
+                "as_of": {
+                    "type": "string"
+                },
+                "available_balance": {
+                    "type": "number",
+                    "example": 1000.5
+                },
+                "balance": {
+                    "type": "number",
+                    "example": 1500.5
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "held": {
+                    "type": "number",
+                    "example": 500
+                },
+                "last_transaction_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "postgres.FXQuote": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "from_currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "3f1c7b8e-2a4d-4a8e-9a57-0c3b1e0f6d2a"
+                },
+                "rate": {
+                    "type": "number",
+                    "example": 0.0108
+                },
+                "to_currency": {
+                    "type": "string",
+                    "example": "USD"
+                }
+            }
+        },
+        "postgres.FeeRule": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "flat": {
+                    "type": "number",
+                    "example": 10
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "max_fee": {
+                    "type": "number",
+                    "example": 3000
+                },
+                "min_fee": {
+                    "type": "number",
+                    "example": 30
+                },
+                "percent": {
+                    "type": "number",
+                    "example": 1.5
+                },
+                "tier": {
+                    "type": "string",
+                    "example": "standard"
+                },
+                "transaction_type": {
+                    "type": "string",
+                    "example": "transfer"
+                }
+            }
+        },
+        "postgres.Hold": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 500
+                },
+                "captured_amount": {
+                    "type": "number",
+                    "example": 450
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "active"
+                },
+                "transaction_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "postgres.LedgerMismatch": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "number",
+                    "example": 1500.5
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "ledger_balance": {
+                    "type": "number",
+                    "example": 1500
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "postgres.LedgerReport": {
+            "type": "object",
+            "properties": {
+                "mismatches": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/postgres.LedgerMismatch"
+                    }
+                },
+                "ok": {
+                    "type": "boolean"
+                },
+                "unbalanced_entries": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "postgres.LimitExceededError": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "remaining": {
+                    "type": "number",
+                    "example": 2500
+                },
+                "type": {
+                    "type": "string",
+                    "example": "daily"
+                }
+            }
+        },
+        "postgres.ReconciliationLine": {
+            "type": "object",
+            "properties": {
+                "adjusted": {
+                    "type": "boolean"
+                },
+                "balance": {
+                    "type": "number"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "difference": {
+                    "type": "number"
+                },
+                "expected_balance": {
+                    "type": "number"
+                },
+                "ledger_balance": {
+                    "type": "number"
+                },
+                "opening_balance": {
+                    "type": "number"
+                },
+                "transaction_id": {
+                    "description": "Транзакция adjustment, которой исправлен баланс; заполняется только при исправлении",
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "postgres.ReconciliationReport": {
+            "type": "object",
+            "properties": {
+                "accounts_checked": {
+                    "type": "integer"
+                },
+                "checked_at": {
+                    "type": "string"
+                },
+                "mismatches": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/postgres.ReconciliationLine"
+                    }
+                }
+            }
+        },
+        "postgres.ScheduledTransfer": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 25000
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "next_run_at": {
+                    "type": "string"
                 },
                 "receiver_id": {
                     "type": "integer"
                 },
+                "recurrence": {
+                    "type": "string",
+                    "example": "FREQ=MONTHLY;INTERVAL=1"
+                },
                 "sender_id": {
                     "type": "integer"
+                },
+                "start_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "active"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "postgres.ScheduledTransferRun": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "scheduled_for": {
+                    "type": "string"
+                },
+                "scheduled_transfer_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "succeeded"
+                },
+                "transaction_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "postgres.SpendingLimits": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "daily": {
+                    "type": "number",
+                    "example": 300000
+                },
+                "monthly": {
+                    "type": "number",
+                    "example": 1000000
+                },
+                "per_transaction": {
+                    "type": "number",
+                    "example": 100000
+                }
+            }
+        },
+        "postgres.SpendingStatus": {
+            "type": "object",
+            "properties": {
+                "limits": {
+                    "$ref": "#/definitions/postgres.SpendingLimits"
+                },
+                "remaining": {
+                    "type": "number",
+                    "example": 100000
+                },
+                "source": {
+                    "type": "string",
+                    "example": "tier"
+                },
+                "spent_this_month": {
+                    "type": "number",
+                    "example": 120000
+                },
+                "spent_today": {
+                    "type": "number",
+                    "example": 15000
+                },
+                "tier": {
+                    "type": "string",
+                    "example": "standard"
+                },
+                "user_id": {
+                    "type": "integer"
                 }
             }
         },
@@ -218,17 +4717,51 @@ const docTemplate = `{
             "type": "object",
             "properties": {
                 "amount": {
-                    "type": "number"
+                    "type": "number",
+                    "example": 100.5
                 },
                 "created_at": {
                     "type": "string"
                 },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "fee": {
+                    "description": "Комиссия, списанная с плательщика сверх Amount в той же валюте (см. FeeRule)",
+                    "type": "number",
+                    "example": 15
+                },
+                "fx_quote_id": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
+                "reason": {
+                    "description": "Причина ручной корректировки баланса; заполнена только у транзакций типа adjustment",
+                    "type": "string",
+                    "example": "compensation for incident #42"
+                },
+                "receiver_amount": {
+                    "description": "Заполнены только у переводов с конвертацией: получателю зачислено ReceiverAmount в ReceiverCurrency\nпо курсу котировки FXQuoteID",
+                    "type": "number",
+                    "example": 1.09
+                },
+                "receiver_currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
                 "receiver_id": {
                     "type": "integer"
                 },
+                "reversal_of": {
+                    "description": "Связь сторно с исходным переводом: у сторно заполнен ReversalOf, у сторнированного перевода — ReversedBy\n(ReversedBy заполняется только в истории транзакций)",
+                    "type": "integer"
+                },
+                "reversed_by": {
+                    "type": "integer"
+                },
                 "sender_id": {
                     "type": "integer"
                 },
@@ -239,18 +4772,146 @@ const docTemplate = `{
                     "type": "integer"
                 }
             }
+        },
+        "postgres.TransactionPage": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "type": "string"
+                },
+                "transactions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/postgres.Transaction"
+                    }
+                }
+            }
+        },
+        "postgres.User": {
+            "type": "object",
+            "properties": {
+                "accounts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/postgres.Account"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "active"
+                },
+                "tier": {
+                    "type": "string",
+                    "example": "standard"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "postgres.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "event_id": {
+                    "type": "integer"
+                },
+                "event_type": {
+                    "type": "string",
+                    "example": "transfer.completed"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_attempt_at": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_status_code": {
+                    "type": "integer"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "pending"
+                },
+                "subscription_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "postgres.WebhookSubscription": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "transfer.completed",
+                        "transfer.failed"
+                    ]
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "secret": {
+                    "type": "string",
+                    "example": "whsec_3f1c..."
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/hooks/fin"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "description": "API-ключ сервиса; допускается только к роутам областей доступа ключа",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "description": "JWT в формате \"Bearer \u003ctoken\u003e\"; субъект токена (sub) — ID пользователя",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
 
 // SwaggerInfo holds exported Swagger Info so clients can modify it
 var SwaggerInfo = &swag.Spec{
-	Version:          "",
-	Host:             "",
-	BasePath:         "",
+	Version:          "1.0",
+	Host:             "localhost:8080",
+	BasePath:         "/",
 	Schemes:          []string{},
-	Title:            "",
-	Description:      "",
+	Title:            "Финансовый сервис API",
+	Description:      "API для управления балансом и переводами денег",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
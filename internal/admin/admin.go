@@ -0,0 +1,94 @@
+// Пакет admin — административный API: просмотр счетов и транзакций любых пользователей, поиск транзакций,
+// заморозка счетов, ручные корректировки балансов и управление ролями.
+//
+// Доступ проверяется по ролям пользователя (RBAC), а не по владельцу счёта, как в обработчиках
+// internal/handlers: каждая роль даёт набор разрешений, и роут требует одно из них (см. Require).
+// Роли хранятся в БД, поэтому выдача и отзыв роли действуют со следующего запроса.
+package admin
+
+import (
+	"slices"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/httpapi"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	service "github.com/EugeneKrivoshein/fin_service/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Permission — разрешение на группу административных операций
+type Permission string
+
+const (
+	PermViewAccounts        Permission = "accounts:read"        // просмотр пользователей и их транзакций, поиск транзакций
+	PermCreateUsers         Permission = "users:create"         // создание пользователей
+	PermDepositFunds        Permission = "deposits:write"       // пополнение балансов
+	PermFreezeAccounts      Permission = "accounts:freeze"      // заморозка и разморозка счетов
+	PermAdjustBalances      Permission = "balances:adjust"      // ручные корректировки балансов
+	PermReverseTransactions Permission = "transactions:reverse" // сторно переводов
+	PermManageSettings      Permission = "settings:manage"      // сверка, лимиты, комиссии, вебхуки и API-ключи
+	PermManageRoles         Permission = "roles:manage"         // выдача и отзыв ролей
+	PermViewAuditLog        Permission = "audit:read"           // просмотр журнала аудита
+)
+
+// Разрешения ролей
+var rolePermissions = map[string][]Permission{
+	postgres.RoleAdmin: {
+		PermViewAccounts, PermCreateUsers, PermDepositFunds, PermFreezeAccounts, PermAdjustBalances,
+		PermReverseTransactions, PermManageSettings, PermManageRoles, PermViewAuditLog,
+	},
+	postgres.RoleSupport: {PermViewAccounts, PermFreezeAccounts},
+}
+
+// Allows сообщает, даёт ли хотя бы одна из ролей разрешение perm
+func Allows(roles []string, perm Permission) bool {
+	for _, role := range roles {
+		if slices.Contains(rolePermissions[role], perm) {
+			return true
+		}
+	}
+	return false
+}
+
+type Handler struct {
+	service *service.Service
+}
+
+func NewHandler(s *service.Service) *Handler {
+	return &Handler{service: s}
+}
+
+// Require — middleware авторизации административных роутов: пропускает запрос, только если у пользователя,
+// от имени которого он выполняется, есть роль с разрешением perm; иначе отвечает 403.
+// Должен стоять после аутентификации; сервисам с API-ключом административный API недоступен.
+func (h *Handler) Require(perm Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		callerID, ok := httpapi.CallerID(c)
+		if !ok {
+			httpapi.RespondForbidden(c, "admin api is available only to users")
+			return
+		}
+		roles, err := h.service.GetUserRoles(c.Request.Context(), callerID)
+		if err != nil {
+			httpapi.RespondError(c, err)
+			return
+		}
+		if !Allows(roles, perm) {
+			httpapi.RespondForbidden(c, "permission "+string(perm)+" is required")
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireForUsers — как Require, но для роутов, открытых и сервисам: запрос с API-ключом пропускается
+// (его область доступа уже проверила аутентификация), а пользователю нужна роль с разрешением perm
+func (h *Handler) RequireForUsers(perm Permission) gin.HandlerFunc {
+	require := h.Require(perm)
+	return func(c *gin.Context) {
+		if _, ok := httpapi.CallerID(c); !ok {
+			c.Next()
+			return
+		}
+		require(c)
+	}
+}
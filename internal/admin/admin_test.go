@@ -0,0 +1,148 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/auth"
+	handler "github.com/EugeneKrivoshein/fin_service/internal/handlers"
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	service "github.com/EugeneKrivoshein/fin_service/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testJWTKey = []byte("test-secret")
+
+// Репозиторий с ролями пользователей и ручными корректировками; остальные методы не вызываются
+type rolesRepository struct {
+	postgres.Repository
+	roles       map[int64][]string
+	adjustments []postgres.Transaction
+}
+
+func (r *rolesRepository) GetUserRoles(_ context.Context, userID int64) ([]string, error) {
+	return r.roles[userID], nil
+}
+
+func (r *rolesRepository) CreateAdjustment(_ context.Context, userID int64, amount money.Amount, currency money.Currency, reason string, _ *postgres.IdempotencyKey) (*postgres.Transaction, error) {
+	t := postgres.Transaction{ID: int64(len(r.adjustments) + 1), UserID: &userID, Amount: amount, Currency: currency, TransactionType: "adjustment", Reason: &reason}
+	r.adjustments = append(r.adjustments, t)
+	return &t, nil
+}
+
+func newTestRouter(t *testing.T, repo postgres.Repository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	verifier, err := auth.NewVerifier(map[string][]byte{"": testJWTKey}, "", "")
+	require.NoError(t, err)
+	s := service.NewService(repo)
+	h, a := handler.NewHandler(s), NewHandler(s)
+
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	r := gin.New()
+	adm := r.Group("/admin", h.Authenticate(verifier, ""))
+	adm.GET("/view", a.Require(PermViewAccounts), ok)
+	adm.GET("/settings", a.Require(PermManageSettings), ok)
+	adm.POST("/deposit", a.RequireForUsers(PermDepositFunds), ok)
+	// Запрос с API-ключом не несёт id пользователя: здесь его имитирует роут без аутентификации
+	r.POST("/service/deposit", a.RequireForUsers(PermDepositFunds), ok)
+	adm.POST("/users/:id/adjustments", a.Require(PermAdjustBalances), a.HandleAdjustBalance)
+	return r
+}
+
+func perform(t *testing.T, r *gin.Engine, method, path, userID, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	token, err := auth.Sign(&auth.Claims{Subject: userID, ExpiresAt: time.Now().Add(time.Hour).Unix()}, "", testJWTKey)
+	require.NoError(t, err)
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAllows(t *testing.T) {
+	assert.True(t, Allows([]string{postgres.RoleAdmin}, PermManageRoles))
+	assert.True(t, Allows([]string{postgres.RoleSupport}, PermFreezeAccounts))
+	assert.False(t, Allows([]string{postgres.RoleSupport}, PermAdjustBalances))
+	assert.False(t, Allows([]string{postgres.RoleSupport}, PermReverseTransactions))
+	assert.True(t, Allows([]string{postgres.RoleAdmin}, PermReverseTransactions))
+	assert.True(t, Allows([]string{postgres.RoleAdmin}, PermCreateUsers))
+	assert.True(t, Allows([]string{postgres.RoleAdmin}, PermDepositFunds))
+	assert.False(t, Allows([]string{postgres.RoleSupport}, PermDepositFunds))
+	assert.False(t, Allows([]string{"unknown"}, PermViewAccounts))
+	assert.False(t, Allows(nil, PermViewAccounts))
+}
+
+func TestRequire(t *testing.T) {
+	r := newTestRouter(t, &rolesRepository{roles: map[int64][]string{
+		1: {postgres.RoleAdmin},
+		2: {postgres.RoleSupport},
+	}})
+
+	cases := []struct {
+		name   string
+		path   string
+		userID string
+		status int
+	}{
+		{"admin views", "/admin/view", "1", http.StatusNoContent},
+		{"admin manages settings", "/admin/settings", "1", http.StatusNoContent},
+		{"support views", "/admin/view", "2", http.StatusNoContent},
+		{"support cannot manage settings", "/admin/settings", "2", http.StatusForbidden},
+		{"regular user", "/admin/view", "3", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		w := perform(t, r, http.MethodGet, tc.path, tc.userID, "")
+		assert.Equal(t, tc.status, w.Code, tc.name)
+	}
+
+	// Без аутентификации запрос не доходит до проверки ролей
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/view", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestHandleAdjustBalance(t *testing.T) {
+	repo := &rolesRepository{roles: map[int64][]string{
+		1: {postgres.RoleAdmin},
+		2: {postgres.RoleSupport},
+	}}
+	r := newTestRouter(t, repo)
+
+	w := perform(t, r, http.MethodPost, "/admin/users/5/adjustments", "1", `{"amount": -25.00, "reason": "duplicate deposit"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, repo.adjustments, 1)
+	assert.Equal(t, int64(5), *repo.adjustments[0].UserID)
+	assert.Equal(t, money.MustParse("-25.00"), repo.adjustments[0].Amount)
+	assert.Equal(t, money.DefaultCurrency, repo.adjustments[0].Currency)
+
+	// Причина обязательна, а поддержке корректировки недоступны
+	w = perform(t, r, http.MethodPost, "/admin/users/5/adjustments", "1", `{"amount": 10}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = perform(t, r, http.MethodPost, "/admin/users/5/adjustments", "2", `{"amount": 10, "reason": "bonus"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Len(t, repo.adjustments, 1)
+}
+
+func TestRequireForUsers(t *testing.T) {
+	r := newTestRouter(t, &rolesRepository{roles: map[int64][]string{
+		1: {postgres.RoleAdmin},
+		2: {postgres.RoleSupport},
+	}})
+
+	assert.Equal(t, http.StatusNoContent, perform(t, r, http.MethodPost, "/admin/deposit", "1", "").Code)
+	assert.Equal(t, http.StatusForbidden, perform(t, r, http.MethodPost, "/admin/deposit", "2", "").Code)
+	assert.Equal(t, http.StatusForbidden, perform(t, r, http.MethodPost, "/admin/deposit", "3", "").Code)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/service/deposit", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
@@ -0,0 +1,74 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/httpapi"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	"github.com/gin-gonic/gin"
+)
+
+// HandleListAuditLog godoc
+// @Summary Журнал аудита
+// @Description Возвращает записи журнала аудита от новых к старым: кто (пользователь или API-ключ) выполнил операцию, меняющую состояние, роут и путь, SHA-256 тела запроса, адрес клиента, идентификатор запроса (X-Request-ID) и результат. Для следующей страницы передайте next_before_id в before_id. Журнал нельзя изменить или очистить. Требуется роль admin
+// @Tags Администрирование
+// @Produce json
+// @Param actor_type query string false "Тип исполнителя" Enums(user, api_key)
+// @Param actor_id query int false "ID пользователя или API-ключа"
+// @Param action query string false "Метод и шаблон роута, например POST /transfer"
+// @Param request_id query string false "Идентификатор запроса"
+// @Param result query string false "Результат" Enums(success, failure)
+// @Param from query string false "Начало периода (RFC 3339), включительно"
+// @Param to query string false "Конец периода (RFC 3339), не включительно"
+// @Param before_id query int false "Записи старше указанной"
+// @Param limit query int false "Размер страницы (по умолчанию 50, максимум 200)"
+// @Success 200 {object} postgres.AuditPage "Страница журнала"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/audit-log [get]
+func (h *Handler) HandleListAuditLog(c *gin.Context) {
+	var query struct {
+		ActorType string `form:"actor_type"`
+		ActorID   int64  `form:"actor_id" binding:"omitempty,min=1"`
+		Action    string `form:"action"`
+		RequestID string `form:"request_id"`
+		Result    string `form:"result"`
+		From      string `form:"from"`
+		To        string `form:"to"`
+		BeforeID  int64  `form:"before_id" binding:"omitempty,min=1"`
+		Limit     int    `form:"limit" binding:"omitempty,min=1,max=200"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		httpapi.RespondBindError(c, err)
+		return
+	}
+
+	filter := postgres.AuditFilter{
+		ActorType: query.ActorType,
+		ActorID:   query.ActorID,
+		Action:    query.Action,
+		RequestID: query.RequestID,
+		Result:    query.Result,
+		BeforeID:  query.BeforeID,
+		Limit:     query.Limit,
+	}
+	var err error
+	if filter.From, err = httpapi.TimeParam(query.From); err != nil {
+		httpapi.RespondBadRequest(c, "invalid from, expected RFC 3339 timestamp")
+		return
+	}
+	if filter.To, err = httpapi.TimeParam(query.To); err != nil {
+		httpapi.RespondBadRequest(c, "invalid to, expected RFC 3339 timestamp")
+		return
+	}
+
+	page, err := h.service.ListAuditLog(c.Request.Context(), filter)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
@@ -0,0 +1,103 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/httpapi"
+	"github.com/gin-gonic/gin"
+)
+
+// RolesResponse — роли пользователя
+type RolesResponse struct {
+	UserID int64    `json:"user_id"`
+	Roles  []string `json:"roles" example:"admin"`
+}
+
+// HandleGetUserRoles godoc
+// @Summary Роли пользователя
+// @Description Возвращает роли пользователя в административном API: admin — полный доступ, support — просмотр пользователей и транзакций и заморозка счетов. Требуется роль admin
+// @Tags Администрирование
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} RolesResponse "Роли"
+// @Failure 400 {object} httpapi.ErrorResponse "Некорректный id"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/users/{id}/roles [get]
+func (h *Handler) HandleGetUserRoles(c *gin.Context) {
+	userID, ok := httpapi.UserIDParam(c)
+	if !ok {
+		return
+	}
+
+	h.respondRoles(c, userID)
+}
+
+// HandleGrantRole godoc
+// @Summary Выдача роли
+// @Description Выдаёт пользователю роль admin или support; повторная выдача ничего не меняет. Требуется роль admin
+// @Tags Администрирование
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param role path string true "Роль" Enums(admin, support)
+// @Success 200 {object} RolesResponse "Роли после выдачи"
+// @Failure 400 {object} httpapi.ErrorResponse "Некорректный id или неизвестная роль"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin"
+// @Failure 404 {object} httpapi.ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/users/{id}/roles/{role} [put]
+func (h *Handler) HandleGrantRole(c *gin.Context) {
+	userID, ok := httpapi.UserIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.GrantRole(c.Request.Context(), userID, c.Param("role")); err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	h.respondRoles(c, userID)
+}
+
+// HandleRevokeRole godoc
+// @Summary Отзыв роли
+// @Description Отзывает роль у пользователя. Свою роль admin отозвать нельзя, чтобы не остаться без администратора. Требуется роль admin
+// @Tags Администрирование
+// @Param id path int true "ID пользователя"
+// @Param role path string true "Роль" Enums(admin, support)
+// @Success 204 "Роль отозвана"
+// @Failure 400 {object} httpapi.ErrorResponse "Некорректный id или неизвестная роль"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin"
+// @Failure 404 {object} httpapi.ErrorResponse "У пользователя нет этой роли"
+// @Failure 409 {object} httpapi.ErrorResponse "Попытка отозвать свою роль admin"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/users/{id}/roles/{role} [delete]
+func (h *Handler) HandleRevokeRole(c *gin.Context) {
+	userID, ok := httpapi.UserIDParam(c)
+	if !ok {
+		return
+	}
+	// Require пропускает только пользователей, поэтому ID вызывающего всегда есть
+	callerID, _ := httpapi.CallerID(c)
+
+	if err := h.service.RevokeRole(c.Request.Context(), callerID, userID, c.Param("role")); err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) respondRoles(c *gin.Context, userID int64) {
+	roles, err := h.service.GetUserRoles(c.Request.Context(), userID)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RolesResponse{UserID: userID, Roles: roles})
+}
@@ -0,0 +1,269 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/httpapi"
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	service "github.com/EugeneKrivoshein/fin_service/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// UsersResponse — страница списка пользователей
+type UsersResponse struct {
+	Users  []postgres.User `json:"users"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
+
+// AdjustmentRequest — ручная корректировка баланса
+type AdjustmentRequest struct {
+	// Сумма корректировки: положительная зачисляется на счёт, отрицательная списывается с него
+	Amount   money.Amount   `json:"amount" binding:"required" swaggertype:"number" example:"-25.00"`
+	Currency money.Currency `json:"currency" swaggertype:"string" example:"RUB"`
+	// Причина корректировки, сохраняется в транзакции
+	Reason string `json:"reason" binding:"required" example:"duplicate deposit #1234"`
+}
+
+// HandleListUsers godoc
+// @Summary Список пользователей
+// @Description Возвращает пользователей, упорядоченных по id, постранично. Требуется роль admin или support
+// @Tags Администрирование
+// @Produce json
+// @Param limit query int false "Размер страницы (по умолчанию 20, максимум 100)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} UsersResponse "Страница пользователей"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin или support"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/users [get]
+func (h *Handler) HandleListUsers(c *gin.Context) {
+	var query struct {
+		Limit  int `form:"limit" binding:"omitempty,min=1,max=100"`
+		Offset int `form:"offset" binding:"omitempty,min=0"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		httpapi.RespondBindError(c, err)
+		return
+	}
+
+	users, err := h.service.ListUsers(c.Request.Context(), query.Limit, query.Offset)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = service.DefaultUsersLimit
+	}
+	c.JSON(http.StatusOK, UsersResponse{Users: users, Limit: limit, Offset: query.Offset})
+}
+
+// HandleGetUser godoc
+// @Summary Пользователь
+// @Description Возвращает любого пользователя со счетами. Требуется роль admin или support
+// @Tags Администрирование
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} postgres.User "Пользователь"
+// @Failure 400 {object} httpapi.ErrorResponse "Некорректный id"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin или support"
+// @Failure 404 {object} httpapi.ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/users/{id} [get]
+func (h *Handler) HandleGetUser(c *gin.Context) {
+	userID, ok := httpapi.UserIDParam(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.service.GetUser(c.Request.Context(), userID)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// HandleGetUserTransactions godoc
+// @Summary История транзакций пользователя
+// @Description Возвращает страницу транзакций любого пользователя от новых к старым с теми же фильтрами и курсорами, что и GET /transactions. Требуется роль admin или support
+// @Tags Администрирование
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param limit query int false "Размер страницы (по умолчанию 10, максимум 100)"
+// @Param before query string false "Курсор: транзакции старше указанной"
+// @Param after query string false "Курсор: транзакции новее указанной"
+// @Param type query string false "Тип транзакции" Enums(deposit, transfer, withdrawal, reversal, adjustment)
+// @Param currency query string false "Валюта транзакций (ISO 4217)"
+// @Param from query string false "Начало периода (RFC 3339), включительно"
+// @Param to query string false "Конец периода (RFC 3339), не включительно"
+// @Param min_amount query number false "Минимальная сумма"
+// @Param max_amount query number false "Максимальная сумма"
+// @Success 200 {object} postgres.TransactionPage "Страница транзакций"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin или support"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/users/{id}/transactions [get]
+func (h *Handler) HandleGetUserTransactions(c *gin.Context) {
+	userID, ok := httpapi.UserIDParam(c)
+	if !ok {
+		return
+	}
+	filter, ok := httpapi.BindTransactionFilter(c)
+	if !ok {
+		return
+	}
+	filter.UserID = userID
+
+	h.respondTransactions(c, filter)
+}
+
+// HandleSearchTransactions godoc
+// @Summary Поиск транзакций
+// @Description Ищет транзакции всех пользователей по фильтрам; с user_id — только транзакции, в которых участвует пользователь. Результат упорядочен от новых к старым, для следующей страницы передайте next_cursor в том же параметре (before или after), что и текущий курсор. Требуется роль admin или support
+// @Tags Администрирование
+// @Produce json
+// @Param user_id query int false "ID пользователя"
+// @Param limit query int false "Размер страницы (по умолчанию 10, максимум 100)"
+// @Param before query string false "Курсор: транзакции старше указанной"
+// @Param after query string false "Курсор: транзакции новее указанной"
+// @Param type query string false "Тип транзакции" Enums(deposit, transfer, withdrawal, reversal, adjustment)
+// @Param currency query string false "Валюта транзакций (ISO 4217)"
+// @Param from query string false "Начало периода (RFC 3339), включительно"
+// @Param to query string false "Конец периода (RFC 3339), не включительно"
+// @Param min_amount query number false "Минимальная сумма"
+// @Param max_amount query number false "Максимальная сумма"
+// @Success 200 {object} postgres.TransactionPage "Страница транзакций"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin или support"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/transactions [get]
+func (h *Handler) HandleSearchTransactions(c *gin.Context) {
+	var query struct {
+		UserID int64 `form:"user_id" binding:"omitempty,min=1"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		httpapi.RespondBindError(c, err)
+		return
+	}
+	filter, ok := httpapi.BindTransactionFilter(c)
+	if !ok {
+		return
+	}
+	filter.UserID = query.UserID
+
+	h.respondTransactions(c, filter)
+}
+
+func (h *Handler) respondTransactions(c *gin.Context, filter postgres.TransactionFilter) {
+	page, err := h.service.GetTransactions(c.Request.Context(), filter)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// HandleFreezeUser godoc
+// @Summary Заморозка счёта
+// @Description Запрещает пополнения, снятия и переводы по счёту до разморозки. Требуется роль admin или support
+// @Tags Администрирование
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} postgres.User "Пользователь"
+// @Failure 400 {object} httpapi.ErrorResponse "Некорректный id"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin или support"
+// @Failure 404 {object} httpapi.ErrorResponse "Пользователь не найден"
+// @Failure 409 {object} httpapi.ErrorResponse "Счёт закрыт"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/users/{id}/freeze [post]
+func (h *Handler) HandleFreezeUser(c *gin.Context) {
+	h.changeUserStatus(c, h.service.FreezeUser)
+}
+
+// HandleUnfreezeUser godoc
+// @Summary Разморозка счёта
+// @Description Снова разрешает операции по замороженному счёту. Требуется роль admin или support
+// @Tags Администрирование
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} postgres.User "Пользователь"
+// @Failure 400 {object} httpapi.ErrorResponse "Некорректный id"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin или support"
+// @Failure 404 {object} httpapi.ErrorResponse "Пользователь не найден"
+// @Failure 409 {object} httpapi.ErrorResponse "Счёт закрыт"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/users/{id}/unfreeze [post]
+func (h *Handler) HandleUnfreezeUser(c *gin.Context) {
+	h.changeUserStatus(c, h.service.UnfreezeUser)
+}
+
+func (h *Handler) changeUserStatus(c *gin.Context, change func(ctx context.Context, userID int64) (*postgres.User, error)) {
+	userID, ok := httpapi.UserIDParam(c)
+	if !ok {
+		return
+	}
+
+	user, err := change(c.Request.Context(), userID)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// HandleAdjustBalance godoc
+// @Summary Ручная корректировка баланса
+// @Description Зачисляет (положительная сумма) или списывает (отрицательная сумма) деньги по счёту пользователя в валюте и создаёт транзакцию типа adjustment с указанной причиной. Корректировка возможна и на замороженном счёте; списать можно только доступный остаток. Требуется роль admin
+// @Tags Администрирование
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param Idempotency-Key header string false "Ключ идемпотентности для безопасного повтора запроса"
+// @Param input body AdjustmentRequest true "Сумма, валюта и причина корректировки"
+// @Success 200 {object} httpapi.OperationResponse "Баланс скорректирован"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации, неподдерживаемая валюта или некорректная причина"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin"
+// @Failure 404 {object} httpapi.ErrorResponse "Пользователь или счёт в валюте не найден"
+// @Failure 409 {object} httpapi.ErrorResponse "Счёт закрыт либо ключ идемпотентности использован с другим запросом"
+// @Failure 422 {object} httpapi.ErrorResponse "Недостаточно доступных средств для списания"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/users/{id}/adjustments [post]
+func (h *Handler) HandleAdjustBalance(c *gin.Context) {
+	userID, ok := httpapi.UserIDParam(c)
+	if !ok {
+		return
+	}
+	var req AdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpapi.RespondBindError(c, err)
+		return
+	}
+
+	key, ok := httpapi.IdempotencyKey(c)
+	if !ok {
+		return
+	}
+
+	t, err := h.service.AdjustBalance(c.Request.Context(), userID, req.Amount, req.Currency, req.Reason, key)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, httpapi.OperationResponse{Message: "Баланс скорректирован", Transaction: t})
+}
@@ -2,26 +2,153 @@ package api
 
 import (
 	_ "github.com/EugeneKrivoshein/fin_service/docs"
+	"github.com/EugeneKrivoshein/fin_service/internal/admin"
+	"github.com/EugeneKrivoshein/fin_service/internal/auth"
 	handler "github.com/EugeneKrivoshein/fin_service/internal/handlers"
 	"github.com/gin-gonic/gin"
 	files "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func SetupRouter(h *handler.Handler) *gin.Engine {
-	r := gin.Default()
+func SetupRouter(h *handler.Handler, a *admin.Handler, verifier *auth.Verifier, limiter *handler.RateLimiter) *gin.Engine {
+	engine := gin.Default()
 
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(files.Handler))
+	// Каждый запрос получает идентификатор (X-Request-ID) для журнала аудита и логов
+	engine.Use(handler.RequestID())
+	// Частота запросов ограничивается по адресу клиента до аутентификации и по клиенту после неё
+	engine.Use(limiter.ByIP())
 
-	// Роут для пополнения баланса
-	r.POST("/deposit", h.HandleDeposit)
+	engine.GET("/swagger/*any", ginSwagger.WrapHandler(files.Handler))
+
+	// Все роуты API, кроме документации, доступны только с действительным JWT пользователя.
+	// Запросы, меняющие состояние, записываются в журнал аудита; отклонённые ограничителем частоты — нет.
+	r := engine.Group("/", h.Authenticate(verifier, ""), limiter.ByClient(), h.Audit())
+	// Роуты, доступные также сервисам с API-ключом, которому выдана область доступа scope
+	scoped := func(scope string) *gin.RouterGroup {
+		return engine.Group("/", h.Authenticate(verifier, scope), limiter.ByClient(), h.Audit())
+	}
+
+	// Роут для пополнения баланса: деньги приходят извне, поэтому пополнять могут только сервисы
+	// и пользователи с административным разрешением
+	scoped(auth.ScopeDepositWrite).POST("/deposit", a.RequireForUsers(admin.PermDepositFunds), h.HandleDeposit)
 
 	// Роут для перевода денег
-	r.POST("/transfer", h.HandleTransfer)
+	scoped(auth.ScopeTransferWrite).POST("/transfer", h.HandleTransfer)
+
+	// Роут для фиксации курса перед переводом с конвертацией
+	scoped(auth.ScopeTransferWrite).POST("/fx/quotes", h.HandleCreateFXQuote)
+
+	// Роуты для запланированных и повторяющихся переводов
+	r.POST("/scheduled-transfers", h.HandleCreateScheduledTransfer)
+	r.GET("/scheduled-transfers/:id", h.HandleGetScheduledTransfer)
+	r.GET("/scheduled-transfers/:id/runs", h.HandleListScheduledTransferRuns)
+	r.POST("/scheduled-transfers/:id/pause", h.HandlePauseScheduledTransfer)
+	r.POST("/scheduled-transfers/:id/resume", h.HandleResumeScheduledTransfer)
+	r.POST("/scheduled-transfers/:id/cancel", h.HandleCancelScheduledTransfer)
+
+	// Роут для снятия денег
+	scoped(auth.ScopeWithdrawWrite).POST("/withdraw", h.HandleWithdraw)
+
+	// Роуты для холдов: резервирование средств, списание и освобождение резерва
+	holds := scoped(auth.ScopeHoldsWrite)
+	holds.POST("/holds", h.HandleCreateHold)
+	holds.GET("/holds/:id", h.HandleGetHold)
+	holds.POST("/holds/:id/capture", h.HandleCaptureHold)
+	holds.POST("/holds/:id/release", h.HandleReleaseHold)
+
+	// Роуты для управления счетами пользователей; создавать пользователей могут только сервисы и администраторы
+	scoped(auth.ScopeUsersWrite).POST("/users", a.RequireForUsers(admin.PermCreateUsers), h.HandleCreateUser)
+	r.GET("/users/:id", h.HandleGetUser)
+	r.POST("/users/:id/close", h.HandleCloseUser)
+
+	// Роуты для счетов пользователя в разных валютах
+	r.GET("/users/:id/accounts", h.HandleListAccounts)
+	r.POST("/users/:id/accounts", h.HandleOpenAccount)
+	r.GET("/users/:id/scheduled-transfers", h.HandleListScheduledTransfers)
+
+	// Роут для лимитов расходов пользователя и их использования
+	// Например: GET /users/1/spending-limits?currency=RUB
+	r.GET("/users/:id/spending-limits", h.HandleGetSpendingStatus)
+
+	// Роут для получения баланса пользователя
+	// Например: GET /users/1/balance?currency=USD&as_of=2025-02-01T12:00:00Z
+	scoped(auth.ScopeBalanceRead).GET("/users/:id/balance", h.HandleGetBalance)
+
+	// Роут для выписки по счёту за период
+	// Например: GET /users/1/statement?format=pdf&from=2025-01-01T00:00:00Z&to=2025-02-01T00:00:00Z
+	scoped(auth.ScopeBalanceRead).GET("/users/:id/statement", h.HandleGetStatement)
+
+	// Административные роуты: каждая группа требует разрешения, которое дают роли admin и support
+	adm := r.Group("/admin")
+	{
+		// Просмотр пользователей и их транзакций, поиск транзакций по всем пользователям
+		// Например: GET /admin/transactions?type=adjustment&min_amount=10000
+		view := adm.Group("/", a.Require(admin.PermViewAccounts))
+		view.GET("/users", a.HandleListUsers)
+		view.GET("/users/:id", a.HandleGetUser)
+		view.GET("/users/:id/transactions", a.HandleGetUserTransactions)
+		view.GET("/transactions", a.HandleSearchTransactions)
+
+		// Заморозка и разморозка счетов
+		freeze := adm.Group("/", a.Require(admin.PermFreezeAccounts))
+		freeze.POST("/users/:id/freeze", a.HandleFreezeUser)
+		freeze.POST("/users/:id/unfreeze", a.HandleUnfreezeUser)
+
+		// Ручные корректировки балансов с обязательной причиной
+		adm.POST("/users/:id/adjustments", a.Require(admin.PermAdjustBalances), a.HandleAdjustBalance)
+
+		// Роли пользователей
+		roles := adm.Group("/", a.Require(admin.PermManageRoles))
+		roles.GET("/users/:id/roles", a.HandleGetUserRoles)
+		roles.PUT("/users/:id/roles/:role", a.HandleGrantRole)
+		roles.DELETE("/users/:id/roles/:role", a.HandleRevokeRole)
+
+		// Журнал аудита операций, меняющих состояние
+		// Например: GET /admin/audit-log?actor_type=user&actor_id=1&result=failure
+		adm.GET("/audit-log", a.Require(admin.PermViewAuditLog), a.HandleListAuditLog)
+
+		settings := adm.Group("/", a.Require(admin.PermManageSettings))
+
+		// Сверка балансов с историей транзакций; POST дополнительно исправляет расхождения
+		settings.GET("/reconciliation", h.HandleReconcile)
+		settings.POST("/reconciliation", h.HandleReconcileFix)
+
+		// Сверка балансов с главной книгой
+		settings.GET("/ledger/verify", h.HandleVerifyLedger)
+
+		// Лимиты расходов тарифов, собственные лимиты пользователей и смена тарифа
+		settings.GET("/spending-limits/tiers/:tier", h.HandleListTierSpendingLimits)
+		settings.PUT("/spending-limits/tiers/:tier", h.HandleSetTierSpendingLimits)
+		settings.PUT("/users/:id/spending-limits", h.HandleSetUserSpendingLimits)
+		settings.DELETE("/users/:id/spending-limits", h.HandleDeleteUserSpendingLimits)
+		settings.PUT("/users/:id/tier", h.HandleSetUserTier)
+
+		// Правила комиссий за переводы и снятия
+		settings.GET("/fee-rules", h.HandleListFeeRules)
+		settings.POST("/fee-rules", h.HandleCreateFeeRule)
+		settings.DELETE("/fee-rules/:id", h.HandleDeleteFeeRule)
+
+		// Подписки на вебхуки, история доставок и повторная отправка событий
+		settings.POST("/webhooks", h.HandleCreateWebhookSubscription)
+		settings.GET("/webhooks", h.HandleListWebhookSubscriptions)
+		settings.DELETE("/webhooks/:id", h.HandleDeleteWebhookSubscription)
+		settings.GET("/webhooks/:id/deliveries", h.HandleListWebhookDeliveries)
+		settings.POST("/webhook-deliveries/:id/redeliver", h.HandleRedeliverWebhook)
+
+		// API-ключи сервисов: выпуск, ротация и отзыв
+		settings.POST("/api-keys", h.HandleCreateAPIKey)
+		settings.GET("/api-keys", h.HandleListAPIKeys)
+		settings.POST("/api-keys/:id/rotate", h.HandleRotateAPIKey)
+		settings.POST("/api-keys/:id/revoke", h.HandleRevokeAPIKey)
+	}
+
+	// Роут для получения истории транзакций с курсорной пагинацией и фильтрами
+	// Например: GET /transactions?user_id=1&limit=20&type=transfer&before=<next_cursor>
+	scoped(auth.ScopeTransactionsRead).GET("/transactions", h.HandleGetTransactions)
 
-	// Роут для получения последних 10 транзакций
-	// Например: GET /transactions?user_id=1
-	r.GET("/transactions", h.HandleGetTransactions)
+	// Роут для сторно ошибочного перевода: деньги возвращаются со счёта получателя, поэтому сторно
+	// доступно только пользователям с административным разрешением
+	r.POST("/transactions/:id/reverse", a.Require(admin.PermReverseTransactions), h.HandleReverseTransaction)
 
-	return r
+	return engine
 }
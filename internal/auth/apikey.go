@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Области доступа (scopes) API-ключей сервисов. Ключ допускается только к роутам, для которых
+// указана одна из его областей.
+const (
+	ScopeDepositWrite     = "deposit:write"
+	ScopeTransferWrite    = "transfer:write"
+	ScopeWithdrawWrite    = "withdraw:write"
+	ScopeHoldsWrite       = "holds:write"
+	ScopeTransactionsRead = "transactions:read"
+	ScopeBalanceRead      = "balance:read"
+	ScopeUsersWrite       = "users:write"
+)
+
+// Scopes — все области доступа, которые можно выдать ключу
+var Scopes = []string{
+	ScopeDepositWrite, ScopeTransferWrite, ScopeWithdrawWrite, ScopeHoldsWrite,
+	ScopeTransactionsRead, ScopeBalanceRead, ScopeUsersWrite,
+}
+
+// Префикс API-ключей: по нему ключ легко узнать в конфигурации и логах
+const apiKeyPrefix = "fsk_"
+
+// Длина видимой части ключа, которая хранится открыто и показывается в списке ключей
+const apiKeyDisplayLength = len(apiKeyPrefix) + 8
+
+// NewAPIKey создаёт случайный API-ключ и возвращает его вместе с видимой частью для списка ключей
+func NewAPIKey() (key, displayPrefix string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	key = apiKeyPrefix + hex.EncodeToString(b)
+	return key, key[:apiKeyDisplayLength], nil
+}
+
+// IsAPIKey сообщает, похожа ли строка на API-ключ сервиса
+func IsAPIKey(s string) bool {
+	return strings.HasPrefix(s, apiKeyPrefix) && len(s) > apiKeyDisplayLength
+}
+
+// HashAPIKey возвращает хеш ключа, под которым он хранится в базе. Ключ случаен и достаточно длинный,
+// поэтому медленное хеширование, как для паролей, не требуется.
+func HashAPIKey(key string) []byte {
+	h := sha256.Sum256([]byte(key))
+	return h[:]
+}
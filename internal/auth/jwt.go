@@ -0,0 +1,179 @@
+// Пакет auth — проверка и выпуск JWT (RFC 7519) для аутентификации запросов к API.
+//
+// Поддерживается только HS256: токены подписываются общим секретом, который знают сервис и выпускающая
+// токены сторона. Секретов может быть несколько — токен выбирает ключ по заголовку kid, что позволяет
+// менять секрет без одномоментной замены всех выданных токенов. Токен без kid проверяется ключом
+// с пустым идентификатором. Субъект токена (sub) — ID пользователя.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNoKeys        = errors.New("at least one JWT signing key is required")
+	ErrInvalidToken  = errors.New("invalid token")
+	ErrUnknownKey    = errors.New("token signed with unknown key")
+	ErrTokenExpired  = errors.New("token expired")
+	ErrTokenNotValid = errors.New("token not valid yet")
+	ErrInvalidClaims = errors.New("token issuer, audience or subject is invalid")
+)
+
+// Допустимое расхождение часов сервиса и выпускающей токены стороны
+const clockSkew = 30 * time.Second
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// Claims — утверждения токена. Время — в секундах Unix; нулевое значение означает, что утверждения нет.
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+}
+
+// UserID возвращает ID пользователя из субъекта токена
+func (c *Claims) UserID() (int64, error) {
+	id, err := strconv.ParseInt(c.Subject, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidClaims
+	}
+	return id, nil
+}
+
+// Audience — получатели токена: по RFC 7519 это строка или массив строк
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*a = Audience{s}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func (a Audience) contains(s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Verifier проверяет подпись и утверждения токенов
+type Verifier struct {
+	keys     map[string][]byte
+	issuer   string
+	audience string
+	now      func() time.Time
+}
+
+// NewVerifier создаёт проверку токенов с ключами keys (kid → секрет). Непустые issuer и audience
+// требуют совпадения утверждений iss и aud.
+func NewVerifier(keys map[string][]byte, issuer, audience string) (*Verifier, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+	for kid, key := range keys {
+		if len(key) == 0 {
+			return nil, fmt.Errorf("empty JWT signing key %q", kid)
+		}
+	}
+	return &Verifier{keys: keys, issuer: issuer, audience: audience, now: time.Now}, nil
+}
+
+// Verify проверяет токен и возвращает его утверждения. Срок действия (exp) обязателен.
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, ErrInvalidToken
+	}
+	// Алгоритм фиксирован: токены с alg "none" или асимметричными алгоритмами не принимаются
+	if h.Alg != "HS256" {
+		return nil, ErrInvalidToken
+	}
+	key, ok := v.keys[h.Kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(sig, sign(key, parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidToken
+	}
+
+	var c Claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return nil, ErrInvalidToken
+	}
+	now := v.now()
+	if c.ExpiresAt == 0 || now.After(time.Unix(c.ExpiresAt, 0).Add(clockSkew)) {
+		return nil, ErrTokenExpired
+	}
+	if c.NotBefore != 0 && now.Before(time.Unix(c.NotBefore, 0).Add(-clockSkew)) {
+		return nil, ErrTokenNotValid
+	}
+	if v.issuer != "" && c.Issuer != v.issuer {
+		return nil, ErrInvalidClaims
+	}
+	if v.audience != "" && !c.Audience.contains(v.audience) {
+		return nil, ErrInvalidClaims
+	}
+	if _, err := c.UserID(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Sign выпускает токен с утверждениями claims, подписанный ключом key с идентификатором kid
+func Sign(claims *Claims, kid string, key []byte) (string, error) {
+	h, err := json.Marshal(header{Alg: "HS256", Typ: "JWT", Kid: kid})
+	if err != nil {
+		return "", err
+	}
+	c, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sign(key, unsigned)), nil
+}
+
+func sign(key []byte, unsigned string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(unsigned))
+	return m.Sum(nil)
+}
+
+func decodeSegment(s string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
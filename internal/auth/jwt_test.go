@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	now    = time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	secret = []byte("test-secret")
+)
+
+func newTestVerifier(t *testing.T, keys map[string][]byte, issuer, audience string) *Verifier {
+	t.Helper()
+	v, err := NewVerifier(keys, issuer, audience)
+	require.NoError(t, err)
+	v.now = func() time.Time { return now }
+	return v
+}
+
+func validClaims() *Claims {
+	return &Claims{Subject: "42", ExpiresAt: now.Add(time.Hour).Unix(), IssuedAt: now.Unix()}
+}
+
+func TestVerify(t *testing.T) {
+	v := newTestVerifier(t, map[string][]byte{"": secret}, "", "")
+
+	token, err := Sign(validClaims(), "", secret)
+	require.NoError(t, err)
+	c, err := v.Verify(token)
+	require.NoError(t, err)
+	id, err := c.UserID()
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), id)
+}
+
+func TestVerify_KeyRotation(t *testing.T) {
+	oldKey, newKey := []byte("old"), []byte("new")
+	v := newTestVerifier(t, map[string][]byte{"2025-01": oldKey, "2025-05": newKey}, "", "")
+
+	for kid, key := range map[string][]byte{"2025-01": oldKey, "2025-05": newKey} {
+		token, err := Sign(validClaims(), kid, key)
+		require.NoError(t, err)
+		_, err = v.Verify(token)
+		assert.NoError(t, err, kid)
+	}
+
+	token, err := Sign(validClaims(), "2024-12", oldKey)
+	require.NoError(t, err)
+	_, err = v.Verify(token)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	// Ключ с чужим kid не подходит
+	token, err = Sign(validClaims(), "2025-05", oldKey)
+	require.NoError(t, err)
+	_, err = v.Verify(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestVerify_Rejects(t *testing.T) {
+	v := newTestVerifier(t, map[string][]byte{"": secret}, "fin_service", "api")
+
+	valid := func() *Claims {
+		c := validClaims()
+		c.Issuer, c.Audience = "fin_service", Audience{"api", "admin"}
+		return c
+	}
+	cases := map[string]struct {
+		claims func(c *Claims)
+		err    error
+	}{
+		"expired":        {func(c *Claims) { c.ExpiresAt = now.Add(-time.Minute).Unix() }, ErrTokenExpired},
+		"no expiry":      {func(c *Claims) { c.ExpiresAt = 0 }, ErrTokenExpired},
+		"not yet valid":  {func(c *Claims) { c.NotBefore = now.Add(time.Minute).Unix() }, ErrTokenNotValid},
+		"wrong issuer":   {func(c *Claims) { c.Issuer = "other" }, ErrInvalidClaims},
+		"wrong audience": {func(c *Claims) { c.Audience = Audience{"other"} }, ErrInvalidClaims},
+		"bad subject":    {func(c *Claims) { c.Subject = "alice" }, ErrInvalidClaims},
+	}
+	for name, tc := range cases {
+		c := valid()
+		tc.claims(c)
+		token, err := Sign(c, "", secret)
+		require.NoError(t, err)
+		_, err = v.Verify(token)
+		assert.ErrorIs(t, err, tc.err, name)
+	}
+
+	// Расхождение часов в пределах clockSkew допустимо
+	c := valid()
+	c.ExpiresAt = now.Add(-clockSkew / 2).Unix()
+	token, err := Sign(c, "", secret)
+	require.NoError(t, err)
+	_, err = v.Verify(token)
+	assert.NoError(t, err)
+}
+
+func TestVerify_Tampered(t *testing.T) {
+	v := newTestVerifier(t, map[string][]byte{"": secret}, "", "")
+
+	token, err := Sign(validClaims(), "", secret)
+	require.NoError(t, err)
+	parts := strings.Split(token, ".")
+
+	// Подмена субъекта ломает подпись
+	forged := validClaims()
+	forged.Subject = "1"
+	other, err := Sign(forged, "", []byte("attacker"))
+	require.NoError(t, err)
+	_, err = v.Verify(parts[0] + "." + strings.Split(other, ".")[1] + "." + parts[2])
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Токен без подписи не принимается
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	_, err = v.Verify(none + "." + parts[1] + ".")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = v.Verify("not-a-token")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestAudience_UnmarshalString(t *testing.T) {
+	var c Claims
+	require.NoError(t, decodeSegment(base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"1","aud":"api"}`)), &c))
+	assert.Equal(t, Audience{"api"}, c.Audience)
+}
+
+func TestNewVerifier_RequiresKeys(t *testing.T) {
+	_, err := NewVerifier(nil, "", "")
+	assert.ErrorIs(t, err, ErrNoKeys)
+	_, err = NewVerifier(map[string][]byte{"a": nil}, "", "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,99 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+)
+
+// FileProvider читает курсы из JSON-файла вида {"USD/RUB": 92.5, "EUR/RUB": "100.10"},
+// где "USD/RUB" — цена одного доллара в рублях. Курс обратной пары вычисляется, если он не задан явно.
+// Файл перечитывается при изменении, поэтому курсы можно менять без перезапуска сервиса.
+// Предназначен для локального запуска и тестов.
+type FileProvider struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	rates   map[string]money.Rate
+}
+
+// NewFileProvider создаёт провайдера и сразу загружает файл, чтобы ошибки в нём обнаруживались при старте
+func NewFileProvider(path string) (*FileProvider, error) {
+	p := &FileProvider{path: path}
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *FileProvider) Rate(_ context.Context, from, to money.Currency) (money.Rate, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.reload(); err != nil {
+		return money.Rate{}, err
+	}
+
+	if r, ok := p.rates[pair(from, to)]; ok {
+		return r, nil
+	}
+	if r, ok := p.rates[pair(to, from)]; ok {
+		return r.Inverse()
+	}
+	return money.Rate{}, fmt.Errorf("%w: %s/%s", ErrRateUnavailable, from, to)
+}
+
+// Перечитывает файл, если он изменился с прошлой загрузки
+func (p *FileProvider) reload() error {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat rates file: %w", err)
+	}
+	if p.rates != nil && info.ModTime().Equal(p.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return fmt.Errorf("failed to read rates file: %w", err)
+	}
+	var raw map[string]money.Rate
+	if err = json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("failed to parse rates file %s: %w", p.path, err)
+	}
+
+	rates := make(map[string]money.Rate, len(raw))
+	for key, r := range raw {
+		from, to, ok := strings.Cut(key, "/")
+		if !ok {
+			return fmt.Errorf("invalid currency pair %q in rates file", key)
+		}
+		fromCurrency, err := money.ParseCurrency(from)
+		if err != nil {
+			return fmt.Errorf("invalid currency pair %q in rates file: %w", key, err)
+		}
+		toCurrency, err := money.ParseCurrency(to)
+		if err != nil {
+			return fmt.Errorf("invalid currency pair %q in rates file: %w", key, err)
+		}
+		if r.IsZero() {
+			return fmt.Errorf("missing rate for %q in rates file", key)
+		}
+		rates[pair(fromCurrency, toCurrency)] = r
+	}
+
+	p.rates = rates
+	p.modTime = info.ModTime()
+	return nil
+}
+
+func pair(from, to money.Currency) string {
+	return string(from) + "/" + string(to)
+}
@@ -0,0 +1,62 @@
+package fx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeRates(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestFileProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	writeRates(t, path, `{"USD/RUB": 92.5, "EUR/RUB": "100.10"}`)
+
+	p, err := NewFileProvider(path)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	r, err := p.Rate(ctx, "USD", "RUB")
+	require.NoError(t, err)
+	assert.Equal(t, "92.5", r.String())
+
+	// Обратный курс вычисляется из прямого
+	r, err = p.Rate(ctx, "RUB", "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, "0.00999001", r.String())
+
+	_, err = p.Rate(ctx, "USD", "EUR")
+	assert.ErrorIs(t, err, ErrRateUnavailable)
+
+	// Изменённый файл перечитывается
+	writeRates(t, path, `{"USD/RUB": 95}`)
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Second)))
+	r, err = p.Rate(ctx, "USD", "RUB")
+	require.NoError(t, err)
+	assert.Equal(t, money.MustParseRate("95").String(), r.String())
+}
+
+func TestFileProvider_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+
+	writeRates(t, path, `{"USDRUB": 92.5}`)
+	_, err := NewFileProvider(path)
+	assert.Error(t, err)
+
+	writeRates(t, path, `{"USD/XXX": 1}`)
+	_, err = NewFileProvider(path)
+	assert.ErrorIs(t, err, money.ErrUnsupportedCurrency)
+
+	writeRates(t, path, `{"USD/RUB": -1}`)
+	_, err = NewFileProvider(path)
+	assert.ErrorIs(t, err, money.ErrInvalidRate)
+}
@@ -0,0 +1,17 @@
+// Пакет fx — источники курсов валют для конвертации при переводах.
+package fx
+
+import (
+	"context"
+	"errors"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+)
+
+var ErrRateUnavailable = errors.New("exchange rate unavailable")
+
+// Provider — источник курсов. Rate возвращает, сколько единиц валюты to стоит одна единица валюты from,
+// или ErrRateUnavailable, если курс для этой пары неизвестен.
+type Provider interface {
+	Rate(ctx context.Context, from, to money.Currency) (money.Rate, error)
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/httpapi"
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/gin-gonic/gin"
+)
+
+type OpenAccountRequest struct {
+	Currency money.Currency `json:"currency" binding:"required" swaggertype:"string" example:"USD"`
+}
+
+// HandleListAccounts godoc
+// @Summary Счета пользователя
+// @Description Возвращает счета пользователя во всех валютах
+// @Tags Пользователи
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {array} postgres.Account "Счета пользователя"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации"
+// @Failure 403 {object} httpapi.ErrorResponse "Операция со счётом другого пользователя"
+// @Failure 404 {object} httpapi.ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /users/{id}/accounts [get]
+func (h *Handler) HandleListAccounts(c *gin.Context) {
+	userID, ok := ownUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	accounts, err := h.service.ListAccounts(c.Request.Context(), userID)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, accounts)
+}
+
+// HandleOpenAccount godoc
+// @Summary Открытие счёта в валюте
+// @Description Открывает пользователю счёт с нулевым балансом в новой валюте (ISO 4217)
+// @Tags Пользователи
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param input body OpenAccountRequest true "Валюта счёта"
+// @Success 201 {object} postgres.Account "Открытый счёт"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации или неподдерживаемая валюта"
+// @Failure 403 {object} httpapi.ErrorResponse "Операция со счётом другого пользователя"
+// @Failure 404 {object} httpapi.ErrorResponse "Пользователь не найден"
+// @Failure 409 {object} httpapi.ErrorResponse "Счёт в этой валюте уже открыт, пользователь заморожен или закрыт"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /users/{id}/accounts [post]
+func (h *Handler) HandleOpenAccount(c *gin.Context) {
+	userID, ok := ownUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req OpenAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpapi.RespondBindError(c, err)
+		return
+	}
+
+	account, err := h.service.OpenAccount(c.Request.Context(), userID, req.Currency)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, account)
+}
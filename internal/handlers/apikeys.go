@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/httpapi"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyRequest — название ключа (обычно имя сервиса), его области доступа и срок действия
+type APIKeyRequest struct {
+	Name      string     `json:"name" binding:"required" example:"billing"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1" example:"deposit:write,transactions:read"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type RotateAPIKeyRequest struct {
+	// Сколько секунд старый ключ действует после ротации; по умолчанию сутки, 0 — отозвать сразу, максимум 30 дней
+	GracePeriodSeconds *int64 `json:"grace_period_seconds,omitempty" example:"3600"`
+}
+
+// HandleCreateAPIKey godoc
+// @Summary Создание API-ключа
+// @Description Выпускает API-ключ для сервиса. Ключ передаётся в заголовке X-API-Key и открывает доступ только к роутам его областей: deposit:write, transfer:write, withdraw:write, holds:write, transactions:read, balance:read, users:write. Сам ключ возвращается только в ответе на этот запрос — в базе хранится его хеш
+// @Tags API-ключи
+// @Accept json
+// @Produce json
+// @Param input body APIKeyRequest true "Ключ"
+// @Success 201 {object} postgres.APIKey "Созданный ключ"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации, неизвестная область доступа или срок действия в прошлом"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/api-keys [post]
+func (h *Handler) HandleCreateAPIKey(c *gin.Context) {
+	var req APIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpapi.RespondBindError(c, err)
+		return
+	}
+
+	key, err := h.service.CreateAPIKey(c.Request.Context(), req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// HandleListAPIKeys godoc
+// @Summary API-ключи
+// @Description Возвращает все ключи, включая отозванные и истёкшие, без самих ключей: только видимую часть (prefix), области доступа и время последнего использования
+// @Tags API-ключи
+// @Produce json
+// @Success 200 {array} postgres.APIKey "Ключи"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/api-keys [get]
+func (h *Handler) HandleListAPIKeys(c *gin.Context) {
+	keys, err := h.service.ListAPIKeys(c.Request.Context())
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// HandleRotateAPIKey godoc
+// @Summary Ротация API-ключа
+// @Description Выпускает новый ключ с теми же названием и областями доступа. Старый ключ действует ещё grace_period_seconds (по умолчанию сутки), чтобы сервис успел перейти на новый. Новый ключ возвращается только в ответе на этот запрос
+// @Tags API-ключи
+// @Accept json
+// @Produce json
+// @Param id path int true "ID ключа"
+// @Param input body RotateAPIKeyRequest false "Параметры ротации"
+// @Success 201 {object} postgres.APIKey "Новый ключ"
+// @Failure 400 {object} httpapi.ErrorResponse "Некорректный id или срок действия старого ключа"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin"
+// @Failure 404 {object} httpapi.ErrorResponse "Ключ не найден"
+// @Failure 409 {object} httpapi.ErrorResponse "Ключ отозван или истёк"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/api-keys/{id}/rotate [post]
+func (h *Handler) HandleRotateAPIKey(c *gin.Context) {
+	id, ok := apiKeyIDParam(c)
+	if !ok {
+		return
+	}
+
+	// Тело необязательно: без него старый ключ действует ещё сутки
+	var req RotateAPIKeyRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			httpapi.RespondBindError(c, err)
+			return
+		}
+	}
+	var grace *time.Duration
+	if req.GracePeriodSeconds != nil {
+		g := time.Duration(*req.GracePeriodSeconds) * time.Second
+		grace = &g
+	}
+
+	key, err := h.service.RotateAPIKey(c.Request.Context(), id, grace)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// HandleRevokeAPIKey godoc
+// @Summary Отзыв API-ключа
+// @Description Отзывает ключ: запросы с ним сразу отклоняются с 401. Повторный отзыв ничего не меняет
+// @Tags API-ключи
+// @Produce json
+// @Param id path int true "ID ключа"
+// @Success 200 {object} postgres.APIKey "Отозванный ключ"
+// @Failure 400 {object} httpapi.ErrorResponse "Некорректный id"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin"
+// @Failure 404 {object} httpapi.ErrorResponse "Ключ не найден"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/api-keys/{id}/revoke [post]
+func (h *Handler) HandleRevokeAPIKey(c *gin.Context) {
+	id, ok := apiKeyIDParam(c)
+	if !ok {
+		return
+	}
+
+	key, err := h.service.RevokeAPIKey(c.Request.Context(), id)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+func apiKeyIDParam(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		httpapi.RespondBadRequest(c, "invalid api key id")
+		return 0, false
+	}
+	return id, true
+}
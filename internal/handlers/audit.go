@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/httpapi"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	"github.com/gin-gonic/gin"
+)
+
+// Заголовок с идентификатором запроса: клиент может передать свой, иначе сервис создаёт его сам
+const requestIDHeader = "X-Request-ID"
+
+// Ключ контекста gin, под которым RequestID сохраняет идентификатор запроса
+const requestIDKey = "request.id"
+
+// Наибольший размер тела запроса, меняющего состояние: тело целиком читается в память для хеша журнала аудита
+const maxRequestBodySize = 1 << 20
+
+// Код ошибки 413: тело запроса больше maxRequestBodySize
+const codeRequestTooLarge = "request_too_large"
+
+// Допустимый идентификатор запроса от клиента; остальные заменяются сгенерированными
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// RequestID — middleware, назначающее запросу идентификатор и возвращающее его в заголовке X-Request-ID,
+// чтобы запрос клиента можно было найти в журнале аудита и логах
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			b := make([]byte, 16)
+			rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+		c.Set(requestIDKey, id)
+		c.Header(requestIDHeader, id)
+		c.Next()
+	}
+}
+
+// Audit — middleware журнала аудита: после обработки каждого запроса, меняющего состояние (все методы,
+// кроме GET, HEAD и OPTIONS), записывает, кто его выполнил, роут и путь, SHA-256 тела запроса, адрес клиента,
+// идентификатор запроса (см. RequestID) и результат — статус ответа и код ошибки. Записываются и отклонённые
+// запросы, в том числе без нужной роли. Должен стоять после аутентификации: запросы без действительных
+// учётных данных до него не доходят.
+//
+// Тело больше maxRequestBodySize отклоняется с 413 без обработки и без записи в журнал.
+// Запись делается после ответа: ошибка записи не меняет результат операции и только логируется.
+func (h *Handler) Audit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxRequestBodySize))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			httpapi.AbortWithError(c, http.StatusRequestEntityTooLarge, httpapi.ErrorResponse{Code: codeRequestTooLarge, Error: "request body too large"})
+			return
+		}
+		if err != nil {
+			httpapi.RespondBadRequest(c, "failed to read request body")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+
+		c.Next()
+
+		e := &postgres.AuditEntry{
+			Action:      c.Request.Method + " " + c.FullPath(),
+			Path:        c.Request.URL.Path,
+			PayloadHash: hex.EncodeToString(sum[:]),
+			ClientIP:    c.ClientIP(),
+			RequestID:   c.GetString(requestIDKey),
+			StatusCode:  c.Writer.Status(),
+			Result:      postgres.AuditSuccess,
+		}
+		if k, ok := c.Get(apiKeyKey); ok {
+			e.ActorType, e.ActorID = postgres.ActorAPIKey, k.(*postgres.APIKey).ID
+		} else {
+			callerID, _ := httpapi.CallerID(c)
+			e.ActorType, e.ActorID = postgres.ActorUser, callerID
+		}
+		if e.StatusCode >= http.StatusBadRequest {
+			e.Result = postgres.AuditFailure
+			if code := httpapi.ErrorCode(c); code != "" {
+				e.ErrorCode = &code
+			}
+		}
+
+		// Клиент мог уже отключиться, но запись в журнал должна состояться
+		if err := h.service.RecordAudit(context.WithoutCancel(c.Request.Context()), e); err != nil {
+			log.Printf("Ошибка записи в журнал аудита (%s %s, request %s): %v", c.Request.Method, e.Path, e.RequestID, err)
+		}
+	}
+}
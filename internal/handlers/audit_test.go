@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/auth"
+	"github.com/EugeneKrivoshein/fin_service/internal/httpapi"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	service "github.com/EugeneKrivoshein/fin_service/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Репозиторий, который запоминает записи журнала аудита и знает один API-ключ
+type auditRepository struct {
+	apiKeyRepository
+	entries []*postgres.AuditEntry
+}
+
+func (r *auditRepository) RecordAudit(_ context.Context, e *postgres.AuditEntry) error {
+	r.entries = append(r.entries, e)
+	return nil
+}
+
+func TestAudit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	apiKey, _, err := auth.NewAPIKey()
+	require.NoError(t, err)
+	verifier, err := auth.NewVerifier(map[string][]byte{"": testJWTKey}, "", "")
+	require.NoError(t, err)
+	repo := &auditRepository{apiKeyRepository: apiKeyRepository{keys: map[string]*postgres.APIKey{
+		string(auth.HashAPIKey(apiKey)): {ID: 3, Name: "billing", Scopes: []string{auth.ScopeTransferWrite}},
+	}}}
+	h := NewHandler(service.NewService(repo))
+
+	r := gin.New()
+	r.Use(RequestID())
+	g := r.Group("/", h.Authenticate(verifier, auth.ScopeTransferWrite), h.Audit())
+	g.POST("/users/:id/ok", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	g.POST("/users/:id/fail", func(c *gin.Context) { httpapi.RespondError(c, postgres.ErrInsufficientFunds) })
+	g.GET("/users/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	body := `{"amount":"10.00"}`
+	sum := sha256.Sum256([]byte(body))
+
+	req := httptest.NewRequest(http.MethodPost, "/users/7/ok", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testToken(t, "7", time.Hour))
+	req.Header.Set(requestIDHeader, "client-req-1")
+	req.RemoteAddr = "203.0.113.7:4242"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "client-req-1", w.Header().Get(requestIDHeader))
+
+	req = httptest.NewRequest(http.MethodPost, "/users/7/fail", nil)
+	req.Header.Set("X-API-Key", apiKey)
+	req.Header.Set(requestIDHeader, "invalid id with spaces")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	// Запросы на чтение и запросы без учётных данных не записываются
+	req = httptest.NewRequest(http.MethodGet, "/users/7", nil)
+	req.Header.Set("Authorization", "Bearer "+testToken(t, "7", time.Hour))
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/users/7/ok", nil))
+
+	require.Len(t, repo.entries, 2)
+	ok := repo.entries[0]
+	assert.Equal(t, postgres.ActorUser, ok.ActorType)
+	assert.Equal(t, int64(7), ok.ActorID)
+	assert.Equal(t, "POST /users/:id/ok", ok.Action)
+	assert.Equal(t, "/users/7/ok", ok.Path)
+	assert.Equal(t, hex.EncodeToString(sum[:]), ok.PayloadHash)
+	assert.Equal(t, "203.0.113.7", ok.ClientIP)
+	assert.Equal(t, "client-req-1", ok.RequestID)
+	assert.Equal(t, http.StatusNoContent, ok.StatusCode)
+	assert.Equal(t, postgres.AuditSuccess, ok.Result)
+	assert.Nil(t, ok.ErrorCode)
+
+	failed := repo.entries[1]
+	assert.Equal(t, postgres.ActorAPIKey, failed.ActorType)
+	assert.Equal(t, int64(3), failed.ActorID)
+	assert.Equal(t, postgres.AuditFailure, failed.Result)
+	assert.Equal(t, "insufficient_funds", *failed.ErrorCode)
+	// Некорректный идентификатор клиента заменяется сгенерированным
+	assert.Len(t, failed.RequestID, 32)
+	assert.Equal(t, w.Header().Get(requestIDHeader), failed.RequestID)
+}
+
+func TestAudit_BodyTooLarge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	verifier, err := auth.NewVerifier(map[string][]byte{"": testJWTKey}, "", "")
+	require.NoError(t, err)
+	repo := &auditRepository{}
+	h := NewHandler(service.NewService(repo))
+
+	var handled int
+	r := gin.New()
+	r.POST("/users/:id/ok", h.Authenticate(verifier, ""), h.Audit(), func(c *gin.Context) {
+		handled++
+		c.Status(http.StatusNoContent)
+	})
+
+	post := func(size int) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/users/7/ok", strings.NewReader(strings.Repeat("a", size)))
+		req.Header.Set("Authorization", "Bearer "+testToken(t, "7", time.Hour))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(maxRequestBodySize + 1)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"`+codeRequestTooLarge+`"`)
+	assert.Zero(t, handled)
+	assert.Empty(t, repo.entries)
+
+	w = post(maxRequestBodySize)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, 1, handled)
+	assert.Len(t, repo.entries, 1)
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/auth"
+	"github.com/EugeneKrivoshein/fin_service/internal/httpapi"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	service "github.com/EugeneKrivoshein/fin_service/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Заголовок, в котором сервис передаёт API-ключ
+const apiKeyHeader = "X-API-Key"
+
+// Ключ контекста gin, под которым Authenticate сохраняет API-ключ сервиса; ID аутентифицированного
+// пользователя сохраняется через httpapi.SetCallerID
+const apiKeyKey = "auth.api_key"
+
+// Код ошибки аутентификации
+const codeUnauthorized = "unauthorized"
+
+// Authenticate — middleware аутентификации. Пользователь передаёт JWT в заголовке
+// "Authorization: Bearer <token>" (субъект токена — ID пользователя), сервис — API-ключ в заголовке
+// X-API-Key. Сервис допускается только к роутам с непустым scope и только с ключом, которому выдана
+// эта область доступа; роуты с пустым scope доступны только пользователям.
+func (h *Handler) Authenticate(v *auth.Verifier, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key := c.GetHeader(apiKeyHeader); key != "" {
+			h.authenticateAPIKey(c, key, scope)
+			return
+		}
+
+		scheme, token, _ := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !strings.EqualFold(scheme, "Bearer") || token == "" {
+			respondUnauthorized(c, "missing bearer token or api key")
+			return
+		}
+		claims, err := v.Verify(token)
+		if err != nil {
+			respondUnauthorized(c, err.Error())
+			return
+		}
+		userID, err := claims.UserID()
+		if err != nil {
+			respondUnauthorized(c, err.Error())
+			return
+		}
+		httpapi.SetCallerID(c, userID)
+		setPrincipal(c)
+		c.Next()
+	}
+}
+
+func (h *Handler) authenticateAPIKey(c *gin.Context, key, scope string) {
+	k, err := h.service.AuthenticateAPIKey(c.Request.Context(), key)
+	if errors.Is(err, service.ErrInvalidAPIKey) {
+		respondUnauthorized(c, err.Error())
+		return
+	}
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+	if scope == "" {
+		httpapi.RespondForbidden(c, "route is not available to api keys")
+		return
+	}
+	if !k.HasScope(scope) {
+		httpapi.RespondForbidden(c, "api key lacks scope "+scope)
+		return
+	}
+	c.Set(apiKeyKey, k)
+	setPrincipal(c)
+	c.Next()
+}
+
+// Идентификатор клиента, выполняющего запрос: «user:<id>» или «api_key:<id>». В его пределах уникальны
+// ключи идемпотентности и считаются лимиты частоты запросов.
+func principal(c *gin.Context) string {
+	if k, ok := c.Get(apiKeyKey); ok {
+		return postgres.ActorAPIKey + ":" + strconv.FormatInt(k.(*postgres.APIKey).ID, 10)
+	}
+	callerID, _ := httpapi.CallerID(c)
+	return postgres.ActorUser + ":" + strconv.FormatInt(callerID, 10)
+}
+
+// Передаёт клиента в контекст запроса, чтобы сервис привязывал к нему ключи идемпотентности
+func setPrincipal(c *gin.Context) {
+	c.Request = c.Request.WithContext(service.WithPrincipal(c.Request.Context(), principal(c)))
+}
+
+// Отвечает 401 с указанием схемы аутентификации
+func respondUnauthorized(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", `Bearer realm="fin_service"`)
+	httpapi.AbortWithError(c, http.StatusUnauthorized, httpapi.ErrorResponse{Code: codeUnauthorized, Error: message})
+}
+
+// Проверяет, что запрос выполняется от имени пользователя userID; иначе отвечает 403 и возвращает false.
+// Сервис с API-ключом действует от имени любого пользователя в пределах областей доступа ключа.
+func authorizeUser(c *gin.Context, userID int64) bool {
+	if ownsAccount(c, userID) {
+		return true
+	}
+	httpapi.RespondForbidden(c, "operation on another user's account is forbidden")
+	return false
+}
+
+// Сообщает, выполняется ли запрос от имени пользователя userID или сервиса с API-ключом
+func ownsAccount(c *gin.Context, userID int64) bool {
+	if _, ok := c.Get(apiKeyKey); ok {
+		return true
+	}
+	callerID, ok := httpapi.CallerID(c)
+	return ok && callerID == userID
+}
+
+// Читает ID пользователя из пути и проверяет, что запрос выполняется от его имени
+func ownUserIDParam(c *gin.Context) (int64, bool) {
+	userID, ok := httpapi.UserIDParam(c)
+	if !ok || !authorizeUser(c, userID) {
+		return 0, false
+	}
+	return userID, true
+}
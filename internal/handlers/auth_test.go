@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/auth"
+	"github.com/EugeneKrivoshein/fin_service/internal/httpapi"
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	service "github.com/EugeneKrivoshein/fin_service/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testJWTKey = []byte("test-secret")
+
+func testToken(t *testing.T, subject string, ttl time.Duration) string {
+	t.Helper()
+	token, err := auth.Sign(&auth.Claims{Subject: subject, ExpiresAt: time.Now().Add(ttl).Unix()}, "", testJWTKey)
+	require.NoError(t, err)
+	return token
+}
+
+// Репозиторий, который знает только действующие API-ключи; остальные методы не вызываются
+type apiKeyRepository struct {
+	postgres.Repository
+	keys map[string]*postgres.APIKey
+}
+
+func (r *apiKeyRepository) GetActiveAPIKey(_ context.Context, hash []byte) (*postgres.APIKey, error) {
+	if k, ok := r.keys[string(hash)]; ok {
+		return k, nil
+	}
+	return nil, postgres.ErrAPIKeyNotFound
+}
+
+// Роутер с роутом /users/:id, доступным только владельцу, и его копией /scoped/:id,
+// доступной также сервисам с областью balance:read
+func newAuthTestRouter(t *testing.T, apiKey string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	verifier, err := auth.NewVerifier(map[string][]byte{"": testJWTKey}, "", "")
+	require.NoError(t, err)
+	repo := &apiKeyRepository{keys: map[string]*postgres.APIKey{
+		string(auth.HashAPIKey(apiKey)): {ID: 1, Name: "billing", Scopes: []string{auth.ScopeBalanceRead}},
+	}}
+	h := NewHandler(service.NewService(repo))
+
+	owner := func(c *gin.Context) {
+		if _, ok := ownUserIDParam(c); ok {
+			c.Status(http.StatusNoContent)
+		}
+	}
+	r := gin.New()
+	r.GET("/users/:id", h.Authenticate(verifier, ""), owner)
+	r.GET("/scoped/:id", h.Authenticate(verifier, auth.ScopeBalanceRead), owner)
+	r.GET("/other-scope/:id", h.Authenticate(verifier, auth.ScopeTransferWrite), owner)
+	return r
+}
+
+func TestAuthenticate(t *testing.T) {
+	apiKey, _, err := auth.NewAPIKey()
+	require.NoError(t, err)
+	unknownKey, _, err := auth.NewAPIKey()
+	require.NoError(t, err)
+	r := newAuthTestRouter(t, apiKey)
+
+	bearer := "Bearer " + testToken(t, "7", time.Hour)
+	cases := []struct {
+		name    string
+		path    string
+		headers map[string]string
+		status  int
+		code    string
+	}{
+		{"own account", "/users/7", map[string]string{"Authorization": bearer}, http.StatusNoContent, ""},
+		{"another account", "/users/8", map[string]string{"Authorization": bearer}, http.StatusForbidden, httpapi.CodeForbidden},
+		{"no token", "/users/7", nil, http.StatusUnauthorized, codeUnauthorized},
+		{"wrong scheme", "/users/7", map[string]string{"Authorization": "Basic dXNlcjpwYXNz"}, http.StatusUnauthorized, codeUnauthorized},
+		{"expired token", "/users/7", map[string]string{"Authorization": "Bearer " + testToken(t, "7", -time.Hour)}, http.StatusUnauthorized, codeUnauthorized},
+		{"garbage token", "/users/7", map[string]string{"Authorization": "Bearer abc"}, http.StatusUnauthorized, codeUnauthorized},
+
+		// Сервис с ключом действует от имени любого пользователя, но только на роутах своих областей
+		{"api key with scope", "/scoped/8", map[string]string{apiKeyHeader: apiKey}, http.StatusNoContent, ""},
+		{"api key without scope", "/other-scope/8", map[string]string{apiKeyHeader: apiKey}, http.StatusForbidden, httpapi.CodeForbidden},
+		{"api key on user route", "/users/8", map[string]string{apiKeyHeader: apiKey}, http.StatusForbidden, httpapi.CodeForbidden},
+		{"unknown api key", "/scoped/8", map[string]string{apiKeyHeader: unknownKey}, http.StatusUnauthorized, codeUnauthorized},
+		{"malformed api key", "/scoped/8", map[string]string{apiKeyHeader: "secret"}, http.StatusUnauthorized, codeUnauthorized},
+		{"user on scoped route", "/scoped/7", map[string]string{"Authorization": bearer}, http.StatusNoContent, ""},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		for k, v := range tc.headers {
+			req.Header.Set(k, v)
+		}
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, tc.status, w.Code, tc.name)
+		if tc.code != "" {
+			assert.Contains(t, w.Body.String(), `"code":"`+tc.code+`"`, tc.name)
+		}
+		if tc.status == http.StatusUnauthorized {
+			assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"), tc.name)
+		}
+	}
+}
+
+// Репозиторий, который запоминает ключи идемпотентности пополнений
+type depositKeysRepository struct {
+	apiKeyRepository
+	keys []*postgres.IdempotencyKey
+}
+
+func (r *depositKeysRepository) Deposit(_ context.Context, userID int64, amount money.Amount, currency money.Currency, key *postgres.IdempotencyKey) (*postgres.Transaction, error) {
+	r.keys = append(r.keys, key)
+	return &postgres.Transaction{ID: 1, TransactionType: "deposit", ReceiverID: &userID, Amount: amount, Currency: currency}, nil
+}
+
+func TestAuthenticate_IdempotencyPrincipal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	apiKey, _, err := auth.NewAPIKey()
+	require.NoError(t, err)
+	verifier, err := auth.NewVerifier(map[string][]byte{"": testJWTKey}, "", "")
+	require.NoError(t, err)
+	repo := &depositKeysRepository{apiKeyRepository: apiKeyRepository{keys: map[string]*postgres.APIKey{
+		string(auth.HashAPIKey(apiKey)): {ID: 3, Name: "billing", Scopes: []string{auth.ScopeDepositWrite}},
+	}}}
+	h := NewHandler(service.NewService(repo))
+	r := gin.New()
+	r.POST("/deposit", h.Authenticate(verifier, auth.ScopeDepositWrite), h.HandleDeposit)
+
+	// Один и тот же ключ идемпотентности от разных клиентов попадает в разные пространства
+	for _, headers := range []map[string]string{
+		{"Authorization": "Bearer " + testToken(t, "7", time.Hour)},
+		{"X-API-Key": apiKey},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/deposit", strings.NewReader(`{"user_id": 7, "amount": 10}`))
+		req.Header.Set(httpapi.IdempotencyKeyHeader, "key-1")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+
+	require.Len(t, repo.keys, 2)
+	assert.Equal(t, "user:7", repo.keys[0].Principal)
+	assert.Equal(t, "api_key:3", repo.keys[1].Principal)
+	assert.Equal(t, repo.keys[0].Key, repo.keys[1].Key)
+}
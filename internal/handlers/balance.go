@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/httpapi"
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/gin-gonic/gin"
+)
+
+// HandleGetBalance godoc
+// @Summary Баланс пользователя
+// @Description Возвращает текущий баланс пользователя в валюте и время последней операции в ней. balance — учётный баланс по главной книге, available_balance — доступный для списания остаток за вычетом действующих холдов (held). С параметром as_of — баланс на указанный момент, восстановленный по истории транзакций
+// @Tags Баланс
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param currency query string false "Валюта (ISO 4217), по умолчанию RUB"
+// @Param as_of query string false "Момент времени в формате RFC 3339, например 2025-02-01T12:00:00Z"
+// @Success 200 {object} postgres.Balance "Баланс пользователя"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации или неподдерживаемая валюта"
+// @Failure 403 {object} httpapi.ErrorResponse "Операция со счётом другого пользователя"
+// @Failure 404 {object} httpapi.ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /users/{id}/balance [get]
+func (h *Handler) HandleGetBalance(c *gin.Context) {
+	userID, ok := ownUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	asOf, err := httpapi.TimeParam(c.Query("as_of"))
+	if err != nil {
+		httpapi.RespondBadRequest(c, "invalid as_of, expected RFC 3339 timestamp")
+		return
+	}
+
+	balance, err := h.service.GetBalance(c.Request.Context(), userID, money.Currency(c.Query("currency")), asOf)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, balance)
+}
+
+// HandleVerifyLedger godoc
+// @Summary Сверка балансов с главной книгой
+// @Description Проверяет, что все проводки главной книги сбалансированы, а баланс каждого счёта пользователя совпадает с остатком его счёта в книге
+// @Tags Баланс
+// @Produce json
+// @Success 200 {object} postgres.LedgerReport "Результат сверки"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет разрешения settings:manage"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/ledger/verify [get]
+func (h *Handler) HandleVerifyLedger(c *gin.Context) {
+	report, err := h.service.VerifyLedger(c.Request.Context())
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/httpapi"
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	"github.com/gin-gonic/gin"
+)
+
+// FeeRuleRequest — правило комиссии: flat плюс percent процентов от суммы операции, в пределах min_fee и max_fee
+type FeeRuleRequest struct {
+	TransactionType string         `json:"transaction_type" binding:"required,oneof=transfer withdrawal" example:"transfer"`
+	Currency        money.Currency `json:"currency" swaggertype:"string" example:"RUB"`
+	// Тариф пользователей, для которых действует правило; без него — для всех
+	Tier    *string       `json:"tier,omitempty" example:"standard"`
+	Flat    money.Amount  `json:"flat" swaggertype:"number" example:"10.00"`
+	Percent *money.Rate   `json:"percent,omitempty" swaggertype:"number" example:"1.5"`
+	MinFee  *money.Amount `json:"min_fee,omitempty" swaggertype:"number" example:"30.00"`
+	MaxFee  *money.Amount `json:"max_fee,omitempty" swaggertype:"number" example:"3000.00"`
+}
+
+// HandleListFeeRules godoc
+// @Summary Правила комиссий
+// @Description Возвращает все правила комиссий за переводы и снятия
+// @Tags Администрирование
+// @Produce json
+// @Success 200 {array} postgres.FeeRule "Правила комиссий"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/fee-rules [get]
+func (h *Handler) HandleListFeeRules(c *gin.Context) {
+	rules, err := h.service.ListFeeRules(c.Request.Context())
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// HandleCreateFeeRule godoc
+// @Summary Создание правила комиссии
+// @Description Создаёт правило комиссии за переводы или снятия в валюте. Правило с тарифом действует для его пользователей вместо общего правила. Комиссия списывается с плательщика сверх суммы операции и зачисляется на системный счёт доходов fee_revenue
+// @Tags Администрирование
+// @Accept json
+// @Produce json
+// @Param input body FeeRuleRequest true "Правило комиссии"
+// @Success 201 {object} postgres.FeeRule "Созданное правило"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации, некорректное правило или неподдерживаемая валюта"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin"
+// @Failure 409 {object} httpapi.ErrorResponse "Правило для этой операции, валюты и тарифа уже существует"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/fee-rules [post]
+func (h *Handler) HandleCreateFeeRule(c *gin.Context) {
+	var req FeeRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpapi.RespondBindError(c, err)
+		return
+	}
+
+	rule, err := h.service.CreateFeeRule(c.Request.Context(), postgres.FeeRule{
+		TransactionType: req.TransactionType,
+		Currency:        req.Currency,
+		Tier:            req.Tier,
+		Flat:            req.Flat,
+		Percent:         req.Percent,
+		MinFee:          req.MinFee,
+		MaxFee:          req.MaxFee,
+	})
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// HandleDeleteFeeRule godoc
+// @Summary Удаление правила комиссии
+// @Description Удаляет правило комиссии; комиссии уже проведённых операций не меняются
+// @Tags Администрирование
+// @Param id path int true "ID правила"
+// @Success 204 "Правило удалено"
+// @Failure 400 {object} httpapi.ErrorResponse "Некорректный id"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin"
+// @Failure 404 {object} httpapi.ErrorResponse "Правило не найдено"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/fee-rules/{id} [delete]
+func (h *Handler) HandleDeleteFeeRule(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		httpapi.RespondBadRequest(c, "invalid fee rule id")
+		return
+	}
+
+	if err := h.service.DeleteFeeRule(c.Request.Context(), id); err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
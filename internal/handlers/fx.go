@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/httpapi"
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/gin-gonic/gin"
+)
+
+type FXQuoteRequest struct {
+	FromCurrency money.Currency `json:"from_currency" binding:"required" swaggertype:"string" example:"RUB"`
+	ToCurrency   money.Currency `json:"to_currency" binding:"required" swaggertype:"string" example:"USD"`
+}
+
+// HandleCreateFXQuote godoc
+// @Summary Котировка курса валют
+// @Description Фиксирует текущий курс обмена from_currency на to_currency до expires_at. Идентификатор котировки передаётся в quote_id перевода; котировка используется один раз
+// @Tags Транзакции
+// @Accept json
+// @Produce json
+// @Param input body FXQuoteRequest true "Валютная пара"
+// @Success 201 {object} postgres.FXQuote "Котировка"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации или неподдерживаемая валюта"
+// @Failure 422 {object} httpapi.ErrorResponse "Курс для пары недоступен или валюты совпадают"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /fx/quotes [post]
+func (h *Handler) HandleCreateFXQuote(c *gin.Context) {
+	var req FXQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpapi.RespondBindError(c, err)
+		return
+	}
+
+	quote, err := h.service.CreateFXQuote(c.Request.Context(), req.FromCurrency, req.ToCurrency)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, quote)
+}
@@ -2,15 +2,14 @@ package handler
 
 import (
 	"net/http"
-	"strconv"
 
+	"github.com/EugeneKrivoshein/fin_service/internal/httpapi"
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
 	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
 	service "github.com/EugeneKrivoshein/fin_service/internal/services"
 	"github.com/gin-gonic/gin"
 )
 
-var _ postgres.Transaction
-
 type Handler struct {
 	service *service.Service
 }
@@ -21,100 +20,212 @@ func NewHandler(s *service.Service) *Handler {
 	}
 }
 
+// Currency в запросах операций — код валюты ISO 4217; если не указан, используется RUB
+
 type DepositRequest struct {
-	UserID int64   `json:"user_id" binding:"required"`
-	Amount float64 `json:"amount" binding:"required,gt=0"`
+	UserID   int64          `json:"user_id" binding:"required"`
+	Amount   money.Amount   `json:"amount" binding:"required,gt=0" swaggertype:"number" example:"100.50"`
+	Currency money.Currency `json:"currency" swaggertype:"string" example:"RUB"`
 }
 
 type TransferRequest struct {
-	SenderID   int64   `json:"sender_id" binding:"required"`
-	ReceiverID int64   `json:"receiver_id" binding:"required"`
-	Amount     float64 `json:"amount" binding:"required,gt=0"`
+	SenderID   int64          `json:"sender_id" binding:"required"`
+	ReceiverID int64          `json:"receiver_id" binding:"required"`
+	Amount     money.Amount   `json:"amount" binding:"required,gt=0" swaggertype:"number" example:"100.50"`
+	Currency   money.Currency `json:"currency" swaggertype:"string" example:"RUB"`
+	// Котировка для перевода с конвертацией (см. POST /fx/quotes); валюты задаёт котировка, поэтому currency не указывается
+	QuoteID string `json:"quote_id,omitempty"`
+}
+
+type WithdrawRequest struct {
+	UserID   int64          `json:"user_id" binding:"required"`
+	Amount   money.Amount   `json:"amount" binding:"required,gt=0" swaggertype:"number" example:"100.50"`
+	Currency money.Currency `json:"currency" swaggertype:"string" example:"RUB"`
 }
 
 // HandleDeposit godoc
 // @Summary Пополнение баланса
-// @Description Позволяет пользователю пополнить свой баланс
+// @Description Пополняет счёт пользователя в указанной валюте; если счёта в этой валюте нет, он открывается. Доступно сервисам с областью deposit:write и пользователям с разрешением deposits:write
 // @Tags Баланс
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Ключ идемпотентности для безопасного повтора запроса"
 // @Param input body DepositRequest true "Данные для пополнения"
-// @Success 200 {object} map[string]string "Баланс успешно пополнен"
-// @Failure 400 {object} map[string]string "Ошибка валидации"
-// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Success 200 {object} httpapi.OperationResponse "Баланс успешно пополнен"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации или неподдерживаемая валюта"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет разрешения deposits:write"
+// @Failure 404 {object} httpapi.ErrorResponse "Пользователь не найден"
+// @Failure 409 {object} httpapi.ErrorResponse "Счёт заморожен или закрыт либо ключ идемпотентности использован с другим запросом"
+// @Failure 422 {object} httpapi.ErrorResponse "Некорректная сумма"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /deposit [post]
 func (h *Handler) HandleDeposit(c *gin.Context) {
 	var req DepositRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		httpapi.RespondBindError(c, err)
 		return
 	}
 
-	err := h.service.Deposit(c.Request.Context(), req.UserID, req.Amount)
+	key, ok := httpapi.IdempotencyKey(c)
+	if !ok {
+		return
+	}
+
+	t, err := h.service.Deposit(c.Request.Context(), req.UserID, req.Amount, req.Currency, key)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		httpapi.RespondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Баланс успешно пополнен"})
+	c.JSON(http.StatusOK, httpapi.OperationResponse{Message: "Баланс успешно пополнен", Transaction: t})
 }
 
 // HandleTransfer godoc
 // @Summary Перевод денег
-// @Description Позволяет пользователю перевести деньги другому пользователю
+// @Description Переводит деньги другому пользователю в одной валюте. Если у получателя нет счёта в валюте перевода, перевод отклоняется с кодом currency_mismatch.
+// @Description С quote_id выполняется перевод с конвертацией: отправитель платит amount в исходной валюте котировки, получатель получает сумму в валюте назначения по зафиксированному курсу.
+// @Description Если для перевода действует правило комиссии, комиссия (поле fee транзакции) списывается с отправителя сверх amount
 // @Tags Транзакции
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Ключ идемпотентности для безопасного повтора запроса"
 // @Param input body TransferRequest true "Данные для перевода"
-// @Success 200 {object} map[string]string "Перевод успешно выполнен"
-// @Failure 400 {object} map[string]string "Ошибка валидации"
-// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Success 200 {object} httpapi.OperationResponse "Перевод успешно выполнен"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации или неподдерживаемая валюта"
+// @Failure 403 {object} httpapi.ErrorResponse "Операция со счётом другого пользователя"
+// @Failure 404 {object} httpapi.ErrorResponse "Отправитель, получатель или котировка не найдены"
+// @Failure 409 {object} httpapi.ErrorResponse "Счёт заморожен или закрыт, котировка истекла или использована либо ключ идемпотентности использован с другим запросом"
+// @Failure 422 {object} httpapi.ErrorResponse "Недостаточно средств, превышен лимит расходов, перевод самому себе или у получателя нет счёта в валюте перевода"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /transfer [post]
 func (h *Handler) HandleTransfer(c *gin.Context) {
 	var req TransferRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		httpapi.RespondBindError(c, err)
+		return
+	}
+	// Переводить можно только со своего счёта
+	if !authorizeUser(c, req.SenderID) {
+		return
+	}
+
+	key, ok := httpapi.IdempotencyKey(c)
+	if !ok {
+		return
+	}
+
+	var t *postgres.Transaction
+	var err error
+	if req.QuoteID != "" {
+		if req.Currency != "" {
+			httpapi.RespondBadRequest(c, "currency must not be set together with quote_id")
+			return
+		}
+		t, err = h.service.TransferWithQuote(c.Request.Context(), req.SenderID, req.ReceiverID, req.Amount, req.QuoteID, key)
+	} else {
+		t, err = h.service.Transfer(c.Request.Context(), req.SenderID, req.ReceiverID, req.Amount, req.Currency, key)
+	}
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, httpapi.OperationResponse{Message: "Перевод успешно выполнен", Transaction: t})
+}
+
+// HandleWithdraw godoc
+// @Summary Снятие денег
+// @Description Списывает деньги со счёта пользователя в указанной валюте, если на нём достаточно средств. Комиссия по правилам комиссий (поле fee транзакции) списывается сверх суммы
+// @Tags Баланс
+// @Accept json
+// @Produce json
+// @Param Idempotency-Key header string false "Ключ идемпотентности для безопасного повтора запроса"
+// @Param input body WithdrawRequest true "Данные для снятия"
+// @Success 200 {object} httpapi.OperationResponse "Деньги успешно списаны"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации или неподдерживаемая валюта"
+// @Failure 403 {object} httpapi.ErrorResponse "Операция со счётом другого пользователя"
+// @Failure 404 {object} httpapi.ErrorResponse "Пользователь не найден"
+// @Failure 409 {object} httpapi.ErrorResponse "Счёт заморожен или закрыт либо ключ идемпотентности использован с другим запросом"
+// @Failure 422 {object} httpapi.ErrorResponse "Недостаточно средств или превышен лимит расходов"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /withdraw [post]
+func (h *Handler) HandleWithdraw(c *gin.Context) {
+	var req WithdrawRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpapi.RespondBindError(c, err)
+		return
+	}
+	if !authorizeUser(c, req.UserID) {
 		return
 	}
 
-	err := h.service.Transfer(c.Request.Context(), req.SenderID, req.ReceiverID, req.Amount)
+	key, ok := httpapi.IdempotencyKey(c)
+	if !ok {
+		return
+	}
+
+	t, err := h.service.Withdraw(c.Request.Context(), req.UserID, req.Amount, req.Currency, key)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		httpapi.RespondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Перевод успешно выполнен"})
+	c.JSON(http.StatusOK, httpapi.OperationResponse{Message: "Деньги успешно списаны", Transaction: t})
 }
 
 // HandleGetTransactions godoc
-// @Summary Получение последних 10 транзакций
-// @Description Возвращает список последних 10 транзакций пользователя
+// @Summary История транзакций
+// @Description Возвращает страницу транзакций пользователя от новых к старым. Для следующей страницы передайте next_cursor в том же параметре (before или after), что и текущий курсор
 // @Tags Транзакции
 // @Accept json
 // @Produce json
 // @Param user_id query int true "ID пользователя"
-// @Success 200 {array} postgres.Transaction "Список транзакций"
-// @Failure 400 {object} map[string]string "Ошибка валидации"
-// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Param limit query int false "Размер страницы (по умолчанию 10, максимум 100)"
+// @Param before query string false "Курсор: транзакции старше указанной"
+// @Param after query string false "Курсор: транзакции новее указанной"
+// @Param type query string false "Тип транзакции" Enums(deposit, transfer, withdrawal, reversal, adjustment)
+// @Param currency query string false "Валюта транзакций (ISO 4217)"
+// @Param from query string false "Начало периода (RFC 3339), включительно"
+// @Param to query string false "Конец периода (RFC 3339), не включительно"
+// @Param min_amount query number false "Минимальная сумма"
+// @Param max_amount query number false "Максимальная сумма"
+// @Success 200 {object} postgres.TransactionPage "Страница транзакций"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации"
+// @Failure 403 {object} httpapi.ErrorResponse "Операция со счётом другого пользователя"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /transactions [get]
 func (h *Handler) HandleGetTransactions(c *gin.Context) {
-	// Можно передавать user_id как параметр запроса
-	userIDParam := c.Query("user_id")
-	if userIDParam == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
+	var query struct {
+		UserID int64 `form:"user_id" binding:"required"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		httpapi.RespondBindError(c, err)
 		return
 	}
-	userID, err := strconv.ParseInt(userIDParam, 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+	// Пользователю доступна только своя история
+	if !authorizeUser(c, query.UserID) {
+		return
+	}
+
+	filter, ok := httpapi.BindTransactionFilter(c)
+	if !ok {
 		return
 	}
+	filter.UserID = query.UserID
 
-	transactions, err := h.service.GetTransactions(c.Request.Context(), userID)
+	page, err := h.service.GetTransactions(c.Request.Context(), filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		httpapi.RespondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, transactions)
+	c.JSON(http.StatusOK, page)
 }
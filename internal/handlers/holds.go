@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/httpapi"
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	"github.com/gin-gonic/gin"
+)
+
+type CreateHoldRequest struct {
+	UserID   int64          `json:"user_id" binding:"required"`
+	Amount   money.Amount   `json:"amount" binding:"required,gt=0" swaggertype:"number" example:"500.00"`
+	Currency money.Currency `json:"currency" swaggertype:"string" example:"RUB"`
+	// Срок жизни холда в секундах; по умолчанию 7 дней, максимум 30 дней
+	TTLSeconds int64 `json:"ttl_seconds,omitempty" binding:"gte=0" example:"3600"`
+}
+
+type CaptureHoldRequest struct {
+	// Сумма списания; если не указана, списывается вся сумма холда
+	Amount money.Amount `json:"amount,omitempty" binding:"gte=0" swaggertype:"number" example:"450.00"`
+	// Получатель перевода; если не указан, сумма списывается со счёта (withdrawal)
+	ReceiverID *int64 `json:"receiver_id,omitempty"`
+}
+
+// CaptureHoldResponse — списанный холд и созданная транзакция.
+// Повтор с тем же ключом идемпотентности возвращает ту же транзакцию.
+type CaptureHoldResponse struct {
+	Hold        *postgres.Hold        `json:"hold"`
+	Transaction *postgres.Transaction `json:"transaction"`
+}
+
+// HandleCreateHold godoc
+// @Summary Создание холда
+// @Description Резервирует сумму на счёте пользователя в валюте. Зарезервированная сумма не входит в доступный остаток, пока холд не списан, не освобождён и не истёк; баланс по главной книге не меняется
+// @Tags Холды
+// @Accept json
+// @Produce json
+// @Param input body CreateHoldRequest true "Данные холда"
+// @Success 201 {object} postgres.Hold "Холд"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации, неподдерживаемая валюта или некорректный срок холда"
+// @Failure 403 {object} httpapi.ErrorResponse "Операция со счётом другого пользователя"
+// @Failure 404 {object} httpapi.ErrorResponse "Пользователь не найден"
+// @Failure 409 {object} httpapi.ErrorResponse "Счёт заморожен или закрыт"
+// @Failure 422 {object} httpapi.ErrorResponse "Недостаточно доступных средств или некорректная сумма"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /holds [post]
+func (h *Handler) HandleCreateHold(c *gin.Context) {
+	var req CreateHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpapi.RespondBindError(c, err)
+		return
+	}
+	if !authorizeUser(c, req.UserID) {
+		return
+	}
+
+	ttl := time.Duration(req.TTLSeconds) * time.Second
+	hold, err := h.service.CreateHold(c.Request.Context(), req.UserID, req.Amount, req.Currency, ttl)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, hold)
+}
+
+// HandleGetHold godoc
+// @Summary Холд
+// @Description Возвращает холд по id
+// @Tags Холды
+// @Produce json
+// @Param id path int true "ID холда"
+// @Success 200 {object} postgres.Hold "Холд"
+// @Failure 400 {object} httpapi.ErrorResponse "Некорректный id"
+// @Failure 404 {object} httpapi.ErrorResponse "Холд не найден или принадлежит другому пользователю"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /holds/{id} [get]
+func (h *Handler) HandleGetHold(c *gin.Context) {
+	holdID, ok := holdIDParam(c)
+	if !ok {
+		return
+	}
+
+	hold, ok := h.ownHold(c, holdID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, hold)
+}
+
+// HandleCaptureHold godoc
+// @Summary Списание холда
+// @Description Списывает холд полностью или частично: с receiver_id сумма переводится получателю, без него — списывается со счёта. Остаток резерва при частичном списании освобождается
+// @Tags Холды
+// @Accept json
+// @Produce json
+// @Param id path int true "ID холда"
+// @Param input body CaptureHoldRequest false "Сумма и получатель"
+// @Param Idempotency-Key header string false "Ключ идемпотентности для безопасного повтора запроса"
+// @Success 200 {object} CaptureHoldResponse "Холд списан"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации"
+// @Failure 404 {object} httpapi.ErrorResponse "Холд или получатель не найден либо холд принадлежит другому пользователю"
+// @Failure 409 {object} httpapi.ErrorResponse "Холд уже списан, освобождён или истёк, счёт заморожен или закрыт либо ключ идемпотентности использован с другим запросом"
+// @Failure 422 {object} httpapi.ErrorResponse "Получатель — владелец холда, сумма больше суммы холда, недостаточно средств, превышен лимит расходов или у получателя нет счёта в валюте холда"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /holds/{id}/capture [post]
+func (h *Handler) HandleCaptureHold(c *gin.Context) {
+	holdID, ok := holdIDParam(c)
+	if !ok {
+		return
+	}
+
+	// Тело необязательно: без него списывается вся сумма холда
+	var req CaptureHoldRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			httpapi.RespondBindError(c, err)
+			return
+		}
+	}
+
+	key, ok := httpapi.IdempotencyKey(c)
+	if !ok {
+		return
+	}
+	if _, ok := h.ownHold(c, holdID); !ok {
+		return
+	}
+
+	hold, t, err := h.service.CaptureHold(c.Request.Context(), holdID, req.Amount, req.ReceiverID, key)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, CaptureHoldResponse{Hold: hold, Transaction: t})
+}
+
+// HandleReleaseHold godoc
+// @Summary Освобождение холда
+// @Description Снимает резерв без списания денег: сумма холда снова становится доступной
+// @Tags Холды
+// @Produce json
+// @Param id path int true "ID холда"
+// @Success 200 {object} postgres.Hold "Холд освобождён"
+// @Failure 400 {object} httpapi.ErrorResponse "Некорректный id"
+// @Failure 404 {object} httpapi.ErrorResponse "Холд не найден или принадлежит другому пользователю"
+// @Failure 409 {object} httpapi.ErrorResponse "Холд уже списан, освобождён или истёк"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /holds/{id}/release [post]
+func (h *Handler) HandleReleaseHold(c *gin.Context) {
+	holdID, ok := holdIDParam(c)
+	if !ok {
+		return
+	}
+
+	if _, ok := h.ownHold(c, holdID); !ok {
+		return
+	}
+
+	hold, err := h.service.ReleaseHold(c.Request.Context(), holdID)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, hold)
+}
+
+func holdIDParam(c *gin.Context) (int64, bool) {
+	holdID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		httpapi.RespondBadRequest(c, "invalid hold id")
+		return 0, false
+	}
+	return holdID, true
+}
+
+// Загружает холд и проверяет, что запрос выполняется от имени его владельца. Чужой холд не отличается
+// от несуществующего, чтобы по ответам нельзя было перебрать id холдов других пользователей.
+func (h *Handler) ownHold(c *gin.Context, holdID int64) (*postgres.Hold, bool) {
+	hold, err := h.service.GetHold(c.Request.Context(), holdID)
+	if err == nil && !ownsAccount(c, hold.UserID) {
+		err = postgres.ErrHoldNotFound
+	}
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return nil, false
+	}
+	return hold, true
+}
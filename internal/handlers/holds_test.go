@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/auth"
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	service "github.com/EugeneKrivoshein/fin_service/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Репозиторий с холдами пользователей; считает списания и освобождения
+type holdsRepository struct {
+	apiKeyRepository
+	holds    map[int64]*postgres.Hold
+	captured int
+	released int
+}
+
+func (r *holdsRepository) GetHold(_ context.Context, holdID int64) (*postgres.Hold, error) {
+	if h, ok := r.holds[holdID]; ok {
+		return h, nil
+	}
+	return nil, postgres.ErrHoldNotFound
+}
+
+func (r *holdsRepository) CaptureHold(_ context.Context, holdID int64, _ money.Amount, _ *int64, _ *postgres.IdempotencyKey) (*postgres.Hold, *postgres.Transaction, error) {
+	r.captured++
+	return r.holds[holdID], &postgres.Transaction{ID: 1}, nil
+}
+
+func (r *holdsRepository) ReleaseHold(_ context.Context, holdID int64) (*postgres.Hold, error) {
+	r.released++
+	return r.holds[holdID], nil
+}
+
+func TestHolds_OnlyOwner(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	verifier, err := auth.NewVerifier(map[string][]byte{"": testJWTKey}, "", "")
+	require.NoError(t, err)
+	repo := &holdsRepository{holds: map[int64]*postgres.Hold{
+		1: {ID: 1, UserID: 7, Currency: money.DefaultCurrency, Amount: money.MustParse("50.00")},
+		2: {ID: 2, UserID: 8, Currency: money.DefaultCurrency, Amount: money.MustParse("50.00")},
+	}}
+	h := NewHandler(service.NewService(repo))
+
+	r := gin.New()
+	holds := r.Group("/", h.Authenticate(verifier, ""))
+	holds.GET("/holds/:id", h.HandleGetHold)
+	holds.POST("/holds/:id/capture", h.HandleCaptureHold)
+	holds.POST("/holds/:id/release", h.HandleReleaseHold)
+
+	bearer := "Bearer " + testToken(t, "7", time.Hour)
+	cases := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodGet, "/holds/1", http.StatusOK},
+		// Чужой холд неотличим от несуществующего
+		{http.MethodGet, "/holds/2", http.StatusNotFound},
+		{http.MethodGet, "/holds/3", http.StatusNotFound},
+		{http.MethodPost, "/holds/2/capture", http.StatusNotFound},
+		{http.MethodPost, "/holds/2/release", http.StatusNotFound},
+		{http.MethodPost, "/holds/1/capture", http.StatusOK},
+		{http.MethodPost, "/holds/1/release", http.StatusOK},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("Authorization", bearer)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, tc.status, w.Code, tc.method+" "+tc.path)
+		if tc.status == http.StatusNotFound {
+			assert.Contains(t, w.Body.String(), `"code":"hold_not_found"`, tc.path)
+		}
+	}
+	// Чужой холд не списан и не освобождён
+	assert.Equal(t, 1, repo.captured)
+	assert.Equal(t, 1, repo.released)
+}
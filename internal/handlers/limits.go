@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/httpapi"
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	"github.com/gin-gonic/gin"
+)
+
+// SpendingLimitsRequest — лимиты исходящих операций в валюте; не заданный лимит не ограничивает расходы
+type SpendingLimitsRequest struct {
+	Currency       money.Currency `json:"currency" swaggertype:"string" example:"RUB"`
+	PerTransaction *money.Amount  `json:"per_transaction,omitempty" binding:"omitempty,gt=0" swaggertype:"number" example:"100000.00"`
+	Daily          *money.Amount  `json:"daily,omitempty" binding:"omitempty,gt=0" swaggertype:"number" example:"300000.00"`
+	Monthly        *money.Amount  `json:"monthly,omitempty" binding:"omitempty,gt=0" swaggertype:"number" example:"1000000.00"`
+}
+
+func (r SpendingLimitsRequest) limits() postgres.SpendingLimits {
+	return postgres.SpendingLimits{
+		Currency:       r.Currency,
+		PerTransaction: r.PerTransaction,
+		Daily:          r.Daily,
+		Monthly:        r.Monthly,
+	}
+}
+
+type SetTierRequest struct {
+	Tier string `json:"tier" binding:"required" example:"premium"`
+}
+
+// HandleGetSpendingStatus godoc
+// @Summary Лимиты расходов пользователя
+// @Description Возвращает действующие лимиты исходящих операций пользователя в валюте (собственные или лимиты тарифа), суммы, потраченные с начала суток и месяца (UTC), и наибольшую сумму, которую можно потратить одной операцией сейчас
+// @Tags Лимиты
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param currency query string false "Валюта (ISO 4217), по умолчанию RUB"
+// @Success 200 {object} postgres.SpendingStatus "Лимиты и их использование"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации или неподдерживаемая валюта"
+// @Failure 403 {object} httpapi.ErrorResponse "Операция со счётом другого пользователя"
+// @Failure 404 {object} httpapi.ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /users/{id}/spending-limits [get]
+func (h *Handler) HandleGetSpendingStatus(c *gin.Context) {
+	userID, ok := ownUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.service.GetSpendingStatus(c.Request.Context(), userID, money.Currency(c.Query("currency")))
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// HandleListTierSpendingLimits godoc
+// @Summary Лимиты тарифа
+// @Description Возвращает лимиты расходов тарифа во всех валютах, для которых они заданы
+// @Tags Администрирование
+// @Produce json
+// @Param tier path string true "Тариф"
+// @Success 200 {array} postgres.SpendingLimits "Лимиты тарифа"
+// @Failure 400 {object} httpapi.ErrorResponse "Некорректное имя тарифа"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/spending-limits/tiers/{tier} [get]
+func (h *Handler) HandleListTierSpendingLimits(c *gin.Context) {
+	limits, err := h.service.ListTierSpendingLimits(c.Request.Context(), c.Param("tier"))
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, limits)
+}
+
+// HandleSetTierSpendingLimits godoc
+// @Summary Установка лимитов тарифа
+// @Description Задаёт лимиты расходов тарифа в валюте, заменяя прежние. Лимиты действуют для всех пользователей тарифа, кроме тех, кому заданы собственные лимиты в этой валюте
+// @Tags Администрирование
+// @Accept json
+// @Produce json
+// @Param tier path string true "Тариф"
+// @Param input body SpendingLimitsRequest true "Лимиты"
+// @Success 200 {object} postgres.SpendingLimits "Лимиты тарифа"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации, некорректное имя тарифа или неподдерживаемая валюта"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin"
+// @Failure 422 {object} httpapi.ErrorResponse "Некорректная сумма лимита"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/spending-limits/tiers/{tier} [put]
+func (h *Handler) HandleSetTierSpendingLimits(c *gin.Context) {
+	var req SpendingLimitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpapi.RespondBindError(c, err)
+		return
+	}
+
+	limits, err := h.service.SetTierSpendingLimits(c.Request.Context(), c.Param("tier"), req.limits())
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, limits)
+}
+
+// HandleSetUserSpendingLimits godoc
+// @Summary Установка лимитов пользователя
+// @Description Задаёт пользователю собственные лимиты расходов в валюте; они полностью заменяют лимиты его тарифа в этой валюте. Запрос без лимитов снимает с пользователя ограничения тарифа
+// @Tags Администрирование
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param input body SpendingLimitsRequest true "Лимиты"
+// @Success 200 {object} postgres.SpendingLimits "Лимиты пользователя"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации или неподдерживаемая валюта"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin"
+// @Failure 404 {object} httpapi.ErrorResponse "Пользователь не найден"
+// @Failure 422 {object} httpapi.ErrorResponse "Некорректная сумма лимита"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/users/{id}/spending-limits [put]
+func (h *Handler) HandleSetUserSpendingLimits(c *gin.Context) {
+	userID, ok := httpapi.UserIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req SpendingLimitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpapi.RespondBindError(c, err)
+		return
+	}
+
+	limits, err := h.service.SetUserSpendingLimits(c.Request.Context(), userID, req.limits())
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, limits)
+}
+
+// HandleDeleteUserSpendingLimits godoc
+// @Summary Удаление лимитов пользователя
+// @Description Удаляет собственные лимиты пользователя в валюте: снова действуют лимиты его тарифа
+// @Tags Администрирование
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param currency query string false "Валюта (ISO 4217), по умолчанию RUB"
+// @Success 200 {object} postgres.SpendingStatus "Действующие лимиты пользователя"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации или неподдерживаемая валюта"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin"
+// @Failure 404 {object} httpapi.ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/users/{id}/spending-limits [delete]
+func (h *Handler) HandleDeleteUserSpendingLimits(c *gin.Context) {
+	userID, ok := httpapi.UserIDParam(c)
+	if !ok {
+		return
+	}
+
+	currency := money.Currency(c.Query("currency"))
+	if err := h.service.DeleteUserSpendingLimits(c.Request.Context(), userID, currency); err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	status, err := h.service.GetSpendingStatus(c.Request.Context(), userID, currency)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// HandleSetUserTier godoc
+// @Summary Смена тарифа пользователя
+// @Description Переводит пользователя на тариф; к его расходам применяются лимиты нового тарифа, если пользователю не заданы собственные. Тариф без лимитов расходы не ограничивает
+// @Tags Администрирование
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param input body SetTierRequest true "Тариф"
+// @Success 200 {object} postgres.User "Пользователь"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации или некорректное имя тарифа"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin"
+// @Failure 404 {object} httpapi.ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/users/{id}/tier [put]
+func (h *Handler) HandleSetUserTier(c *gin.Context) {
+	userID, ok := httpapi.UserIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req SetTierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpapi.RespondBindError(c, err)
+		return
+	}
+
+	user, err := h.service.SetUserTier(c.Request.Context(), userID, req.Tier)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/httpapi"
+	"github.com/EugeneKrivoshein/fin_service/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+const codeRateLimited = "rate_limited"
+
+// Ключ контекста gin, под которым сохраняется самый строгий из применённых к запросу лимитов
+const rateLimitKey = "ratelimit.result"
+
+// RateLimiter ограничивает частоту запросов по политике policy; корзины хранятся в store
+type RateLimiter struct {
+	store  ratelimit.Store
+	policy ratelimit.Policy
+}
+
+// NewRateLimiter создаёт ограничитель частоты запросов
+func NewRateLimiter(store ratelimit.Store, policy ratelimit.Policy) *RateLimiter {
+	return &RateLimiter{store: store, policy: policy}
+}
+
+// ByIP — middleware, ограничивающее частоту запросов с одного адреса клиента. Ставится до аутентификации,
+// чтобы отсекать перебор учётных данных и потоки запросов без них.
+func (l *RateLimiter) ByIP() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l.take(c, "ip:"+c.ClientIP(), l.policy.IP)
+	}
+}
+
+// ByClient — middleware, ограничивающее частоту запросов клиента: пользователя или API-ключа сервиса.
+// Запросы к роутам с собственным лимитом (policy.Routes) проверяются и по нему, отдельно для каждого клиента.
+// Ставится после аутентификации.
+func (l *RateLimiter) ByClient() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := principal(c)
+		if !l.take(c, client, l.policy.Client) {
+			return
+		}
+		route := c.Request.Method + " " + c.FullPath()
+		if limit, ok := l.policy.Routes[route]; ok {
+			l.take(c, client+"|"+route, limit)
+		}
+	}
+}
+
+// Забирает токен из корзины key и выставляет заголовки RateLimit-*. Если токенов нет, отвечает 429
+// с заголовком Retry-After и возвращает false. Ошибка хранилища не блокирует запрос и только логируется.
+func (l *RateLimiter) take(c *gin.Context, key string, limit ratelimit.Limit) bool {
+	if !limit.Enabled() {
+		return true
+	}
+	res, err := l.store.Take(c.Request.Context(), key, limit)
+	if err != nil {
+		log.Printf("Ошибка ограничителя частоты запросов (%s): %v", key, err)
+		return true
+	}
+
+	// Заголовки описывают лимит, ближе всего к исчерпанию: на запрос могут действовать несколько лимитов
+	if prev, ok := c.Get(rateLimitKey); !ok || !res.Allowed || res.Remaining < prev.(ratelimit.Result).Remaining {
+		c.Set(rateLimitKey, res)
+		c.Header("RateLimit-Limit", strconv.Itoa(res.Limit.Requests))
+		c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("RateLimit-Reset", ceilSeconds(res.Reset))
+		c.Header("RateLimit-Policy", strconv.Itoa(res.Limit.Requests)+";w="+ceilSeconds(res.Limit.Period))
+	}
+	if !res.Allowed {
+		c.Header("Retry-After", ceilSeconds(res.RetryAfter))
+		httpapi.AbortWithError(c, http.StatusTooManyRequests, httpapi.ErrorResponse{Code: codeRateLimited, Error: "rate limit exceeded"})
+		return false
+	}
+	return true
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/auth"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	"github.com/EugeneKrivoshein/fin_service/internal/ratelimit"
+	service "github.com/EugeneKrivoshein/fin_service/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	apiKey, _, err := auth.NewAPIKey()
+	require.NoError(t, err)
+	verifier, err := auth.NewVerifier(map[string][]byte{"": testJWTKey}, "", "")
+	require.NoError(t, err)
+	h := NewHandler(service.NewService(&apiKeyRepository{keys: map[string]*postgres.APIKey{
+		string(auth.HashAPIKey(apiKey)): {ID: 3, Name: "billing", Scopes: []string{auth.ScopeTransferWrite}},
+	}}))
+	limiter := NewRateLimiter(ratelimit.NewMemoryStore(), ratelimit.Policy{
+		IP:     ratelimit.Limit{Requests: 10, Period: time.Minute},
+		Client: ratelimit.Limit{Requests: 3, Period: time.Minute},
+		Routes: map[string]ratelimit.Limit{"POST /transfer": {Requests: 1, Period: time.Minute}},
+	})
+
+	r := gin.New()
+	r.Use(limiter.ByIP())
+	g := r.Group("/", h.Authenticate(verifier, auth.ScopeTransferWrite), limiter.ByClient())
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	g.POST("/transfer", ok)
+	g.GET("/balance", ok)
+
+	do := func(method, path, ip string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = ip + ":4242"
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	user := map[string]string{"Authorization": "Bearer " + testToken(t, "7", time.Hour)}
+	key := map[string]string{"X-API-Key": apiKey}
+
+	// Роут с собственным лимитом проверяется по самому строгому лимиту
+	w := do(http.MethodPost, "/transfer", "203.0.113.1", user)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", w.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "1;w=60", w.Header().Get("RateLimit-Policy"))
+
+	w = do(http.MethodPost, "/transfer", "203.0.113.1", user)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), codeRateLimited)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	// Остальные роуты ограничены общим лимитом клиента, в который вошли и запросы к /transfer
+	w = do(http.MethodGet, "/balance", "203.0.113.1", user)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "3", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	w = do(http.MethodGet, "/balance", "203.0.113.2", user)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "20", w.Header().Get("Retry-After"))
+
+	// У API-ключа свои лимиты
+	w = do(http.MethodPost, "/transfer", "203.0.113.1", key)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	// Лимит по адресу действует и на запросы без учётных данных: с адреса уже было 4 запроса
+	for range 6 {
+		w = do(http.MethodGet, "/balance", "203.0.113.1", nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+	w = do(http.MethodGet, "/balance", "203.0.113.1", nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	w = do(http.MethodGet, "/balance", "203.0.113.3", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/httpapi"
+	service "github.com/EugeneKrivoshein/fin_service/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// HandleReconcile godoc
+// @Summary Сверка балансов с историей транзакций
+// @Description Пересчитывает балансы всех пользователей по истории транзакций с учётом начальных остатков и возвращает расхождения с текущими балансами
+// @Tags Администрирование
+// @Produce json,text/csv
+// @Param format query string false "Формат отчёта" Enums(json, csv)
+// @Success 200 {object} postgres.ReconciliationReport "Отчёт о сверке"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/reconciliation [get]
+func (h *Handler) HandleReconcile(c *gin.Context) {
+	h.reconcile(c, false)
+}
+
+// HandleReconcileFix godoc
+// @Summary Исправление расхождений балансов
+// @Description Выполняет сверку и исправляет каждое расхождение корректирующей проводкой; в отчёте исправленные строки отмечены adjusted
+// @Tags Администрирование
+// @Produce json,text/csv
+// @Param format query string false "Формат отчёта" Enums(json, csv)
+// @Success 200 {object} postgres.ReconciliationReport "Отчёт о сверке"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin"
+// @Failure 409 {object} httpapi.ErrorResponse "Баланс счёта по истории отрицательный"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/reconciliation [post]
+func (h *Handler) HandleReconcileFix(c *gin.Context) {
+	h.reconcile(c, true)
+}
+
+func (h *Handler) reconcile(c *gin.Context, fix bool) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		httpapi.RespondBadRequest(c, "format must be json or csv")
+		return
+	}
+
+	report, err := h.service.Reconcile(c.Request.Context(), fix)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="reconciliation.csv"`)
+		c.Status(http.StatusOK)
+		if err := service.WriteReconciliationCSV(c.Writer, report); err != nil {
+			c.Error(err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
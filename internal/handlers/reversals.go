@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/httpapi"
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/gin-gonic/gin"
+)
+
+type ReverseRequest struct {
+	// Сумма сторно; если не указана, сторнируется вся сумма перевода
+	Amount money.Amount `json:"amount,omitempty" binding:"gte=0" swaggertype:"number" example:"50.00"`
+}
+
+// HandleReverseTransaction godoc
+// @Summary Сторно перевода
+// @Description Создаёт компенсирующую транзакцию типа reversal, которая возвращает отправителю всю сумму перевода или её часть. Исходная транзакция не меняется и получает ссылку reversed_by в истории; каждый перевод сторнируется один раз, перевод с конвертацией — только полностью
+// @Tags Транзакции
+// @Accept json
+// @Produce json
+// @Param id path int true "ID перевода"
+// @Param input body ReverseRequest false "Сумма сторно"
+// @Param Idempotency-Key header string false "Ключ идемпотентности для безопасного повтора запроса"
+// @Success 200 {object} httpapi.OperationResponse "Перевод сторнирован"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет разрешения transactions:reverse"
+// @Failure 404 {object} httpapi.ErrorResponse "Транзакция не найдена"
+// @Failure 409 {object} httpapi.ErrorResponse "Перевод уже сторнирован, счёт закрыт либо ключ идемпотентности использован с другим запросом"
+// @Failure 422 {object} httpapi.ErrorResponse "Транзакция не является переводом, сумма больше суммы перевода или у получателя недостаточно средств"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /transactions/{id}/reverse [post]
+func (h *Handler) HandleReverseTransaction(c *gin.Context) {
+	transactionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		httpapi.RespondBadRequest(c, "invalid transaction id")
+		return
+	}
+
+	// Тело необязательно: без него сторнируется вся сумма
+	var req ReverseRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			httpapi.RespondBindError(c, err)
+			return
+		}
+	}
+
+	key, ok := httpapi.IdempotencyKey(c)
+	if !ok {
+		return
+	}
+
+	t, err := h.service.ReverseTransaction(c.Request.Context(), transactionID, req.Amount, key)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, httpapi.OperationResponse{Message: "Перевод сторнирован", Transaction: t})
+}
@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/httpapi"
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	"github.com/gin-gonic/gin"
+)
+
+type ScheduledTransferRequest struct {
+	SenderID   int64          `json:"sender_id" binding:"required"`
+	ReceiverID int64          `json:"receiver_id" binding:"required"`
+	Amount     money.Amount   `json:"amount" binding:"required,gt=0" swaggertype:"number" example:"25000.00"`
+	Currency   money.Currency `json:"currency" swaggertype:"string" example:"RUB"`
+	// Время первого выполнения (RFC 3339), должно быть в будущем
+	StartAt time.Time `json:"start_at" binding:"required" example:"2025-04-01T09:00:00Z"`
+	// Правило повторения RRULE: FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL, COUNT, UNTIL; без него перевод разовый
+	Recurrence string `json:"recurrence,omitempty" example:"FREQ=MONTHLY;INTERVAL=1"`
+}
+
+// HandleCreateScheduledTransfer godoc
+// @Summary Запланированный перевод
+// @Description Планирует перевод на start_at; с recurrence перевод повторяется по правилу (например, ежемесячная оплата аренды). Переводы выполняет фоновый планировщик сервиса, результат каждого выполнения сохраняется
+// @Tags Запланированные переводы
+// @Accept json
+// @Produce json
+// @Param input body ScheduledTransferRequest true "Данные перевода"
+// @Success 201 {object} postgres.ScheduledTransfer "Запланированный перевод"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации, некорректное правило повторения или start_at в прошлом"
+// @Failure 403 {object} httpapi.ErrorResponse "Операция со счётом другого пользователя"
+// @Failure 404 {object} httpapi.ErrorResponse "Отправитель или получатель не найден"
+// @Failure 409 {object} httpapi.ErrorResponse "Счёт заморожен или закрыт"
+// @Failure 422 {object} httpapi.ErrorResponse "Некорректная сумма или перевод самому себе"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /scheduled-transfers [post]
+func (h *Handler) HandleCreateScheduledTransfer(c *gin.Context) {
+	var req ScheduledTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpapi.RespondBindError(c, err)
+		return
+	}
+	if !authorizeUser(c, req.SenderID) {
+		return
+	}
+
+	st, err := h.service.CreateScheduledTransfer(c.Request.Context(),
+		req.SenderID, req.ReceiverID, req.Amount, req.Currency, req.StartAt, req.Recurrence)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, st)
+}
+
+// HandleGetScheduledTransfer godoc
+// @Summary Запланированный перевод
+// @Description Возвращает запланированный перевод по id
+// @Tags Запланированные переводы
+// @Produce json
+// @Param id path int true "ID запланированного перевода"
+// @Success 200 {object} postgres.ScheduledTransfer "Запланированный перевод"
+// @Failure 400 {object} httpapi.ErrorResponse "Некорректный id"
+// @Failure 404 {object} httpapi.ErrorResponse "Запланированный перевод не найден или принадлежит другому пользователю"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /scheduled-transfers/{id} [get]
+func (h *Handler) HandleGetScheduledTransfer(c *gin.Context) {
+	st, ok := h.ownScheduledTransfer(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, st)
+}
+
+// HandleListScheduledTransfers godoc
+// @Summary Запланированные переводы пользователя
+// @Description Возвращает запланированные переводы, в которых пользователь — отправитель, от новых к старым
+// @Tags Запланированные переводы
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {array} postgres.ScheduledTransfer "Запланированные переводы"
+// @Failure 400 {object} httpapi.ErrorResponse "Некорректный id"
+// @Failure 403 {object} httpapi.ErrorResponse "Операция со счётом другого пользователя"
+// @Failure 404 {object} httpapi.ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /users/{id}/scheduled-transfers [get]
+func (h *Handler) HandleListScheduledTransfers(c *gin.Context) {
+	userID, ok := ownUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	transfers, err := h.service.ListScheduledTransfers(c.Request.Context(), userID)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, transfers)
+}
+
+// HandleListScheduledTransferRuns godoc
+// @Summary История выполнения запланированного перевода
+// @Description Возвращает последние выполнения перевода от новых к старым: плановое время, результат, созданную транзакцию или текст ошибки
+// @Tags Запланированные переводы
+// @Produce json
+// @Param id path int true "ID запланированного перевода"
+// @Param limit query int false "Количество записей (по умолчанию 20, максимум 100)"
+// @Success 200 {array} postgres.ScheduledTransferRun "Выполнения"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации"
+// @Failure 404 {object} httpapi.ErrorResponse "Запланированный перевод не найден или принадлежит другому пользователю"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /scheduled-transfers/{id}/runs [get]
+func (h *Handler) HandleListScheduledTransferRuns(c *gin.Context) {
+	var query struct {
+		Limit int `form:"limit" binding:"omitempty,min=1,max=100"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		httpapi.RespondBindError(c, err)
+		return
+	}
+	st, ok := h.ownScheduledTransfer(c)
+	if !ok {
+		return
+	}
+
+	runs, err := h.service.ListScheduledTransferRuns(c.Request.Context(), st.ID, query.Limit)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// HandlePauseScheduledTransfer godoc
+// @Summary Приостановка запланированного перевода
+// @Description Приостанавливает выполнение перевода до возобновления
+// @Tags Запланированные переводы
+// @Produce json
+// @Param id path int true "ID запланированного перевода"
+// @Success 200 {object} postgres.ScheduledTransfer "Перевод приостановлен"
+// @Failure 400 {object} httpapi.ErrorResponse "Некорректный id"
+// @Failure 404 {object} httpapi.ErrorResponse "Запланированный перевод не найден или принадлежит другому пользователю"
+// @Failure 409 {object} httpapi.ErrorResponse "Перевод уже завершён или отменён"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /scheduled-transfers/{id}/pause [post]
+func (h *Handler) HandlePauseScheduledTransfer(c *gin.Context) {
+	h.changeScheduledTransfer(c, h.service.PauseScheduledTransfer)
+}
+
+// HandleResumeScheduledTransfer godoc
+// @Summary Возобновление запланированного перевода
+// @Description Возобновляет приостановленный перевод. Вхождения, пропущенные за время паузы, не выполняются; просроченный разовый перевод выполняется сразу
+// @Tags Запланированные переводы
+// @Produce json
+// @Param id path int true "ID запланированного перевода"
+// @Success 200 {object} postgres.ScheduledTransfer "Перевод возобновлён"
+// @Failure 400 {object} httpapi.ErrorResponse "Некорректный id"
+// @Failure 404 {object} httpapi.ErrorResponse "Запланированный перевод не найден или принадлежит другому пользователю"
+// @Failure 409 {object} httpapi.ErrorResponse "Перевод уже завершён или отменён"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /scheduled-transfers/{id}/resume [post]
+func (h *Handler) HandleResumeScheduledTransfer(c *gin.Context) {
+	h.changeScheduledTransfer(c, h.service.ResumeScheduledTransfer)
+}
+
+// HandleCancelScheduledTransfer godoc
+// @Summary Отмена запланированного перевода
+// @Description Отменяет перевод; отменённый перевод возобновить нельзя, история выполнений сохраняется
+// @Tags Запланированные переводы
+// @Produce json
+// @Param id path int true "ID запланированного перевода"
+// @Success 200 {object} postgres.ScheduledTransfer "Перевод отменён"
+// @Failure 400 {object} httpapi.ErrorResponse "Некорректный id"
+// @Failure 404 {object} httpapi.ErrorResponse "Запланированный перевод не найден или принадлежит другому пользователю"
+// @Failure 409 {object} httpapi.ErrorResponse "Перевод уже завершён или отменён"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /scheduled-transfers/{id}/cancel [post]
+func (h *Handler) HandleCancelScheduledTransfer(c *gin.Context) {
+	h.changeScheduledTransfer(c, h.service.CancelScheduledTransfer)
+}
+
+func (h *Handler) changeScheduledTransfer(c *gin.Context, change func(ctx context.Context, id int64) (*postgres.ScheduledTransfer, error)) {
+	st, ok := h.ownScheduledTransfer(c)
+	if !ok {
+		return
+	}
+
+	st, err := change(c.Request.Context(), st.ID)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, st)
+}
+
+// Загружает запланированный перевод по id из пути и проверяет, что запрос выполняется от имени его отправителя.
+// Чужой перевод, как и в ownHold, не отличается от несуществующего.
+func (h *Handler) ownScheduledTransfer(c *gin.Context) (*postgres.ScheduledTransfer, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		httpapi.RespondBadRequest(c, "invalid scheduled transfer id")
+		return nil, false
+	}
+	st, err := h.service.GetScheduledTransfer(c.Request.Context(), id)
+	if err == nil && !ownsAccount(c, st.SenderID) {
+		err = postgres.ErrScheduledTransferNotFound
+	}
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return nil, false
+	}
+	return st, true
+}
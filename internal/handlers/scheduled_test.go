@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/auth"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	service "github.com/EugeneKrivoshein/fin_service/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Репозиторий с запланированными переводами; считает изменения статуса
+type scheduledRepository struct {
+	apiKeyRepository
+	transfers map[int64]*postgres.ScheduledTransfer
+	changed   int
+}
+
+func (r *scheduledRepository) GetScheduledTransfer(_ context.Context, id int64) (*postgres.ScheduledTransfer, error) {
+	if st, ok := r.transfers[id]; ok {
+		return st, nil
+	}
+	return nil, postgres.ErrScheduledTransferNotFound
+}
+
+func (r *scheduledRepository) ListScheduledTransferRuns(context.Context, int64, int) ([]postgres.ScheduledTransferRun, error) {
+	return nil, nil
+}
+
+func (r *scheduledRepository) SetScheduledTransferStatus(_ context.Context, id int64, _ string, _ *time.Time) (*postgres.ScheduledTransfer, error) {
+	r.changed++
+	return r.transfers[id], nil
+}
+
+func TestScheduledTransfers_OnlySender(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	verifier, err := auth.NewVerifier(map[string][]byte{"": testJWTKey}, "", "")
+	require.NoError(t, err)
+	next := time.Now().Add(time.Hour)
+	repo := &scheduledRepository{transfers: map[int64]*postgres.ScheduledTransfer{
+		1: {ID: 1, SenderID: 7, ReceiverID: 8, Status: postgres.ScheduleStatusActive, NextRunAt: &next},
+		2: {ID: 2, SenderID: 8, ReceiverID: 7, Status: postgres.ScheduleStatusActive, NextRunAt: &next},
+	}}
+	h := NewHandler(service.NewService(repo))
+
+	r := gin.New()
+	scheduled := r.Group("/", h.Authenticate(verifier, ""))
+	scheduled.GET("/scheduled-transfers/:id", h.HandleGetScheduledTransfer)
+	scheduled.GET("/scheduled-transfers/:id/runs", h.HandleListScheduledTransferRuns)
+	scheduled.POST("/scheduled-transfers/:id/pause", h.HandlePauseScheduledTransfer)
+	scheduled.POST("/scheduled-transfers/:id/resume", h.HandleResumeScheduledTransfer)
+	scheduled.POST("/scheduled-transfers/:id/cancel", h.HandleCancelScheduledTransfer)
+
+	bearer := "Bearer " + testToken(t, "7", time.Hour)
+	cases := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodGet, "/scheduled-transfers/1", http.StatusOK},
+		{http.MethodGet, "/scheduled-transfers/1/runs", http.StatusOK},
+		{http.MethodPost, "/scheduled-transfers/1/pause", http.StatusOK},
+		// Получатель перевода не может ни просматривать, ни менять его; для него перевод как будто не существует
+		{http.MethodGet, "/scheduled-transfers/2", http.StatusNotFound},
+		{http.MethodGet, "/scheduled-transfers/2/runs", http.StatusNotFound},
+		{http.MethodPost, "/scheduled-transfers/2/pause", http.StatusNotFound},
+		{http.MethodPost, "/scheduled-transfers/2/resume", http.StatusNotFound},
+		{http.MethodPost, "/scheduled-transfers/2/cancel", http.StatusNotFound},
+		{http.MethodGet, "/scheduled-transfers/3", http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("Authorization", bearer)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, tc.status, w.Code, tc.method+" "+tc.path)
+		if tc.status == http.StatusNotFound {
+			assert.Contains(t, w.Body.String(), `"code":"scheduled_transfer_not_found"`, tc.path)
+		}
+	}
+	assert.Equal(t, 1, repo.changed)
+}
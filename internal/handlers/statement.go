@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/httpapi"
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	service "github.com/EugeneKrivoshein/fin_service/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+var statementContentTypes = map[string]string{
+	service.StatementFormatCSV:   "text/csv; charset=utf-8",
+	service.StatementFormatJSONL: "application/x-ndjson",
+	service.StatementFormatPDF:   "application/pdf",
+}
+
+// HandleGetStatement godoc
+// @Summary Выписка по счёту
+// @Description Выгружает все транзакции пользователя в валюте за период [from, to) с начальным балансом, балансом после каждой транзакции и конечным балансом. Без from выписка начинается с первой транзакции, без to — заканчивается текущим моментом. Форматы: csv (первая и последняя строки — opening_balance и closing_balance), jsonl (записи opening, transaction и closing) и pdf. Выписка передаётся потоком по мере чтения из базы
+// @Tags Баланс
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/pdf
+// @Param id path int true "ID пользователя"
+// @Param format query string false "Формат выписки" Enums(csv, jsonl, pdf) default(csv)
+// @Param currency query string false "Валюта (ISO 4217), по умолчанию RUB"
+// @Param from query string false "Начало периода (RFC 3339), включительно"
+// @Param to query string false "Конец периода (RFC 3339), не включительно"
+// @Success 200 {file} file "Выписка"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации или неподдерживаемая валюта"
+// @Failure 403 {object} httpapi.ErrorResponse "Операция со счётом другого пользователя"
+// @Failure 404 {object} httpapi.ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /users/{id}/statement [get]
+func (h *Handler) HandleGetStatement(c *gin.Context) {
+	userID, ok := ownUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", service.StatementFormatCSV)
+	from, err := httpapi.TimeParam(c.Query("from"))
+	if err != nil {
+		httpapi.RespondBadRequest(c, "invalid from, expected RFC 3339 timestamp")
+		return
+	}
+	to, err := httpapi.TimeParam(c.Query("to"))
+	if err != nil {
+		httpapi.RespondBadRequest(c, "invalid to, expected RFC 3339 timestamp")
+		return
+	}
+
+	w := &statementResponseWriter{c: c, format: format, userID: userID}
+	err = h.service.WriteStatement(c.Request.Context(), w, format, userID, money.Currency(c.Query("currency")), from, to)
+	if err == nil {
+		return
+	}
+	if !w.started {
+		httpapi.RespondError(c, err)
+		return
+	}
+	// Заголовки и часть выписки уже отправлены: ответ остаётся оборванным, клиент увидит неполный файл
+	log.Printf("%s %s: выписка прервана: %v", c.Request.Method, c.Request.URL.Path, err)
+	c.Abort()
+}
+
+// Выставляет заголовки ответа при первой записи, чтобы ошибки до начала выписки
+// (неверные параметры, неизвестный пользователь) можно было вернуть обычным JSON-ответом
+type statementResponseWriter struct {
+	c       *gin.Context
+	format  string
+	userID  int64
+	started bool
+}
+
+func (w *statementResponseWriter) Write(b []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.c.Header("Content-Type", statementContentTypes[w.format])
+		w.c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="statement-%d.%s"`, w.userID, w.format))
+		w.c.Status(http.StatusOK)
+	}
+	return w.c.Writer.Write(b)
+}
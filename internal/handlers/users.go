@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/httpapi"
+	"github.com/gin-gonic/gin"
+)
+
+type CreateUserRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=255"`
+}
+
+// HandleCreateUser godoc
+// @Summary Создание пользователя
+// @Description Создаёт пользователя с уникальным именем и нулевым счётом в RUB. Доступно сервисам с областью users:write и пользователям с разрешением users:create
+// @Tags Пользователи
+// @Accept json
+// @Produce json
+// @Param input body CreateUserRequest true "Данные пользователя"
+// @Success 201 {object} postgres.User "Созданный пользователь"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет разрешения users:create"
+// @Failure 409 {object} httpapi.ErrorResponse "Имя пользователя занято"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /users [post]
+func (h *Handler) HandleCreateUser(c *gin.Context) {
+	var req CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpapi.RespondBindError(c, err)
+		return
+	}
+
+	user, err := h.service.CreateUser(c.Request.Context(), req.Username)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+// HandleGetUser godoc
+// @Summary Получение пользователя
+// @Tags Пользователи
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} postgres.User "Пользователь"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации"
+// @Failure 403 {object} httpapi.ErrorResponse "Операция со счётом другого пользователя"
+// @Failure 404 {object} httpapi.ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /users/{id} [get]
+func (h *Handler) HandleGetUser(c *gin.Context) {
+	userID, ok := ownUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.service.GetUser(c.Request.Context(), userID)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// HandleCloseUser godoc
+// @Summary Закрытие счёта
+// @Description Окончательно закрывает счёт; балансы во всех валютах должны быть нулевыми
+// @Tags Пользователи
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} postgres.User "Пользователь"
+// @Failure 403 {object} httpapi.ErrorResponse "Операция со счётом другого пользователя"
+// @Failure 404 {object} httpapi.ErrorResponse "Пользователь не найден"
+// @Failure 409 {object} httpapi.ErrorResponse "Счёт уже закрыт или баланс не нулевой"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /users/{id}/close [post]
+func (h *Handler) HandleCloseUser(c *gin.Context) {
+	userID, ok := ownUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.service.CloseUser(c.Request.Context(), userID)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/httpapi"
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookSubscriptionRequest — адрес получателя и типы событий, на которые он подписывается
+type WebhookSubscriptionRequest struct {
+	URL        string   `json:"url" binding:"required" example:"https://example.com/hooks/fin"`
+	EventTypes []string `json:"event_types" binding:"required,min=1" example:"transfer.completed,transfer.failed"`
+}
+
+// HandleCreateWebhookSubscription godoc
+// @Summary Подписка на вебхуки
+// @Description Подписывает адрес на события: deposit.completed, transfer.completed, withdrawal.completed, reversal.completed, adjustment.completed, deposit.failed, transfer.failed, withdrawal.failed, balance.updated. Каждый запрос подписан HMAC-SHA256 секретом подписки в заголовке X-Webhook-Signature. Секрет возвращается только в ответе на этот запрос
+// @Tags Вебхуки
+// @Accept json
+// @Produce json
+// @Param input body WebhookSubscriptionRequest true "Подписка"
+// @Success 201 {object} postgres.WebhookSubscription "Созданная подписка с секретом"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации, некорректный адрес или неизвестный тип события"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/webhooks [post]
+func (h *Handler) HandleCreateWebhookSubscription(c *gin.Context) {
+	var req WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpapi.RespondBindError(c, err)
+		return
+	}
+
+	sub, err := h.service.CreateWebhookSubscription(c.Request.Context(), req.URL, req.EventTypes)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, sub)
+}
+
+// HandleListWebhookSubscriptions godoc
+// @Summary Подписки на вебхуки
+// @Description Возвращает все подписки на события без секретов
+// @Tags Вебхуки
+// @Produce json
+// @Success 200 {array} postgres.WebhookSubscription "Подписки"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/webhooks [get]
+func (h *Handler) HandleListWebhookSubscriptions(c *gin.Context) {
+	subs, err := h.service.ListWebhookSubscriptions(c.Request.Context())
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subs)
+}
+
+// HandleDeleteWebhookSubscription godoc
+// @Summary Удаление подписки на вебхуки
+// @Description Удаляет подписку вместе с историей доставок; недоставленные события больше не отправляются
+// @Tags Вебхуки
+// @Param id path int true "ID подписки"
+// @Success 204 "Подписка удалена"
+// @Failure 400 {object} httpapi.ErrorResponse "Некорректный id"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin"
+// @Failure 404 {object} httpapi.ErrorResponse "Подписка не найдена"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/webhooks/{id} [delete]
+func (h *Handler) HandleDeleteWebhookSubscription(c *gin.Context) {
+	id, ok := webhookIDParam(c, "invalid webhook subscription id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteWebhookSubscription(c.Request.Context(), id); err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// HandleListWebhookDeliveries godoc
+// @Summary Доставки вебхуков
+// @Description Возвращает последние доставки событий подписчику от новых к старым: статус (pending — ждёт попытки, delivered — доставлено, dead — попытки исчерпаны), число попыток и результат последней
+// @Tags Вебхуки
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param status query string false "Статус доставки: pending, delivered или dead"
+// @Param limit query int false "Количество записей (по умолчанию 50, максимум 200)"
+// @Success 200 {array} postgres.WebhookDelivery "Доставки"
+// @Failure 400 {object} httpapi.ErrorResponse "Ошибка валидации"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin"
+// @Failure 404 {object} httpapi.ErrorResponse "Подписка не найдена"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/webhooks/{id}/deliveries [get]
+func (h *Handler) HandleListWebhookDeliveries(c *gin.Context) {
+	id, ok := webhookIDParam(c, "invalid webhook subscription id")
+	if !ok {
+		return
+	}
+
+	var query struct {
+		Status string `form:"status"`
+		Limit  int    `form:"limit" binding:"omitempty,min=1,max=200"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		httpapi.RespondBindError(c, err)
+		return
+	}
+
+	deliveries, err := h.service.ListWebhookDeliveries(c.Request.Context(), id, query.Status, query.Limit)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// HandleRedeliverWebhook godoc
+// @Summary Повторная доставка вебхука
+// @Description Ставит доставленное или исчерпавшее попытки событие в очередь заново с полным набором попыток. Получатель увидит тот же id события, что и в прошлый раз
+// @Tags Вебхуки
+// @Produce json
+// @Param id path int true "ID доставки"
+// @Success 200 {object} postgres.WebhookDelivery "Доставка поставлена в очередь"
+// @Failure 400 {object} httpapi.ErrorResponse "Некорректный id"
+// @Failure 403 {object} httpapi.ErrorResponse "Нет роли admin"
+// @Failure 404 {object} httpapi.ErrorResponse "Доставка не найдена"
+// @Failure 409 {object} httpapi.ErrorResponse "Доставка ещё ожидает отправки"
+// @Failure 500 {object} httpapi.ErrorResponse "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/webhook-deliveries/{id}/redeliver [post]
+func (h *Handler) HandleRedeliverWebhook(c *gin.Context) {
+	id, ok := webhookIDParam(c, "invalid webhook delivery id")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.service.RedeliverWebhook(c.Request.Context(), id)
+	if err != nil {
+		httpapi.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+func webhookIDParam(c *gin.Context, message string) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		httpapi.RespondBadRequest(c, message)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,21 @@
+package httpapi
+
+import "github.com/gin-gonic/gin"
+
+// Ключ контекста gin, под которым сохраняется ID аутентифицированного пользователя
+const callerIDKey = "auth.user_id"
+
+// SetCallerID запоминает пользователя, от имени которого выполняется запрос; вызывается при аутентификации
+func SetCallerID(c *gin.Context, userID int64) {
+	c.Set(callerIDKey, userID)
+}
+
+// CallerID возвращает ID пользователя, от имени которого выполняется запрос; false — запрос от сервиса
+// с API-ключом или без аутентификации
+func CallerID(c *gin.Context) (int64, bool) {
+	callerID, ok := c.Get(callerIDKey)
+	if !ok {
+		return 0, false
+	}
+	return callerID.(int64), true
+}
@@ -0,0 +1,170 @@
+// Пакет httpapi — общие части HTTP API для обработчиков internal/handlers и административного API
+// internal/admin: формат ошибок и их соответствие статусам, разбор общих параметров запроса и пользователь,
+// от имени которого выполняется запрос. Так ответы обоих API не расходятся.
+package httpapi
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/fx"
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	"github.com/EugeneKrivoshein/fin_service/internal/recurrence"
+	service "github.com/EugeneKrivoshein/fin_service/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorResponse — тело ответа с ошибкой.
+// Code — стабильный машиночитаемый код, Error — сообщение для человека.
+type ErrorResponse struct {
+	Code  string `json:"code" example:"insufficient_funds"`
+	Error string `json:"error" example:"insufficient funds"`
+	// Превышенный лимит и остаток лимита; заполняется только для limit_exceeded
+	Limit *postgres.LimitExceededError `json:"limit,omitempty"`
+}
+
+// Коды ошибок, не связанные с ошибками предметной области
+const (
+	CodeInvalidRequest = "invalid_request"
+	CodeInvalidAmount  = "invalid_amount"
+	CodeInternal       = "internal_error"
+	CodeForbidden      = "forbidden"
+)
+
+// Ключ контекста gin, под которым сохраняется код ошибки ответа
+const errorCodeKey = "response.error_code"
+
+type errorMapping struct {
+	err    error
+	status int
+	code   string
+}
+
+// Соответствие ошибок предметной области HTTP-статусам и кодам.
+// Клиенту отдаётся только текст самой ошибки из таблицы, без контекста обёрток.
+var errorMappings = []errorMapping{
+	{postgres.ErrInvalidCursor, http.StatusBadRequest, "invalid_cursor"},
+	{service.ErrConflictingCursors, http.StatusBadRequest, CodeInvalidRequest},
+	{service.ErrInvalidDateRange, http.StatusBadRequest, CodeInvalidRequest},
+	{service.ErrInvalidAmountRange, http.StatusBadRequest, CodeInvalidRequest},
+	{service.ErrInvalidHoldTTL, http.StatusBadRequest, CodeInvalidRequest},
+	{service.ErrStartInPast, http.StatusBadRequest, CodeInvalidRequest},
+	{service.ErrInvalidTier, http.StatusBadRequest, CodeInvalidRequest},
+	{recurrence.ErrInvalidRule, http.StatusBadRequest, "invalid_recurrence"},
+	{service.ErrInvalidFeeRule, http.StatusBadRequest, "invalid_fee_rule"},
+	{service.ErrInvalidWebhookURL, http.StatusBadRequest, "invalid_webhook_url"},
+	{service.ErrUnknownEventType, http.StatusBadRequest, "unknown_event_type"},
+	{service.ErrInvalidDeliveryStatus, http.StatusBadRequest, CodeInvalidRequest},
+	{service.ErrInvalidStatementFormat, http.StatusBadRequest, CodeInvalidRequest},
+	{service.ErrInvalidAPIKeyName, http.StatusBadRequest, CodeInvalidRequest},
+	{service.ErrUnknownScope, http.StatusBadRequest, "unknown_scope"},
+	{service.ErrExpiresInPast, http.StatusBadRequest, CodeInvalidRequest},
+	{service.ErrInvalidGracePeriod, http.StatusBadRequest, CodeInvalidRequest},
+	{service.ErrInvalidReason, http.StatusBadRequest, CodeInvalidRequest},
+	{service.ErrUnknownRole, http.StatusBadRequest, "unknown_role"},
+	{service.ErrInvalidActorType, http.StatusBadRequest, CodeInvalidRequest},
+	{service.ErrInvalidAuditResult, http.StatusBadRequest, CodeInvalidRequest},
+	{money.ErrUnsupportedCurrency, http.StatusBadRequest, "unsupported_currency"},
+	{money.ErrTooManyFractionDigits, http.StatusBadRequest, CodeInvalidAmount},
+
+	{postgres.ErrUserNotFound, http.StatusNotFound, "user_not_found"},
+	{postgres.ErrSenderNotFound, http.StatusNotFound, "sender_not_found"},
+	{postgres.ErrReceiverNotFound, http.StatusNotFound, "receiver_not_found"},
+	{postgres.ErrCurrencyAccountNotFound, http.StatusNotFound, "currency_account_not_found"},
+	{postgres.ErrQuoteNotFound, http.StatusNotFound, "quote_not_found"},
+	{postgres.ErrHoldNotFound, http.StatusNotFound, "hold_not_found"},
+	{postgres.ErrTransactionNotFound, http.StatusNotFound, "transaction_not_found"},
+	{postgres.ErrScheduledTransferNotFound, http.StatusNotFound, "scheduled_transfer_not_found"},
+	{postgres.ErrFeeRuleNotFound, http.StatusNotFound, "fee_rule_not_found"},
+	{postgres.ErrWebhookSubscriptionNotFound, http.StatusNotFound, "webhook_subscription_not_found"},
+	{postgres.ErrWebhookDeliveryNotFound, http.StatusNotFound, "webhook_delivery_not_found"},
+	{postgres.ErrAPIKeyNotFound, http.StatusNotFound, "api_key_not_found"},
+	{postgres.ErrRoleNotGranted, http.StatusNotFound, "role_not_granted"},
+
+	{postgres.ErrUsernameTaken, http.StatusConflict, "username_taken"},
+	{postgres.ErrAccountFrozen, http.StatusConflict, "account_frozen"},
+	{postgres.ErrAccountClosed, http.StatusConflict, "account_closed"},
+	{postgres.ErrAccountNotEmpty, http.StatusConflict, "account_not_empty"},
+	{postgres.ErrCurrencyAccountExists, http.StatusConflict, "currency_account_exists"},
+	{postgres.ErrQuoteExpired, http.StatusConflict, "quote_expired"},
+	{postgres.ErrQuoteUsed, http.StatusConflict, "quote_already_used"},
+	{postgres.ErrHoldNotActive, http.StatusConflict, "hold_not_active"},
+	{postgres.ErrHoldExpired, http.StatusConflict, "hold_expired"},
+	{postgres.ErrScheduleFinished, http.StatusConflict, "schedule_finished"},
+	{postgres.ErrAlreadyReversed, http.StatusConflict, "already_reversed"},
+	{postgres.ErrFeeRuleExists, http.StatusConflict, "fee_rule_exists"},
+	{postgres.ErrWebhookDeliveryPending, http.StatusConflict, "webhook_delivery_pending"},
+	{postgres.ErrAPIKeyInactive, http.StatusConflict, "api_key_inactive"},
+	{service.ErrRevokeOwnAdminRole, http.StatusConflict, "own_admin_role"},
+	{postgres.ErrIdempotencyKeyReused, http.StatusConflict, "idempotency_key_reused"},
+	{postgres.ErrNegativeExpectedBalance, http.StatusConflict, "negative_expected_balance"},
+
+	{postgres.ErrInsufficientFunds, http.StatusUnprocessableEntity, "insufficient_funds"},
+	{money.ErrAmountOutOfRange, http.StatusUnprocessableEntity, "amount_out_of_range"},
+	{postgres.ErrLimitExceeded, http.StatusUnprocessableEntity, "limit_exceeded"},
+	{postgres.ErrCurrencyMismatch, http.StatusUnprocessableEntity, "currency_mismatch"},
+	{postgres.ErrConvertedAmountTooSmall, http.StatusUnprocessableEntity, "converted_amount_too_small"},
+	{postgres.ErrCaptureExceedsHold, http.StatusUnprocessableEntity, "capture_exceeds_hold"},
+	{postgres.ErrNotReversible, http.StatusUnprocessableEntity, "not_reversible"},
+	{postgres.ErrReversalExceedsAmount, http.StatusUnprocessableEntity, "reversal_exceeds_amount"},
+	{postgres.ErrPartialFXReversal, http.StatusUnprocessableEntity, "partial_fx_reversal"},
+	{service.ErrSameCurrency, http.StatusUnprocessableEntity, "same_currency"},
+	{fx.ErrRateUnavailable, http.StatusUnprocessableEntity, "rate_unavailable"},
+	{service.ErrNonPositiveAmount, http.StatusUnprocessableEntity, "non_positive_amount"},
+	{service.ErrZeroAdjustment, http.StatusUnprocessableEntity, "zero_adjustment"},
+	{service.ErrSameAccount, http.StatusUnprocessableEntity, "same_account"},
+}
+
+// RespondError отвечает ошибкой сервиса со статусом и кодом из таблицы соответствий. Неизвестные ошибки
+// логируются, а клиент получает 500 без подробностей, чтобы не раскрывать детали работы с БД.
+func RespondError(c *gin.Context, err error) {
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			resp := ErrorResponse{Code: m.code, Error: m.err.Error()}
+			// Остаток лимита нужен клиенту, чтобы уменьшить сумму операции
+			var limitErr *postgres.LimitExceededError
+			if errors.As(err, &limitErr) {
+				resp.Error, resp.Limit = limitErr.Error(), limitErr
+			}
+			AbortWithError(c, m.status, resp)
+			return
+		}
+	}
+
+	log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+	AbortWithError(c, http.StatusInternalServerError, ErrorResponse{Code: CodeInternal, Error: "internal server error"})
+}
+
+// RespondBadRequest отвечает 400 на некорректный запрос
+func RespondBadRequest(c *gin.Context, message string) {
+	AbortWithError(c, http.StatusBadRequest, ErrorResponse{Code: CodeInvalidRequest, Error: message})
+}
+
+// RespondBindError отвечает 400 на ошибку разбора тела или параметров запроса
+func RespondBindError(c *gin.Context, err error) {
+	if errors.Is(err, money.ErrInvalidAmount) ||
+		errors.Is(err, money.ErrTooManyFractionDigits) ||
+		errors.Is(err, money.ErrAmountOutOfRange) {
+		AbortWithError(c, http.StatusBadRequest, ErrorResponse{Code: CodeInvalidAmount, Error: err.Error()})
+		return
+	}
+	RespondBadRequest(c, err.Error())
+}
+
+// RespondForbidden отвечает 403
+func RespondForbidden(c *gin.Context, message string) {
+	AbortWithError(c, http.StatusForbidden, ErrorResponse{Code: CodeForbidden, Error: message})
+}
+
+// AbortWithError прерывает обработку запроса ответом с ошибкой и запоминает её код для журнала аудита
+func AbortWithError(c *gin.Context, status int, resp ErrorResponse) {
+	c.Set(errorCodeKey, resp.Code)
+	c.AbortWithStatusJSON(status, resp)
+}
+
+// ErrorCode возвращает код ошибки, которой ответил запрос; пустая строка — ответ без ошибки
+func ErrorCode(c *gin.Context) string {
+	return c.GetString(errorCodeKey)
+}
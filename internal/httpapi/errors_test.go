@@ -0,0 +1,90 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	service "github.com/EugeneKrivoshein/fin_service/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func performRespondError(t *testing.T, err error) (int, ErrorResponse) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/transfer", nil)
+
+	RespondError(c, err)
+
+	var body ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return w.Code, body
+}
+
+func TestRespondError_DomainErrors(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{postgres.ErrUserNotFound, http.StatusNotFound, "user_not_found"},
+		{postgres.ErrReceiverNotFound, http.StatusNotFound, "receiver_not_found"},
+		{postgres.ErrAccountFrozen, http.StatusConflict, "account_frozen"},
+		{postgres.ErrIdempotencyKeyReused, http.StatusConflict, "idempotency_key_reused"},
+		{postgres.ErrInsufficientFunds, http.StatusUnprocessableEntity, "insufficient_funds"},
+		{service.ErrSameAccount, http.StatusUnprocessableEntity, "same_account"},
+		{postgres.ErrCurrencyMismatch, http.StatusUnprocessableEntity, "currency_mismatch"},
+		{money.ErrUnsupportedCurrency, http.StatusBadRequest, "unsupported_currency"},
+		{postgres.ErrHoldNotFound, http.StatusNotFound, "hold_not_found"},
+		{postgres.ErrHoldExpired, http.StatusConflict, "hold_expired"},
+		{postgres.ErrCaptureExceedsHold, http.StatusUnprocessableEntity, "capture_exceeds_hold"},
+		{postgres.ErrTransactionNotFound, http.StatusNotFound, "transaction_not_found"},
+		{postgres.ErrAlreadyReversed, http.StatusConflict, "already_reversed"},
+		{postgres.ErrPartialFXReversal, http.StatusUnprocessableEntity, "partial_fx_reversal"},
+		{service.ErrUnknownEventType, http.StatusBadRequest, "unknown_event_type"},
+		{postgres.ErrWebhookDeliveryPending, http.StatusConflict, "webhook_delivery_pending"},
+		{service.ErrUnknownScope, http.StatusBadRequest, "unknown_scope"},
+		{postgres.ErrAPIKeyInactive, http.StatusConflict, "api_key_inactive"},
+		{postgres.ErrNegativeExpectedBalance, http.StatusConflict, "negative_expected_balance"},
+		{postgres.ErrRoleNotGranted, http.StatusNotFound, "role_not_granted"},
+		{service.ErrUnknownRole, http.StatusBadRequest, "unknown_role"},
+		{service.ErrZeroAdjustment, http.StatusUnprocessableEntity, "zero_adjustment"},
+	}
+	for _, tc := range cases {
+		status, body := performRespondError(t, tc.err)
+		assert.Equal(t, tc.status, status, tc.code)
+		assert.Equal(t, tc.code, body.Code)
+		assert.Equal(t, tc.err.Error(), body.Error)
+	}
+}
+
+func TestRespondError_WrappedErrorIsSanitized(t *testing.T) {
+	// Контекст обёртки не должен попадать в ответ клиенту
+	status, body := performRespondError(t, fmt.Errorf("failed to lock user 42: %w", postgres.ErrSenderNotFound))
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, "sender not found", body.Error)
+
+	status, body = performRespondError(t, errors.New(`pq: relation "users" does not exist`))
+	assert.Equal(t, http.StatusInternalServerError, status)
+	assert.Equal(t, "internal_error", body.Code)
+	assert.Equal(t, "internal server error", body.Error)
+}
+
+func TestRespondError_LimitExceeded(t *testing.T) {
+	// Клиент получает вид превышенного лимита и его остаток
+	limitErr := &postgres.LimitExceededError{Type: postgres.LimitDaily, Currency: "RUB", Remaining: money.MustParse("2500.00")}
+	status, body := performRespondError(t, fmt.Errorf("failed to transfer: %w", limitErr))
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.Equal(t, "limit_exceeded", body.Code)
+	assert.Equal(t, "daily spending limit exceeded: 2500.00 RUB remaining", body.Error)
+	require.NotNil(t, body.Limit)
+	assert.Equal(t, *limitErr, *body.Limit)
+}
@@ -0,0 +1,126 @@
+package httpapi
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader — заголовок, по которому клиент может безопасно повторять POST-запросы
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+const maxIdempotencyKeyLen = 255
+
+// OperationResponse — ответ на денежную операцию.
+// Повтор с тем же ключом идемпотентности возвращает ту же транзакцию.
+type OperationResponse struct {
+	Message     string                `json:"message"`
+	Transaction *postgres.Transaction `json:"transaction"`
+}
+
+// IdempotencyKey читает заголовок Idempotency-Key; при некорректном значении отвечает 400 и возвращает false
+func IdempotencyKey(c *gin.Context) (string, bool) {
+	key := c.GetHeader(IdempotencyKeyHeader)
+	if len(key) > maxIdempotencyKeyLen {
+		RespondBadRequest(c, "Idempotency-Key is too long")
+		return "", false
+	}
+	return key, true
+}
+
+// UserIDParam читает id пользователя из пути; при ошибке отвечает 400 и возвращает false
+func UserIDParam(c *gin.Context) (int64, bool) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondBadRequest(c, "invalid user id")
+		return 0, false
+	}
+	return userID, true
+}
+
+// BindTransactionFilter читает из строки запроса фильтры и курсор истории транзакций, кроме пользователя;
+// при ошибке отвечает 400 и возвращает false
+func BindTransactionFilter(c *gin.Context) (postgres.TransactionFilter, bool) {
+	var query struct {
+		Limit     int    `form:"limit" binding:"omitempty,min=1,max=100"`
+		Before    string `form:"before"`
+		After     string `form:"after"`
+		Type      string `form:"type" binding:"omitempty,oneof=deposit transfer withdrawal reversal adjustment"`
+		Currency  string `form:"currency"`
+		From      string `form:"from"`
+		To        string `form:"to"`
+		MinAmount string `form:"min_amount"`
+		MaxAmount string `form:"max_amount"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		RespondBindError(c, err)
+		return postgres.TransactionFilter{}, false
+	}
+
+	filter := postgres.TransactionFilter{
+		Limit:    query.Limit,
+		Type:     query.Type,
+		Currency: money.Currency(query.Currency),
+	}
+	var err error
+	if filter.Before, err = cursorParam(query.Before); err != nil {
+		RespondError(c, err)
+		return filter, false
+	}
+	if filter.After, err = cursorParam(query.After); err != nil {
+		RespondError(c, err)
+		return filter, false
+	}
+	if filter.From, err = TimeParam(query.From); err != nil {
+		RespondBadRequest(c, "invalid from, expected RFC 3339 timestamp")
+		return filter, false
+	}
+	if filter.To, err = TimeParam(query.To); err != nil {
+		RespondBadRequest(c, "invalid to, expected RFC 3339 timestamp")
+		return filter, false
+	}
+	if filter.MinAmount, err = amountParam(query.MinAmount); err != nil {
+		RespondBindError(c, err)
+		return filter, false
+	}
+	if filter.MaxAmount, err = amountParam(query.MaxAmount); err != nil {
+		RespondBindError(c, err)
+		return filter, false
+	}
+	return filter, true
+}
+
+// Необязательные параметры запроса: пустая строка означает, что параметр не задан
+
+func cursorParam(s string) (*postgres.Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return postgres.DecodeCursor(s)
+}
+
+// TimeParam разбирает необязательный параметр в формате RFC 3339
+func TimeParam(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func amountParam(s string) (*money.Amount, error) {
+	if s == "" {
+		return nil, nil
+	}
+	a, err := money.ParseAmount(s)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
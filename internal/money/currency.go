@@ -0,0 +1,94 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrUnsupportedCurrency = errors.New("unsupported currency")
+
+// Currency — трёхбуквенный код валюты ISO 4217
+type Currency string
+
+// DefaultCurrency — валюта, в которой велись балансы до появления мультивалютных счетов.
+// Используется, если валюта в запросе не указана.
+const DefaultCurrency Currency = "RUB"
+
+// Поддерживаемые валюты и число знаков после запятой в их минимальной единице (ISO 4217).
+// Суммы хранятся с Scale знаками, поэтому валюты с большим числом знаков не поддерживаются.
+var currencyDigits = map[Currency]int{
+	"RUB": 2,
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"CHF": 2,
+	"CNY": 2,
+	"KZT": 2,
+	"BYN": 2,
+	"TRY": 2,
+	"AED": 2,
+	"JPY": 0,
+	"KRW": 0,
+}
+
+// ParseCurrency разбирает код валюты без учёта регистра ("usd" -> "USD")
+func ParseCurrency(s string) (Currency, error) {
+	c := Currency(strings.ToUpper(s))
+	if err := c.Validate(); err != nil {
+		return "", err
+	}
+	return c, nil
+}
+
+// Validate проверяет, что валюта поддерживается
+func (c Currency) Validate() error {
+	if _, ok := currencyDigits[c]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnsupportedCurrency, string(c))
+	}
+	return nil
+}
+
+// Digits возвращает число знаков после запятой в минимальной единице валюты
+func (c Currency) Digits() int {
+	return currencyDigits[c]
+}
+
+// CheckAmount проверяет, что сумма выражается в минимальных единицах валюты (например, для JPY — целая)
+// и помещается в колонку суммы в БД
+func (c Currency) CheckAmount(a Amount) error {
+	if a > MaxAmount || a < -MaxAmount {
+		return ErrAmountOutOfRange
+	}
+	step := Amount(1)
+	for i := c.Digits(); i < Scale; i++ {
+		step *= 10
+	}
+	if a%step != 0 {
+		return ErrTooManyFractionDigits
+	}
+	return nil
+}
+
+func (c Currency) String() string {
+	return string(c)
+}
+
+// Scan читает код валюты из колонки CHAR(3)
+func (c *Currency) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		*c = Currency(v)
+	case []byte:
+		*c = Currency(v)
+	default:
+		return fmt.Errorf("cannot scan %T into money.Currency", src)
+	}
+	return nil
+}
+
+// Value передаёт код валюты в БД строкой
+func (c Currency) Value() (driver.Value, error) {
+	return string(c), nil
+}
@@ -0,0 +1,158 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Scale — количество знаков после запятой, с которым суммы хранятся в БД (NUMERIC(15,2))
+const Scale = 2
+
+const unit = 100 // 10^Scale
+
+// MaxAmount — наибольшая по модулю сумма, которая помещается в колонку NUMERIC(15,2): 9 999 999 999 999.99
+const MaxAmount Amount = 999_999_999_999_999
+
+var (
+	ErrInvalidAmount         = errors.New("invalid amount")
+	ErrTooManyFractionDigits = errors.New("amount has too many fraction digits")
+	ErrAmountOutOfRange      = errors.New("amount is out of range")
+)
+
+// Amount — денежная сумма в минимальных единицах (сотых долях).
+// Хранится как целое число, поэтому арифметика над суммами не накапливает ошибок округления.
+type Amount int64
+
+// ParseAmount разбирает десятичную запись суммы ("100", "100.5", "-0.01").
+// Экспоненциальная запись и более Scale знаков после запятой отклоняются.
+func ParseAmount(s string) (Amount, error) {
+	return ParseAmountDigits(s, Scale)
+}
+
+// ParseAmountDigits разбирает сумму, допуская не более digits знаков после запятой
+func ParseAmountDigits(s string, digits int) (Amount, error) {
+	if digits < 0 || digits > Scale {
+		return 0, fmt.Errorf("unsupported fraction digits: %d", digits)
+	}
+
+	neg := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		neg = true
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	intPart, fracPart, hasDot := strings.Cut(s, ".")
+	if intPart == "" || (hasDot && fracPart == "") || !isDigits(intPart) || !isDigits(fracPart) {
+		return 0, ErrInvalidAmount
+	}
+
+	// Незначащие нули в дробной части не считаются лишними знаками: "10.500" == "10.50"
+	fracPart = strings.TrimRight(fracPart, "0")
+	if len(fracPart) > digits {
+		return 0, ErrTooManyFractionDigits
+	}
+	fracPart += strings.Repeat("0", Scale-len(fracPart))
+
+	frac, _ := strconv.ParseInt(fracPart, 10, 64)
+	whole, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil || whole > (math.MaxInt64-frac)/unit {
+		return 0, ErrAmountOutOfRange
+	}
+
+	v := Amount(whole*unit + frac)
+	if neg {
+		v = -v
+	}
+	return v, nil
+}
+
+// MustParse — ParseAmount для констант и тестов, паникует при ошибке
+func MustParse(s string) Amount {
+	a, err := ParseAmount(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String возвращает сумму с фиксированными Scale знаками после запятой, например "1500.50"
+func (a Amount) String() string {
+	sign := ""
+	v := uint64(a)
+	if a < 0 {
+		sign = "-"
+		v = uint64(-a)
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/unit, v%unit)
+}
+
+// MarshalJSON кодирует сумму JSON-числом в точной десятичной записи
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON принимает как JSON-число, так и строку, не проходя через float64
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	v, err := ParseAmount(s)
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+// Scan читает NUMERIC из БД (pgx передаёт его строкой)
+func (a *Amount) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case int64:
+		if v > math.MaxInt64/unit || v < math.MinInt64/unit {
+			return fmt.Errorf("cannot scan %d into money.Amount: %w", v, ErrAmountOutOfRange)
+		}
+		*a = Amount(v * unit)
+		return nil
+	case nil:
+		return errors.New("cannot scan NULL into money.Amount")
+	default:
+		return fmt.Errorf("cannot scan %T into money.Amount", src)
+	}
+
+	v, err := ParseAmount(s)
+	if err != nil {
+		return fmt.Errorf("cannot scan %q into money.Amount: %w", s, err)
+	}
+	*a = v
+	return nil
+}
+
+// Value передаёт сумму в БД десятичной строкой, которую Postgres приводит к NUMERIC без потерь
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
@@ -0,0 +1,172 @@
+package money
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAmount(t *testing.T) {
+	cases := map[string]Amount{
+		"100":     10000,
+		"100.5":   10050,
+		"100.50":  10050,
+		"0.01":    1,
+		"-0.01":   -1,
+		"1500.50": 150050,
+		"10.500":  1050,
+	}
+	for in, want := range cases {
+		got, err := ParseAmount(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+}
+
+func TestParseAmount_Invalid(t *testing.T) {
+	// Больше двух значащих знаков после запятой — строгий отказ, без округления
+	_, err := ParseAmount("0.001")
+	assert.ErrorIs(t, err, ErrTooManyFractionDigits)
+	_, err = ParseAmount("10.123")
+	assert.ErrorIs(t, err, ErrTooManyFractionDigits)
+
+	for _, in := range []string{"", ".5", "5.", "1e3", "abc", "1,5", "--1", " 1"} {
+		_, err := ParseAmount(in)
+		assert.ErrorIs(t, err, ErrInvalidAmount, in)
+	}
+
+	_, err = ParseAmount("92233720368547758.08")
+	assert.ErrorIs(t, err, ErrAmountOutOfRange)
+}
+
+func TestParseAmountDigits(t *testing.T) {
+	_, err := ParseAmountDigits("100.5", 0)
+	assert.ErrorIs(t, err, ErrTooManyFractionDigits)
+
+	a, err := ParseAmountDigits("100", 0)
+	require.NoError(t, err)
+	assert.Equal(t, Amount(10000), a)
+}
+
+func TestAmount_String(t *testing.T) {
+	assert.Equal(t, "0.00", Amount(0).String())
+	assert.Equal(t, "0.07", Amount(7).String())
+	assert.Equal(t, "-12.30", Amount(-1230).String())
+	assert.Equal(t, "1500.50", Amount(150050).String())
+}
+
+func TestAmount_JSON(t *testing.T) {
+	var req struct {
+		Amount Amount `json:"amount"`
+	}
+
+	// Принимаем как число, так и строку
+	require.NoError(t, json.Unmarshal([]byte(`{"amount": 0.3}`), &req))
+	assert.Equal(t, Amount(30), req.Amount)
+	require.NoError(t, json.Unmarshal([]byte(`{"amount": "100.10"}`), &req))
+	assert.Equal(t, Amount(10010), req.Amount)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"amount": 0.105}`), &req))
+
+	out, err := json.Marshal(req)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount": 100.10}`, string(out))
+}
+
+func TestAmount_ScanValue(t *testing.T) {
+	var a Amount
+	require.NoError(t, a.Scan("2000.75"))
+	assert.Equal(t, Amount(200075), a)
+
+	v, err := a.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "2000.75", v)
+
+	require.NoError(t, a.Scan(int64(42)))
+	assert.Equal(t, Amount(4200), a)
+	assert.ErrorIs(t, a.Scan(int64(math.MaxInt64/10)), ErrAmountOutOfRange)
+	assert.ErrorIs(t, a.Scan(int64(math.MinInt64/10)), ErrAmountOutOfRange)
+
+	assert.Error(t, a.Scan(nil))
+	assert.Error(t, a.Scan(1.5))
+}
+
+func TestParseCurrency(t *testing.T) {
+	c, err := ParseCurrency("usd")
+	require.NoError(t, err)
+	assert.Equal(t, Currency("USD"), c)
+
+	for _, in := range []string{"", "US", "XXX", "рубль"} {
+		_, err := ParseCurrency(in)
+		assert.ErrorIs(t, err, ErrUnsupportedCurrency, in)
+	}
+}
+
+func TestCurrency_CheckAmount(t *testing.T) {
+	assert.NoError(t, Currency("USD").CheckAmount(MustParse("10.01")))
+	assert.NoError(t, Currency("JPY").CheckAmount(MustParse("1500")))
+	assert.ErrorIs(t, Currency("JPY").CheckAmount(MustParse("1500.50")), ErrTooManyFractionDigits)
+
+	// Сумма должна помещаться в NUMERIC(15,2)
+	assert.NoError(t, Currency("USD").CheckAmount(MustParse("9999999999999.99")))
+	assert.ErrorIs(t, Currency("USD").CheckAmount(MustParse("10000000000000.00")), ErrAmountOutOfRange)
+	assert.ErrorIs(t, Currency("USD").CheckAmount(MustParse("-10000000000000.00")), ErrAmountOutOfRange)
+}
+
+func TestParseRate(t *testing.T) {
+	r, err := ParseRate("92.5000")
+	require.NoError(t, err)
+	assert.Equal(t, "92.5", r.String())
+
+	for _, in := range []string{"", "0", "0.00", "-1", "1e3", "1/3", "1.00000000001"} {
+		_, err := ParseRate(in)
+		assert.ErrorIs(t, err, ErrInvalidRate, in)
+	}
+}
+
+func TestRate_Convert(t *testing.T) {
+	r := MustParseRate("92.5")
+	converted, err := r.Convert(MustParse("10.01"), "RUB")
+	require.NoError(t, err)
+	assert.Equal(t, MustParse("925.93"), converted) // 925.925 округляется вверх
+
+	// В валюте без дробных единиц результат округляется до целого
+	converted, err = MustParseRate("151.37").Convert(MustParse("10.00"), "JPY")
+	require.NoError(t, err)
+	assert.Equal(t, MustParse("1514"), converted)
+
+	inverse, err := r.Inverse()
+	require.NoError(t, err)
+	assert.Equal(t, "0.0108108108", inverse.String())
+}
+
+func TestRate_Percent(t *testing.T) {
+	fee, err := MustParseRate("1.5").Percent(MustParse("1000.30"), "RUB")
+	require.NoError(t, err)
+	assert.Equal(t, MustParse("15.00"), fee) // 15.0045 округляется вниз
+
+	fee, err = MustParseRate("2.5").Percent(MustParse("0.10"), "RUB")
+	require.NoError(t, err)
+	assert.Equal(t, MustParse("0.00"), fee) // 0.0025 меньше минимальной единицы
+
+	fee, err = MustParseRate("0.5").Percent(MustParse("1500"), "JPY")
+	require.NoError(t, err)
+	assert.Equal(t, MustParse("8"), fee) // 7.5 округляется от нуля
+
+	assert.Equal(t, 1, MustParseRate("100.01").Cmp(MustParseRate("100")))
+	assert.Equal(t, 0, MustParseRate("1.50").Cmp(MustParseRate("1.5")))
+	assert.Equal(t, -1, Rate{}.Cmp(MustParseRate("0.0000000001")))
+}
+
+func TestRate_JSON(t *testing.T) {
+	var v struct {
+		Rate Rate `json:"rate"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"rate": 0.0108}`), &v))
+	out, err := json.Marshal(v)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"rate": 0.0108}`, string(out))
+}
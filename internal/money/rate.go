@@ -0,0 +1,150 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// RateScale — максимальное число знаков после запятой в курсе (в БД — NUMERIC(20,10))
+const RateScale = 10
+
+var ErrInvalidRate = errors.New("invalid exchange rate")
+
+// Rate — курс обмена: сколько единиц валюты назначения стоит одна единица исходной валюты.
+// Хранится точной дробью, поэтому пересчёт сумм не проходит через float64.
+type Rate struct {
+	r *big.Rat
+}
+
+// ParseRate разбирает положительный курс в десятичной записи ("92.5", "0.0108")
+func ParseRate(s string) (Rate, error) {
+	intPart, fracPart, hasDot := strings.Cut(s, ".")
+	if intPart == "" || (hasDot && fracPart == "") || !isDigits(intPart) || !isDigits(fracPart) {
+		return Rate{}, ErrInvalidRate
+	}
+	if len(strings.TrimRight(fracPart, "0")) > RateScale {
+		return Rate{}, fmt.Errorf("%w: more than %d fraction digits", ErrInvalidRate, RateScale)
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || r.Sign() <= 0 {
+		return Rate{}, ErrInvalidRate
+	}
+	return Rate{r: r}, nil
+}
+
+// MustParseRate — ParseRate для констант и тестов, паникует при ошибке
+func MustParseRate(s string) Rate {
+	r, err := ParseRate(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// IsZero сообщает, что курс не задан
+func (r Rate) IsZero() bool {
+	return r.r == nil
+}
+
+// Inverse возвращает обратный курс, округлённый до RateScale знаков
+func (r Rate) Inverse() (Rate, error) {
+	if r.IsZero() {
+		return Rate{}, ErrInvalidRate
+	}
+	return ParseRate(new(big.Rat).Inv(r.r).FloatString(RateScale))
+}
+
+// Convert пересчитывает сумму a по курсу в валюту to. Результат округляется
+// до минимальной единицы валюты to, половина округляется от нуля.
+func (r Rate) Convert(a Amount, to Currency) (Amount, error) {
+	if r.IsZero() {
+		return 0, ErrInvalidRate
+	}
+	major := new(big.Rat).SetFrac(big.NewInt(int64(a)), big.NewInt(unit))
+	converted := new(big.Rat).Mul(major, r.r)
+	return ParseAmount(converted.FloatString(to.Digits()))
+}
+
+// Percent возвращает r процентов от суммы a (r трактуется как число процентов, например 1.5),
+// округлённые до минимальной единицы валюты c; половина округляется от нуля
+func (r Rate) Percent(a Amount, c Currency) (Amount, error) {
+	if r.IsZero() {
+		return 0, ErrInvalidRate
+	}
+	major := new(big.Rat).SetFrac(big.NewInt(int64(a)), big.NewInt(unit*100))
+	return ParseAmount(new(big.Rat).Mul(major, r.r).FloatString(c.Digits()))
+}
+
+// Cmp сравнивает курсы: -1, если r меньше other, 0, если равны, и +1, если больше.
+// Незаданный курс считается нулём.
+func (r Rate) Cmp(other Rate) int {
+	return r.rat().Cmp(other.rat())
+}
+
+func (r Rate) rat() *big.Rat {
+	if r.IsZero() {
+		return new(big.Rat)
+	}
+	return r.r
+}
+
+// String возвращает курс в десятичной записи без незначащих нулей, например "92.5"
+func (r Rate) String() string {
+	if r.IsZero() {
+		return "0"
+	}
+	s := strings.TrimRight(r.r.FloatString(RateScale), "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// MarshalJSON кодирует курс JSON-числом в точной десятичной записи
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalJSON принимает как JSON-число, так и строку
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	v, err := ParseRate(s)
+	if err != nil {
+		return err
+	}
+	*r = v
+	return nil
+}
+
+// Scan читает NUMERIC из БД (pgx передаёт его строкой)
+func (r *Rate) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into money.Rate", src)
+	}
+
+	v, err := ParseRate(s)
+	if err != nil {
+		return fmt.Errorf("cannot scan %q into money.Rate: %w", s, err)
+	}
+	*r = v
+	return nil
+}
+
+// Value передаёт курс в БД десятичной строкой
+func (r Rate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
@@ -0,0 +1,69 @@
+// Пакет outbox — публикация событий предметной области, записанных в таблицу outbox_events.
+//
+// Операция записывает событие в той же транзакции Postgres, что и изменения балансов, поэтому событие
+// появляется тогда и только тогда, когда операция зафиксирована. Ретранслятор (Service.RunOutboxRelay)
+// публикует записанные события через Publisher с гарантией «хотя бы один раз»: после сбоя событие может
+// быть опубликовано повторно, и получатели должны отбрасывать дубликаты по Event.ID. События одного
+// пользователя (Event.UserID) публикуются в порядке их ID.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Event — событие предметной области
+type Event struct {
+	ID        int64           `json:"id"`
+	UserID    int64           `json:"user_id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Publisher доставляет события получателям. Событие считается опубликованным, только если Publish
+// вернул nil; иначе ретранслятор повторит его позже вместе со всеми следующими событиями пользователя.
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// WriterPublisher пишет события в w построчно в формате JSON (JSON Lines) — например, в stdout или файл
+type WriterPublisher struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewWriterPublisher создаёт публикатор, пишущий события в w
+func NewWriterPublisher(w io.Writer) *WriterPublisher {
+	return &WriterPublisher{enc: json.NewEncoder(w)}
+}
+
+func (p *WriterPublisher) Publish(_ context.Context, e Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.enc.Encode(e)
+}
+
+// MemoryPublisher хранит опубликованные события в памяти; используется в тестах
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (p *MemoryPublisher) Publish(_ context.Context, e Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, e)
+	return nil
+}
+
+// Events возвращает копию опубликованных событий в порядке публикации
+func (p *MemoryPublisher) Events() []Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.events)
+}
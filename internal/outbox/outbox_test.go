@@ -0,0 +1,52 @@
+package outbox
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriterPublisher(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewWriterPublisher(&buf)
+
+	created := time.Date(2025, 4, 20, 12, 0, 0, 0, time.UTC)
+	events := []Event{
+		{ID: 1, UserID: 10, Type: "deposit.completed", Payload: json.RawMessage(`{"id":5}`), CreatedAt: created},
+		{ID: 2, UserID: 11, Type: "transfer.completed", Payload: json.RawMessage(`{"id":6}`), CreatedAt: created},
+	}
+	for _, e := range events {
+		require.NoError(t, p.Publish(context.Background(), e))
+	}
+
+	// Каждое событие — отдельная строка JSON
+	scanner := bufio.NewScanner(&buf)
+	var got []Event
+	for scanner.Scan() {
+		var e Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		got = append(got, e)
+	}
+	require.Len(t, got, 2)
+	assert.Equal(t, int64(2), got[1].ID)
+	assert.Equal(t, "transfer.completed", got[1].Type)
+	assert.JSONEq(t, `{"id":6}`, string(got[1].Payload))
+	assert.True(t, created.Equal(got[0].CreatedAt))
+}
+
+func TestMemoryPublisher(t *testing.T) {
+	var p MemoryPublisher
+	require.NoError(t, p.Publish(context.Background(), Event{ID: 1}))
+	require.NoError(t, p.Publish(context.Background(), Event{ID: 2}))
+
+	events := p.Events()
+	require.Len(t, events, 2)
+	events[0].ID = 100
+	assert.Equal(t, int64(1), p.Events()[0].ID, "Events возвращает копию")
+}
@@ -0,0 +1,170 @@
+// Пакет pdf — минимальный генератор текстовых PDF-документов (PDF 1.4) без внешних зависимостей.
+//
+// Документ состоит из строк моноширинного шрифта Courier, разбитых на страницы A4. Страницы пишутся
+// в выходной поток по мере заполнения, поэтому длинный документ не держится в памяти целиком.
+// Стандартные шрифты PDF поддерживают только латиницу: символы вне ASCII заменяются на "?".
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Геометрия страницы A4 в пунктах
+const (
+	pageWidth  = 595
+	pageHeight = 842
+	margin     = 40
+	fontSize   = 9
+	lineHeight = 12
+)
+
+// LinesPerPage — число строк на странице
+const LinesPerPage = (pageHeight - 2*margin) / lineHeight
+
+// LineWidth — число символов Courier, помещающихся в строку
+const LineWidth = (pageWidth - 2*margin) * 10 / (fontSize * 6)
+
+// Номера объектов, которые известны заранее: каталог, дерево страниц и шрифт.
+// Дерево страниц пишется последним, когда известны все страницы.
+const (
+	catalogObj = 1
+	pagesObj   = 2
+	fontObj    = 3
+)
+
+// Writer пишет PDF-документ в w построчно. После последней строки нужно вызвать Close.
+type Writer struct {
+	w       *countingWriter
+	offsets map[int]int64
+	nextObj int
+	pages   []int
+	page    []string
+	err     error
+}
+
+// NewWriter начинает документ
+func NewWriter(w io.Writer) *Writer {
+	pw := &Writer{
+		w:       &countingWriter{w: bufio.NewWriter(w)},
+		offsets: map[int]int64{},
+		nextObj: fontObj + 1,
+	}
+	pw.printf("%%PDF-1.4\n")
+	pw.object(catalogObj, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObj))
+	pw.object(fontObj, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	return pw
+}
+
+// Line добавляет строку; строка длиннее LineWidth обрезается
+func (p *Writer) Line(s string) error {
+	if len(p.page) == LinesPerPage {
+		p.flushPage()
+	}
+	p.page = append(p.page, s)
+	return p.err
+}
+
+// PageBreak начинает новую страницу
+func (p *Writer) PageBreak() error {
+	p.flushPage()
+	return p.err
+}
+
+// Close дописывает дерево страниц, таблицу перекрёстных ссылок и завершает документ
+func (p *Writer) Close() error {
+	if len(p.page) > 0 || len(p.pages) == 0 {
+		p.flushPage()
+	}
+
+	kids := make([]string, len(p.pages))
+	for i, id := range p.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", id)
+	}
+	p.object(pagesObj, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+
+	xref := p.w.n
+	p.printf("xref\n0 %d\n0000000000 65535 f \n", p.nextObj)
+	for id := 1; id < p.nextObj; id++ {
+		p.printf("%010d 00000 n \n", p.offsets[id])
+	}
+	p.printf("trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", p.nextObj, catalogObj, xref)
+
+	if p.err != nil {
+		return p.err
+	}
+	return p.w.w.Flush()
+}
+
+func (p *Writer) flushPage() {
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "BT /F1 %d Tf %d TL %d %d Td\n", fontSize, lineHeight, margin, pageHeight-margin-fontSize)
+	for _, line := range p.page {
+		fmt.Fprintf(&content, "(%s) Tj T*\n", escape(line))
+	}
+	content.WriteString("ET")
+	p.page = p.page[:0]
+
+	contentObj := p.newObject()
+	p.object(contentObj, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.Bytes()))
+	pageObj := p.newObject()
+	p.object(pageObj, fmt.Sprintf(
+		"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+		pagesObj, pageWidth, pageHeight, fontObj, contentObj))
+	p.pages = append(p.pages, pageObj)
+}
+
+func (p *Writer) newObject() int {
+	id := p.nextObj
+	p.nextObj++
+	return id
+}
+
+func (p *Writer) object(id int, body string) {
+	p.offsets[id] = p.w.n
+	p.printf("%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+func (p *Writer) printf(format string, args ...any) {
+	if p.err != nil {
+		return
+	}
+	_, p.err = fmt.Fprintf(p.w, format, args...)
+}
+
+// Экранирует строку для строкового литерала PDF и обрезает её до LineWidth символов
+func escape(s string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range s {
+		if n == LineWidth {
+			break
+		}
+		n++
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Считает записанные байты: PDF ссылается на объекты по смещению от начала файла
+type countingWriter struct {
+	w *bufio.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
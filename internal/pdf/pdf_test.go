@@ -0,0 +1,56 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for i := range LinesPerPage + 1 {
+		require.NoError(t, w.Line(fmt.Sprintf("line %d", i)))
+	}
+	require.NoError(t, w.Line(`escaped (\) and non-ASCII: Привет`))
+	require.NoError(t, w.Close())
+
+	doc := buf.String()
+	assert.True(t, strings.HasPrefix(doc, "%PDF-1.4\n"))
+	assert.True(t, strings.HasSuffix(doc, "%%EOF\n"))
+	assert.Contains(t, doc, "/Count 2")
+	assert.Contains(t, doc, `(escaped \(\\\) and non-ASCII: ??????) Tj`)
+
+	// startxref указывает на таблицу, а таблица — на начало каждого объекта
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(doc)
+	require.NotNil(t, m)
+	xref, err := strconv.Atoi(m[1])
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(doc[xref:], "xref\n"))
+
+	lines := strings.Split(doc[xref:], "\n")
+	var size int
+	_, err = fmt.Sscanf(lines[1], "0 %d", &size)
+	require.NoError(t, err)
+	for id := 1; id < size; id++ {
+		offset, err := strconv.Atoi(lines[2+id][:10])
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(doc[offset:], fmt.Sprintf("%d 0 obj\n", id)), "объект %d", id)
+	}
+}
+
+func TestWriter_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewWriter(&buf).Close())
+	assert.Contains(t, buf.String(), "/Count 1", "пустой документ состоит из одной пустой страницы")
+}
+
+func TestEscape_Truncates(t *testing.T) {
+	assert.Len(t, escape(strings.Repeat("x", LineWidth+10)), LineWidth)
+}
@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/jackc/pgx/v5"
+)
+
+// Account — счёт пользователя в одной валюте
+type Account struct {
+	ID        int64          `json:"id"`
+	UserID    int64          `json:"user_id"`
+	Currency  money.Currency `json:"currency" swaggertype:"string" example:"RUB"`
+	Balance   money.Amount   `json:"balance" swaggertype:"number" example:"1500.50"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// Общие колонки для выборки счетов, порядок совпадает с scanAccount
+const accountColumns = `id, user_id, currency, balance, created_at`
+
+func scanAccount(row pgx.Row) (*Account, error) {
+	var a Account
+	if err := row.Scan(&a.ID, &a.UserID, &a.Currency, &a.Balance, &a.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// Возвращает счёт пользователя в валюте или nil, если такого счёта нет
+func getAccount(ctx context.Context, q querier, userID int64, currency money.Currency) (*Account, error) {
+	query := `SELECT ` + accountColumns + ` FROM accounts WHERE user_id = $1 AND currency = $2`
+	a, err := scanAccount(q.QueryRow(ctx, query, userID, currency))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	return a, nil
+}
+
+// Открывает счёт пользователя в валюте вместе со счётом в главной книге.
+// Если счёт уже открыт, возвращает его и created = false.
+func openAccount(ctx context.Context, q querier, userID int64, currency money.Currency) (a *Account, created bool, err error) {
+	query := `
+		WITH a AS (
+			INSERT INTO accounts (user_id, currency) VALUES ($1, $2)
+			ON CONFLICT (user_id, currency) DO NOTHING
+			RETURNING ` + accountColumns + `
+		), ledger AS (
+			INSERT INTO ledger_accounts (user_id, currency) SELECT user_id, currency FROM a
+		)
+		SELECT ` + accountColumns + ` FROM a`
+	a, err = scanAccount(q.QueryRow(ctx, query, userID, currency))
+	if errors.Is(err, pgx.ErrNoRows) {
+		a, err = getAccount(ctx, q, userID, currency)
+		return a, false, err
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to open account: %w", err)
+	}
+	return a, true, nil
+}
+
+// Возвращает счета пользователей, сгруппированные по id пользователя
+func listAccounts(ctx context.Context, q querier, userIDs ...int64) (map[int64][]Account, error) {
+	query := `SELECT ` + accountColumns + ` FROM accounts WHERE user_id = ANY($1) ORDER BY user_id, currency`
+	rows, err := q.Query(ctx, query, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query accounts: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := make(map[int64][]Account, len(userIDs))
+	for rows.Next() {
+		a, err := scanAccount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		accounts[a.UserID] = append(accounts[a.UserID], *a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+// Заполняет счета пользователей
+func loadAccounts(ctx context.Context, q querier, users ...*User) error {
+	ids := make([]int64, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	accounts, err := listAccounts(ctx, q, ids...)
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		u.Accounts = accounts[u.ID]
+		if u.Accounts == nil {
+			u.Accounts = []Account{}
+		}
+	}
+	return nil
+}
+
+// Открывает пользователю счёт в новой валюте. Замороженным и закрытым пользователям счета не открываются.
+func (r *RepositoryImpl) OpenAccount(ctx context.Context, userID int64, currency money.Currency) (a *Account, err error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	if _, err = lockActiveUser(ctx, tx, userID, ErrUserNotFound); err != nil {
+		return nil, err
+	}
+
+	a, created, err := openAccount(ctx, tx, userID, currency)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, ErrCurrencyAccountExists
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit account: %w", err)
+	}
+
+	return a, nil
+}
+
+// Возвращает счета пользователя во всех валютах
+func (r *RepositoryImpl) ListAccounts(ctx context.Context, userID int64) ([]Account, error) {
+	u, err := r.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return u.Accounts, nil
+}
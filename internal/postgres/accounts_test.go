@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccounts_MultiCurrency(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	usd := money.Currency("USD")
+
+	a := createFundedUser(t, r, "100.00")
+	b := createFundedUser(t, r, "0")
+
+	// Пополнение в новой валюте открывает счёт, рублёвый баланс не меняется
+	tx, err := r.Deposit(ctx, a, money.MustParse("50.00"), usd, nil)
+	require.NoError(t, err)
+	assert.Equal(t, usd, tx.Currency)
+
+	accounts, err := r.ListAccounts(ctx, a)
+	require.NoError(t, err)
+	require.Len(t, accounts, 2)
+	assert.Equal(t, rub, accounts[0].Currency)
+	assert.Equal(t, money.MustParse("100.00"), accounts[0].Balance)
+	assert.Equal(t, usd, accounts[1].Currency)
+	assert.Equal(t, money.MustParse("50.00"), accounts[1].Balance)
+
+	// У получателя нет счёта в долларах: валюты не смешиваются
+	_, err = r.Transfer(ctx, a, b, money.MustParse("10.00"), usd, nil)
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = r.OpenAccount(ctx, b, usd)
+	require.NoError(t, err)
+	_, err = r.OpenAccount(ctx, b, usd)
+	assert.ErrorIs(t, err, ErrCurrencyAccountExists)
+
+	_, err = r.Transfer(ctx, a, b, money.MustParse("10.00"), usd, nil)
+	require.NoError(t, err)
+
+	// Рублей у получателя нет, долларов у отправителя не хватает
+	_, err = r.Withdraw(ctx, b, money.MustParse("1.00"), rub, nil)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+	_, err = r.Withdraw(ctx, a, money.MustParse("40.01"), usd, nil)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+	balance, err := r.GetBalance(ctx, b, usd, nil)
+	require.NoError(t, err)
+	assert.Equal(t, money.MustParse("10.00"), balance.Balance)
+	assert.Equal(t, money.MustParse("100.00"), balanceOf(t, r, a))
+
+	page, err := r.GetTransactions(ctx, TransactionFilter{UserID: a, Limit: 10, Currency: usd})
+	require.NoError(t, err)
+	assert.Len(t, page.Transactions, 2)
+
+	// Проводки сбалансированы в каждой валюте, сверка расхождений не находит
+	report, err := r.VerifyLedger(ctx)
+	require.NoError(t, err)
+	assert.True(t, report.OK, "%+v", report)
+	reconciliation, err := r.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, reconciliation.Mismatches)
+
+	// Закрыть пользователя можно только с нулевыми балансами во всех валютах
+	_, err = r.SetUserStatus(ctx, b, UserStatusClosed)
+	assert.ErrorIs(t, err, ErrAccountNotEmpty)
+}
@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/jackc/pgx/v5"
+)
+
+// Вносит ручную корректировку баланса пользователя и создаёт транзакцию типа "adjustment" с причиной reason.
+// Положительная сумма зачисляется на счёт (receiver_id), отрицательная — списывается с него (sender_id);
+// вторая сторона проводки — системный счёт adjustments. Корректировка возможна и на замороженном счёте,
+// но не на закрытом; счёт в валюте должен существовать. Списать можно только доступный остаток.
+// При повторе с тем же ключом идемпотентности возвращает ранее созданную транзакцию.
+func (r *RepositoryImpl) CreateAdjustment(ctx context.Context, userID int64, amount money.Amount, currency money.Currency, reason string, key *IdempotencyKey) (t *Transaction, err error) {
+	err = withRetry(ctx, func() error {
+		t, err = r.createAdjustment(ctx, userID, amount, currency, reason, key)
+		return err
+	})
+	return t, err
+}
+
+func (r *RepositoryImpl) createAdjustment(ctx context.Context, userID int64, amount money.Amount, currency money.Currency, reason string, key *IdempotencyKey) (t *Transaction, err error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	// Повтор запроса с тем же ключом: возвращаем сохранённый результат
+	replay, err := claimIdempotencyKey(ctx, tx, key, "adjustment")
+	if err != nil {
+		return nil, err
+	}
+	if replay != nil {
+		tx.Rollback(ctx)
+		return replay, nil
+	}
+
+	if t, err = executeAdjustment(ctx, tx, userID, amount, currency, reason); err != nil {
+		return nil, err
+	}
+
+	if err = completeIdempotencyKey(ctx, tx, key, "adjustment", t.ID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit adjustment transaction: %w", err)
+	}
+
+	return t, nil
+}
+
+func executeAdjustment(ctx context.Context, tx pgx.Tx, userID int64, amount money.Amount, currency money.Currency, reason string) (*Transaction, error) {
+	users, err := lockUsers(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	u := users[userID]
+	if u == nil {
+		return nil, ErrUserNotFound
+	}
+	// Заморозка не мешает исправлять баланс, закрытый счёт не меняется
+	if u.Status == UserStatusClosed {
+		return nil, ErrAccountClosed
+	}
+	account, err := getAccount(ctx, tx, userID, currency)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, ErrCurrencyAccountNotFound
+	}
+
+	user, system := userAccount(userID, currency), systemAccount(AccountAdjustments, currency)
+	from, to, abs := system, user, amount
+	var senderID, receiverID *int64
+	if amount < 0 {
+		abs = -amount
+		if err = checkAvailable(ctx, tx, userID, currency, abs); err != nil {
+			return nil, err
+		}
+		from, to = user, system
+		senderID = &userID
+	} else {
+		receiverID = &userID
+	}
+
+	insertQuery := `
+		INSERT INTO transactions (user_id, sender_id, receiver_id, amount, currency, transaction_type, reason)
+		VALUES ($1, $2, $3, $4, $5, 'adjustment', $6)
+		RETURNING ` + transactionColumns
+	t, err := scanTransaction(tx.QueryRow(ctx, insertQuery, userID, senderID, receiverID, abs, currency, reason))
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert adjustment transaction: %w", err)
+	}
+
+	if err = postEntry(ctx, tx, &t.ID, "adjustment", move(from, to, abs)...); err != nil {
+		return nil, err
+	}
+	if err = recordTransactionEvent(ctx, tx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
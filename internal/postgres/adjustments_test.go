@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAdjustment(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	userID := createFundedUser(t, r, "100.00")
+
+	credit, err := r.CreateAdjustment(ctx, userID, money.MustParse("50.00"), rub, "compensation", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "adjustment", credit.TransactionType)
+	assert.Equal(t, userID, *credit.ReceiverID)
+	assert.Nil(t, credit.SenderID)
+	assert.Equal(t, money.MustParse("50.00"), credit.Amount)
+	assert.Equal(t, "compensation", *credit.Reason)
+	assert.Equal(t, money.MustParse("150.00"), balanceOf(t, r, userID))
+
+	// Отрицательная сумма списывается, но не больше доступного остатка
+	_, err = r.CreateAdjustment(ctx, userID, money.MustParse("-150.01"), rub, "duplicate deposit", nil)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+	debit, err := r.CreateAdjustment(ctx, userID, money.MustParse("-30.00"), rub, "duplicate deposit", nil)
+	require.NoError(t, err)
+	assert.Equal(t, userID, *debit.SenderID)
+	assert.Nil(t, debit.ReceiverID)
+	assert.Equal(t, money.MustParse("30.00"), debit.Amount)
+	assert.Equal(t, money.MustParse("120.00"), balanceOf(t, r, userID))
+
+	_, err = r.CreateAdjustment(ctx, userID, money.MustParse("1.00"), "USD", "no account", nil)
+	assert.ErrorIs(t, err, ErrCurrencyAccountNotFound)
+	_, err = r.CreateAdjustment(ctx, userID+100, money.MustParse("1.00"), rub, "no user", nil)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	// Замороженный счёт корректируется, закрытый — нет
+	_, err = r.SetUserStatus(ctx, userID, UserStatusFrozen)
+	require.NoError(t, err)
+	_, err = r.CreateAdjustment(ctx, userID, money.MustParse("-120.00"), rub, "write-off", nil)
+	require.NoError(t, err)
+	_, err = r.SetUserStatus(ctx, userID, UserStatusClosed)
+	require.NoError(t, err)
+	_, err = r.CreateAdjustment(ctx, userID, money.MustParse("1.00"), rub, "late", nil)
+	assert.ErrorIs(t, err, ErrAccountClosed)
+
+	page, err := r.GetTransactions(ctx, TransactionFilter{UserID: userID, Type: "adjustment", Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, page.Transactions, 3)
+
+	report, err := r.VerifyLedger(ctx)
+	require.NoError(t, err)
+	assert.True(t, report.OK, "%+v", report)
+	reconciliation, err := r.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, reconciliation.Mismatches)
+}
+
+func TestGetTransactions_AllUsers(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	first := createFundedUser(t, r, "10.00")
+	second := createFundedUser(t, r, "20.00")
+
+	page, err := r.GetTransactions(ctx, TransactionFilter{Type: "deposit", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Transactions, 2)
+	assert.Equal(t, second, *page.Transactions[0].UserID)
+	assert.Equal(t, first, *page.Transactions[1].UserID)
+}
+
+func TestUserRoles(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	userID := createFundedUser(t, r, "0")
+
+	roles, err := r.GetUserRoles(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, roles)
+
+	require.NoError(t, r.GrantRole(ctx, userID, RoleSupport))
+	require.NoError(t, r.GrantRole(ctx, userID, RoleAdmin))
+	// Повторная выдача ничего не меняет
+	require.NoError(t, r.GrantRole(ctx, userID, RoleAdmin))
+	roles, err = r.GetUserRoles(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{RoleAdmin, RoleSupport}, roles)
+
+	require.NoError(t, r.RevokeRole(ctx, userID, RoleAdmin))
+	assert.ErrorIs(t, r.RevokeRole(ctx, userID, RoleAdmin), ErrRoleNotGranted)
+	assert.ErrorIs(t, r.GrantRole(ctx, userID+100, RoleAdmin), ErrUserNotFound)
+}
@@ -0,0 +1,175 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// APIKey — API-ключ сервиса. Сам ключ (Key) известен только при создании и ротации: в базе хранится
+// его хеш, а в списке ключей — видимая часть Prefix.
+type APIKey struct {
+	ID          int64      `json:"id"`
+	Name        string     `json:"name" example:"billing"`
+	Prefix      string     `json:"prefix" example:"fsk_3f1c9a2b"`
+	Key         string     `json:"key,omitempty" example:"fsk_3f1c9a2b..."`
+	Scopes      []string   `json:"scopes" example:"deposit:write,transactions:read"`
+	RotatedFrom *int64     `json:"rotated_from,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+}
+
+// HasScope сообщает, выдана ли ключу область доступа scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+const apiKeyColumns = `id, name, prefix, scopes, rotated_from, created_at, expires_at, revoked_at, last_used_at`
+
+// Условие действующего ключа
+const apiKeyActive = `revoked_at IS NULL AND (expires_at IS NULL OR expires_at > LOCALTIMESTAMP)`
+
+// last_used_at обновляется не чаще раза в минуту, чтобы не писать в базу на каждый запрос
+const apiKeyLastUsedPrecision = time.Minute
+
+func scanAPIKey(row pgx.Row) (*APIKey, error) {
+	var k APIKey
+	err := row.Scan(&k.ID, &k.Name, &k.Prefix, &k.Scopes, &k.RotatedFrom, &k.CreatedAt, &k.ExpiresAt, &k.RevokedAt, &k.LastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// Сохраняет ключ k с хешем hash
+func (r *RepositoryImpl) CreateAPIKey(ctx context.Context, k *APIKey, hash []byte) (*APIKey, error) {
+	query := `
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + apiKeyColumns
+	created, err := scanAPIKey(r.pool.QueryRow(ctx, query, k.Name, k.Prefix, hash, k.Scopes, k.ExpiresAt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+	return created, nil
+}
+
+// Возвращает все ключи, включая отозванные и истёкшие
+func (r *RepositoryImpl) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, *k)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Находит действующий ключ по хешу и отмечает время его использования
+func (r *RepositoryImpl) GetActiveAPIKey(ctx context.Context, hash []byte) (*APIKey, error) {
+	k, err := scanAPIKey(r.pool.QueryRow(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = $1 AND `+apiKeyActive, hash))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	if k.LastUsedAt == nil || time.Since(*k.LastUsedAt) > apiKeyLastUsedPrecision {
+		_, err = r.pool.Exec(ctx, `
+			UPDATE api_keys SET last_used_at = LOCALTIMESTAMP
+			WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < LOCALTIMESTAMP - make_interval(secs => $2))
+		`, k.ID, apiKeyLastUsedPrecision.Seconds())
+		if err != nil {
+			return nil, fmt.Errorf("failed to update api key usage: %w", err)
+		}
+	}
+	return k, nil
+}
+
+// Заменяет действующий ключ id новым ключом с теми же названием и областями доступа. Старый ключ
+// продолжает действовать ещё grace (если не истекает раньше), чтобы клиенты успели перейти на новый;
+// нулевой grace отзывает его сразу.
+func (r *RepositoryImpl) RotateAPIKey(ctx context.Context, id int64, prefix string, hash []byte, grace time.Duration) (*APIKey, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Срок действия запоминается до того, как переходный период его сократит: новый ключ получает исходный срок
+	var active bool
+	var expiresAt *time.Time
+	err = tx.QueryRow(ctx, `SELECT `+apiKeyActive+`, expires_at FROM api_keys WHERE id = $1 FOR UPDATE`, id).Scan(&active, &expiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock api key: %w", err)
+	}
+	if !active {
+		return nil, ErrAPIKeyInactive
+	}
+
+	if grace > 0 {
+		_, err = tx.Exec(ctx, `
+			UPDATE api_keys SET expires_at = LEAST(expires_at, LOCALTIMESTAMP + make_interval(secs => $2))
+			WHERE id = $1
+		`, id, grace.Seconds())
+	} else {
+		_, err = tx.Exec(ctx, `UPDATE api_keys SET revoked_at = LOCALTIMESTAMP WHERE id = $1`, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retire api key: %w", err)
+	}
+
+	rotated, err := scanAPIKey(tx.QueryRow(ctx, `
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, expires_at, rotated_from)
+		SELECT name, $2, $3, scopes, $4, id FROM api_keys WHERE id = $1
+		RETURNING `+apiKeyColumns, id, prefix, hash, expiresAt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rotated api key: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return rotated, nil
+}
+
+// Отзывает ключ; повторный отзыв ничего не меняет
+func (r *RepositoryImpl) RevokeAPIKey(ctx context.Context, id int64) (*APIKey, error) {
+	k, err := scanAPIKey(r.pool.QueryRow(ctx, `
+		UPDATE api_keys SET revoked_at = COALESCE(revoked_at, LOCALTIMESTAMP)
+		WHERE id = $1
+		RETURNING `+apiKeyColumns, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	return k, nil
+}
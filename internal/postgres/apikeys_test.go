@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestAPIKey(t *testing.T, r *RepositoryImpl, secret string) *APIKey {
+	t.Helper()
+	k, err := r.CreateAPIKey(context.Background(), &APIKey{Name: "billing", Prefix: "fsk_test", Scopes: []string{"deposit:write"}}, []byte(secret))
+	require.NoError(t, err)
+	return k
+}
+
+func TestAPIKey_Lifecycle(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	k := createTestAPIKey(t, r, "secret-1")
+
+	got, err := r.GetActiveAPIKey(ctx, []byte("secret-1"))
+	require.NoError(t, err)
+	assert.Equal(t, k.ID, got.ID)
+	assert.Equal(t, []string{"deposit:write"}, got.Scopes)
+
+	_, err = r.GetActiveAPIKey(ctx, []byte("unknown"))
+	assert.ErrorIs(t, err, ErrAPIKeyNotFound)
+
+	revoked, err := r.RevokeAPIKey(ctx, k.ID)
+	require.NoError(t, err)
+	require.NotNil(t, revoked.RevokedAt)
+	_, err = r.GetActiveAPIKey(ctx, []byte("secret-1"))
+	assert.ErrorIs(t, err, ErrAPIKeyNotFound)
+
+	// Повторный отзыв не меняет время отзыва
+	again, err := r.RevokeAPIKey(ctx, k.ID)
+	require.NoError(t, err)
+	assert.Equal(t, revoked.RevokedAt, again.RevokedAt)
+
+	_, err = r.RevokeAPIKey(ctx, 999999)
+	assert.ErrorIs(t, err, ErrAPIKeyNotFound)
+
+	// Отозванный ключ нельзя ротировать
+	_, err = r.RotateAPIKey(ctx, k.ID, "fsk_new", []byte("secret-2"), time.Hour)
+	assert.ErrorIs(t, err, ErrAPIKeyInactive)
+}
+
+func TestRotateAPIKey(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	expiresAt := time.Now().UTC().Add(30 * 24 * time.Hour).Truncate(time.Microsecond)
+	old, err := r.CreateAPIKey(ctx, &APIKey{Name: "billing", Prefix: "fsk_test", Scopes: []string{"deposit:write"}, ExpiresAt: &expiresAt}, []byte("old-secret"))
+	require.NoError(t, err)
+
+	rotated, err := r.RotateAPIKey(ctx, old.ID, "fsk_new", []byte("new-secret"), time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, old.Name, rotated.Name)
+	assert.Equal(t, old.Scopes, rotated.Scopes)
+	// Новый ключ наследует срок действия старого, а не укороченный переходным периодом
+	require.NotNil(t, rotated.ExpiresAt)
+	assert.True(t, expiresAt.Equal(*rotated.ExpiresAt), "expires_at %v, want %v", rotated.ExpiresAt, expiresAt)
+	require.NotNil(t, rotated.RotatedFrom)
+	assert.Equal(t, old.ID, *rotated.RotatedFrom)
+
+	// В течение переходного периода действуют оба ключа
+	_, err = r.GetActiveAPIKey(ctx, []byte("new-secret"))
+	assert.NoError(t, err)
+	stillActive, err := r.GetActiveAPIKey(ctx, []byte("old-secret"))
+	require.NoError(t, err)
+	require.NotNil(t, stillActive.ExpiresAt)
+	assert.True(t, stillActive.ExpiresAt.Before(expiresAt))
+
+	// Без переходного периода старый ключ отзывается сразу
+	next, err := r.RotateAPIKey(ctx, rotated.ID, "fsk_next", []byte("next-secret"), 0)
+	require.NoError(t, err)
+	_, err = r.GetActiveAPIKey(ctx, []byte("new-secret"))
+	assert.ErrorIs(t, err, ErrAPIKeyNotFound)
+	_, err = r.GetActiveAPIKey(ctx, []byte("next-secret"))
+	assert.NoError(t, err)
+	assert.Equal(t, rotated.ID, *next.RotatedFrom)
+}
@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Кто выполнил операцию: пользователь по JWT или сервис по API-ключу
+const (
+	ActorUser   = "user"
+	ActorAPIKey = "api_key"
+)
+
+// Результат операции в журнале аудита
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// AuditEntry — запись журнала аудита. ActorID — ID пользователя или API-ключа в зависимости от ActorType.
+// Action — метод и шаблон роута, Path — фактический путь запроса.
+type AuditEntry struct {
+	ID          int64     `json:"id"`
+	ActorType   string    `json:"actor_type" example:"user"`
+	ActorID     int64     `json:"actor_id" example:"1"`
+	Action      string    `json:"action" example:"POST /admin/users/:id/adjustments"`
+	Path        string    `json:"path" example:"/admin/users/42/adjustments"`
+	PayloadHash string    `json:"payload_hash" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	ClientIP    string    `json:"client_ip" example:"203.0.113.7"`
+	RequestID   string    `json:"request_id" example:"4b1e0c2f9a7d3e85"`
+	StatusCode  int       `json:"status_code" example:"200"`
+	Result      string    `json:"result" example:"success"`
+	ErrorCode   *string   `json:"error_code,omitempty" example:"insufficient_funds"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AuditFilter — параметры выборки журнала аудита; пустые поля не ограничивают выборку.
+// Записи упорядочены от новых к старым, BeforeID возвращает записи старше указанной.
+type AuditFilter struct {
+	ActorType string
+	ActorID   int64
+	Action    string
+	RequestID string
+	Result    string
+	From      *time.Time // включительно
+	To        *time.Time // не включительно
+	BeforeID  int64
+	Limit     int
+}
+
+// AuditPage — страница журнала аудита. NextBeforeID передаётся в before_id для следующей страницы;
+// пуст, если страница последняя.
+type AuditPage struct {
+	Entries      []AuditEntry `json:"entries"`
+	NextBeforeID *int64       `json:"next_before_id,omitempty"`
+}
+
+// Колонки для выборки журнала, порядок совпадает со сканированием в ListAuditLog
+const auditColumns = `id, actor_type, actor_id, action, path, payload_hash, client_ip, request_id,
+	status_code, result, error_code, created_at`
+
+// Добавляет запись в журнал аудита. Изменить или удалить её потом нельзя: это запрещено триггером в базе.
+func (r *RepositoryImpl) RecordAudit(ctx context.Context, e *AuditEntry) error {
+	query := `
+		INSERT INTO audit_log (actor_type, actor_id, action, path, payload_hash, client_ip, request_id,
+			status_code, result, error_code)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at`
+	err := r.pool.QueryRow(ctx, query, e.ActorType, e.ActorID, e.Action, e.Path, e.PayloadHash, e.ClientIP, e.RequestID,
+		e.StatusCode, e.Result, e.ErrorCode).Scan(&e.ID, &e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	return nil
+}
+
+// Возвращает страницу журнала аудита с фильтрами от новых записей к старым
+func (r *RepositoryImpl) ListAuditLog(ctx context.Context, filter AuditFilter) (*AuditPage, error) {
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	conditions := []string{"TRUE"}
+	if filter.ActorType != "" {
+		conditions = append(conditions, "actor_type = "+arg(filter.ActorType))
+	}
+	if filter.ActorID != 0 {
+		conditions = append(conditions, "actor_id = "+arg(filter.ActorID))
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = "+arg(filter.Action))
+	}
+	if filter.RequestID != "" {
+		conditions = append(conditions, "request_id = "+arg(filter.RequestID))
+	}
+	if filter.Result != "" {
+		conditions = append(conditions, "result = "+arg(filter.Result))
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "created_at >= "+arg(filter.From.UTC()))
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "created_at < "+arg(filter.To.UTC()))
+	}
+	if filter.BeforeID != 0 {
+		conditions = append(conditions, "id < "+arg(filter.BeforeID))
+	}
+
+	// Запрашиваем на одну строку больше, чтобы понять, есть ли следующая страница
+	query := `
+		SELECT ` + auditColumns + `
+		FROM audit_log
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY id DESC
+		LIMIT ` + arg(filter.Limit+1)
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	page := &AuditPage{Entries: []AuditEntry{}}
+	for rows.Next() {
+		var e AuditEntry
+		err := rows.Scan(&e.ID, &e.ActorType, &e.ActorID, &e.Action, &e.Path, &e.PayloadHash, &e.ClientIP, &e.RequestID,
+			&e.StatusCode, &e.Result, &e.ErrorCode, &e.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		page.Entries = append(page.Entries, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(page.Entries) > filter.Limit {
+		page.Entries = page.Entries[:filter.Limit]
+		last := page.Entries[len(page.Entries)-1].ID
+		page.NextBeforeID = &last
+	}
+	return page, nil
+}
@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLog(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	code := "insufficient_funds"
+	entries := []*AuditEntry{
+		{ActorType: ActorUser, ActorID: 1, Action: "POST /deposit", Path: "/deposit", StatusCode: 200, Result: AuditSuccess, RequestID: "req-1"},
+		{ActorType: ActorAPIKey, ActorID: 5, Action: "POST /transfer", Path: "/transfer", StatusCode: 200, Result: AuditSuccess, RequestID: "req-2"},
+		{ActorType: ActorUser, ActorID: 1, Action: "POST /transfer", Path: "/transfer", StatusCode: 422, Result: AuditFailure, ErrorCode: &code, RequestID: "req-3"},
+	}
+	for _, e := range entries {
+		e.PayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+		e.ClientIP = "203.0.113.7"
+		require.NoError(t, r.RecordAudit(ctx, e))
+		assert.NotZero(t, e.ID)
+		assert.False(t, e.CreatedAt.IsZero())
+	}
+
+	// Записи возвращаются от новых к старым
+	page, err := r.ListAuditLog(ctx, AuditFilter{ActorType: ActorUser, ActorID: 1, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 1)
+	assert.Equal(t, "req-3", page.Entries[0].RequestID)
+	assert.Equal(t, code, *page.Entries[0].ErrorCode)
+	require.NotNil(t, page.NextBeforeID)
+
+	page, err = r.ListAuditLog(ctx, AuditFilter{ActorType: ActorUser, ActorID: 1, BeforeID: *page.NextBeforeID, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 1)
+	assert.Equal(t, "req-1", page.Entries[0].RequestID)
+	assert.Nil(t, page.NextBeforeID)
+
+	page, err = r.ListAuditLog(ctx, AuditFilter{Result: AuditFailure, Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, page.Entries, 1)
+	page, err = r.ListAuditLog(ctx, AuditFilter{Action: "POST /transfer", Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, page.Entries, 2)
+	page, err = r.ListAuditLog(ctx, AuditFilter{RequestID: "req-2", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 1)
+	assert.Equal(t, ActorAPIKey, page.Entries[0].ActorType)
+}
+
+func TestAuditLog_AppendOnly(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	e := &AuditEntry{ActorType: ActorUser, ActorID: 1, Action: "POST /deposit", Path: "/deposit",
+		PayloadHash: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", ClientIP: "203.0.113.7",
+		RequestID: "req-1", StatusCode: 200, Result: AuditSuccess}
+	require.NoError(t, r.RecordAudit(ctx, e))
+
+	// Изменить, удалить или очистить журнал нельзя даже напрямую в базе
+	_, err := r.pool.Exec(ctx, `UPDATE audit_log SET result = 'failure' WHERE id = $1`, e.ID)
+	assert.ErrorContains(t, err, "append-only")
+	_, err = r.pool.Exec(ctx, `DELETE FROM audit_log WHERE id = $1`, e.ID)
+	assert.ErrorContains(t, err, "append-only")
+	_, err = r.pool.Exec(ctx, `TRUNCATE audit_log`)
+	assert.ErrorContains(t, err, "append-only")
+
+	page, err := r.ListAuditLog(ctx, AuditFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 1)
+	assert.Equal(t, AuditSuccess, page.Entries[0].Result)
+}
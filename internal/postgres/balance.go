@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/jackc/pgx/v5"
+)
+
+// Balance — баланс пользователя в валюте на текущий момент или на момент AsOf.
+// Balance — учётный баланс по главной книге; Held и Available (заполняются только для текущего
+// момента) — сумма действующих холдов и доступный для списания остаток Balance - Held.
+type Balance struct {
+	UserID            int64          `json:"user_id"`
+	Currency          money.Currency `json:"currency" swaggertype:"string" example:"RUB"`
+	Balance           money.Amount   `json:"balance" swaggertype:"number" example:"1500.50"`
+	Held              *money.Amount  `json:"held,omitempty" swaggertype:"number" example:"500.00"`
+	Available         *money.Amount  `json:"available_balance,omitempty" swaggertype:"number" example:"1000.50"`
+	LastTransactionAt *time.Time     `json:"last_transaction_at,omitempty"`
+	AsOf              *time.Time     `json:"as_of,omitempty"`
+}
+
+// Влияние транзакций на балансы пользователей: по строке (id, user_id, currency, amount, created_at) на каждый
+// затронутый счёт пользователя, где id — транзакция, amount положителен для зачислений и отрицателен для списаний.
+// Комиссия списывается с плательщика транзакции (user_id) отдельной строкой.
+// Используется как подзапрос везде, где баланс восстанавливается по истории транзакций;
+// новый тип транзакции, меняющий баланс, нужно добавить сюда.
+const transactionEffectsSQL = `
+	SELECT id, user_id, currency, amount, created_at FROM transactions WHERE transaction_type = 'deposit'
+	UNION ALL
+	SELECT id, user_id, currency, -amount, created_at FROM transactions WHERE transaction_type = 'withdrawal'
+	UNION ALL
+	SELECT id, sender_id, currency, -amount, created_at FROM transactions
+	WHERE transaction_type IN ('transfer', 'reversal', 'adjustment') AND sender_id IS NOT NULL
+	UNION ALL
+	SELECT id, receiver_id, COALESCE(receiver_currency, currency), COALESCE(receiver_amount, amount), created_at
+	FROM transactions WHERE transaction_type IN ('transfer', 'reversal', 'adjustment') AND receiver_id IS NOT NULL
+	UNION ALL
+	SELECT id, user_id, currency, -fee, created_at FROM transactions WHERE fee IS NOT NULL`
+
+// Возвращает баланс пользователя в валюте; если счёта в этой валюте нет, баланс нулевой. Если asOf задан, баланс восстанавливается на этот момент:
+// из текущего баланса вычитается эффект всех транзакций, созданных позже asOf.
+// Это корректно и для начальных балансов, заданных без транзакций.
+func (r *RepositoryImpl) GetBalance(ctx context.Context, userID int64, currency money.Currency, asOf *time.Time) (b *Balance, err error) {
+	// Оба запроса должны видеть один и тот же снимок данных
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	b = &Balance{UserID: userID, Currency: currency}
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE(a.balance, 0)
+		FROM users u
+		LEFT JOIN accounts a ON a.user_id = u.id AND a.currency = $2
+		WHERE u.id = $1
+	`, userID, currency).Scan(&b.Balance)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance: %w", err)
+	}
+
+	// created_at хранится без часового пояса в UTC (сессии БД работают в UTC, см. sessionTimeZone). История холдов не хранится,
+	// поэтому доступный остаток считается только для текущего момента.
+	if asOf != nil {
+		at := asOf.UTC()
+		b.AsOf = &at
+	} else {
+		held, err := heldAmount(ctx, tx, userID, currency)
+		if err != nil {
+			return nil, err
+		}
+		available := b.Balance - held
+		b.Held, b.Available = &held, &available
+	}
+
+	query := `
+		SELECT
+			COALESCE(SUM(amount) FILTER (WHERE created_at > $3), 0),
+			MAX(created_at) FILTER (WHERE $3::timestamp IS NULL OR created_at <= $3)
+		FROM (` + transactionEffectsSQL + `) effects
+		WHERE user_id = $1 AND currency = $2
+	`
+	var laterEffect money.Amount
+	err = tx.QueryRow(ctx, query, userID, currency, b.AsOf).Scan(&laterEffect, &b.LastTransactionAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance history: %w", err)
+	}
+	b.Balance -= laterEffect
+
+	return b, nil
+}
@@ -9,6 +9,11 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// Часовой пояс сессий БД. Колонки TIMESTAMP хранят время в UTC без пояса и заполняются CURRENT_TIMESTAMP
+// и LOCALTIMESTAMP, которые берут пояс сессии, а код сравнивает их со временем Go в UTC. Пояс задаётся явно,
+// чтобы это не зависело от настройки timezone сервера БД.
+const sessionTimeZone = "UTC"
+
 // PGXProvider оборачивает pgxpool.Pool
 type PGXProvider struct {
 	Pool *pgxpool.Pool
@@ -29,9 +34,15 @@ func NewPGXProvider() (*PGXProvider, error) {
 		cfg.DBUser, cfg.DBPass, cfg.DBHost, cfg.DBPort, cfg.DBName,
 	)
 
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора параметров подключения: %w", err)
+	}
+	poolConfig.ConnConfig.RuntimeParams["timezone"] = sessionTimeZone
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	pool, err := pgxpool.New(ctx, dsn)
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка подключения к базе данных через pgx: %w", err)
 	}
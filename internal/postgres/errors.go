@@ -0,0 +1,62 @@
+package postgres
+
+import "errors"
+
+// Ошибки предметной области, которые возвращает репозиторий.
+// Проверяются через errors.Is; обработчики HTTP сопоставляют им статусы и коды ошибок.
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrSenderNotFound   = errors.New("sender not found")
+	ErrReceiverNotFound = errors.New("receiver not found")
+
+	ErrCurrencyAccountNotFound = errors.New("account in this currency not found")
+
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrCurrencyMismatch  = errors.New("receiver has no account in the transfer currency")
+
+	// Возвращается обёрнутой в LimitExceededError с остатком лимита
+	ErrLimitExceeded = errors.New("spending limit exceeded")
+
+	ErrQuoteNotFound           = errors.New("fx quote not found")
+	ErrQuoteExpired            = errors.New("fx quote has expired")
+	ErrQuoteUsed               = errors.New("fx quote has already been used")
+	ErrConvertedAmountTooSmall = errors.New("converted amount is too small")
+
+	ErrHoldNotFound       = errors.New("hold not found")
+	ErrHoldNotActive      = errors.New("hold has already been captured or released")
+	ErrHoldExpired        = errors.New("hold has expired")
+	ErrCaptureExceedsHold = errors.New("capture amount exceeds hold amount")
+
+	ErrScheduledTransferNotFound = errors.New("scheduled transfer not found")
+	ErrScheduleFinished          = errors.New("scheduled transfer is already completed or cancelled")
+
+	// Баланс, пересчитанный сверкой по истории, отрицателен: исправить его корректировкой нельзя
+	ErrNegativeExpectedBalance = errors.New("balance recomputed from history is negative")
+
+	ErrTransactionNotFound   = errors.New("transaction not found")
+	ErrNotReversible         = errors.New("only transfers can be reversed")
+	ErrAlreadyReversed       = errors.New("transaction has already been reversed")
+	ErrReversalExceedsAmount = errors.New("reversal amount exceeds transaction amount")
+	ErrPartialFXReversal     = errors.New("transfers with currency conversion can only be reversed in full")
+
+	ErrFeeRuleNotFound = errors.New("fee rule not found")
+	ErrFeeRuleExists   = errors.New("fee rule for this operation, currency and tier already exists")
+
+	ErrWebhookSubscriptionNotFound = errors.New("webhook subscription not found")
+	ErrWebhookDeliveryNotFound     = errors.New("webhook delivery not found")
+	ErrWebhookDeliveryPending      = errors.New("webhook delivery is still pending")
+
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrAPIKeyInactive = errors.New("api key is revoked or expired")
+
+	ErrRoleNotGranted = errors.New("user does not have this role")
+
+	ErrUsernameTaken   = errors.New("username already taken")
+	ErrAccountFrozen   = errors.New("account is frozen")
+	ErrAccountClosed   = errors.New("account is closed")
+	ErrAccountNotEmpty = errors.New("account balance must be zero to close it")
+
+	ErrCurrencyAccountExists = errors.New("account in this currency already exists")
+
+	ErrIdempotencyKeyReused = errors.New("idempotency key already used with a different request")
+)
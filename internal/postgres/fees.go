@@ -0,0 +1,141 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// AccountFeeRevenue — системный счёт доходов: на него зачисляются комиссии за операции
+const AccountFeeRevenue = "fee_revenue"
+
+// FeeRule — правило комиссии за операции типа TransactionType (transfer или withdrawal) в валюте Currency.
+// Правило с Tier действует только для пользователей этого тарифа и важнее правила без тарифа.
+// Комиссия равна Flat плюс Percent процентов от суммы операции, но не меньше MinFee и не больше MaxFee;
+// она списывается с плательщика сверх суммы операции.
+type FeeRule struct {
+	ID              int64          `json:"id"`
+	TransactionType string         `json:"transaction_type" example:"transfer"`
+	Currency        money.Currency `json:"currency" swaggertype:"string" example:"RUB"`
+	Tier            *string        `json:"tier,omitempty" example:"standard"`
+	Flat            money.Amount   `json:"flat" swaggertype:"number" example:"10.00"`
+	Percent         *money.Rate    `json:"percent,omitempty" swaggertype:"number" example:"1.5"`
+	MinFee          *money.Amount  `json:"min_fee,omitempty" swaggertype:"number" example:"30.00"`
+	MaxFee          *money.Amount  `json:"max_fee,omitempty" swaggertype:"number" example:"3000.00"`
+	CreatedAt       time.Time      `json:"created_at"`
+}
+
+// Fee возвращает комиссию за операцию на сумму amount
+func (r *FeeRule) Fee(amount money.Amount) (money.Amount, error) {
+	fee := r.Flat
+	if r.Percent != nil {
+		p, err := r.Percent.Percent(amount, r.Currency)
+		if err != nil {
+			return 0, err
+		}
+		fee += p
+	}
+	if r.MinFee != nil && fee < *r.MinFee {
+		fee = *r.MinFee
+	}
+	if r.MaxFee != nil && fee > *r.MaxFee {
+		fee = *r.MaxFee
+	}
+	return fee, nil
+}
+
+// Общие колонки для выборки правил комиссий, порядок совпадает с scanFeeRule
+const feeRuleColumns = `id, transaction_type, currency, tier, flat, percent, min_fee, max_fee, created_at`
+
+func scanFeeRule(row pgx.Row) (*FeeRule, error) {
+	var r FeeRule
+	err := row.Scan(&r.ID, &r.TransactionType, &r.Currency, &r.Tier, &r.Flat, &r.Percent, &r.MinFee, &r.MaxFee, &r.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// Возвращает комиссию пользователя u за операцию типа transactionType на сумму amount в валюте
+// по правилу его тарифа или общему правилу; ноль, если подходящего правила нет
+func calculateFee(ctx context.Context, q querier, u *User, transactionType string, currency money.Currency, amount money.Amount) (money.Amount, error) {
+	query := `
+		SELECT ` + feeRuleColumns + ` FROM fee_rules
+		WHERE transaction_type = $1 AND currency = $2 AND (tier = $3 OR tier IS NULL)
+		ORDER BY tier IS NULL
+		LIMIT 1`
+	rule, err := scanFeeRule(q.QueryRow(ctx, query, transactionType, currency, u.Tier))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get fee rule: %w", err)
+	}
+	return rule.Fee(amount)
+}
+
+// Записи проводки, переносящие комиссию со счёта плательщика на счёт доходов; без комиссии — пусто
+func feePostings(payerID int64, currency money.Currency, fee money.Amount) []posting {
+	if fee == 0 {
+		return nil
+	}
+	return move(userAccount(payerID, currency), systemAccount(AccountFeeRevenue, currency), fee)
+}
+
+// Возвращает все правила комиссий
+func (r *RepositoryImpl) ListFeeRules(ctx context.Context) ([]FeeRule, error) {
+	query := `SELECT ` + feeRuleColumns + ` FROM fee_rules ORDER BY transaction_type, currency, tier NULLS FIRST`
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fee rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []FeeRule{}
+	for rows.Next() {
+		rule, err := scanFeeRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan fee rule: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Создаёт правило комиссии. Для операции, валюты и тарифа (или всех тарифов) правило может быть только одно.
+func (r *RepositoryImpl) CreateFeeRule(ctx context.Context, rule *FeeRule) (*FeeRule, error) {
+	query := `
+		INSERT INTO fee_rules (transaction_type, currency, tier, flat, percent, min_fee, max_fee)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + feeRuleColumns
+	created, err := scanFeeRule(r.pool.QueryRow(ctx, query,
+		rule.TransactionType, rule.Currency, rule.Tier, rule.Flat, rule.Percent, rule.MinFee, rule.MaxFee))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrFeeRuleExists
+		}
+		return nil, fmt.Errorf("failed to create fee rule: %w", err)
+	}
+	return created, nil
+}
+
+// Удаляет правило комиссии; комиссии уже проведённых операций не меняются
+func (r *RepositoryImpl) DeleteFeeRule(ctx context.Context, id int64) error {
+	ct, err := r.pool.Exec(ctx, `DELETE FROM fee_rules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete fee rule: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrFeeRuleNotFound
+	}
+	return nil
+}
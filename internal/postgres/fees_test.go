@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ratePtr(s string) *money.Rate {
+	r := money.MustParseRate(s)
+	return &r
+}
+
+func TestFeeRule_Fee(t *testing.T) {
+	rule := FeeRule{
+		Currency: rub,
+		Flat:     money.MustParse("10.00"),
+		Percent:  ratePtr("1.5"),
+		MinFee:   amountPtr("30.00"),
+		MaxFee:   amountPtr("500.00"),
+	}
+
+	cases := []struct{ amount, fee string }{
+		{"100.00", "30.00"},     // 10 + 1.50 меньше минимума
+		{"2000.00", "40.00"},    // 10 + 30
+		{"1000.30", "30.00"},    // 10 + 15.00 (15.0045 округляется вниз) меньше минимума
+		{"100000.00", "500.00"}, // 10 + 1500 ограничено максимумом
+	}
+	for _, tc := range cases {
+		fee, err := rule.Fee(money.MustParse(tc.amount))
+		require.NoError(t, err)
+		assert.Equal(t, money.MustParse(tc.fee), fee, tc.amount)
+	}
+
+	free := FeeRule{Currency: rub}
+	fee, err := free.Fee(money.MustParse("100.00"))
+	require.NoError(t, err)
+	assert.Zero(t, fee)
+}
+
+func TestTransfer_Fee(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	sender := createFundedUser(t, r, "1000.00")
+	receiver := createFundedUser(t, r, "0")
+
+	_, err := r.CreateFeeRule(ctx, &FeeRule{TransactionType: "transfer", Currency: rub, Flat: money.MustParse("5.00"), Percent: ratePtr("1")})
+	require.NoError(t, err)
+	premium := "premium"
+	_, err = r.CreateFeeRule(ctx, &FeeRule{TransactionType: "transfer", Currency: rub, Tier: &premium})
+	require.NoError(t, err)
+	_, err = r.CreateFeeRule(ctx, &FeeRule{TransactionType: "transfer", Currency: rub})
+	assert.ErrorIs(t, err, ErrFeeRuleExists)
+
+	// Комиссия 5 + 1% списывается сверх суммы перевода и зачисляется на счёт доходов
+	transfer, err := r.Transfer(ctx, sender, receiver, money.MustParse("500.00"), rub, nil)
+	require.NoError(t, err)
+	require.NotNil(t, transfer.Fee)
+	assert.Equal(t, money.MustParse("10.00"), *transfer.Fee)
+	assert.Equal(t, money.MustParse("490.00"), balanceOf(t, r, sender))
+	assert.Equal(t, money.MustParse("500.00"), balanceOf(t, r, receiver))
+
+	var revenue money.Amount
+	err = r.pool.QueryRow(ctx, `
+		SELECT COALESCE(SUM(CASE p.direction WHEN 'credit' THEN p.amount ELSE -p.amount END), 0)
+		FROM postings p JOIN ledger_accounts a ON a.id = p.account_id
+		WHERE a.code = $1 AND a.currency = $2
+	`, AccountFeeRevenue, rub).Scan(&revenue)
+	require.NoError(t, err)
+	assert.Equal(t, money.MustParse("10.00"), revenue)
+
+	// Без средств на комиссию перевод отклоняется
+	_, err = r.Transfer(ctx, sender, receiver, money.MustParse("485.00"), rub, nil)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// Правило тарифа важнее общего
+	_, err = r.SetUserTier(ctx, sender, premium)
+	require.NoError(t, err)
+	transfer, err = r.Transfer(ctx, sender, receiver, money.MustParse("490.00"), rub, nil)
+	require.NoError(t, err)
+	assert.Nil(t, transfer.Fee)
+	assert.Equal(t, money.MustParse("0"), balanceOf(t, r, sender))
+
+	// Баланс, восстановленный по истории, учитывает комиссию
+	before := time.Now().UTC().Add(-time.Hour)
+	b, err := r.GetBalance(ctx, sender, rub, &before)
+	require.NoError(t, err)
+	assert.Equal(t, money.MustParse("0"), b.Balance)
+
+	report, err := r.VerifyLedger(ctx)
+	require.NoError(t, err)
+	assert.True(t, report.OK, "%+v", report)
+	reconciliation, err := r.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, reconciliation.Mismatches)
+}
+
+func TestWithdraw_Fee(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	userID := createFundedUser(t, r, "1000.00")
+	rule, err := r.CreateFeeRule(ctx, &FeeRule{TransactionType: "withdrawal", Currency: rub, Flat: money.MustParse("50.00")})
+	require.NoError(t, err)
+
+	withdrawal, err := r.Withdraw(ctx, userID, money.MustParse("100.00"), rub, nil)
+	require.NoError(t, err)
+	assert.Equal(t, money.MustParse("50.00"), *withdrawal.Fee)
+	assert.Equal(t, money.MustParse("850.00"), balanceOf(t, r, userID))
+
+	require.NoError(t, r.DeleteFeeRule(ctx, rule.ID))
+	assert.ErrorIs(t, r.DeleteFeeRule(ctx, rule.ID), ErrFeeRuleNotFound)
+
+	withdrawal, err = r.Withdraw(ctx, userID, money.MustParse("850.00"), rub, nil)
+	require.NoError(t, err)
+	assert.Nil(t, withdrawal.Fee)
+
+	reconciliation, err := r.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, reconciliation.Mismatches)
+}
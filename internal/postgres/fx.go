@@ -0,0 +1,198 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// AccountFX — системный счёт конвертации: принимает деньги в исходной валюте и выдаёт в валюте назначения
+const AccountFX = "fx"
+
+// FXQuote — котировка: курс обмена FromCurrency на ToCurrency, зафиксированный до ExpiresAt.
+// Котировка может быть использована только одним переводом.
+type FXQuote struct {
+	ID           string         `json:"id" example:"3f1c7b8e-2a4d-4a8e-9a57-0c3b1e0f6d2a"`
+	FromCurrency money.Currency `json:"from_currency" swaggertype:"string" example:"RUB"`
+	ToCurrency   money.Currency `json:"to_currency" swaggertype:"string" example:"USD"`
+	Rate         money.Rate     `json:"rate" swaggertype:"number" example:"0.0108"`
+	ExpiresAt    time.Time      `json:"expires_at"`
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+// Общие колонки для выборки котировок, порядок совпадает с scanFXQuote
+const fxQuoteColumns = `id, from_currency, to_currency, rate, expires_at, created_at`
+
+func scanFXQuote(row pgx.Row) (*FXQuote, error) {
+	var q FXQuote
+	if err := row.Scan(&q.ID, &q.FromCurrency, &q.ToCurrency, &q.Rate, &q.ExpiresAt, &q.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// Сохраняет котировку со сроком жизни ttl, отсчитываемым по часам БД
+func (r *RepositoryImpl) CreateFXQuote(ctx context.Context, from, to money.Currency, rate money.Rate, ttl time.Duration) (*FXQuote, error) {
+	query := `
+		INSERT INTO fx_quotes (from_currency, to_currency, rate, expires_at)
+		VALUES ($1, $2, $3, LOCALTIMESTAMP + make_interval(secs => $4))
+		RETURNING ` + fxQuoteColumns
+	q, err := scanFXQuote(r.pool.QueryRow(ctx, query, from, to, rate, ttl.Seconds()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fx quote: %w", err)
+	}
+	return q, nil
+}
+
+// Переводит деньги с конвертацией по котировке: отправитель платит amount в исходной валюте котировки,
+// получатель получает сумму в валюте назначения по зафиксированному курсу. Котировка должна быть
+// действующей и неиспользованной; у получателя должен быть счёт в валюте назначения.
+// При повторе с тем же ключом идемпотентности возвращает ранее созданную транзакцию.
+func (r *RepositoryImpl) TransferFX(ctx context.Context, senderID, receiverID int64, amount money.Amount, quoteID string, key *IdempotencyKey) (t *Transaction, err error) {
+	err = withRetry(ctx, func() error {
+		t, err = r.transferFX(ctx, senderID, receiverID, amount, quoteID, key)
+		return err
+	})
+	return t, err
+}
+
+func (r *RepositoryImpl) transferFX(ctx context.Context, senderID, receiverID int64, amount money.Amount, quoteID string, key *IdempotencyKey) (t *Transaction, err error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	// Повтор запроса с тем же ключом: возвращаем сохранённый результат
+	replay, err := claimIdempotencyKey(ctx, tx, key, "transfer")
+	if err != nil {
+		return nil, err
+	}
+	if replay != nil {
+		tx.Rollback(ctx)
+		return replay, nil
+	}
+
+	quote, err := lockFXQuote(ctx, tx, quoteID)
+	if err != nil {
+		return nil, err
+	}
+	if err = quote.FromCurrency.CheckAmount(amount); err != nil {
+		return nil, err
+	}
+	converted, err := quote.Rate.Convert(amount, quote.ToCurrency)
+	if err != nil {
+		return nil, err
+	}
+	if converted <= 0 {
+		return nil, ErrConvertedAmountTooSmall
+	}
+
+	users, err := lockUsers(ctx, tx, senderID, receiverID)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkActive(users[senderID], ErrSenderNotFound); err != nil {
+		return nil, err
+	}
+	if err = checkActive(users[receiverID], ErrReceiverNotFound); err != nil {
+		return nil, err
+	}
+	receiverAccount, err := getAccount(ctx, tx, receiverID, quote.ToCurrency)
+	if err != nil {
+		return nil, err
+	}
+	if receiverAccount == nil {
+		return nil, ErrCurrencyMismatch
+	}
+	fee, err := calculateFee(ctx, tx, users[senderID], "transfer", quote.FromCurrency, amount)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkAvailable(ctx, tx, senderID, quote.FromCurrency, amount+fee); err != nil {
+		return nil, err
+	}
+	if err = checkSpendingLimits(ctx, tx, users[senderID], quote.FromCurrency, amount); err != nil {
+		return nil, err
+	}
+
+	insertQuery := `
+		INSERT INTO transactions (
+			user_id, sender_id, receiver_id, amount, currency, transaction_type,
+			receiver_amount, receiver_currency, fx_quote_id, fee
+		)
+		VALUES ($1, $2, $3, $4, $5, 'transfer', $6, $7, $8, NULLIF($9::numeric, 0))
+		RETURNING ` + transactionColumns
+	t, err = scanTransaction(tx.QueryRow(ctx, insertQuery,
+		senderID, senderID, receiverID, amount, quote.FromCurrency, converted, quote.ToCurrency, quote.ID, fee))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrQuoteUsed
+		}
+		return nil, fmt.Errorf("failed to insert transfer transaction: %w", err)
+	}
+
+	// Конвертация проходит через системный счёт fx, поэтому проводка сбалансирована в каждой валюте.
+	// Комиссия берётся в исходной валюте
+	postings := append(
+		move(userAccount(senderID, quote.FromCurrency), systemAccount(AccountFX, quote.FromCurrency), amount),
+		move(systemAccount(AccountFX, quote.ToCurrency), userAccount(receiverID, quote.ToCurrency), converted)...,
+	)
+	postings = append(postings, feePostings(senderID, quote.FromCurrency, fee)...)
+	if err = postEntry(ctx, tx, &t.ID, "transfer", postings...); err != nil {
+		return nil, err
+	}
+	if err = recordTransactionEvent(ctx, tx, t); err != nil {
+		return nil, err
+	}
+
+	if err = completeIdempotencyKey(ctx, tx, key, "transfer", t.ID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transfer transaction: %w", err)
+	}
+
+	return t, nil
+}
+
+// Блокирует котировку до конца транзакции и проверяет, что она действует и ещё не использована
+func lockFXQuote(ctx context.Context, tx pgx.Tx, quoteID string) (*FXQuote, error) {
+	query := `
+		SELECT ` + fxQuoteColumns + `,
+			expires_at <= LOCALTIMESTAMP,
+			EXISTS (SELECT 1 FROM transactions WHERE fx_quote_id = q.id)
+		FROM fx_quotes q
+		WHERE id = $1
+		FOR UPDATE`
+	var (
+		q             FXQuote
+		expired, used bool
+	)
+	err := tx.QueryRow(ctx, query, quoteID).
+		Scan(&q.ID, &q.FromCurrency, &q.ToCurrency, &q.Rate, &q.ExpiresAt, &q.CreatedAt, &expired, &used)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrQuoteNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock fx quote: %w", err)
+	}
+	if used {
+		return nil, ErrQuoteUsed
+	}
+	if expired {
+		return nil, ErrQuoteExpired
+	}
+	return &q, nil
+}
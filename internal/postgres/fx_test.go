@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferFX(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	usd := money.Currency("USD")
+
+	sender := createFundedUser(t, r, "1000.00")
+	receiver := createFundedUser(t, r, "0")
+
+	quote, err := r.CreateFXQuote(ctx, rub, usd, money.MustParseRate("0.0108"), time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "0.0108", quote.Rate.String())
+
+	// У получателя нет долларового счёта
+	_, err = r.TransferFX(ctx, sender, receiver, money.MustParse("500.00"), quote.ID, nil)
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = r.OpenAccount(ctx, receiver, usd)
+	require.NoError(t, err)
+
+	tx, err := r.TransferFX(ctx, sender, receiver, money.MustParse("500.00"), quote.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, rub, tx.Currency)
+	require.NotNil(t, tx.ReceiverAmount)
+	assert.Equal(t, money.MustParse("5.40"), *tx.ReceiverAmount)
+	assert.Equal(t, usd, *tx.ReceiverCurrency)
+
+	assert.Equal(t, money.MustParse("500.00"), balanceOf(t, r, sender))
+	b, err := r.GetBalance(ctx, receiver, usd, nil)
+	require.NoError(t, err)
+	assert.Equal(t, money.MustParse("5.40"), b.Balance)
+
+	// Котировка одноразовая
+	_, err = r.TransferFX(ctx, sender, receiver, money.MustParse("10.00"), quote.ID, nil)
+	assert.ErrorIs(t, err, ErrQuoteUsed)
+
+	expired, err := r.CreateFXQuote(ctx, rub, usd, money.MustParseRate("0.0108"), -time.Second)
+	require.NoError(t, err)
+	_, err = r.TransferFX(ctx, sender, receiver, money.MustParse("10.00"), expired.ID, nil)
+	assert.ErrorIs(t, err, ErrQuoteExpired)
+
+	_, err = r.TransferFX(ctx, sender, receiver, money.MustParse("10.00"), "missing", nil)
+	assert.ErrorIs(t, err, ErrQuoteNotFound)
+
+	// Конвертация проходит через счёт fx, книга и история сходятся
+	report, err := r.VerifyLedger(ctx)
+	require.NoError(t, err)
+	assert.True(t, report.OK, "%+v", report)
+	reconciliation, err := r.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, reconciliation.Mismatches)
+}
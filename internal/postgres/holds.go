@@ -0,0 +1,298 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/jackc/pgx/v5"
+)
+
+// Статусы холда
+const (
+	HoldStatusActive   = "active"
+	HoldStatusCaptured = "captured"
+	HoldStatusReleased = "released"
+	HoldStatusExpired  = "expired"
+)
+
+// Hold — резерв части баланса счёта пользователя. Пока холд активен и не истёк, его сумма
+// не входит в доступный остаток. Холд списывается (полностью или частично) переводом
+// или списанием, освобождается вручную или истекает в ExpiresAt.
+type Hold struct {
+	ID             int64          `json:"id"`
+	UserID         int64          `json:"user_id"`
+	Currency       money.Currency `json:"currency" swaggertype:"string" example:"RUB"`
+	Amount         money.Amount   `json:"amount" swaggertype:"number" example:"500.00"`
+	CapturedAmount *money.Amount  `json:"captured_amount,omitempty" swaggertype:"number" example:"450.00"`
+	Status         string         `json:"status" example:"active"`
+	TransactionID  *int64         `json:"transaction_id,omitempty"`
+	ExpiresAt      time.Time      `json:"expires_at"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// Общие колонки для выборки холдов, порядок совпадает с scanHold
+const holdColumns = `id, user_id, currency, amount, captured_amount, status, transaction_id, expires_at, created_at, updated_at`
+
+func scanHold(row pgx.Row) (*Hold, error) {
+	var h Hold
+	err := row.Scan(&h.ID, &h.UserID, &h.Currency, &h.Amount, &h.CapturedAmount, &h.Status, &h.TransactionID,
+		&h.ExpiresAt, &h.CreatedAt, &h.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// Условие действующего холда: активен и ещё не истёк. Истёкшие холды перестают резервировать
+// деньги сразу, не дожидаясь, пока ExpireHolds переведёт их в статус expired.
+const holdInEffectSQL = `status = 'active' AND expires_at > LOCALTIMESTAMP`
+
+// Возвращает сумму действующих холдов по счёту пользователя в валюте
+func heldAmount(ctx context.Context, q querier, userID int64, currency money.Currency) (money.Amount, error) {
+	query := `SELECT COALESCE(SUM(amount), 0) FROM holds WHERE user_id = $1 AND currency = $2 AND ` + holdInEffectSQL
+	var held money.Amount
+	if err := q.QueryRow(ctx, query, userID, currency).Scan(&held); err != nil {
+		return 0, fmt.Errorf("failed to get held amount: %w", err)
+	}
+	return held, nil
+}
+
+// Проверяет, что на счёте пользователя в валюте есть amount доступного остатка (баланс за вычетом
+// действующих холдов). Пользователь должен быть заблокирован вызывающей стороной.
+func checkAvailable(ctx context.Context, tx pgx.Tx, userID int64, currency money.Currency, amount money.Amount) error {
+	account, err := getAccount(ctx, tx, userID, currency)
+	if err != nil {
+		return err
+	}
+	if account == nil {
+		return ErrInsufficientFunds
+	}
+	held, err := heldAmount(ctx, tx, userID, currency)
+	if err != nil {
+		return err
+	}
+	if account.Balance-held < amount {
+		return ErrInsufficientFunds
+	}
+	return nil
+}
+
+// Резервирует amount на счёте пользователя в валюте на время ttl (по часам БД).
+// Сумма холда должна укладываться в доступный остаток; баланс при этом не меняется.
+func (r *RepositoryImpl) CreateHold(ctx context.Context, userID int64, amount money.Amount, currency money.Currency, ttl time.Duration) (h *Hold, err error) {
+	err = withRetry(ctx, func() error {
+		h, err = r.createHold(ctx, userID, amount, currency, ttl)
+		return err
+	})
+	return h, err
+}
+
+func (r *RepositoryImpl) createHold(ctx context.Context, userID int64, amount money.Amount, currency money.Currency, ttl time.Duration) (h *Hold, err error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	// Блокировка пользователя сериализует холды с переводами и списаниями по его счетам
+	if _, err = lockActiveUser(ctx, tx, userID, ErrUserNotFound); err != nil {
+		return nil, err
+	}
+	if err = checkAvailable(ctx, tx, userID, currency, amount); err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO holds (user_id, currency, amount, expires_at)
+		VALUES ($1, $2, $3, LOCALTIMESTAMP + make_interval(secs => $4))
+		RETURNING ` + holdColumns
+	h, err = scanHold(tx.QueryRow(ctx, query, userID, currency, amount, ttl.Seconds()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create hold: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit hold: %w", err)
+	}
+
+	return h, nil
+}
+
+// Возвращает холд по id
+func (r *RepositoryImpl) GetHold(ctx context.Context, holdID int64) (*Hold, error) {
+	return getHold(ctx, r.pool, holdID)
+}
+
+func getHold(ctx context.Context, q querier, holdID int64) (*Hold, error) {
+	h, err := scanHold(q.QueryRow(ctx, `SELECT `+holdColumns+` FROM holds WHERE id = $1`, holdID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrHoldNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hold: %w", err)
+	}
+	return h, nil
+}
+
+// Списывает холд: amount (или всю сумму холда, если amount равен нулю) переводится пользователю
+// receiverID, а если он не задан — списывается со счёта. Остаток резерва при частичном списании
+// освобождается. Холд должен быть активен и не истёк.
+// При повторе с тем же ключом идемпотентности возвращает холд и ранее созданную транзакцию.
+func (r *RepositoryImpl) CaptureHold(ctx context.Context, holdID int64, amount money.Amount, receiverID *int64, key *IdempotencyKey) (h *Hold, t *Transaction, err error) {
+	err = withRetry(ctx, func() error {
+		h, t, err = r.captureHold(ctx, holdID, amount, receiverID, key)
+		return err
+	})
+	return h, t, err
+}
+
+func (r *RepositoryImpl) captureHold(ctx context.Context, holdID int64, amount money.Amount, receiverID *int64, key *IdempotencyKey) (h *Hold, t *Transaction, err error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	// Повтор запроса с тем же ключом: возвращаем сохранённый результат
+	replay, err := claimIdempotencyKey(ctx, tx, key, "capture")
+	if err != nil {
+		return nil, nil, err
+	}
+	if replay != nil {
+		tx.Rollback(ctx)
+		h, err = getHold(ctx, r.pool, holdID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return h, replay, nil
+	}
+
+	h, err = lockHold(ctx, tx, holdID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if amount == 0 {
+		amount = h.Amount
+	}
+	if amount > h.Amount {
+		return nil, nil, ErrCaptureExceedsHold
+	}
+	if err = h.Currency.CheckAmount(amount); err != nil {
+		return nil, nil, err
+	}
+
+	// Холд закрывается до списания, чтобы зарезервированная сумма вошла в доступный остаток
+	_, err = tx.Exec(ctx, `
+		UPDATE holds SET status = 'captured', captured_amount = $2, updated_at = LOCALTIMESTAMP
+		WHERE id = $1
+	`, holdID, amount)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to capture hold: %w", err)
+	}
+
+	if receiverID != nil {
+		t, err = executeTransfer(ctx, tx, h.UserID, *receiverID, amount, h.Currency)
+	} else {
+		t, err = executeWithdrawal(ctx, tx, h.UserID, amount, h.Currency)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	h, err = scanHold(tx.QueryRow(ctx, `
+		UPDATE holds SET transaction_id = $2 WHERE id = $1
+		RETURNING `+holdColumns, holdID, t.ID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to capture hold: %w", err)
+	}
+
+	if err = completeIdempotencyKey(ctx, tx, key, "capture", t.ID); err != nil {
+		return nil, nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit hold capture: %w", err)
+	}
+
+	return h, t, nil
+}
+
+// Освобождает активный холд: зарезервированная сумма снова становится доступной
+func (r *RepositoryImpl) ReleaseHold(ctx context.Context, holdID int64) (h *Hold, err error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	if _, err = lockHold(ctx, tx, holdID); err != nil {
+		return nil, err
+	}
+
+	h, err = scanHold(tx.QueryRow(ctx, `
+		UPDATE holds SET status = 'released', updated_at = LOCALTIMESTAMP
+		WHERE id = $1
+		RETURNING `+holdColumns, holdID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to release hold: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit hold release: %w", err)
+	}
+
+	return h, nil
+}
+
+// Переводит истёкшие активные холды в статус expired и возвращает их число.
+// Доступный остаток от этого не меняется: истёкшие холды не учитываются и до перевода.
+func (r *RepositoryImpl) ExpireHolds(ctx context.Context) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE holds SET status = 'expired', updated_at = LOCALTIMESTAMP
+		WHERE status = 'active' AND expires_at <= LOCALTIMESTAMP
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire holds: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// Блокирует холд до конца транзакции и проверяет, что он активен и не истёк
+func lockHold(ctx context.Context, tx pgx.Tx, holdID int64) (*Hold, error) {
+	query := `SELECT ` + holdColumns + `, expires_at <= LOCALTIMESTAMP FROM holds WHERE id = $1 FOR UPDATE`
+	var (
+		h       Hold
+		expired bool
+	)
+	err := tx.QueryRow(ctx, query, holdID).Scan(&h.ID, &h.UserID, &h.Currency, &h.Amount, &h.CapturedAmount,
+		&h.Status, &h.TransactionID, &h.ExpiresAt, &h.CreatedAt, &h.UpdatedAt, &expired)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrHoldNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock hold: %w", err)
+	}
+	if h.Status == HoldStatusExpired || (h.Status == HoldStatusActive && expired) {
+		return nil, ErrHoldExpired
+	}
+	if h.Status != HoldStatusActive {
+		return nil, ErrHoldNotActive
+	}
+	return &h, nil
+}
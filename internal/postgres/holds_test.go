@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHold_ReducesAvailableBalance(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	sender := createFundedUser(t, r, "1000.00")
+	receiver := createFundedUser(t, r, "0")
+
+	hold, err := r.CreateHold(ctx, sender, money.MustParse("700.00"), rub, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, HoldStatusActive, hold.Status)
+
+	// Учётный баланс не меняется, доступный уменьшается на сумму холда
+	b, err := r.GetBalance(ctx, sender, rub, nil)
+	require.NoError(t, err)
+	assert.Equal(t, money.MustParse("1000.00"), b.Balance)
+	assert.Equal(t, money.MustParse("700.00"), *b.Held)
+	assert.Equal(t, money.MustParse("300.00"), *b.Available)
+
+	// Переводы, списания и новые холды ограничены доступным остатком
+	_, err = r.Transfer(ctx, sender, receiver, money.MustParse("300.01"), rub, nil)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+	_, err = r.Withdraw(ctx, sender, money.MustParse("300.01"), rub, nil)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+	_, err = r.CreateHold(ctx, sender, money.MustParse("300.01"), rub, time.Hour)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+	_, err = r.Transfer(ctx, sender, receiver, money.MustParse("300.00"), rub, nil)
+	require.NoError(t, err)
+
+	// После освобождения холда сумма снова доступна
+	released, err := r.ReleaseHold(ctx, hold.ID)
+	require.NoError(t, err)
+	assert.Equal(t, HoldStatusReleased, released.Status)
+	_, err = r.ReleaseHold(ctx, hold.ID)
+	assert.ErrorIs(t, err, ErrHoldNotActive)
+
+	b, err = r.GetBalance(ctx, sender, rub, nil)
+	require.NoError(t, err)
+	assert.Equal(t, money.MustParse("700.00"), *b.Available)
+}
+
+func TestCaptureHold(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	sender := createFundedUser(t, r, "1000.00")
+	receiver := createFundedUser(t, r, "0")
+
+	// Частичное списание переводом: остаток резерва освобождается
+	hold, err := r.CreateHold(ctx, sender, money.MustParse("1000.00"), rub, time.Hour)
+	require.NoError(t, err)
+	_, _, err = r.CaptureHold(ctx, hold.ID, money.MustParse("1000.01"), &receiver, nil)
+	assert.ErrorIs(t, err, ErrCaptureExceedsHold)
+
+	key := &IdempotencyKey{Key: "capture-1", RequestHash: "h1"}
+	captured, tx, err := r.CaptureHold(ctx, hold.ID, money.MustParse("400.00"), &receiver, key)
+	require.NoError(t, err)
+	assert.Equal(t, HoldStatusCaptured, captured.Status)
+	assert.Equal(t, money.MustParse("400.00"), *captured.CapturedAmount)
+	assert.Equal(t, tx.ID, *captured.TransactionID)
+	assert.Equal(t, "transfer", tx.TransactionType)
+
+	assert.Equal(t, money.MustParse("600.00"), balanceOf(t, r, sender))
+	assert.Equal(t, money.MustParse("400.00"), balanceOf(t, r, receiver))
+	b, err := r.GetBalance(ctx, sender, rub, nil)
+	require.NoError(t, err)
+	assert.Equal(t, money.MustParse("600.00"), *b.Available)
+
+	// Повтор с тем же ключом возвращает ту же транзакцию, повторное списание без ключа — ошибка
+	_, replay, err := r.CaptureHold(ctx, hold.ID, money.MustParse("400.00"), &receiver, key)
+	require.NoError(t, err)
+	assert.Equal(t, tx.ID, replay.ID)
+	_, _, err = r.CaptureHold(ctx, hold.ID, 0, nil, nil)
+	assert.ErrorIs(t, err, ErrHoldNotActive)
+
+	// Полное списание без получателя — это списание со счёта
+	hold, err = r.CreateHold(ctx, sender, money.MustParse("100.00"), rub, time.Hour)
+	require.NoError(t, err)
+	_, tx, err = r.CaptureHold(ctx, hold.ID, 0, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "withdrawal", tx.TransactionType)
+	assert.Equal(t, money.MustParse("100.00"), tx.Amount)
+	assert.Equal(t, money.MustParse("500.00"), balanceOf(t, r, sender))
+
+	report, err := r.VerifyLedger(ctx)
+	require.NoError(t, err)
+	assert.True(t, report.OK, "%+v", report)
+}
+
+func TestHold_Expiry(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	user := createFundedUser(t, r, "100.00")
+
+	hold, err := r.CreateHold(ctx, user, money.MustParse("100.00"), rub, -time.Second)
+	require.NoError(t, err)
+
+	// Истёкший холд не резервирует деньги ещё до того, как получит статус expired
+	b, err := r.GetBalance(ctx, user, rub, nil)
+	require.NoError(t, err)
+	assert.Equal(t, money.MustParse("100.00"), *b.Available)
+
+	_, _, err = r.CaptureHold(ctx, hold.ID, 0, nil, nil)
+	assert.ErrorIs(t, err, ErrHoldExpired)
+
+	n, err := r.ExpireHolds(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	expired, err := r.GetHold(ctx, hold.ID)
+	require.NoError(t, err)
+	assert.Equal(t, HoldStatusExpired, expired.Status)
+
+	_, err = r.GetHold(ctx, hold.ID+1)
+	assert.ErrorIs(t, err, ErrHoldNotFound)
+}
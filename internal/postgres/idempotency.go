@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// IdempotencyKey — ключ из заголовка Idempotency-Key и хэш запроса, к которому он привязан.
+// Ключ уникален в пределах клиента (Principal) и операции: ключи разных клиентов не пересекаются.
+type IdempotencyKey struct {
+	Principal   string
+	Key         string
+	RequestHash string
+}
+
+// Захватывает ключ идемпотентности в рамках транзакции tx.
+// Если ключ уже был использован тем же запросом, возвращает ранее созданную транзакцию;
+// если другим запросом — ErrIdempotencyKeyReused. Если ключ новый, возвращает nil, nil.
+// Конкурентный запрос с тем же ключом ждёт на INSERT, пока первый не завершится.
+func claimIdempotencyKey(ctx context.Context, tx pgx.Tx, key *IdempotencyKey, operation string) (*Transaction, error) {
+	if key == nil {
+		return nil, nil
+	}
+
+	insertQuery := `
+		INSERT INTO idempotency_keys (principal, operation, key, request_hash)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (principal, operation, key) DO NOTHING
+	`
+	ct, err := tx.Exec(ctx, insertQuery, key.Principal, operation, key.Key, key.RequestHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	if ct.RowsAffected() == 1 {
+		return nil, nil
+	}
+
+	var (
+		storedHash    string
+		transactionID *int64
+	)
+	selectQuery := `
+		SELECT request_hash, transaction_id FROM idempotency_keys
+		WHERE principal = $1 AND operation = $2 AND key = $3
+	`
+	err = tx.QueryRow(ctx, selectQuery, key.Principal, operation, key.Key).Scan(&storedHash, &transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	if storedHash != key.RequestHash || transactionID == nil {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	t, err := getTransaction(ctx, tx, *transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotent result: %w", err)
+	}
+	return t, nil
+}
+
+// Привязывает захваченный ключ к созданной транзакции
+func completeIdempotencyKey(ctx context.Context, tx pgx.Tx, key *IdempotencyKey, operation string, transactionID int64) error {
+	if key == nil {
+		return nil
+	}
+
+	query := `
+		UPDATE idempotency_keys SET transaction_id = $1
+		WHERE principal = $2 AND operation = $3 AND key = $4
+	`
+	_, err := tx.Exec(ctx, query, transactionID, key.Principal, operation, key.Key)
+	if err != nil {
+		return fmt.Errorf("failed to store idempotency key result: %w", err)
+	}
+	return nil
+}
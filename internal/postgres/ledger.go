@@ -0,0 +1,205 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/jackc/pgx/v5"
+)
+
+// Коды системных счетов главной книги
+const (
+	AccountExternal       = "external"        // деньги за пределами сервиса: источник пополнений и получатель снятий
+	AccountOpeningBalance = "opening_balance" // начальные остатки, существовавшие до ведения книги
+	AccountAdjustments    = "adjustments"     // ручные корректировки балансов администраторами
+)
+
+// Направления записей. Кредит увеличивает остаток счёта, дебет уменьшает.
+const (
+	debit  = "debit"
+	credit = "credit"
+)
+
+// Ссылка на счёт книги: счёт пользователя или системный счёт по коду, в заданной валюте
+type accountRef struct {
+	userID   int64
+	code     string
+	currency money.Currency
+}
+
+func userAccount(userID int64, currency money.Currency) accountRef {
+	return accountRef{userID: userID, currency: currency}
+}
+
+func systemAccount(code string, currency money.Currency) accountRef {
+	return accountRef{code: code, currency: currency}
+}
+
+type posting struct {
+	account   accountRef
+	direction string
+	amount    money.Amount
+}
+
+// Записи перемещения amount со счёта from на счёт to (в одной валюте)
+func move(from, to accountRef, amount money.Amount) []posting {
+	return []posting{
+		{account: from, direction: debit, amount: amount},
+		{account: to, direction: credit, amount: amount},
+	}
+}
+
+// Записывает проводку в книгу и обновляет кэшированные балансы счетов пользователей (accounts.balance).
+// Все изменения балансов должны проходить через эту функцию — так accounts.balance
+// всегда совпадает с суммой записей по счёту и может быть сверен с книгой (см. VerifyLedger).
+// Проводка должна быть сбалансирована в каждой валюте. Счета пользователей должны быть открыты
+// заранее (см. openAccount), системные счета в новой валюте заводятся автоматически.
+// Вызывающий должен заранее заблокировать строки затрагиваемых пользователей.
+func postEntry(ctx context.Context, tx pgx.Tx, transactionID *int64, description string, postings ...posting) error {
+	sums := make(map[money.Currency]money.Amount)
+	for _, p := range postings {
+		if p.amount <= 0 {
+			return fmt.Errorf("invalid posting amount %s", p.amount)
+		}
+		if p.direction == credit {
+			sums[p.account.currency] += p.amount
+		} else {
+			sums[p.account.currency] -= p.amount
+		}
+	}
+	for currency, sum := range sums {
+		if sum != 0 {
+			return fmt.Errorf("journal entry is not balanced in %s", currency)
+		}
+	}
+	if len(postings) == 0 {
+		return errors.New("journal entry is empty")
+	}
+
+	var entryID int64
+	err := tx.QueryRow(ctx, `
+		INSERT INTO journal_entries (transaction_id, description)
+		VALUES ($1, $2)
+		RETURNING id
+	`, transactionID, description).Scan(&entryID)
+	if err != nil {
+		return fmt.Errorf("failed to insert journal entry: %w", err)
+	}
+
+	for _, p := range postings {
+		accountCond, accountArg := `code = $2`, any(p.account.code)
+		if p.account.code == "" {
+			accountCond, accountArg = `user_id = $2`, p.account.userID
+		} else {
+			_, err = tx.Exec(ctx, `
+				INSERT INTO ledger_accounts (code, currency) VALUES ($1, $2)
+				ON CONFLICT (code, currency) DO NOTHING
+			`, p.account.code, p.account.currency)
+			if err != nil {
+				return fmt.Errorf("failed to create ledger account: %w", err)
+			}
+		}
+
+		insertQuery := `
+			INSERT INTO postings (entry_id, account_id, direction, amount)
+			SELECT $1, id, $3, $4 FROM ledger_accounts WHERE currency = $5 AND ` + accountCond
+		ct, err := tx.Exec(ctx, insertQuery, entryID, accountArg, p.direction, p.amount, p.account.currency)
+		if err != nil {
+			return fmt.Errorf("failed to insert posting: %w", err)
+		}
+		if ct.RowsAffected() != 1 {
+			return fmt.Errorf("ledger account not found for %+v", p.account)
+		}
+
+		if p.account.code != "" {
+			continue
+		}
+		delta := p.amount
+		if p.direction == debit {
+			delta = -delta
+		}
+		ct, err = tx.Exec(ctx, `
+			UPDATE accounts SET balance = balance + $1 WHERE user_id = $2 AND currency = $3
+		`, delta, p.account.userID, p.account.currency)
+		if err != nil {
+			return fmt.Errorf("failed to update balance: %w", err)
+		}
+		if ct.RowsAffected() != 1 {
+			return fmt.Errorf("account not found for %+v", p.account)
+		}
+	}
+
+	return nil
+}
+
+// LedgerMismatch — расхождение кэшированного баланса счёта пользователя с остатком по книге
+type LedgerMismatch struct {
+	UserID        int64          `json:"user_id"`
+	Currency      money.Currency `json:"currency" swaggertype:"string" example:"RUB"`
+	Balance       money.Amount   `json:"balance" swaggertype:"number" example:"1500.50"`
+	LedgerBalance money.Amount   `json:"ledger_balance" swaggertype:"number" example:"1500.00"`
+}
+
+// LedgerReport — результат сверки балансов с книгой.
+// OK истинно, если все проводки сбалансированы и все балансы совпадают с книгой.
+type LedgerReport struct {
+	OK                bool             `json:"ok"`
+	UnbalancedEntries []int64          `json:"unbalanced_entries"`
+	Mismatches        []LedgerMismatch `json:"mismatches"`
+}
+
+// Сверяет accounts.balance с остатками счетов в книге и проверяет, что каждая проводка сбалансирована
+func (r *RepositoryImpl) VerifyLedger(ctx context.Context) (report *LedgerReport, err error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	report = &LedgerReport{UnbalancedEntries: []int64{}, Mismatches: []LedgerMismatch{}}
+
+	rows, err := tx.Query(ctx, `
+		SELECT DISTINCT p.entry_id
+		FROM postings p
+		JOIN ledger_accounts a ON a.id = p.account_id
+		GROUP BY p.entry_id, a.currency
+		HAVING SUM(CASE p.direction WHEN 'credit' THEN p.amount ELSE -p.amount END) <> 0
+		ORDER BY p.entry_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check journal entries: %w", err)
+	}
+	report.UnbalancedEntries, err = pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return nil, fmt.Errorf("failed to check journal entries: %w", err)
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT ac.user_id, ac.currency, ac.balance, COALESCE(SUM(CASE p.direction WHEN 'credit' THEN p.amount ELSE -p.amount END), 0)
+		FROM accounts ac
+		LEFT JOIN ledger_accounts a ON a.user_id = ac.user_id AND a.currency = ac.currency
+		LEFT JOIN postings p ON p.account_id = a.id
+		GROUP BY ac.id, ac.user_id, ac.currency, ac.balance
+		HAVING ac.balance <> COALESCE(SUM(CASE p.direction WHEN 'credit' THEN p.amount ELSE -p.amount END), 0)
+		ORDER BY ac.user_id, ac.currency
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify balances: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var m LedgerMismatch
+		if err := rows.Scan(&m.UserID, &m.Currency, &m.Balance, &m.LedgerBalance); err != nil {
+			return nil, fmt.Errorf("failed to scan ledger mismatch: %w", err)
+		}
+		report.Mismatches = append(report.Mismatches, m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	report.OK = len(report.UnbalancedEntries) == 0 && len(report.Mismatches) == 0
+	return report, nil
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedger_OperationsArePosted(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	// Начальные балансы пользователей из миграции перенесены в книгу
+	report, err := r.VerifyLedger(ctx)
+	require.NoError(t, err)
+	assert.True(t, report.OK, "%+v", report)
+
+	a := createFundedUser(t, r, "100.00")
+	b := createFundedUser(t, r, "0")
+	_, err = r.Transfer(ctx, a, b, money.MustParse("40.00"), rub, nil)
+	require.NoError(t, err)
+	tx, err := r.Withdraw(ctx, b, money.MustParse("15.50"), rub, nil)
+	require.NoError(t, err)
+
+	report, err = r.VerifyLedger(ctx)
+	require.NoError(t, err)
+	assert.True(t, report.OK, "%+v", report)
+
+	// Каждая транзакция оформлена одной сбалансированной проводкой
+	var debits, credits money.Amount
+	err = r.pool.QueryRow(ctx, `
+		SELECT
+			SUM(p.amount) FILTER (WHERE p.direction = 'debit'),
+			SUM(p.amount) FILTER (WHERE p.direction = 'credit')
+		FROM journal_entries e JOIN postings p ON p.entry_id = e.id
+		WHERE e.transaction_id = $1
+	`, tx.ID).Scan(&debits, &credits)
+	require.NoError(t, err)
+	assert.Equal(t, money.MustParse("15.50"), debits)
+	assert.Equal(t, debits, credits)
+
+	// Прямое изменение кэшированного баланса обнаруживается сверкой
+	_, err = r.pool.Exec(ctx, `UPDATE accounts SET balance = balance + 1 WHERE user_id = $1 AND currency = $2`, a, rub)
+	require.NoError(t, err)
+	report, err = r.VerifyLedger(ctx)
+	require.NoError(t, err)
+	assert.False(t, report.OK)
+	require.Len(t, report.Mismatches, 1)
+	assert.Equal(t, LedgerMismatch{UserID: a, Currency: rub, Balance: money.MustParse("61.00"), LedgerBalance: money.MustParse("60.00")}, report.Mismatches[0])
+}
+
+func TestLedger_AppendOnly(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	createFundedUser(t, r, "10.00")
+
+	_, err := r.pool.Exec(ctx, `UPDATE postings SET amount = 1`)
+	assert.Error(t, err)
+	_, err = r.pool.Exec(ctx, `DELETE FROM journal_entries`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,257 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Виды лимитов расходов
+const (
+	LimitPerTransaction = "per_transaction"
+	LimitDaily          = "daily"
+	LimitMonthly        = "monthly"
+)
+
+// Источник действующих лимитов пользователя
+const (
+	LimitSourceUser = "user"
+	LimitSourceTier = "tier"
+)
+
+// SpendingLimits — лимиты исходящих операций (переводов и списаний) в валюте: максимум одной операции
+// и суммы за календарные сутки и месяц по UTC. nil — без ограничения.
+type SpendingLimits struct {
+	Currency       money.Currency `json:"currency" swaggertype:"string" example:"RUB"`
+	PerTransaction *money.Amount  `json:"per_transaction,omitempty" swaggertype:"number" example:"100000.00"`
+	Daily          *money.Amount  `json:"daily,omitempty" swaggertype:"number" example:"300000.00"`
+	Monthly        *money.Amount  `json:"monthly,omitempty" swaggertype:"number" example:"1000000.00"`
+}
+
+// SpendingStatus — действующие лимиты пользователя в валюте и их использование.
+// Source — откуда взяты лимиты: user (заданы пользователю), tier (лимиты тарифа) или пусто (лимитов нет).
+// Remaining — наибольшая сумма, которую можно потратить одной операцией прямо сейчас; nil — без ограничения.
+type SpendingStatus struct {
+	UserID         int64          `json:"user_id"`
+	Tier           string         `json:"tier" example:"standard"`
+	Source         string         `json:"source,omitempty" example:"tier"`
+	Limits         SpendingLimits `json:"limits"`
+	SpentToday     money.Amount   `json:"spent_today" swaggertype:"number" example:"15000.00"`
+	SpentThisMonth money.Amount   `json:"spent_this_month" swaggertype:"number" example:"120000.00"`
+	Remaining      *money.Amount  `json:"remaining,omitempty" swaggertype:"number" example:"100000.00"`
+}
+
+// LimitExceededError — операция превышает лимит расходов Type. Remaining — наибольшая сумма,
+// которую пользователь может потратить одной операцией с учётом всех лимитов.
+// errors.Is(err, ErrLimitExceeded) для неё истинно.
+type LimitExceededError struct {
+	Type      string         `json:"type" example:"daily"`
+	Currency  money.Currency `json:"currency" swaggertype:"string" example:"RUB"`
+	Remaining money.Amount   `json:"remaining" swaggertype:"number" example:"2500.00"`
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("%s spending limit exceeded: %s %s remaining", e.Type, e.Remaining, e.Currency)
+}
+
+func (e *LimitExceededError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// Возвращает лимиты пользователя в валюте: заданные ему самому, а если их нет — лимиты его тарифа.
+// Второй результат — источник лимитов; пустой, если лимитов нет.
+func effectiveLimits(ctx context.Context, q querier, u *User, currency money.Currency) (SpendingLimits, string, error) {
+	query := `
+		SELECT source, per_transaction, daily, monthly FROM (
+			SELECT 1 AS priority, 'user' AS source, per_transaction, daily, monthly
+			FROM user_spending_limits WHERE user_id = $1 AND currency = $3
+			UNION ALL
+			SELECT 2, 'tier', per_transaction, daily, monthly
+			FROM tier_spending_limits WHERE tier = $2 AND currency = $3
+		) l
+		ORDER BY priority
+		LIMIT 1`
+	limits := SpendingLimits{Currency: currency}
+	var source string
+	err := q.QueryRow(ctx, query, u.ID, u.Tier, currency).Scan(&source, &limits.PerTransaction, &limits.Daily, &limits.Monthly)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return limits, "", nil
+	}
+	if err != nil {
+		return limits, "", fmt.Errorf("failed to get spending limits: %w", err)
+	}
+	return limits, source, nil
+}
+
+// Возвращает суммы исходящих операций пользователя в валюте с начала текущих суток и месяца (UTC).
+// Переводы с конвертацией учитываются в валюте списания.
+func outgoingSpent(ctx context.Context, q querier, userID int64, currency money.Currency) (today, month money.Amount, err error) {
+	query := `
+		SELECT
+			COALESCE(SUM(amount) FILTER (WHERE created_at >= date_trunc('day', LOCALTIMESTAMP)), 0),
+			COALESCE(SUM(amount), 0)
+		FROM transactions
+		WHERE user_id = $1 AND currency = $2 AND transaction_type IN ('transfer', 'withdrawal')
+			AND created_at >= date_trunc('month', LOCALTIMESTAMP)`
+	if err = q.QueryRow(ctx, query, userID, currency).Scan(&today, &month); err != nil {
+		return 0, 0, fmt.Errorf("failed to get outgoing amounts: %w", err)
+	}
+	return today, month, nil
+}
+
+func spendingStatus(ctx context.Context, q querier, u *User, currency money.Currency) (*SpendingStatus, error) {
+	limits, source, err := effectiveLimits(ctx, q, u, currency)
+	if err != nil {
+		return nil, err
+	}
+	s := &SpendingStatus{UserID: u.ID, Tier: u.Tier, Source: source, Limits: limits}
+	if source == "" {
+		return s, nil
+	}
+	if s.SpentToday, s.SpentThisMonth, err = outgoingSpent(ctx, q, u.ID, currency); err != nil {
+		return nil, err
+	}
+
+	remaining := func(limit *money.Amount, spent money.Amount) {
+		if limit == nil {
+			return
+		}
+		left := max(*limit-spent, 0)
+		if s.Remaining == nil || left < *s.Remaining {
+			s.Remaining = &left
+		}
+	}
+	remaining(limits.PerTransaction, 0)
+	remaining(limits.Daily, s.SpentToday)
+	remaining(limits.Monthly, s.SpentThisMonth)
+	return s, nil
+}
+
+// Проверяет, что исходящая операция на amount укладывается в лимиты пользователя.
+// Вызывается в транзакции списания после блокировки строки пользователя: все исходящие операции
+// пользователя блокируют её же, поэтому конкурентные операции не превысят лимит вместе.
+func checkSpendingLimits(ctx context.Context, tx pgx.Tx, u *User, currency money.Currency, amount money.Amount) error {
+	s, err := spendingStatus(ctx, tx, u, currency)
+	if err != nil {
+		return err
+	}
+	if s.Remaining == nil || amount <= *s.Remaining {
+		return nil
+	}
+
+	limitErr := &LimitExceededError{Currency: currency, Remaining: *s.Remaining}
+	switch l := s.Limits; {
+	case l.PerTransaction != nil && amount > *l.PerTransaction:
+		limitErr.Type = LimitPerTransaction
+	case l.Daily != nil && s.SpentToday+amount > *l.Daily:
+		limitErr.Type = LimitDaily
+	default:
+		limitErr.Type = LimitMonthly
+	}
+	return limitErr
+}
+
+// Возвращает действующие лимиты пользователя в валюте и их использование
+func (r *RepositoryImpl) GetSpendingStatus(ctx context.Context, userID int64, currency money.Currency) (*SpendingStatus, error) {
+	u, err := scanUser(r.pool.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return spendingStatus(ctx, r.pool, u, currency)
+}
+
+// Возвращает лимиты тарифа во всех валютах, для которых они заданы
+func (r *RepositoryImpl) ListTierSpendingLimits(ctx context.Context, tier string) ([]SpendingLimits, error) {
+	query := `
+		SELECT currency, per_transaction, daily, monthly FROM tier_spending_limits
+		WHERE tier = $1 ORDER BY currency`
+	rows, err := r.pool.Query(ctx, query, tier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tier spending limits: %w", err)
+	}
+	defer rows.Close()
+
+	limits := []SpendingLimits{}
+	for rows.Next() {
+		var l SpendingLimits
+		if err := rows.Scan(&l.Currency, &l.PerTransaction, &l.Daily, &l.Monthly); err != nil {
+			return nil, fmt.Errorf("failed to scan tier spending limits: %w", err)
+		}
+		limits = append(limits, l)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
+
+// Задаёт лимиты тарифа в валюте limits.Currency, заменяя прежние
+func (r *RepositoryImpl) SetTierSpendingLimits(ctx context.Context, tier string, limits SpendingLimits) (*SpendingLimits, error) {
+	query := `
+		INSERT INTO tier_spending_limits (tier, currency, per_transaction, daily, monthly)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (tier, currency) DO UPDATE SET
+			per_transaction = EXCLUDED.per_transaction,
+			daily = EXCLUDED.daily,
+			monthly = EXCLUDED.monthly,
+			updated_at = LOCALTIMESTAMP`
+	_, err := r.pool.Exec(ctx, query, tier, limits.Currency, limits.PerTransaction, limits.Daily, limits.Monthly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set tier spending limits: %w", err)
+	}
+	return &limits, nil
+}
+
+// Задаёт пользователю собственные лимиты в валюте limits.Currency вместо лимитов тарифа.
+// Лимиты без ограничений (все поля nil) снимают для пользователя ограничения тарифа.
+func (r *RepositoryImpl) SetUserSpendingLimits(ctx context.Context, userID int64, limits SpendingLimits) (*SpendingLimits, error) {
+	query := `
+		INSERT INTO user_spending_limits (user_id, currency, per_transaction, daily, monthly)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, currency) DO UPDATE SET
+			per_transaction = EXCLUDED.per_transaction,
+			daily = EXCLUDED.daily,
+			monthly = EXCLUDED.monthly,
+			updated_at = LOCALTIMESTAMP`
+	_, err := r.pool.Exec(ctx, query, userID, limits.Currency, limits.PerTransaction, limits.Daily, limits.Monthly)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to set user spending limits: %w", err)
+	}
+	return &limits, nil
+}
+
+// Удаляет собственные лимиты пользователя в валюте: снова действуют лимиты его тарифа
+func (r *RepositoryImpl) DeleteUserSpendingLimits(ctx context.Context, userID int64, currency money.Currency) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM user_spending_limits WHERE user_id = $1 AND currency = $2`, userID, currency)
+	if err != nil {
+		return fmt.Errorf("failed to delete user spending limits: %w", err)
+	}
+	return nil
+}
+
+// Переводит пользователя на тариф tier
+func (r *RepositoryImpl) SetUserTier(ctx context.Context, userID int64, tier string) (*User, error) {
+	query := `UPDATE users SET tier = $1 WHERE id = $2 RETURNING ` + userColumns
+	u, err := scanUser(r.pool.QueryRow(ctx, query, tier, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user tier: %w", err)
+	}
+	if err = loadAccounts(ctx, r.pool, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
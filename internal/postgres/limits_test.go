@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func amountPtr(s string) *money.Amount {
+	a := money.MustParse(s)
+	return &a
+}
+
+func TestSpendingLimits_Tier(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	sender := createFundedUser(t, r, "10000.00")
+	receiver := createFundedUser(t, r, "0")
+
+	_, err := r.SetTierSpendingLimits(ctx, "standard", SpendingLimits{
+		Currency:       rub,
+		PerTransaction: amountPtr("1000.00"),
+		Daily:          amountPtr("1500.00"),
+	})
+	require.NoError(t, err)
+
+	_, err = r.Transfer(ctx, sender, receiver, money.MustParse("1000.01"), rub, nil)
+	var limitErr *LimitExceededError
+	require.True(t, errors.As(err, &limitErr), "%v", err)
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+	assert.Equal(t, LimitPerTransaction, limitErr.Type)
+	assert.Equal(t, money.MustParse("1000.00"), limitErr.Remaining)
+
+	_, err = r.Transfer(ctx, sender, receiver, money.MustParse("1000.00"), rub, nil)
+	require.NoError(t, err)
+
+	// Списания расходуют тот же дневной лимит
+	_, err = r.Withdraw(ctx, sender, money.MustParse("600.00"), rub, nil)
+	require.True(t, errors.As(err, &limitErr), "%v", err)
+	assert.Equal(t, LimitDaily, limitErr.Type)
+	assert.Equal(t, money.MustParse("500.00"), limitErr.Remaining)
+
+	_, err = r.Withdraw(ctx, sender, money.MustParse("500.00"), rub, nil)
+	require.NoError(t, err)
+
+	status, err := r.GetSpendingStatus(ctx, sender, rub)
+	require.NoError(t, err)
+	assert.Equal(t, LimitSourceTier, status.Source)
+	assert.Equal(t, money.MustParse("1500.00"), status.SpentToday)
+	assert.Equal(t, money.MustParse("0"), *status.Remaining)
+
+	// Входящие операции лимит не расходуют
+	status, err = r.GetSpendingStatus(ctx, receiver, rub)
+	require.NoError(t, err)
+	assert.Equal(t, money.MustParse("0"), status.SpentToday)
+	assert.Equal(t, money.MustParse("1000.00"), *status.Remaining)
+}
+
+func TestSpendingLimits_UserOverridesTier(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	sender := createFundedUser(t, r, "10000.00")
+	receiver := createFundedUser(t, r, "0")
+
+	_, err := r.SetTierSpendingLimits(ctx, "standard", SpendingLimits{Currency: rub, Monthly: amountPtr("100.00")})
+	require.NoError(t, err)
+	_, err = r.SetTierSpendingLimits(ctx, "premium", SpendingLimits{Currency: rub, Monthly: amountPtr("5000.00")})
+	require.NoError(t, err)
+
+	_, err = r.Transfer(ctx, sender, receiver, money.MustParse("200.00"), rub, nil)
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+
+	u, err := r.SetUserTier(ctx, sender, "premium")
+	require.NoError(t, err)
+	assert.Equal(t, "premium", u.Tier)
+	_, err = r.Transfer(ctx, sender, receiver, money.MustParse("200.00"), rub, nil)
+	require.NoError(t, err)
+
+	// Собственные лимиты без ограничений снимают лимиты тарифа
+	_, err = r.SetUserSpendingLimits(ctx, sender, SpendingLimits{Currency: rub})
+	require.NoError(t, err)
+	_, err = r.Transfer(ctx, sender, receiver, money.MustParse("6000.00"), rub, nil)
+	require.NoError(t, err)
+	status, err := r.GetSpendingStatus(ctx, sender, rub)
+	require.NoError(t, err)
+	assert.Equal(t, LimitSourceUser, status.Source)
+	assert.Nil(t, status.Remaining)
+
+	require.NoError(t, r.DeleteUserSpendingLimits(ctx, sender, rub))
+	_, err = r.Transfer(ctx, sender, receiver, money.MustParse("1.00"), rub, nil)
+	var limitErr *LimitExceededError
+	require.True(t, errors.As(err, &limitErr), "%v", err)
+	assert.Equal(t, LimitMonthly, limitErr.Type)
+	assert.Equal(t, money.MustParse("0"), limitErr.Remaining)
+
+	_, err = r.SetUserSpendingLimits(ctx, sender+1000, SpendingLimits{Currency: rub})
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestSpendingLimits_Concurrent(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	sender := createFundedUser(t, r, "10000.00")
+	receiver := createFundedUser(t, r, "0")
+	_, err := r.SetUserSpendingLimits(ctx, sender, SpendingLimits{Currency: rub, Daily: amountPtr("500.00")})
+	require.NoError(t, err)
+
+	// Лимит проверяется под блокировкой отправителя, поэтому вместе переводы его не превысят
+	errs := runConcurrently(10, func(int) error {
+		_, err := r.Transfer(ctx, sender, receiver, money.MustParse("100.00"), rub, nil)
+		return err
+	})
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+		} else {
+			assert.ErrorIs(t, err, ErrLimitExceeded)
+		}
+	}
+	assert.Equal(t, 5, succeeded)
+	assert.Equal(t, money.MustParse("9500.00"), balanceOf(t, r, sender))
+}
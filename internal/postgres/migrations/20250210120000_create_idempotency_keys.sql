@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE idempotency_keys (
+    key VARCHAR(255) PRIMARY KEY,
+    operation VARCHAR(20) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    transaction_id INT REFERENCES transactions(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_idempotency_keys_created_at ON idempotency_keys(created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE transactions DROP CONSTRAINT transactions_transaction_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_transaction_type_check
+    CHECK (transaction_type IN ('deposit', 'transfer', 'withdrawal'));
+
+-- +goose Down
+-- Откат возможен, только пока снятий не было: история транзакций не удаляется
+-- +goose StatementBegin
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM transactions WHERE transaction_type = 'withdrawal') THEN
+        RAISE EXCEPTION 'cannot roll back withdrawal type: withdrawal transactions exist';
+    END IF;
+END;
+$$;
+-- +goose StatementEnd
+
+ALTER TABLE transactions DROP CONSTRAINT transactions_transaction_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_transaction_type_check
+    CHECK (transaction_type IN ('deposit', 'transfer'));
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE users
+    ADD COLUMN status VARCHAR(10) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'frozen', 'closed'));
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS status;
@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX idx_transactions_created_at_id ON transactions(created_at DESC, id DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_transactions_created_at_id;
@@ -0,0 +1,146 @@
+-- +goose Up
+-- Счета главной книги: у каждого пользователя свой счёт, системные счета задаются кодом
+CREATE TABLE ledger_accounts (
+    id SERIAL PRIMARY KEY,
+    user_id INT UNIQUE REFERENCES users(id),
+    code VARCHAR(50) UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((user_id IS NULL) <> (code IS NULL))
+);
+
+-- Проводка: набор сбалансированных записей, как правило привязанный к транзакции
+CREATE TABLE journal_entries (
+    id SERIAL PRIMARY KEY,
+    transaction_id INT REFERENCES transactions(id),
+    description VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Записи по счетам. Кредит увеличивает остаток счёта пользователя, дебет уменьшает
+CREATE TABLE postings (
+    id SERIAL PRIMARY KEY,
+    entry_id INT NOT NULL REFERENCES journal_entries(id),
+    account_id INT NOT NULL REFERENCES ledger_accounts(id),
+    direction VARCHAR(6) NOT NULL CHECK (direction IN ('debit', 'credit')),
+    amount NUMERIC(15,2) NOT NULL CHECK (amount > 0)
+);
+
+CREATE INDEX idx_journal_entries_transaction_id ON journal_entries(transaction_id);
+CREATE INDEX idx_postings_entry_id ON postings(entry_id);
+CREATE INDEX idx_postings_account_id ON postings(account_id);
+
+INSERT INTO ledger_accounts (code) VALUES
+    ('external'),         -- деньги за пределами сервиса: источник пополнений и получатель снятий
+    ('opening_balance');  -- начальные остатки, существовавшие до ведения книги
+
+-- Сумма дебетов каждой проводки должна совпадать с суммой кредитов.
+-- Проверка отложена до COMMIT, чтобы записи проводки можно было вставлять по одной.
+-- +goose StatementBegin
+CREATE FUNCTION check_journal_entry_balanced() RETURNS trigger AS $$
+DECLARE
+    diff NUMERIC;
+BEGIN
+    SELECT COALESCE(SUM(CASE direction WHEN 'credit' THEN amount ELSE -amount END), 0)
+    INTO diff
+    FROM postings
+    WHERE entry_id = NEW.entry_id;
+
+    IF diff <> 0 THEN
+        RAISE EXCEPTION 'journal entry % is not balanced: credits - debits = %', NEW.entry_id, diff;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE CONSTRAINT TRIGGER postings_balanced
+    AFTER INSERT ON postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION check_journal_entry_balanced();
+
+-- Книга только дополняется: исправления оформляются новыми проводками
+-- +goose StatementBegin
+CREATE FUNCTION forbid_ledger_modification() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger is append-only: % on % is not allowed', TG_OP, TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER journal_entries_append_only
+    BEFORE UPDATE OR DELETE ON journal_entries
+    FOR EACH ROW EXECUTE FUNCTION forbid_ledger_modification();
+
+CREATE TRIGGER postings_append_only
+    BEFORE UPDATE OR DELETE ON postings
+    FOR EACH ROW EXECUTE FUNCTION forbid_ledger_modification();
+
+-- Перенос существующих данных: счета пользователей, начальные остатки и история транзакций
+INSERT INTO ledger_accounts (user_id) SELECT id FROM users;
+
+-- +goose StatementBegin
+DO $$
+DECLARE
+    u RECORD;
+    t RECORD;
+    opening NUMERIC;
+    entry INT;
+BEGIN
+    -- Начальный остаток = текущий баланс за вычетом эффекта всех транзакций пользователя
+    FOR u IN SELECT id, balance, created_at FROM users ORDER BY id LOOP
+        SELECT u.balance - COALESCE(SUM(
+            CASE transaction_type
+                WHEN 'deposit' THEN amount
+                WHEN 'withdrawal' THEN -amount
+                WHEN 'transfer' THEN
+                    (CASE WHEN receiver_id = u.id THEN amount ELSE 0 END) -
+                    (CASE WHEN sender_id = u.id THEN amount ELSE 0 END)
+                ELSE 0
+            END), 0)
+        INTO opening
+        FROM transactions
+        WHERE user_id = u.id OR sender_id = u.id OR receiver_id = u.id;
+
+        IF opening > 0 THEN
+            INSERT INTO journal_entries (description, created_at)
+            VALUES ('opening balance', u.created_at)
+            RETURNING id INTO entry;
+
+            INSERT INTO postings (entry_id, account_id, direction, amount)
+            SELECT entry, id, 'debit', opening FROM ledger_accounts WHERE code = 'opening_balance';
+            INSERT INTO postings (entry_id, account_id, direction, amount)
+            SELECT entry, id, 'credit', opening FROM ledger_accounts WHERE user_id = u.id;
+        END IF;
+    END LOOP;
+
+    FOR t IN SELECT * FROM transactions ORDER BY id LOOP
+        INSERT INTO journal_entries (transaction_id, description, created_at)
+        VALUES (t.id, t.transaction_type, t.created_at)
+        RETURNING id INTO entry;
+
+        INSERT INTO postings (entry_id, account_id, direction, amount)
+        SELECT entry, id, 'debit', t.amount FROM ledger_accounts
+        WHERE CASE t.transaction_type
+            WHEN 'deposit' THEN code = 'external'
+            WHEN 'withdrawal' THEN user_id = t.user_id
+            ELSE user_id = t.sender_id
+        END;
+
+        INSERT INTO postings (entry_id, account_id, direction, amount)
+        SELECT entry, id, 'credit', t.amount FROM ledger_accounts
+        WHERE CASE t.transaction_type
+            WHEN 'deposit' THEN user_id = t.user_id
+            WHEN 'withdrawal' THEN code = 'external'
+            ELSE user_id = t.receiver_id
+        END;
+    END LOOP;
+END;
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE IF EXISTS postings;
+DROP TABLE IF EXISTS journal_entries;
+DROP TABLE IF EXISTS ledger_accounts;
+DROP FUNCTION IF EXISTS check_journal_entry_balanced();
+DROP FUNCTION IF EXISTS forbid_ledger_modification();
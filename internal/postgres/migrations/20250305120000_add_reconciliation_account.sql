@@ -0,0 +1,8 @@
+-- +goose Up
+INSERT INTO ledger_accounts (code) VALUES ('reconciliation');
+
+-- +goose Down
+-- Счёт нельзя удалить, если по нему уже есть записи: книга только дополняется
+DELETE FROM ledger_accounts
+WHERE code = 'reconciliation'
+  AND NOT EXISTS (SELECT 1 FROM postings p WHERE p.account_id = ledger_accounts.id);
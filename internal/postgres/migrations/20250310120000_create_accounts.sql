@@ -0,0 +1,98 @@
+-- +goose Up
+-- Счета пользователей в валютах ISO 4217: у пользователя не более одного счёта в каждой валюте.
+-- balance — кэш остатка соответствующего счёта главной книги
+CREATE TABLE accounts (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    currency CHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    balance NUMERIC(15,2) DEFAULT 0 NOT NULL CHECK (balance >= 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, currency)
+);
+
+-- Существующие балансы велись в рублях
+INSERT INTO accounts (user_id, currency, balance, created_at)
+SELECT id, 'RUB', balance, created_at FROM users;
+
+ALTER TABLE users DROP COLUMN balance;
+
+ALTER TABLE transactions ADD COLUMN currency CHAR(3);
+UPDATE transactions SET currency = 'RUB';
+ALTER TABLE transactions ALTER COLUMN currency SET NOT NULL;
+
+-- Каждый счёт книги ведётся в одной валюте: у пользователя — по счёту на валюту,
+-- системные счета заводятся для каждой валюты по мере надобности
+ALTER TABLE ledger_accounts ADD COLUMN currency CHAR(3);
+UPDATE ledger_accounts SET currency = 'RUB';
+ALTER TABLE ledger_accounts ALTER COLUMN currency SET NOT NULL;
+ALTER TABLE ledger_accounts DROP CONSTRAINT ledger_accounts_user_id_key;
+ALTER TABLE ledger_accounts DROP CONSTRAINT ledger_accounts_code_key;
+ALTER TABLE ledger_accounts ADD CONSTRAINT ledger_accounts_user_id_currency_key UNIQUE (user_id, currency);
+ALTER TABLE ledger_accounts ADD CONSTRAINT ledger_accounts_code_currency_key UNIQUE (code, currency);
+
+-- Проводка должна быть сбалансирована в каждой валюте отдельно
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION check_journal_entry_balanced() RETURNS trigger AS $$
+DECLARE
+    cur CHAR(3);
+    diff NUMERIC;
+BEGIN
+    SELECT a.currency, SUM(CASE p.direction WHEN 'credit' THEN p.amount ELSE -p.amount END)
+    INTO cur, diff
+    FROM postings p
+    JOIN ledger_accounts a ON a.id = p.account_id
+    WHERE p.entry_id = NEW.entry_id
+    GROUP BY a.currency
+    HAVING SUM(CASE p.direction WHEN 'credit' THEN p.amount ELSE -p.amount END) <> 0
+    LIMIT 1;
+
+    IF FOUND THEN
+        RAISE EXCEPTION 'journal entry % is not balanced in %: credits - debits = %', NEW.entry_id, cur, diff;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- Откат возможен, только пока все деньги в рублях
+-- +goose StatementBegin
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM ledger_accounts WHERE currency <> 'RUB') THEN
+        RAISE EXCEPTION 'cannot roll back multi-currency accounts: non-RUB ledger accounts exist';
+    END IF;
+END;
+$$;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION check_journal_entry_balanced() RETURNS trigger AS $$
+DECLARE
+    diff NUMERIC;
+BEGIN
+    SELECT COALESCE(SUM(CASE direction WHEN 'credit' THEN amount ELSE -amount END), 0)
+    INTO diff
+    FROM postings
+    WHERE entry_id = NEW.entry_id;
+
+    IF diff <> 0 THEN
+        RAISE EXCEPTION 'journal entry % is not balanced: credits - debits = %', NEW.entry_id, diff;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+ALTER TABLE ledger_accounts DROP CONSTRAINT ledger_accounts_code_currency_key;
+ALTER TABLE ledger_accounts DROP CONSTRAINT ledger_accounts_user_id_currency_key;
+ALTER TABLE ledger_accounts ADD CONSTRAINT ledger_accounts_code_key UNIQUE (code);
+ALTER TABLE ledger_accounts ADD CONSTRAINT ledger_accounts_user_id_key UNIQUE (user_id);
+ALTER TABLE ledger_accounts DROP COLUMN currency;
+
+ALTER TABLE transactions DROP COLUMN currency;
+
+ALTER TABLE users ADD COLUMN balance NUMERIC(15,2) DEFAULT 0 NOT NULL CHECK (balance >= 0);
+UPDATE users u SET balance = a.balance FROM accounts a WHERE a.user_id = u.id;
+
+DROP TABLE IF EXISTS accounts;
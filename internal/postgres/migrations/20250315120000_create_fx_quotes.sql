@@ -0,0 +1,28 @@
+-- +goose Up
+-- Котировки курсов: курс фиксируется на время жизни котировки и используется одним переводом
+CREATE TABLE fx_quotes (
+    id TEXT PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    from_currency CHAR(3) NOT NULL,
+    to_currency CHAR(3) NOT NULL CHECK (to_currency <> from_currency),
+    rate NUMERIC(20,10) NOT NULL CHECK (rate > 0),
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Для перевода с конвертацией получателю зачисляется receiver_amount в receiver_currency;
+-- у остальных транзакций эти колонки пусты. Каждая котировка используется не более одного раза.
+ALTER TABLE transactions
+    ADD COLUMN receiver_amount NUMERIC(15,2) CHECK (receiver_amount > 0),
+    ADD COLUMN receiver_currency CHAR(3),
+    ADD COLUMN fx_quote_id TEXT UNIQUE REFERENCES fx_quotes(id),
+    ADD CONSTRAINT transactions_receiver_amount_check
+        CHECK ((receiver_amount IS NULL) = (receiver_currency IS NULL));
+
+-- +goose Down
+ALTER TABLE transactions
+    DROP CONSTRAINT transactions_receiver_amount_check,
+    DROP COLUMN fx_quote_id,
+    DROP COLUMN receiver_currency,
+    DROP COLUMN receiver_amount;
+
+DROP TABLE IF EXISTS fx_quotes;
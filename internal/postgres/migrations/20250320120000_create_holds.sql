@@ -0,0 +1,25 @@
+-- +goose Up
+-- Холды: резервирование части баланса счёта до списания. Пока холд активен и не истёк,
+-- его сумма не входит в доступный остаток. При списании captured_amount может быть меньше
+-- суммы холда — остаток резерва освобождается.
+CREATE TABLE holds (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    currency CHAR(3) NOT NULL,
+    amount NUMERIC(15,2) NOT NULL CHECK (amount > 0),
+    captured_amount NUMERIC(15,2) CHECK (captured_amount > 0 AND captured_amount <= amount),
+    status VARCHAR(10) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'captured', 'released', 'expired')),
+    transaction_id INT REFERENCES transactions(id),
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id, currency) REFERENCES accounts(user_id, currency),
+    CHECK ((status = 'captured') = (captured_amount IS NOT NULL))
+);
+
+CREATE INDEX holds_active_idx ON holds (user_id, currency) WHERE status = 'active';
+CREATE INDEX holds_expires_at_idx ON holds (expires_at) WHERE status = 'active';
+
+-- +goose Down
+DROP TABLE IF EXISTS holds;
@@ -0,0 +1,39 @@
+-- +goose Up
+-- Запланированные переводы: разовый (recurrence пуст) или повторяющийся по правилу RRULE.
+-- next_run_at — время следующего выполнения; у завершённых и отменённых переводов он пуст.
+CREATE TABLE scheduled_transfers (
+    id SERIAL PRIMARY KEY,
+    sender_id INT NOT NULL REFERENCES users(id),
+    receiver_id INT NOT NULL REFERENCES users(id) CHECK (receiver_id <> sender_id),
+    amount NUMERIC(15,2) NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL,
+    start_at TIMESTAMP NOT NULL,
+    recurrence TEXT,
+    next_run_at TIMESTAMP,
+    status VARCHAR(10) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'paused', 'completed', 'cancelled')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (status IN ('completed', 'cancelled') OR next_run_at IS NOT NULL)
+);
+
+CREATE INDEX scheduled_transfers_due_idx ON scheduled_transfers (next_run_at) WHERE status = 'active';
+CREATE INDEX scheduled_transfers_sender_idx ON scheduled_transfers (sender_id);
+
+-- Результаты выполнения: по одной записи на каждое плановое время. Уникальность защищает
+-- от повторного выполнения одного и того же вхождения.
+CREATE TABLE scheduled_transfer_runs (
+    id SERIAL PRIMARY KEY,
+    scheduled_transfer_id INT NOT NULL REFERENCES scheduled_transfers(id),
+    scheduled_for TIMESTAMP NOT NULL,
+    status VARCHAR(10) NOT NULL CHECK (status IN ('succeeded', 'failed')),
+    transaction_id INT REFERENCES transactions(id),
+    error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (scheduled_transfer_id, scheduled_for),
+    CHECK ((status = 'succeeded') = (transaction_id IS NOT NULL))
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS scheduled_transfer_runs;
+DROP TABLE IF EXISTS scheduled_transfers;
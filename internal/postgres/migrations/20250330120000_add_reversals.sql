@@ -0,0 +1,27 @@
+-- +goose Up
+-- Сторно: компенсирующая транзакция, возвращающая деньги по переводу reversal_of.
+-- Каждую транзакцию можно сторнировать один раз.
+ALTER TABLE transactions DROP CONSTRAINT transactions_transaction_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_transaction_type_check
+    CHECK (transaction_type IN ('deposit', 'transfer', 'withdrawal', 'reversal'));
+
+ALTER TABLE transactions
+    ADD COLUMN reversal_of INT UNIQUE REFERENCES transactions(id),
+    ADD CONSTRAINT transactions_reversal_of_check
+        CHECK ((transaction_type = 'reversal') = (reversal_of IS NOT NULL));
+
+-- Транзакции неизменяемы: ошибки исправляются компенсирующими транзакциями
+CREATE TRIGGER transactions_immutable
+    BEFORE UPDATE ON transactions
+    FOR EACH ROW EXECUTE FUNCTION forbid_ledger_modification();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS transactions_immutable ON transactions;
+
+ALTER TABLE transactions
+    DROP CONSTRAINT transactions_reversal_of_check,
+    DROP COLUMN reversal_of;
+
+ALTER TABLE transactions DROP CONSTRAINT transactions_transaction_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_transaction_type_check
+    CHECK (transaction_type IN ('deposit', 'transfer', 'withdrawal'));
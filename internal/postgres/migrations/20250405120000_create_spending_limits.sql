@@ -0,0 +1,38 @@
+-- +goose Up
+-- Тариф пользователя определяет его лимиты расходов по умолчанию
+ALTER TABLE users
+    ADD COLUMN tier VARCHAR(32) NOT NULL DEFAULT 'standard'
+        CHECK (tier ~ '^[a-z0-9_-]+$');
+
+-- Лимиты исходящих операций (переводов и списаний) в валюте: максимум одной операции и суммы за
+-- календарные сутки и месяц (UTC). NULL — ограничения нет. Лимиты задаются для тарифа и, при
+-- необходимости, отдельно для пользователя: строка пользователя целиком заменяет строку его тарифа.
+CREATE TABLE tier_spending_limits (
+    tier VARCHAR(32) NOT NULL CHECK (tier ~ '^[a-z0-9_-]+$'),
+    currency CHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    per_transaction NUMERIC(15,2) CHECK (per_transaction > 0),
+    daily NUMERIC(15,2) CHECK (daily > 0),
+    monthly NUMERIC(15,2) CHECK (monthly > 0),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tier, currency)
+);
+
+CREATE TABLE user_spending_limits (
+    user_id INT NOT NULL REFERENCES users(id),
+    currency CHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    per_transaction NUMERIC(15,2) CHECK (per_transaction > 0),
+    daily NUMERIC(15,2) CHECK (daily > 0),
+    monthly NUMERIC(15,2) CHECK (monthly > 0),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, currency)
+);
+
+-- Сумма исходящих операций пользователя за период считается при каждом переводе и списании
+CREATE INDEX idx_transactions_user_outgoing ON transactions (user_id, currency, created_at)
+    WHERE transaction_type IN ('transfer', 'withdrawal');
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_transactions_user_outgoing;
+DROP TABLE IF EXISTS user_spending_limits;
+DROP TABLE IF EXISTS tier_spending_limits;
+ALTER TABLE users DROP COLUMN IF EXISTS tier;
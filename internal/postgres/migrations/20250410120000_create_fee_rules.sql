@@ -0,0 +1,26 @@
+-- +goose Up
+-- Правила комиссий за операции типа transaction_type в валюте currency; правило с tier действует
+-- только для пользователей этого тарифа и важнее общего правила. Комиссия равна flat плюс percent
+-- процентов от суммы операции, но не меньше min_fee и не больше max_fee.
+CREATE TABLE fee_rules (
+    id SERIAL PRIMARY KEY,
+    transaction_type VARCHAR(20) NOT NULL CHECK (transaction_type IN ('transfer', 'withdrawal')),
+    currency CHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    tier VARCHAR(32) CHECK (tier ~ '^[a-z0-9_-]+$'),
+    flat NUMERIC(15,2) NOT NULL DEFAULT 0 CHECK (flat >= 0),
+    percent NUMERIC(20,10) CHECK (percent > 0 AND percent <= 100),
+    min_fee NUMERIC(15,2) CHECK (min_fee >= 0),
+    max_fee NUMERIC(15,2) CHECK (max_fee >= 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (min_fee <= max_fee)
+);
+
+-- Для каждой операции, валюты и тарифа (или всех тарифов) — не более одного правила
+CREATE UNIQUE INDEX fee_rules_scope_idx ON fee_rules (transaction_type, currency, COALESCE(tier, ''));
+
+-- Комиссия, списанная с плательщика сверх суммы операции и зачисленная на системный счёт fee_revenue
+ALTER TABLE transactions ADD COLUMN fee NUMERIC(15,2) CHECK (fee > 0);
+
+-- +goose Down
+ALTER TABLE transactions DROP COLUMN IF EXISTS fee;
+DROP TABLE IF EXISTS fee_rules;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- Исходящие события предметной области (transactional outbox). Событие записывается в той же транзакции,
+-- что и операция, и публикуется ретранслятором после фиксации; published_at — время успешной публикации.
+-- user_id — ключ упорядочивания: события одного пользователя публикуются в порядке id.
+CREATE TABLE outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP
+);
+
+CREATE INDEX outbox_events_unpublished_idx ON outbox_events (id) WHERE published_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS outbox_events;
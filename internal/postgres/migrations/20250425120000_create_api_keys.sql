@@ -0,0 +1,19 @@
+-- +goose Up
+-- API-ключи сервисов. Хранится только SHA-256 ключа (key_hash) и его видимая часть (prefix) для списка ключей.
+-- Ключ действует, пока не отозван (revoked_at) и не истёк (expires_at). При ротации новый ключ ссылается
+-- на предыдущий через rotated_from, а предыдущий получает срок действия на время перехода.
+CREATE TABLE api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(20) NOT NULL,
+    key_hash BYTEA NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL CHECK (cardinality(scopes) > 0),
+    rotated_from BIGINT REFERENCES api_keys(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    last_used_at TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS api_keys;
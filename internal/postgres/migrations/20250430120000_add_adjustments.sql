@@ -0,0 +1,23 @@
+-- +goose Up
+-- Ручная корректировка баланса администратором: зачисление (receiver_id) или списание (sender_id)
+-- против системного счёта adjustments. Причина корректировки обязательна.
+ALTER TABLE transactions DROP CONSTRAINT transactions_transaction_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_transaction_type_check
+    CHECK (transaction_type IN ('deposit', 'transfer', 'withdrawal', 'reversal', 'adjustment'));
+
+ALTER TABLE transactions
+    ADD COLUMN reason TEXT,
+    ADD CONSTRAINT transactions_reason_check
+        CHECK ((transaction_type = 'adjustment') = (reason IS NOT NULL)),
+    ADD CONSTRAINT transactions_adjustment_check
+        CHECK (transaction_type <> 'adjustment' OR (sender_id IS NULL) <> (receiver_id IS NULL));
+
+-- +goose Down
+ALTER TABLE transactions
+    DROP CONSTRAINT transactions_adjustment_check,
+    DROP CONSTRAINT transactions_reason_check,
+    DROP COLUMN reason;
+
+ALTER TABLE transactions DROP CONSTRAINT transactions_transaction_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_transaction_type_check
+    CHECK (transaction_type IN ('deposit', 'transfer', 'withdrawal', 'reversal'));
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Роли пользователей в административном API: admin — полный доступ, support — просмотр счетов
+-- и транзакций и заморозка счетов
+CREATE TABLE user_roles (
+    user_id INT NOT NULL REFERENCES users(id),
+    role VARCHAR(20) NOT NULL CHECK (role IN ('admin', 'support')),
+    granted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, role)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS user_roles;
@@ -0,0 +1,41 @@
+-- +goose Up
+-- Журнал аудита операций, меняющих состояние: кто, что, откуда и с каким результатом.
+-- Тело запроса не хранится, только его SHA-256.
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor_type VARCHAR(10) NOT NULL CHECK (actor_type IN ('user', 'api_key')),
+    actor_id BIGINT NOT NULL,
+    action VARCHAR(255) NOT NULL,
+    path TEXT NOT NULL,
+    payload_hash CHAR(64) NOT NULL,
+    client_ip VARCHAR(45) NOT NULL,
+    request_id VARCHAR(128) NOT NULL,
+    status_code INT NOT NULL,
+    result VARCHAR(10) NOT NULL CHECK (result IN ('success', 'failure')),
+    error_code VARCHAR(64),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX audit_log_actor_idx ON audit_log (actor_type, actor_id, id);
+CREATE INDEX audit_log_request_id_idx ON audit_log (request_id);
+
+-- Журнал только дополняется: записи нельзя изменить или удалить, в том числе через TRUNCATE
+-- +goose StatementBegin
+CREATE FUNCTION forbid_audit_log_modification() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit log is append-only: % is not allowed', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION forbid_audit_log_modification();
+
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION forbid_audit_log_modification();
+
+-- +goose Down
+DROP TABLE IF EXISTS audit_log;
+DROP FUNCTION IF EXISTS forbid_audit_log_modification();
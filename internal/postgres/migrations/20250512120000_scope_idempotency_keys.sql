@@ -0,0 +1,14 @@
+-- +goose Up
+-- Ключ идемпотентности уникален в пределах клиента (principal) и операции: чужой клиент не может заранее
+-- занять ключ, а один и тот же ключ можно использовать для разных операций
+ALTER TABLE idempotency_keys ADD COLUMN principal VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE idempotency_keys ALTER COLUMN principal DROP DEFAULT;
+ALTER TABLE idempotency_keys DROP CONSTRAINT idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (principal, operation, key);
+
+-- +goose Down
+ALTER TABLE idempotency_keys DROP CONSTRAINT idempotency_keys_pkey;
+DELETE FROM idempotency_keys a USING idempotency_keys b
+WHERE a.key = b.key AND (a.created_at, a.principal, a.operation) > (b.created_at, b.principal, b.operation);
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (key);
+ALTER TABLE idempotency_keys DROP COLUMN principal;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Исправление сверки записывается транзакцией типа adjustment с отметкой reconciliation: она входит в историю
+-- балансов (as_of, выписки), но не в ожидаемый баланс сверки, который определяется остальной историей
+ALTER TABLE transactions
+    ADD COLUMN reconciliation BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD CONSTRAINT transactions_reconciliation_check
+        CHECK (NOT reconciliation OR transaction_type = 'adjustment');
+
+-- +goose Down
+ALTER TABLE transactions
+    DROP CONSTRAINT transactions_reconciliation_check,
+    DROP COLUMN reconciliation;
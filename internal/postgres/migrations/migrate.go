@@ -16,8 +16,9 @@ func main() {
 		log.Fatalf("ошибка загрузки конфигурации: %v", err)
 	}
 
+	// Миграции заполняют колонки TIMESTAMP временем в UTC, как и сервис (см. sessionTimeZone в пакете postgres)
 	dsn := fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=disable",
+		"postgres://%s:%s@%s:%s/%s?sslmode=disable&timezone=UTC",
 		cfg.DBUser, cfg.DBPass, cfg.DBHost, cfg.DBPort, cfg.DBName,
 	)
 
@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/outbox"
+	"github.com/jackc/pgx/v5"
+)
+
+// Класс рекомендательной блокировки ретранслятора outbox (см. scheduledTransferLockClass)
+const outboxLockClass int32 = 19
+
+// Записывает в outbox событие <тип транзакции>.completed о проведённой транзакции t — по одному на каждого
+// затронутого пользователя, чтобы у каждого была полная упорядоченная история. Вызывается в транзакции
+// операции после блокировки строк пользователей и изменения их балансов: события одного пользователя получают
+// id в порядке фиксации. В той же транзакции событие ставится и в очередь вебхуков (см. recordWebhookEvents).
+func recordTransactionEvent(ctx context.Context, tx pgx.Tx, t *Transaction) error {
+	payload, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("failed to marshal transaction event: %w", err)
+	}
+
+	var userIDs []int64
+	for _, id := range []*int64{t.UserID, t.SenderID, t.ReceiverID} {
+		if id != nil && !slices.Contains(userIDs, *id) {
+			userIDs = append(userIDs, *id)
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO outbox_events (user_id, event_type, payload)
+		SELECT unnest($1::bigint[]), $2, $3`,
+		userIDs, t.TransactionType+".completed", payload)
+	if err != nil {
+		return fmt.Errorf("failed to record outbox event: %w", err)
+	}
+	return recordWebhookEvents(ctx, tx, t)
+}
+
+// Публикует до limit неопубликованных событий outbox через publish в порядке id и отмечает опубликованные.
+// Если publish вернул ошибку, остальные события того же пользователя в этом проходе не публикуются, чтобы
+// не нарушить их порядок, а проход продолжается дальше по очереди: события пользователя с недоступным
+// получателем не задерживают события остальных. Первая ошибка публикации возвращается вместе с числом
+// опубликованных событий.
+// Одновременно события публикует только один экземпляр сервиса: проход держит рекомендательную блокировку
+// до конца транзакции, и если её держит другой экземпляр, ничего не делает.
+func (r *RepositoryImpl) RelayOutbox(ctx context.Context, limit int, publish func(ctx context.Context, e outbox.Event) error) (int, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	// После Commit откат ничего не делает
+	defer tx.Rollback(ctx)
+
+	var acquired bool
+	if err = tx.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock($1, 0)`, outboxLockClass).Scan(&acquired); err != nil {
+		return 0, fmt.Errorf("failed to lock outbox: %w", err)
+	}
+	if !acquired {
+		return 0, nil
+	}
+
+	var (
+		published  []int64
+		blocked    = []int64{}
+		publishErr error
+		after      int64
+	)
+	for len(published) < limit {
+		// Заблокированные пользователи исключаются из выборки, чтобы их события не занимали место в пачке
+		events, err := pendingOutboxEvents(ctx, tx, after, blocked, limit-len(published))
+		if err != nil {
+			return 0, err
+		}
+		if len(events) == 0 {
+			break
+		}
+		for _, e := range events {
+			after = e.ID
+			if slices.Contains(blocked, e.UserID) {
+				continue
+			}
+			if err := publish(ctx, e); err != nil {
+				blocked = append(blocked, e.UserID)
+				if publishErr == nil {
+					publishErr = fmt.Errorf("failed to publish outbox event %d: %w", e.ID, err)
+				}
+				continue
+			}
+			published = append(published, e.ID)
+		}
+	}
+
+	if len(published) > 0 {
+		_, err = tx.Exec(ctx, `UPDATE outbox_events SET published_at = LOCALTIMESTAMP WHERE id = ANY($1)`, published)
+		if err != nil {
+			return 0, fmt.Errorf("failed to mark outbox events published: %w", err)
+		}
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit outbox relay: %w", err)
+	}
+	return len(published), publishErr
+}
+
+// Возвращает до limit неопубликованных событий с id больше after, кроме событий пользователей из blocked
+func pendingOutboxEvents(ctx context.Context, tx pgx.Tx, after int64, blocked []int64, limit int) ([]outbox.Event, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT id, user_id, event_type, payload, created_at FROM outbox_events
+		WHERE published_at IS NULL AND id > $1 AND user_id <> ALL($2)
+		ORDER BY id
+		LIMIT $3`, after, blocked, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []outbox.Event
+	for rows.Next() {
+		var e outbox.Event
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Type, &e.Payload, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/EugeneKrivoshein/fin_service/internal/outbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutbox_RecordsCommittedOperations(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	sender := createFundedUser(t, r, "100.00")
+	receiver := createFundedUser(t, r, "0")
+
+	transfer, err := r.Transfer(ctx, sender, receiver, money.MustParse("40.00"), rub, nil)
+	require.NoError(t, err)
+	// Откаченная операция события не оставляет
+	_, err = r.Transfer(ctx, sender, receiver, money.MustParse("1000.00"), rub, nil)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	var publisher outbox.MemoryPublisher
+	n, err := r.RelayOutbox(ctx, 100, publisher.Publish)
+	require.NoError(t, err)
+	events := publisher.Events()
+	assert.Len(t, events, n)
+
+	// Пополнение отправителя и перевод — по событию отправителю и получателю
+	var types []string
+	var transferUsers []int64
+	for _, e := range events {
+		types = append(types, e.Type)
+		if e.Type == "transfer.completed" {
+			transferUsers = append(transferUsers, e.UserID)
+			var got Transaction
+			require.NoError(t, json.Unmarshal(e.Payload, &got))
+			assert.Equal(t, transfer.ID, got.ID)
+		}
+	}
+	assert.Equal(t, []string{"deposit.completed", "transfer.completed", "transfer.completed"}, types)
+	assert.ElementsMatch(t, []int64{sender, receiver}, transferUsers)
+
+	// Опубликованные события повторно не публикуются
+	n, err = r.RelayOutbox(ctx, 100, publisher.Publish)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestRelayOutbox_PerUserOrderOnFailure(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	failing := createFundedUser(t, r, "10.00")
+	healthy := createFundedUser(t, r, "10.00")
+	_, err := r.Deposit(ctx, failing, money.MustParse("20.00"), rub, nil)
+	require.NoError(t, err)
+	_, err = r.Deposit(ctx, healthy, money.MustParse("20.00"), rub, nil)
+	require.NoError(t, err)
+
+	// Первое событие пользователя failing не публикуется: его второе событие тоже должно подождать
+	var publisher outbox.MemoryPublisher
+	attempts := 0
+	flaky := func(ctx context.Context, e outbox.Event) error {
+		if e.UserID == failing && attempts == 0 {
+			attempts++
+			return errors.New("broker unavailable")
+		}
+		return publisher.Publish(ctx, e)
+	}
+	n, err := r.RelayOutbox(ctx, 100, flaky)
+	assert.Error(t, err)
+	assert.Equal(t, 2, n)
+	for _, e := range publisher.Events() {
+		assert.Equal(t, healthy, e.UserID)
+	}
+
+	n, err = r.RelayOutbox(ctx, 100, flaky)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	var ids []int64
+	for _, e := range publisher.Events() {
+		if e.UserID == failing {
+			ids = append(ids, e.ID)
+		}
+	}
+	require.Len(t, ids, 2)
+	assert.Less(t, ids[0], ids[1])
+}
+
+func TestRelayOutbox_FailingUserDoesNotBlockOthers(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	// События failing стоят в очереди первыми, и их больше, чем limit
+	failing := createFundedUser(t, r, "10.00")
+	for range 2 {
+		_, err := r.Deposit(ctx, failing, money.MustParse("20.00"), rub, nil)
+		require.NoError(t, err)
+	}
+	healthy := createFundedUser(t, r, "10.00")
+
+	var publisher outbox.MemoryPublisher
+	rejecting := func(ctx context.Context, e outbox.Event) error {
+		if e.UserID == failing {
+			return errors.New("broker unavailable")
+		}
+		return publisher.Publish(ctx, e)
+	}
+	n, err := r.RelayOutbox(ctx, 1, rejecting)
+	assert.Error(t, err)
+	assert.Equal(t, 1, n)
+	require.Len(t, publisher.Events(), 1)
+	assert.Equal(t, healthy, publisher.Events()[0].UserID)
+}
+
+func TestRelayOutbox_SingleRelay(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	for range 5 {
+		createFundedUser(t, r, "10.00")
+	}
+
+	var publisher outbox.MemoryPublisher
+	errs := runConcurrently(4, func(int) error {
+		_, err := r.RelayOutbox(ctx, 100, publisher.Publish)
+		return err
+	})
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	// Проходы не пересекаются: каждое событие опубликовано один раз
+	seen := map[int64]bool{}
+	for _, e := range publisher.Events() {
+		assert.False(t, seen[e.ID], "событие %d опубликовано дважды", e.ID)
+		seen[e.ID] = true
+	}
+}
@@ -0,0 +1,219 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/jackc/pgx/v5"
+)
+
+// AccountReconciliation — системный счёт, на который относятся корректировки по итогам сверки
+const AccountReconciliation = "reconciliation"
+
+// ReconciliationReason — причина транзакции adjustment, которой сверка исправляет баланс
+const ReconciliationReason = "balance reconciliation"
+
+// ReconciliationLine — пересчёт баланса одного счёта пользователя по истории транзакций.
+// Expected = Opening + эффект всех транзакций по счёту; Difference = Expected - Balance.
+type ReconciliationLine struct {
+	UserID        int64          `json:"user_id"`
+	Username      string         `json:"username"`
+	Currency      money.Currency `json:"currency" swaggertype:"string" example:"RUB"`
+	Balance       money.Amount   `json:"balance" swaggertype:"number"`
+	Opening       money.Amount   `json:"opening_balance" swaggertype:"number"`
+	Expected      money.Amount   `json:"expected_balance" swaggertype:"number"`
+	LedgerBalance money.Amount   `json:"ledger_balance" swaggertype:"number"`
+	Difference    money.Amount   `json:"difference" swaggertype:"number"`
+	Adjusted      bool           `json:"adjusted"`
+	// Транзакция adjustment, которой исправлен баланс; заполняется только при исправлении
+	TransactionID *int64 `json:"transaction_id,omitempty"`
+}
+
+// ReconciliationReport — результат сверки: в Mismatches попадают только счета с расхождением
+type ReconciliationReport struct {
+	CheckedAt       time.Time            `json:"checked_at"`
+	AccountsChecked int                  `json:"accounts_checked"`
+	Mismatches      []ReconciliationLine `json:"mismatches"`
+}
+
+// Начальные остатки берутся из проводок по счёту opening_balance: это балансы,
+// существовавшие до ведения истории (например, заданные миграцией), которых нет в transactions.
+// Исправления сверки (транзакции с отметкой reconciliation) в ожидаемый баланс не входят: он определяется
+// остальной историей.
+const reconciliationQuery = `
+	WITH opening AS (
+		SELECT a.user_id, a.currency, SUM(CASE p.direction WHEN 'credit' THEN p.amount ELSE -p.amount END) AS amount
+		FROM postings p
+		JOIN ledger_accounts a ON a.id = p.account_id AND a.user_id IS NOT NULL
+		WHERE p.entry_id IN (
+			SELECT op.entry_id
+			FROM postings op
+			JOIN ledger_accounts oa ON oa.id = op.account_id AND oa.code = 'opening_balance'
+		)
+		GROUP BY a.user_id, a.currency
+	), history AS (
+		SELECT user_id, currency, SUM(amount) AS amount
+		FROM (` + transactionEffectsSQL + `) effects
+		WHERE id NOT IN (SELECT id FROM transactions WHERE reconciliation)
+		GROUP BY user_id, currency
+	), ledger AS (
+		SELECT a.user_id, a.currency, SUM(CASE p.direction WHEN 'credit' THEN p.amount ELSE -p.amount END) AS amount
+		FROM postings p
+		JOIN ledger_accounts a ON a.id = p.account_id AND a.user_id IS NOT NULL
+		GROUP BY a.user_id, a.currency
+	)
+	SELECT
+		u.id, u.username, ac.currency, ac.balance,
+		COALESCE(o.amount, 0),
+		COALESCE(o.amount, 0) + COALESCE(h.amount, 0),
+		COALESCE(l.amount, 0)
+	FROM accounts ac
+	JOIN users u ON u.id = ac.user_id
+	LEFT JOIN opening o ON o.user_id = ac.user_id AND o.currency = ac.currency
+	LEFT JOIN history h ON h.user_id = ac.user_id AND h.currency = ac.currency
+	LEFT JOIN ledger l ON l.user_id = ac.user_id AND l.currency = ac.currency
+`
+
+func scanReconciliationLine(row pgx.Row) (*ReconciliationLine, error) {
+	var l ReconciliationLine
+	if err := row.Scan(&l.UserID, &l.Username, &l.Currency, &l.Balance, &l.Opening, &l.Expected, &l.LedgerBalance); err != nil {
+		return nil, err
+	}
+	l.Difference = l.Expected - l.Balance
+	return &l, nil
+}
+
+// Пересчитывает балансы всех счетов пользователей по истории транзакций и возвращает расхождения с accounts.balance
+func (r *RepositoryImpl) Reconcile(ctx context.Context) (*ReconciliationReport, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	report := &ReconciliationReport{Mismatches: []ReconciliationLine{}}
+	if err = tx.QueryRow(ctx, `SELECT LOCALTIMESTAMP`).Scan(&report.CheckedAt); err != nil {
+		return nil, fmt.Errorf("failed to get reconciliation time: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, reconciliationQuery+` ORDER BY u.id, ac.currency`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile balances: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		l, err := scanReconciliationLine(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reconciliation line: %w", err)
+		}
+		report.AccountsChecked++
+		if l.Difference != 0 {
+			report.Mismatches = append(report.Mismatches, *l)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// Исправляет баланс счёта пользователя в валюте по истории транзакций. Под блокировкой пользователя
+// баланс пересчитывается заново. Изменение accounts.balance записывается транзакцией типа adjustment
+// с отметкой reconciliation и причиной ReconciliationReason, поэтому баланс на момент as_of и выписки его
+// учитывают; у транзакции, как у любой другой, есть проводка через счёт reconciliation. Если книга расходится
+// и с accounts.balance, перед ней в книгу отдельной проводкой вносится изменение баланса, прошедшее мимо книги.
+// Отрицательный пересчитанный баланс не исправляется: возвращается ErrNegativeExpectedBalance.
+// Возвращает строку сверки до исправления с Adjusted = true, если что-то было изменено.
+func (r *RepositoryImpl) AdjustBalance(ctx context.Context, userID int64, currency money.Currency) (l *ReconciliationLine, err error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	users, err := lockUsers(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if users[userID] == nil {
+		return nil, ErrUserNotFound
+	}
+
+	l, err = scanReconciliationLine(tx.QueryRow(ctx, reconciliationQuery+` WHERE ac.user_id = $1 AND ac.currency = $2`, userID, currency))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCurrencyAccountNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile balance: %w", err)
+	}
+	if l.Expected < 0 {
+		return nil, ErrNegativeExpectedBalance
+	}
+
+	account, reconciliation := userAccount(userID, currency), systemAccount(AccountReconciliation, currency)
+	correct := func(transactionID *int64, description string, diff money.Amount) error {
+		postings := move(reconciliation, account, diff)
+		if diff < 0 {
+			postings = move(account, reconciliation, -diff)
+		}
+		return postEntry(ctx, tx, transactionID, description, postings...)
+	}
+
+	// Изменение accounts.balance в обход книги: книга доводится до кэшированного баланса,
+	// чтобы проводка транзакции ниже отражала ровно сумму исправления
+	if drift := l.Balance - l.LedgerBalance; drift != 0 {
+		if err = correct(nil, "reconciliation: unrecorded balance change", drift); err != nil {
+			return nil, err
+		}
+		l.Adjusted = true
+	}
+
+	var t *Transaction
+	if diff := l.Expected - l.Balance; diff != 0 {
+		amount, senderID, receiverID := diff, (*int64)(nil), &userID
+		if diff < 0 {
+			amount, senderID, receiverID = -diff, &userID, nil
+		}
+		insertQuery := `
+			INSERT INTO transactions (user_id, sender_id, receiver_id, amount, currency, transaction_type, reason, reconciliation)
+			VALUES ($1, $2, $3, $4, $5, 'adjustment', $6, TRUE)
+			RETURNING ` + transactionColumns
+		t, err = scanTransaction(tx.QueryRow(ctx, insertQuery, userID, senderID, receiverID, amount, currency, ReconciliationReason))
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert reconciliation transaction: %w", err)
+		}
+		if err = correct(&t.ID, "reconciliation adjustment", diff); err != nil {
+			return nil, err
+		}
+		l.TransactionID, l.Adjusted = &t.ID, true
+	}
+
+	// Проводки меняют и кэшированный баланс, который уже содержал изменение в обход книги,
+	// поэтому итоговый баланс выставляется явно
+	_, err = tx.Exec(ctx, `
+		UPDATE accounts SET balance = $1 WHERE user_id = $2 AND currency = $3 AND balance <> $1
+	`, l.Expected, userID, currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update balance: %w", err)
+	}
+	if t != nil {
+		if err = recordTransactionEvent(ctx, tx, t); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit balance adjustment: %w", err)
+	}
+
+	return l, nil
+}
@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcile(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	a := createFundedUser(t, r, "100.00")
+	b := createFundedUser(t, r, "0")
+	_, err := r.Transfer(ctx, a, b, money.MustParse("40.00"), rub, nil)
+	require.NoError(t, err)
+
+	// Начальные балансы из миграции и операции через API расхождений не дают
+	report, err := r.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, report.Mismatches)
+	assert.GreaterOrEqual(t, report.AccountsChecked, 2)
+
+	// Баланс изменён в обход истории транзакций
+	_, err = r.pool.Exec(ctx, `UPDATE accounts SET balance = balance + 5 WHERE user_id = $1 AND currency = $2`, a, rub)
+	require.NoError(t, err)
+
+	report, err = r.Reconcile(ctx)
+	require.NoError(t, err)
+	require.Len(t, report.Mismatches, 1)
+	l := report.Mismatches[0]
+	assert.Equal(t, a, l.UserID)
+	assert.Equal(t, money.MustParse("65.00"), l.Balance)
+	assert.Equal(t, money.MustParse("60.00"), l.Expected)
+	assert.Equal(t, money.MustParse("-5.00"), l.Difference)
+
+	adjusted, err := r.AdjustBalance(ctx, a, rub)
+	require.NoError(t, err)
+	assert.True(t, adjusted.Adjusted)
+	assert.Equal(t, money.MustParse("60.00"), balanceOf(t, r, a))
+
+	// Исправление записано транзакцией adjustment и входит в историю баланса
+	require.NotNil(t, adjusted.TransactionID)
+	page, err := r.GetTransactions(ctx, TransactionFilter{UserID: a, Type: "adjustment", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Transactions, 1)
+	fix := page.Transactions[0]
+	assert.Equal(t, *adjusted.TransactionID, fix.ID)
+	assert.Equal(t, a, *fix.SenderID)
+	assert.Equal(t, money.MustParse("5.00"), fix.Amount)
+	assert.Equal(t, ReconciliationReason, *fix.Reason)
+
+	// Книга совпадала с историей, но у транзакции исправления всё равно есть своя проводка
+	var entries int
+	var posted money.Amount
+	err = r.pool.QueryRow(ctx, `
+		SELECT COUNT(DISTINCT e.id), COALESCE(SUM(CASE p.direction WHEN 'credit' THEN p.amount ELSE -p.amount END), 0)
+		FROM journal_entries e
+		JOIN postings p ON p.entry_id = e.id
+		JOIN ledger_accounts la ON la.id = p.account_id AND la.user_id = $2
+		WHERE e.transaction_id = $1
+	`, fix.ID, a).Scan(&entries, &posted)
+	require.NoError(t, err)
+	assert.Equal(t, 1, entries)
+	assert.Equal(t, money.MustParse("-5.00"), posted)
+
+	// Баланс на момент до исправления — тот, что был на счёте, после — исправленный
+	before := fix.CreatedAt.Add(-time.Microsecond)
+	bal, err := r.GetBalance(ctx, a, rub, &before)
+	require.NoError(t, err)
+	assert.Equal(t, money.MustParse("65.00"), bal.Balance)
+	bal, err = r.GetBalance(ctx, a, rub, &fix.CreatedAt)
+	require.NoError(t, err)
+	assert.Equal(t, money.MustParse("60.00"), bal.Balance)
+
+	report, err = r.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, report.Mismatches)
+
+	ledger, err := r.VerifyLedger(ctx)
+	require.NoError(t, err)
+	assert.True(t, ledger.OK, "%+v", ledger)
+
+	// Повторное исправление ничего не меняет
+	adjusted, err = r.AdjustBalance(ctx, a, rub)
+	require.NoError(t, err)
+	assert.False(t, adjusted.Adjusted)
+
+	_, err = r.AdjustBalance(ctx, -1, rub)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestAdjustBalance_NegativeExpected(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	a := createFundedUser(t, r, "0")
+	// Снятие записано в историю, но баланс не изменён: по истории баланс отрицательный
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO transactions (user_id, amount, currency, transaction_type)
+		VALUES ($1, 10, $2, 'withdrawal')
+	`, a, rub)
+	require.NoError(t, err)
+
+	_, err = r.AdjustBalance(ctx, a, rub)
+	assert.ErrorIs(t, err, ErrNegativeExpectedBalance)
+	assert.Equal(t, money.Amount(0), balanceOf(t, r, a))
+}
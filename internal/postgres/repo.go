@@ -2,27 +2,132 @@ package postgres
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"time"
 
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/EugeneKrivoshein/fin_service/internal/outbox"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Repository interface {
-	Deposit(ctx context.Context, userID int64, amount float64) error
-	Transfer(ctx context.Context, senderID, receiverID int64, amount float64) error
-	GetTransactions(ctx context.Context, userID int64) ([]Transaction, error)
+	Deposit(ctx context.Context, userID int64, amount money.Amount, currency money.Currency, key *IdempotencyKey) (*Transaction, error)
+	Transfer(ctx context.Context, senderID, receiverID int64, amount money.Amount, currency money.Currency, key *IdempotencyKey) (*Transaction, error)
+	Withdraw(ctx context.Context, userID int64, amount money.Amount, currency money.Currency, key *IdempotencyKey) (*Transaction, error)
+	CreateFXQuote(ctx context.Context, from, to money.Currency, rate money.Rate, ttl time.Duration) (*FXQuote, error)
+	TransferFX(ctx context.Context, senderID, receiverID int64, amount money.Amount, quoteID string, key *IdempotencyKey) (*Transaction, error)
+	CreateHold(ctx context.Context, userID int64, amount money.Amount, currency money.Currency, ttl time.Duration) (*Hold, error)
+	GetHold(ctx context.Context, holdID int64) (*Hold, error)
+	CaptureHold(ctx context.Context, holdID int64, amount money.Amount, receiverID *int64, key *IdempotencyKey) (*Hold, *Transaction, error)
+	ReleaseHold(ctx context.Context, holdID int64) (*Hold, error)
+	ExpireHolds(ctx context.Context) (int64, error)
+	CreateScheduledTransfer(ctx context.Context, s *ScheduledTransfer) (*ScheduledTransfer, error)
+	GetScheduledTransfer(ctx context.Context, id int64) (*ScheduledTransfer, error)
+	ListScheduledTransfers(ctx context.Context, userID int64) ([]ScheduledTransfer, error)
+	ListDueScheduledTransfers(ctx context.Context, now time.Time, limit int) ([]ScheduledTransfer, error)
+	SetScheduledTransferStatus(ctx context.Context, id int64, status string, nextRunAt *time.Time) (*ScheduledTransfer, error)
+	RecordScheduledTransferRun(ctx context.Context, run *ScheduledTransferRun, nextRunAt *time.Time) (*ScheduledTransferRun, error)
+	ListScheduledTransferRuns(ctx context.Context, id int64, limit int) ([]ScheduledTransferRun, error)
+	WithScheduledTransferLock(ctx context.Context, id int64, fn func(ctx context.Context) error) (bool, error)
+	ReverseTransaction(ctx context.Context, transactionID int64, amount money.Amount, key *IdempotencyKey) (*Transaction, error)
+	GetTransactions(ctx context.Context, filter TransactionFilter) (*TransactionPage, error)
+	StreamStatement(ctx context.Context, userID int64, currency money.Currency, from *time.Time, to time.Time, w StatementWriter) error
+	GetBalance(ctx context.Context, userID int64, currency money.Currency, asOf *time.Time) (*Balance, error)
+	VerifyLedger(ctx context.Context) (*LedgerReport, error)
+	Reconcile(ctx context.Context) (*ReconciliationReport, error)
+	AdjustBalance(ctx context.Context, userID int64, currency money.Currency) (*ReconciliationLine, error)
+	ListFeeRules(ctx context.Context) ([]FeeRule, error)
+	CreateFeeRule(ctx context.Context, rule *FeeRule) (*FeeRule, error)
+	DeleteFeeRule(ctx context.Context, id int64) error
+	GetSpendingStatus(ctx context.Context, userID int64, currency money.Currency) (*SpendingStatus, error)
+	ListTierSpendingLimits(ctx context.Context, tier string) ([]SpendingLimits, error)
+	SetTierSpendingLimits(ctx context.Context, tier string, limits SpendingLimits) (*SpendingLimits, error)
+	SetUserSpendingLimits(ctx context.Context, userID int64, limits SpendingLimits) (*SpendingLimits, error)
+	DeleteUserSpendingLimits(ctx context.Context, userID int64, currency money.Currency) error
+	CreateWebhookSubscription(ctx context.Context, s *WebhookSubscription) (*WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx context.Context) ([]WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, id int64) error
+	EnqueueWebhookEvent(ctx context.Context, eventType string, payload []byte) (int64, error)
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]WebhookMessage, error)
+	RecordWebhookAttempt(ctx context.Context, deliveryID int64, attempt WebhookAttempt) error
+	ListWebhookDeliveries(ctx context.Context, subscriptionID int64, status string, limit int) ([]WebhookDelivery, error)
+	RedeliverWebhook(ctx context.Context, deliveryID int64) (*WebhookDelivery, error)
+	RelayOutbox(ctx context.Context, limit int, publish func(ctx context.Context, e outbox.Event) error) (int, error)
+	CreateAPIKey(ctx context.Context, k *APIKey, hash []byte) (*APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]APIKey, error)
+	GetActiveAPIKey(ctx context.Context, hash []byte) (*APIKey, error)
+	RotateAPIKey(ctx context.Context, id int64, prefix string, hash []byte, grace time.Duration) (*APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64) (*APIKey, error)
+	CreateAdjustment(ctx context.Context, userID int64, amount money.Amount, currency money.Currency, reason string, key *IdempotencyKey) (*Transaction, error)
+	GetUserRoles(ctx context.Context, userID int64) ([]string, error)
+	GrantRole(ctx context.Context, userID int64, role string) error
+	RevokeRole(ctx context.Context, userID int64, role string) error
+	RecordAudit(ctx context.Context, e *AuditEntry) error
+	ListAuditLog(ctx context.Context, filter AuditFilter) (*AuditPage, error)
+
+	CreateUser(ctx context.Context, username string) (*User, error)
+	GetUser(ctx context.Context, userID int64) (*User, error)
+	GetUserByUsername(ctx context.Context, username string) (*User, error)
+	ListUsers(ctx context.Context, limit, offset int) ([]User, error)
+	SetUserStatus(ctx context.Context, userID int64, status string) (*User, error)
+	SetUserTier(ctx context.Context, userID int64, tier string) (*User, error)
+	OpenAccount(ctx context.Context, userID int64, currency money.Currency) (*Account, error)
+	ListAccounts(ctx context.Context, userID int64) ([]Account, error)
 }
 
 type Transaction struct {
-	ID              int64     `json:"id"`
-	UserID          *int64    `json:"user_id,omitempty"`
-	SenderID        *int64    `json:"sender_id,omitempty"`
-	ReceiverID      *int64    `json:"receiver_id,omitempty"`
-	Amount          float64   `json:"amount"`
-	TransactionType string    `json:"transaction_type"`
-	CreatedAt       time.Time `json:"created_at"`
+	ID              int64          `json:"id"`
+	UserID          *int64         `json:"user_id,omitempty"`
+	SenderID        *int64         `json:"sender_id,omitempty"`
+	ReceiverID      *int64         `json:"receiver_id,omitempty"`
+	Amount          money.Amount   `json:"amount" swaggertype:"number" example:"100.50"`
+	Currency        money.Currency `json:"currency" swaggertype:"string" example:"RUB"`
+	TransactionType string         `json:"transaction_type"`
+	CreatedAt       time.Time      `json:"created_at"`
+
+	// Комиссия, списанная с плательщика сверх Amount в той же валюте (см. FeeRule)
+	Fee *money.Amount `json:"fee,omitempty" swaggertype:"number" example:"15.00"`
+
+	// Заполнены только у переводов с конвертацией: получателю зачислено ReceiverAmount в ReceiverCurrency
+	// по курсу котировки FXQuoteID
+	ReceiverAmount   *money.Amount   `json:"receiver_amount,omitempty" swaggertype:"number" example:"1.09"`
+	ReceiverCurrency *money.Currency `json:"receiver_currency,omitempty" swaggertype:"string" example:"USD"`
+	FXQuoteID        *string         `json:"fx_quote_id,omitempty"`
+
+	// Связь сторно с исходным переводом: у сторно заполнен ReversalOf, у сторнированного перевода — ReversedBy
+	// (ReversedBy заполняется только в истории транзакций)
+	ReversalOf *int64 `json:"reversal_of,omitempty"`
+	ReversedBy *int64 `json:"reversed_by,omitempty"`
+
+	// Причина ручной корректировки баланса; заполнена только у транзакций типа adjustment
+	Reason *string `json:"reason,omitempty" example:"compensation for incident #42"`
+}
+
+// Общие колонки для выборки транзакций, порядок совпадает с scanTransaction
+const transactionColumns = `id, user_id, sender_id, receiver_id, amount, currency, transaction_type, created_at,
+	receiver_amount, receiver_currency, fx_quote_id, reversal_of, fee, reason`
+
+// querier — общий интерфейс pgxpool.Pool и pgx.Tx
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func scanTransaction(row pgx.Row) (*Transaction, error) {
+	var t Transaction
+	err := row.Scan(&t.ID, &t.UserID, &t.SenderID, &t.ReceiverID, &t.Amount, &t.Currency, &t.TransactionType, &t.CreatedAt,
+		&t.ReceiverAmount, &t.ReceiverCurrency, &t.FXQuoteID, &t.ReversalOf, &t.Fee, &t.Reason)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func getTransaction(ctx context.Context, q querier, id int64) (*Transaction, error) {
+	return scanTransaction(q.QueryRow(ctx, `SELECT `+transactionColumns+` FROM transactions WHERE id = $1`, id))
 }
 
 type RepositoryImpl struct {
@@ -33,11 +138,21 @@ func NewRepository(pool *pgxpool.Pool) *RepositoryImpl {
 	return &RepositoryImpl{pool: pool}
 }
 
-// Пополняет баланс пользователя и создаёт транзакцию типа "deposit"
-func (r *RepositoryImpl) Deposit(ctx context.Context, userID int64, amount float64) (err error) {
+// Пополняет счёт пользователя в валюте и создаёт транзакцию типа "deposit".
+// Если счёта в этой валюте ещё нет, он открывается.
+// При повторе с тем же ключом идемпотентности возвращает ранее созданную транзакцию.
+func (r *RepositoryImpl) Deposit(ctx context.Context, userID int64, amount money.Amount, currency money.Currency, key *IdempotencyKey) (t *Transaction, err error) {
+	err = withRetry(ctx, func() error {
+		t, err = r.deposit(ctx, userID, amount, currency, key)
+		return err
+	})
+	return t, err
+}
+
+func (r *RepositoryImpl) deposit(ctx context.Context, userID int64, amount money.Amount, currency money.Currency, key *IdempotencyKey) (t *Transaction, err error) {
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	// Откат транзакции при ошибке
 	defer func() {
@@ -46,36 +161,72 @@ func (r *RepositoryImpl) Deposit(ctx context.Context, userID int64, amount float
 		}
 	}()
 
-	updateQuery := `UPDATE users SET balance = balance + $1 WHERE id = $2`
-	ct, err := tx.Exec(ctx, updateQuery, amount, userID)
+	// Повтор запроса с тем же ключом: возвращаем сохранённый результат
+	replay, err := claimIdempotencyKey(ctx, tx, key, "deposit")
 	if err != nil {
-		return fmt.Errorf("failed to update balance: %w", err)
+		return nil, err
+	}
+	if replay != nil {
+		tx.Rollback(ctx)
+		return replay, nil
 	}
-	if ct.RowsAffected() == 0 {
-		return errors.New("user not found")
+
+	// Замороженные и закрытые счета не пополняются
+	if _, err = lockActiveUser(ctx, tx, userID, ErrUserNotFound); err != nil {
+		return nil, err
+	}
+	if _, _, err = openAccount(ctx, tx, userID, currency); err != nil {
+		return nil, err
 	}
 
 	insertQuery := `
-		INSERT INTO transactions (user_id, amount, transaction_type)
-		VALUES ($1, $2, 'deposit')
-	`
-	_, err = tx.Exec(ctx, insertQuery, userID, amount)
+		INSERT INTO transactions (user_id, amount, currency, transaction_type)
+		VALUES ($1, $2, $3, 'deposit')
+		RETURNING ` + transactionColumns
+	t, err = scanTransaction(tx.QueryRow(ctx, insertQuery, userID, amount, currency))
 	if err != nil {
-		return fmt.Errorf("failed to insert deposit transaction: %w", err)
+		return nil, fmt.Errorf("failed to insert deposit transaction: %w", err)
+	}
+
+	err = postEntry(ctx, tx, &t.ID, "deposit",
+		move(systemAccount(AccountExternal, currency), userAccount(userID, currency), amount)...)
+	if err != nil {
+		return nil, err
+	}
+	if err = recordTransactionEvent(ctx, tx, t); err != nil {
+		return nil, err
+	}
+
+	if err = completeIdempotencyKey(ctx, tx, key, "deposit", t.ID); err != nil {
+		return nil, err
 	}
 
 	if err = tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit deposit transaction: %w", err)
+		return nil, fmt.Errorf("failed to commit deposit transaction: %w", err)
 	}
 
-	return nil
+	return t, nil
+}
+
+// Переводит деньги от одного пользователя к другому в одной валюте. Валюты не смешиваются:
+// если у получателя нет счёта в валюте перевода, возвращается ErrCurrencyMismatch.
+// Списать можно только доступный остаток — баланс за вычетом действующих холдов.
+// Строки обоих пользователей блокируются в порядке id (см. lockUsers), поэтому встречные
+// переводы не взаимоблокируются, а проверка остатка не может устареть до списания.
+// Если Postgres всё же прервал транзакцию из-за конфликта, она повторяется с задержкой.
+// При повторе с тем же ключом идемпотентности возвращает ранее созданную транзакцию.
+func (r *RepositoryImpl) Transfer(ctx context.Context, senderID, receiverID int64, amount money.Amount, currency money.Currency, key *IdempotencyKey) (t *Transaction, err error) {
+	err = withRetry(ctx, func() error {
+		t, err = r.transfer(ctx, senderID, receiverID, amount, currency, key)
+		return err
+	})
+	return t, err
 }
 
-// Переводит деньги от одного пользователя к другому
-func (r *RepositoryImpl) Transfer(ctx context.Context, senderID, receiverID int64, amount float64) (err error) {
+func (r *RepositoryImpl) transfer(ctx context.Context, senderID, receiverID int64, amount money.Amount, currency money.Currency, key *IdempotencyKey) (t *Transaction, err error) {
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() {
 		if err != nil {
@@ -83,77 +234,169 @@ func (r *RepositoryImpl) Transfer(ctx context.Context, senderID, receiverID int6
 		}
 	}()
 
-	// Проверяем, что у отправителя достаточно средств
-	var senderBalance float64
-	err = tx.QueryRow(ctx, `SELECT balance FROM users WHERE id = $1`, senderID).Scan(&senderBalance)
+	// Повтор запроса с тем же ключом: возвращаем сохранённый результат
+	replay, err := claimIdempotencyKey(ctx, tx, key, "transfer")
 	if err != nil {
-		return fmt.Errorf("failed to get sender balance: %w", err)
+		return nil, err
+	}
+	if replay != nil {
+		tx.Rollback(ctx)
+		return replay, nil
+	}
+
+	if t, err = executeTransfer(ctx, tx, senderID, receiverID, amount, currency); err != nil {
+		return nil, err
+	}
+
+	if err = completeIdempotencyKey(ctx, tx, key, "transfer", t.ID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transfer transaction: %w", err)
 	}
-	if senderBalance < amount {
-		return errors.New("insufficient funds")
+
+	return t, nil
+}
+
+// Списывает деньги со счёта пользователя в валюте и создаёт транзакцию типа "withdrawal".
+// Списать можно только доступный остаток — баланс за вычетом действующих холдов.
+// Строка пользователя блокируется до конца транзакции (см. lockActiveUser), поэтому
+// проверка остатка и списание атомарны относительно параллельных операций.
+func (r *RepositoryImpl) Withdraw(ctx context.Context, userID int64, amount money.Amount, currency money.Currency, key *IdempotencyKey) (t *Transaction, err error) {
+	err = withRetry(ctx, func() error {
+		t, err = r.withdraw(ctx, userID, amount, currency, key)
+		return err
+	})
+	return t, err
+}
+
+func (r *RepositoryImpl) withdraw(ctx context.Context, userID int64, amount money.Amount, currency money.Currency, key *IdempotencyKey) (t *Transaction, err error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
 	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
 
-	updateSenderQuery := `UPDATE users SET balance = balance - $1 WHERE id = $2`
-	ct, err := tx.Exec(ctx, updateSenderQuery, amount, senderID)
+	// Повтор запроса с тем же ключом: возвращаем сохранённый результат
+	replay, err := claimIdempotencyKey(ctx, tx, key, "withdrawal")
 	if err != nil {
-		return fmt.Errorf("failed to update sender balance: %w", err)
+		return nil, err
+	}
+	if replay != nil {
+		tx.Rollback(ctx)
+		return replay, nil
 	}
-	if ct.RowsAffected() == 0 {
-		return errors.New("sender not found")
+
+	if t, err = executeWithdrawal(ctx, tx, userID, amount, currency); err != nil {
+		return nil, err
+	}
+
+	if err = completeIdempotencyKey(ctx, tx, key, "withdrawal", t.ID); err != nil {
+		return nil, err
 	}
 
-	updateReceiverQuery := `UPDATE users SET balance = balance + $1 WHERE id = $2`
-	ct, err = tx.Exec(ctx, updateReceiverQuery, amount, receiverID)
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit withdrawal transaction: %w", err)
+	}
+
+	return t, nil
+}
+
+// Выполняет перевод в рамках открытой транзакции: блокирует обоих пользователей, проверяет,
+// что у получателя есть счёт в валюте перевода, доступного остатка отправителя хватает на перевод
+// и комиссию, а перевод укладывается в его лимиты расходов, и создаёт транзакцию с проводкой. Используется переводом и списанием холда.
+func executeTransfer(ctx context.Context, tx pgx.Tx, senderID, receiverID int64, amount money.Amount, currency money.Currency) (*Transaction, error) {
+	users, err := lockUsers(ctx, tx, senderID, receiverID)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkActive(users[senderID], ErrSenderNotFound); err != nil {
+		return nil, err
+	}
+	if err = checkActive(users[receiverID], ErrReceiverNotFound); err != nil {
+		return nil, err
+	}
+	receiverAccount, err := getAccount(ctx, tx, receiverID, currency)
 	if err != nil {
-		return fmt.Errorf("failed to update receiver balance: %w", err)
+		return nil, err
 	}
-	if ct.RowsAffected() == 0 {
-		return errors.New("receiver not found")
+	if receiverAccount == nil {
+		return nil, ErrCurrencyMismatch
+	}
+	fee, err := calculateFee(ctx, tx, users[senderID], "transfer", currency, amount)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkAvailable(ctx, tx, senderID, currency, amount+fee); err != nil {
+		return nil, err
+	}
+	if err = checkSpendingLimits(ctx, tx, users[senderID], currency, amount); err != nil {
+		return nil, err
 	}
 
 	insertQuery := `
-		INSERT INTO transactions (user_id, sender_id, receiver_id, amount, transaction_type)
-		VALUES ($1, $2, $3, $4, 'transfer')
-	`
-	_, err = tx.Exec(ctx, insertQuery, senderID, senderID, receiverID, amount)
+		INSERT INTO transactions (user_id, sender_id, receiver_id, amount, currency, transaction_type, fee)
+		VALUES ($1, $2, $3, $4, $5, 'transfer', NULLIF($6::numeric, 0))
+		RETURNING ` + transactionColumns
+	t, err := scanTransaction(tx.QueryRow(ctx, insertQuery, senderID, senderID, receiverID, amount, currency, fee))
 	if err != nil {
-		return fmt.Errorf("failed to insert transfer transaction: %w", err)
+		return nil, fmt.Errorf("failed to insert transfer transaction: %w", err)
 	}
 
-	if err = tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit transfer transaction: %w", err)
+	postings := append(
+		move(userAccount(senderID, currency), userAccount(receiverID, currency), amount),
+		feePostings(senderID, currency, fee)...,
+	)
+	if err = postEntry(ctx, tx, &t.ID, "transfer", postings...); err != nil {
+		return nil, err
 	}
-
-	return nil
+	if err = recordTransactionEvent(ctx, tx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
 }
 
-// Получает 10 последних транзакций для указанного пользователя
-func (r *RepositoryImpl) GetTransactions(ctx context.Context, userID int64) ([]Transaction, error) {
-	query := `
-		SELECT id, user_id, sender_id, receiver_id, amount, transaction_type, created_at
-		FROM transactions
-		WHERE user_id = $1 OR sender_id = $1 OR receiver_id = $1
-		ORDER BY created_at DESC
-		LIMIT 10
-	`
-	rows, err := r.pool.Query(ctx, query, userID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query transactions: %w", err)
-	}
-	defer rows.Close()
-
-	var transactions []Transaction
-	for rows.Next() {
-		var t Transaction
-		err := rows.Scan(&t.ID, &t.UserID, &t.SenderID, &t.ReceiverID, &t.Amount, &t.TransactionType, &t.CreatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan transaction: %w", err)
-		}
-		transactions = append(transactions, t)
+// Выполняет списание в рамках открытой транзакции: блокирует пользователя, проверяет доступный
+// остаток (с учётом комиссии) и лимиты расходов и создаёт транзакцию с проводкой. Используется списанием и списанием холда.
+func executeWithdrawal(ctx context.Context, tx pgx.Tx, userID int64, amount money.Amount, currency money.Currency) (*Transaction, error) {
+	u, err := lockActiveUser(ctx, tx, userID, ErrUserNotFound)
+	if err != nil {
+		return nil, err
+	}
+	fee, err := calculateFee(ctx, tx, u, "withdrawal", currency, amount)
+	if err != nil {
+		return nil, err
 	}
-	if err = rows.Err(); err != nil {
+	if err = checkAvailable(ctx, tx, userID, currency, amount+fee); err != nil {
 		return nil, err
 	}
+	if err = checkSpendingLimits(ctx, tx, u, currency, amount); err != nil {
+		return nil, err
+	}
+
+	insertQuery := `
+		INSERT INTO transactions (user_id, amount, currency, transaction_type, fee)
+		VALUES ($1, $2, $3, 'withdrawal', NULLIF($4::numeric, 0))
+		RETURNING ` + transactionColumns
+	t, err := scanTransaction(tx.QueryRow(ctx, insertQuery, userID, amount, currency, fee))
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert withdrawal transaction: %w", err)
+	}
 
-	return transactions, nil
+	postings := append(
+		move(userAccount(userID, currency), systemAccount(AccountExternal, currency), amount),
+		feePostings(userID, currency, fee)...,
+	)
+	if err = postEntry(ctx, tx, &t.ID, "withdrawal", postings...); err != nil {
+		return nil, err
+	}
+	if err = recordTransactionEvent(ctx, tx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
 }
@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Параметры повтора транзакций, прерванных из-за конфликта сериализации или взаимоблокировки
+const (
+	maxTxAttempts  = 5
+	retryBaseDelay = 10 * time.Millisecond
+	retryMaxDelay  = 500 * time.Millisecond
+)
+
+// Коды ошибок Postgres, после которых транзакцию безопасно выполнить заново
+const (
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+	pgNumericOverflow      = "22003"
+)
+
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == pgSerializationFailure || pgErr.Code == pgDeadlockDetected
+}
+
+// Переполнение NUMERIC(15,2) — например, баланс, который после операции не помещается в колонку, —
+// превращается в ошибку предметной области money.ErrAmountOutOfRange
+func wrapNumericOverflow(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgNumericOverflow {
+		return fmt.Errorf("%w: %w", money.ErrAmountOutOfRange, err)
+	}
+	return err
+}
+
+// Выполняет fn и повторяет её с экспоненциальной задержкой и джиттером,
+// если транзакция была прервана из-за конфликта сериализации или взаимоблокировки.
+// fn должна целиком открывать и завершать собственную транзакцию.
+func withRetry(ctx context.Context, fn func() error) error {
+	delay := retryBaseDelay
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !isRetryable(err) || attempt == maxTxAttempts {
+			return wrapNumericOverflow(err)
+		}
+
+		// Полный джиттер: разводит во времени повторы столкнувшихся транзакций
+		sleep := time.Duration(rand.Int64N(int64(delay))) + time.Millisecond
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(sleep):
+		}
+		delay = min(delay*2, retryMaxDelay)
+	}
+}
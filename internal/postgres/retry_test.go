@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRetry_RetriesDeadlocks(t *testing.T) {
+	calls := 0
+	err := withRetry(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return fmt.Errorf("failed to update sender balance: %w", &pgconn.PgError{Code: pgDeadlockDetected})
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestWithRetry_GivesUp(t *testing.T) {
+	calls := 0
+	err := withRetry(context.Background(), func() error {
+		calls++
+		return &pgconn.PgError{Code: pgSerializationFailure}
+	})
+
+	assert.True(t, isRetryable(err))
+	assert.Equal(t, maxTxAttempts, calls)
+}
+
+func TestWithRetry_DoesNotRetryDomainErrors(t *testing.T) {
+	calls := 0
+	err := withRetry(context.Background(), func() error {
+		calls++
+		return ErrInsufficientFunds
+	})
+
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+	assert.Equal(t, 1, calls)
+}
+
+func TestWithRetry_NumericOverflow(t *testing.T) {
+	err := withRetry(context.Background(), func() error {
+		return fmt.Errorf("failed to update balance: %w", &pgconn.PgError{Code: pgNumericOverflow})
+	})
+
+	assert.ErrorIs(t, err, money.ErrAmountOutOfRange)
+}
+
+func TestWithRetry_StopsOnContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := withRetry(ctx, func() error {
+		return &pgconn.PgError{Code: pgDeadlockDetected}
+	})
+
+	assert.True(t, errors.Is(err, context.Canceled))
+}
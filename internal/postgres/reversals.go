@@ -0,0 +1,172 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Сторнирует перевод: создаёт связанную с ним компенсирующую транзакцию типа "reversal", которая
+// возвращает amount (ноль — всю сумму) от получателя перевода отправителю. Исходная транзакция не меняется.
+// Каждый перевод можно сторнировать один раз; перевод с конвертацией — только полностью,
+// по курсу исходного перевода. У получателя должно быть достаточно доступных средств.
+// Сторно разрешено и по замороженным счетам, но не по закрытым.
+// При повторе с тем же ключом идемпотентности возвращает ранее созданную транзакцию.
+func (r *RepositoryImpl) ReverseTransaction(ctx context.Context, transactionID int64, amount money.Amount, key *IdempotencyKey) (t *Transaction, err error) {
+	err = withRetry(ctx, func() error {
+		t, err = r.reverseTransaction(ctx, transactionID, amount, key)
+		return err
+	})
+	return t, err
+}
+
+func (r *RepositoryImpl) reverseTransaction(ctx context.Context, transactionID int64, amount money.Amount, key *IdempotencyKey) (t *Transaction, err error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	// Повтор запроса с тем же ключом: возвращаем сохранённый результат
+	replay, err := claimIdempotencyKey(ctx, tx, key, "reversal")
+	if err != nil {
+		return nil, err
+	}
+	if replay != nil {
+		tx.Rollback(ctx)
+		return replay, nil
+	}
+
+	// Блокировка исходной транзакции сериализует конкурентные сторно одного перевода
+	original, err := scanTransaction(tx.QueryRow(ctx,
+		`SELECT `+transactionColumns+` FROM transactions WHERE id = $1 FOR UPDATE`, transactionID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock transaction: %w", err)
+	}
+	if original.TransactionType != "transfer" {
+		return nil, ErrNotReversible
+	}
+	if amount == 0 {
+		amount = original.Amount
+	}
+	if amount > original.Amount {
+		return nil, ErrReversalExceedsAmount
+	}
+	if err = original.Currency.CheckAmount(amount); err != nil {
+		return nil, err
+	}
+
+	// Получатель исходного перевода возвращает деньги в той валюте, в которой их получил
+	senderID, receiverID := *original.ReceiverID, *original.SenderID
+	debitCurrency, debitAmount := original.Currency, amount
+	var creditAmount *money.Amount
+	var creditCurrency *money.Currency
+	if original.ReceiverAmount != nil {
+		if amount != original.Amount {
+			return nil, ErrPartialFXReversal
+		}
+		debitCurrency, debitAmount = *original.ReceiverCurrency, *original.ReceiverAmount
+		creditAmount, creditCurrency = &original.Amount, &original.Currency
+	}
+
+	users, err := lockUsers(ctx, tx, senderID, receiverID)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range []int64{senderID, receiverID} {
+		if users[id] == nil {
+			return nil, ErrUserNotFound
+		}
+		if users[id].Status == UserStatusClosed {
+			return nil, ErrAccountClosed
+		}
+	}
+	if err = checkAvailable(ctx, tx, senderID, debitCurrency, debitAmount); err != nil {
+		return nil, err
+	}
+
+	insertQuery := `
+		INSERT INTO transactions (
+			user_id, sender_id, receiver_id, amount, currency, transaction_type,
+			receiver_amount, receiver_currency, reversal_of
+		)
+		VALUES ($1, $2, $3, $4, $5, 'reversal', $6, $7, $8)
+		RETURNING ` + transactionColumns
+	t, err = scanTransaction(tx.QueryRow(ctx, insertQuery,
+		senderID, senderID, receiverID, debitAmount, debitCurrency, creditAmount, creditCurrency, original.ID))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrAlreadyReversed
+		}
+		return nil, fmt.Errorf("failed to insert reversal transaction: %w", err)
+	}
+
+	postings := move(userAccount(senderID, debitCurrency), userAccount(receiverID, debitCurrency), debitAmount)
+	if creditAmount != nil {
+		// Обратная конвертация проходит через системный счёт fx, как и исходный перевод
+		postings = append(
+			move(userAccount(senderID, debitCurrency), systemAccount(AccountFX, debitCurrency), debitAmount),
+			move(systemAccount(AccountFX, *creditCurrency), userAccount(receiverID, *creditCurrency), *creditAmount)...,
+		)
+	}
+	if err = postEntry(ctx, tx, &t.ID, "reversal", postings...); err != nil {
+		return nil, err
+	}
+	if err = recordTransactionEvent(ctx, tx, t); err != nil {
+		return nil, err
+	}
+
+	if err = completeIdempotencyKey(ctx, tx, key, "reversal", t.ID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit reversal transaction: %w", err)
+	}
+
+	return t, nil
+}
+
+// Заполняет ReversedBy у сторнированных транзакций
+func loadReversals(ctx context.Context, q querier, transactions []Transaction) error {
+	ids := make([]int64, len(transactions))
+	for i, t := range transactions {
+		ids[i] = t.ID
+	}
+	rows, err := q.Query(ctx, `SELECT reversal_of, id FROM transactions WHERE reversal_of = ANY($1)`, ids)
+	if err != nil {
+		return fmt.Errorf("failed to query reversals: %w", err)
+	}
+	defer rows.Close()
+
+	reversedBy := make(map[int64]int64)
+	for rows.Next() {
+		var originalID, reversalID int64
+		if err := rows.Scan(&originalID, &reversalID); err != nil {
+			return fmt.Errorf("failed to scan reversal: %w", err)
+		}
+		reversedBy[originalID] = reversalID
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range transactions {
+		if id, ok := reversedBy[transactions[i].ID]; ok {
+			transactions[i].ReversedBy = &id
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReverseTransaction(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	sender := createFundedUser(t, r, "1000.00")
+	receiver := createFundedUser(t, r, "0")
+
+	transfer, err := r.Transfer(ctx, sender, receiver, money.MustParse("300.00"), rub, nil)
+	require.NoError(t, err)
+
+	_, err = r.ReverseTransaction(ctx, transfer.ID, money.MustParse("300.01"), nil)
+	assert.ErrorIs(t, err, ErrReversalExceedsAmount)
+
+	// Частичное сторно возвращает часть суммы отправителю
+	reversal, err := r.ReverseTransaction(ctx, transfer.ID, money.MustParse("100.00"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "reversal", reversal.TransactionType)
+	assert.Equal(t, transfer.ID, *reversal.ReversalOf)
+	assert.Equal(t, receiver, *reversal.SenderID)
+	assert.Equal(t, sender, *reversal.ReceiverID)
+	assert.Equal(t, money.MustParse("800.00"), balanceOf(t, r, sender))
+	assert.Equal(t, money.MustParse("200.00"), balanceOf(t, r, receiver))
+
+	// Повторное сторно запрещено
+	_, err = r.ReverseTransaction(ctx, transfer.ID, 0, nil)
+	assert.ErrorIs(t, err, ErrAlreadyReversed)
+	_, err = r.ReverseTransaction(ctx, reversal.ID, 0, nil)
+	assert.ErrorIs(t, err, ErrNotReversible)
+	_, err = r.ReverseTransaction(ctx, reversal.ID+100, 0, nil)
+	assert.ErrorIs(t, err, ErrTransactionNotFound)
+
+	// Связь видна в истории, исходная транзакция не изменилась
+	page, err := r.GetTransactions(ctx, TransactionFilter{UserID: sender, Limit: 10})
+	require.NoError(t, err)
+	var original *Transaction
+	for i := range page.Transactions {
+		if page.Transactions[i].ID == transfer.ID {
+			original = &page.Transactions[i]
+		}
+	}
+	require.NotNil(t, original)
+	assert.Equal(t, reversal.ID, *original.ReversedBy)
+	assert.Equal(t, transfer.Amount, original.Amount)
+
+	_, err = r.pool.Exec(ctx, `UPDATE transactions SET amount = 1 WHERE id = $1`, transfer.ID)
+	assert.Error(t, err, "transactions must be immutable")
+
+	report, err := r.VerifyLedger(ctx)
+	require.NoError(t, err)
+	assert.True(t, report.OK, "%+v", report)
+	reconciliation, err := r.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, reconciliation.Mismatches)
+}
+
+func TestReverseTransaction_FX(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+	usd := money.Currency("USD")
+
+	sender := createFundedUser(t, r, "1000.00")
+	receiver := createFundedUser(t, r, "0")
+	_, err := r.OpenAccount(ctx, receiver, usd)
+	require.NoError(t, err)
+
+	quote, err := r.CreateFXQuote(ctx, rub, usd, money.MustParseRate("0.0108"), time.Minute)
+	require.NoError(t, err)
+	transfer, err := r.TransferFX(ctx, sender, receiver, money.MustParse("500.00"), quote.ID, nil)
+	require.NoError(t, err)
+
+	_, err = r.ReverseTransaction(ctx, transfer.ID, money.MustParse("100.00"), nil)
+	assert.ErrorIs(t, err, ErrPartialFXReversal)
+
+	// Полное сторно возвращает ровно исходные суммы в обеих валютах
+	reversal, err := r.ReverseTransaction(ctx, transfer.ID, 0, nil)
+	require.NoError(t, err)
+	assert.Equal(t, usd, reversal.Currency)
+	assert.Equal(t, money.MustParse("5.40"), reversal.Amount)
+	assert.Equal(t, money.MustParse("500.00"), *reversal.ReceiverAmount)
+	assert.Equal(t, money.MustParse("1000.00"), balanceOf(t, r, sender))
+
+	report, err := r.VerifyLedger(ctx)
+	require.NoError(t, err)
+	assert.True(t, report.OK, "%+v", report)
+}
+
+func TestReverseTransaction_InsufficientFunds(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	sender := createFundedUser(t, r, "100.00")
+	receiver := createFundedUser(t, r, "0")
+
+	transfer, err := r.Transfer(ctx, sender, receiver, money.MustParse("100.00"), rub, nil)
+	require.NoError(t, err)
+	_, err = r.Withdraw(ctx, receiver, money.MustParse("60.00"), rub, nil)
+	require.NoError(t, err)
+
+	_, err = r.ReverseTransaction(ctx, transfer.ID, 0, nil)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+	_, err = r.ReverseTransaction(ctx, transfer.ID, money.MustParse("40.00"), nil)
+	assert.NoError(t, err)
+}
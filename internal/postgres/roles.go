@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Роли пользователей в административном API
+const (
+	RoleAdmin   = "admin"   // полный доступ к административному API
+	RoleSupport = "support" // просмотр счетов и транзакций, заморозка счетов
+)
+
+// Roles — все известные роли
+var Roles = []string{RoleAdmin, RoleSupport}
+
+// Возвращает роли пользователя в алфавитном порядке; у обычного пользователя ролей нет
+func (r *RepositoryImpl) GetUserRoles(ctx context.Context, userID int64) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `SELECT role FROM user_roles WHERE user_id = $1 ORDER BY role`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user roles: %w", err)
+	}
+	defer rows.Close()
+
+	roles := []string{}
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, fmt.Errorf("failed to scan user role: %w", err)
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+// Выдаёт пользователю роль; повторная выдача ничего не меняет
+func (r *RepositoryImpl) GrantRole(ctx context.Context, userID int64, role string) error {
+	query := `INSERT INTO user_roles (user_id, role) VALUES ($1, $2) ON CONFLICT (user_id, role) DO NOTHING`
+	if _, err := r.pool.Exec(ctx, query, userID, role); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to grant role: %w", err)
+	}
+	return nil
+}
+
+// Отзывает роль у пользователя
+func (r *RepositoryImpl) RevokeRole(ctx context.Context, userID int64, role string) error {
+	ct, err := r.pool.Exec(ctx, `DELETE FROM user_roles WHERE user_id = $1 AND role = $2`, userID, role)
+	if err != nil {
+		return fmt.Errorf("failed to revoke role: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrRoleNotGranted
+	}
+	return nil
+}
@@ -0,0 +1,333 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/jackc/pgx/v5"
+)
+
+// Статусы запланированного перевода
+const (
+	ScheduleStatusActive    = "active"
+	ScheduleStatusPaused    = "paused"
+	ScheduleStatusCompleted = "completed"
+	ScheduleStatusCancelled = "cancelled"
+)
+
+// Статусы выполнения запланированного перевода
+const (
+	RunStatusSucceeded = "succeeded"
+	RunStatusFailed    = "failed"
+)
+
+// Класс рекомендательных блокировок запланированных переводов (первый ключ pg_try_advisory_lock),
+// чтобы они не пересекались с другими блокировками по тем же id
+const scheduledTransferLockClass int32 = 14
+
+// ScheduledTransfer — перевод, выполняемый планировщиком в StartAt и далее по правилу Recurrence
+// (формат RRULE, см. пакет recurrence). Без Recurrence перевод выполняется один раз.
+type ScheduledTransfer struct {
+	ID         int64          `json:"id"`
+	SenderID   int64          `json:"sender_id"`
+	ReceiverID int64          `json:"receiver_id"`
+	Amount     money.Amount   `json:"amount" swaggertype:"number" example:"25000.00"`
+	Currency   money.Currency `json:"currency" swaggertype:"string" example:"RUB"`
+	StartAt    time.Time      `json:"start_at"`
+	Recurrence *string        `json:"recurrence,omitempty" example:"FREQ=MONTHLY;INTERVAL=1"`
+	NextRunAt  *time.Time     `json:"next_run_at,omitempty"`
+	Status     string         `json:"status" example:"active"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// ScheduledTransferRun — результат выполнения запланированного перевода в плановое время ScheduledFor
+type ScheduledTransferRun struct {
+	ID                  int64     `json:"id"`
+	ScheduledTransferID int64     `json:"scheduled_transfer_id"`
+	ScheduledFor        time.Time `json:"scheduled_for"`
+	Status              string    `json:"status" example:"succeeded"`
+	TransactionID       *int64    `json:"transaction_id,omitempty"`
+	Error               *string   `json:"error,omitempty"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+// Общие колонки для выборки запланированных переводов, порядок совпадает с scanScheduledTransfer
+const scheduledTransferColumns = `id, sender_id, receiver_id, amount, currency, start_at, recurrence, next_run_at,
+	status, created_at, updated_at`
+
+func scanScheduledTransfer(row pgx.Row) (*ScheduledTransfer, error) {
+	var s ScheduledTransfer
+	err := row.Scan(&s.ID, &s.SenderID, &s.ReceiverID, &s.Amount, &s.Currency, &s.StartAt, &s.Recurrence, &s.NextRunAt,
+		&s.Status, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Общие колонки для выборки выполнений, порядок совпадает с scanScheduledTransferRun
+const scheduledTransferRunColumns = `id, scheduled_transfer_id, scheduled_for, status, transaction_id, error, created_at`
+
+func scanScheduledTransferRun(row pgx.Row) (*ScheduledTransferRun, error) {
+	var r ScheduledTransferRun
+	if err := row.Scan(&r.ID, &r.ScheduledTransferID, &r.ScheduledFor, &r.Status, &r.TransactionID, &r.Error, &r.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func collectScheduledTransfers(rows pgx.Rows) ([]ScheduledTransfer, error) {
+	defer rows.Close()
+	transfers := []ScheduledTransfer{}
+	for rows.Next() {
+		s, err := scanScheduledTransfer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan scheduled transfer: %w", err)
+		}
+		transfers = append(transfers, *s)
+	}
+	return transfers, rows.Err()
+}
+
+// Создаёт запланированный перевод с первым выполнением в s.StartAt.
+// Отправитель и получатель должны существовать и быть активны; остаток проверяется при выполнении.
+func (r *RepositoryImpl) CreateScheduledTransfer(ctx context.Context, s *ScheduledTransfer) (created *ScheduledTransfer, err error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	users, err := lockUsers(ctx, tx, s.SenderID, s.ReceiverID)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkActive(users[s.SenderID], ErrSenderNotFound); err != nil {
+		return nil, err
+	}
+	if err = checkActive(users[s.ReceiverID], ErrReceiverNotFound); err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO scheduled_transfers (sender_id, receiver_id, amount, currency, start_at, recurrence, next_run_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $5)
+		RETURNING ` + scheduledTransferColumns
+	created, err = scanScheduledTransfer(tx.QueryRow(ctx, query,
+		s.SenderID, s.ReceiverID, s.Amount, s.Currency, s.StartAt, s.Recurrence))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scheduled transfer: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit scheduled transfer: %w", err)
+	}
+
+	return created, nil
+}
+
+// Возвращает запланированный перевод по id
+func (r *RepositoryImpl) GetScheduledTransfer(ctx context.Context, id int64) (*ScheduledTransfer, error) {
+	query := `SELECT ` + scheduledTransferColumns + ` FROM scheduled_transfers WHERE id = $1`
+	s, err := scanScheduledTransfer(r.pool.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrScheduledTransferNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled transfer: %w", err)
+	}
+	return s, nil
+}
+
+// Возвращает запланированные переводы, отправителем которых является пользователь, от новых к старым
+func (r *RepositoryImpl) ListScheduledTransfers(ctx context.Context, userID int64) ([]ScheduledTransfer, error) {
+	if _, err := r.GetUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	query := `SELECT ` + scheduledTransferColumns + ` FROM scheduled_transfers WHERE sender_id = $1 ORDER BY id DESC`
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scheduled transfers: %w", err)
+	}
+	return collectScheduledTransfers(rows)
+}
+
+// Возвращает до limit активных переводов, время выполнения которых наступило к моменту now,
+// начиная с самых просроченных
+func (r *RepositoryImpl) ListDueScheduledTransfers(ctx context.Context, now time.Time, limit int) ([]ScheduledTransfer, error) {
+	query := `
+		SELECT ` + scheduledTransferColumns + `
+		FROM scheduled_transfers
+		WHERE status = 'active' AND next_run_at <= $1
+		ORDER BY next_run_at, id
+		LIMIT $2`
+	rows, err := r.pool.Query(ctx, query, now.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due scheduled transfers: %w", err)
+	}
+	return collectScheduledTransfers(rows)
+}
+
+// Меняет статус запланированного перевода, пока он не завершён и не отменён (иначе ErrScheduleFinished).
+// При переводе в active next_run_at становится равным nextRunAt, при паузе сохраняется,
+// при завершении и отмене очищается.
+func (r *RepositoryImpl) SetScheduledTransferStatus(ctx context.Context, id int64, status string, nextRunAt *time.Time) (s *ScheduledTransfer, err error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	var current string
+	err = tx.QueryRow(ctx, `SELECT status FROM scheduled_transfers WHERE id = $1 FOR UPDATE`, id).Scan(&current)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrScheduledTransferNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock scheduled transfer: %w", err)
+	}
+	if current == ScheduleStatusCompleted || current == ScheduleStatusCancelled {
+		return nil, ErrScheduleFinished
+	}
+
+	query := `
+		UPDATE scheduled_transfers SET
+			status = $2,
+			next_run_at = CASE $2
+				WHEN 'active' THEN $3
+				WHEN 'paused' THEN next_run_at
+			END,
+			updated_at = LOCALTIMESTAMP
+		WHERE id = $1
+		RETURNING ` + scheduledTransferColumns
+	s, err = scanScheduledTransfer(tx.QueryRow(ctx, query, id, status, nextRunAt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update scheduled transfer: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit scheduled transfer: %w", err)
+	}
+
+	return s, nil
+}
+
+// Записывает результат выполнения перевода в плановое время run.ScheduledFor и переносит
+// следующее выполнение на nextRunAt; без nextRunAt перевод завершается.
+// Запись выполняется, только если перевод ещё активен и ожидал именно этого выполнения,
+// иначе возвращается ErrScheduleFinished.
+func (r *RepositoryImpl) RecordScheduledTransferRun(ctx context.Context, run *ScheduledTransferRun, nextRunAt *time.Time) (recorded *ScheduledTransferRun, err error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	ct, err := tx.Exec(ctx, `
+		UPDATE scheduled_transfers SET
+			next_run_at = $3,
+			status = CASE WHEN $3::timestamp IS NULL THEN 'completed' ELSE status END,
+			updated_at = LOCALTIMESTAMP
+		WHERE id = $1 AND status = 'active' AND next_run_at = $2
+	`, run.ScheduledTransferID, run.ScheduledFor, nextRunAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to advance scheduled transfer: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return nil, ErrScheduleFinished
+	}
+
+	query := `
+		INSERT INTO scheduled_transfer_runs (scheduled_transfer_id, scheduled_for, status, transaction_id, error)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + scheduledTransferRunColumns
+	recorded, err = scanScheduledTransferRun(tx.QueryRow(ctx, query,
+		run.ScheduledTransferID, run.ScheduledFor, run.Status, run.TransactionID, run.Error))
+	if err != nil {
+		return nil, fmt.Errorf("failed to record scheduled transfer run: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit scheduled transfer run: %w", err)
+	}
+
+	return recorded, nil
+}
+
+// Возвращает до limit последних выполнений запланированного перевода, от новых к старым
+func (r *RepositoryImpl) ListScheduledTransferRuns(ctx context.Context, id int64, limit int) ([]ScheduledTransferRun, error) {
+	if _, err := r.GetScheduledTransfer(ctx, id); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + scheduledTransferRunColumns + `
+		FROM scheduled_transfer_runs
+		WHERE scheduled_transfer_id = $1
+		ORDER BY scheduled_for DESC
+		LIMIT $2`
+	rows, err := r.pool.Query(ctx, query, id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scheduled transfer runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []ScheduledTransferRun{}
+	for rows.Next() {
+		run, err := scanScheduledTransferRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan scheduled transfer run: %w", err)
+		}
+		runs = append(runs, *run)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+// Выполняет fn, удерживая сессионную рекомендательную блокировку запланированного перевода.
+// Если блокировку держит другой экземпляр сервиса, fn не вызывается и возвращается acquired = false.
+// Блокировка привязана к соединению, а не к транзакции, поэтому fn может выполнять собственные транзакции.
+func (r *RepositoryImpl) WithScheduledTransferLock(ctx context.Context, id int64, fn func(ctx context.Context) error) (acquired bool, err error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	err = conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1, $2)`, scheduledTransferLockClass, int32(id)).Scan(&acquired)
+	if err != nil {
+		return false, fmt.Errorf("failed to lock scheduled transfer: %w", err)
+	}
+	if !acquired {
+		return false, nil
+	}
+	defer func() {
+		// Блокировка снимается и при отменённом ctx; если снять её не удалось,
+		// соединение закрывается, и Postgres освобождает блокировку сам
+		_, unlockErr := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1, $2)`, scheduledTransferLockClass, int32(id))
+		if unlockErr != nil {
+			conn.Conn().Close(context.Background())
+		}
+	}()
+
+	return true, fn(ctx)
+}
@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduledTransfer_Lifecycle(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	sender := createFundedUser(t, r, "1000.00")
+	receiver := createFundedUser(t, r, "0")
+	rule := "FREQ=DAILY;INTERVAL=1"
+	startAt := time.Now().UTC().Add(time.Hour).Truncate(time.Microsecond)
+
+	st, err := r.CreateScheduledTransfer(ctx, &ScheduledTransfer{
+		SenderID: sender, ReceiverID: receiver, Amount: money.MustParse("100.00"), Currency: rub,
+		StartAt: startAt, Recurrence: &rule,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ScheduleStatusActive, st.Status)
+	assert.True(t, st.NextRunAt.Equal(startAt))
+
+	due, err := r.ListDueScheduledTransfers(ctx, startAt.Add(-time.Second), 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+	due, err = r.ListDueScheduledTransfers(ctx, startAt, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+
+	// Результат записывается только для ожидаемого вхождения
+	tx, err := r.Transfer(ctx, sender, receiver, st.Amount, rub, nil)
+	require.NoError(t, err)
+	next := startAt.AddDate(0, 0, 1)
+	run := &ScheduledTransferRun{ScheduledTransferID: st.ID, ScheduledFor: startAt, Status: RunStatusSucceeded, TransactionID: &tx.ID}
+	_, err = r.RecordScheduledTransferRun(ctx, run, &next)
+	require.NoError(t, err)
+	_, err = r.RecordScheduledTransferRun(ctx, run, &next)
+	assert.ErrorIs(t, err, ErrScheduleFinished)
+
+	runs, err := r.ListScheduledTransferRuns(ctx, st.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, tx.ID, *runs[0].TransactionID)
+
+	// Приостановленный перевод не попадает в очередь планировщика
+	paused, err := r.SetScheduledTransferStatus(ctx, st.ID, ScheduleStatusPaused, nil)
+	require.NoError(t, err)
+	assert.True(t, paused.NextRunAt.Equal(next))
+	due, err = r.ListDueScheduledTransfers(ctx, next, 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	cancelled, err := r.SetScheduledTransferStatus(ctx, st.ID, ScheduleStatusCancelled, nil)
+	require.NoError(t, err)
+	assert.Nil(t, cancelled.NextRunAt)
+	_, err = r.SetScheduledTransferStatus(ctx, st.ID, ScheduleStatusActive, &next)
+	assert.ErrorIs(t, err, ErrScheduleFinished)
+
+	list, err := r.ListScheduledTransfers(ctx, sender)
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+	_, err = r.GetScheduledTransfer(ctx, st.ID+1)
+	assert.ErrorIs(t, err, ErrScheduledTransferNotFound)
+}
+
+func TestWithScheduledTransferLock(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	// Пока блокировку держит один экземпляр, другой перевод не выполняет
+	acquired, err := r.WithScheduledTransferLock(ctx, 1, func(ctx context.Context) error {
+		nested, err := r.WithScheduledTransferLock(ctx, 1, func(context.Context) error {
+			t.Error("lock must not be acquired twice")
+			return nil
+		})
+		require.NoError(t, err)
+		assert.False(t, nested)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	// После выполнения блокировка снята
+	acquired, err = r.WithScheduledTransferLock(ctx, 1, func(context.Context) error { return nil })
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}
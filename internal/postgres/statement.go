@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/jackc/pgx/v5"
+)
+
+// Statement — выписка по счёту пользователя в валюте за период [From, To). Без From период начинается
+// с первой транзакции. OpeningBalance — баланс на начало периода, ClosingBalance — на конец
+// (заполняется к вызову StatementWriter.End вместе с Transactions — числом строк выписки).
+type Statement struct {
+	UserID         int64          `json:"user_id"`
+	Currency       money.Currency `json:"currency" swaggertype:"string" example:"RUB"`
+	From           *time.Time     `json:"from,omitempty"`
+	To             time.Time      `json:"to"`
+	OpeningBalance money.Amount   `json:"opening_balance" swaggertype:"number" example:"1000.00"`
+	ClosingBalance money.Amount   `json:"closing_balance" swaggertype:"number" example:"1500.00"`
+	Transactions   int            `json:"transactions"`
+}
+
+// StatementLine — транзакция выписки: Change — изменение баланса пользователя (с учётом комиссии),
+// Balance — баланс после транзакции
+type StatementLine struct {
+	Transaction
+	Change  money.Amount `json:"change" swaggertype:"number" example:"-100.50"`
+	Balance money.Amount `json:"balance" swaggertype:"number" example:"899.50"`
+}
+
+// StatementWriter принимает выписку по мере чтения из базы: Begin — с начальным балансом, Line — для
+// каждой транзакции по порядку, End — с конечным балансом
+type StatementWriter interface {
+	Begin(s *Statement) error
+	Line(l *StatementLine) error
+	End(s *Statement) error
+}
+
+// Изменение баланса пользователя userID в валюте currency от транзакции; повторяет transactionEffectsSQL
+func (t *Transaction) balanceChange(userID int64, currency money.Currency) money.Amount {
+	is := func(id *int64) bool { return id != nil && *id == userID }
+
+	var change money.Amount
+	switch t.TransactionType {
+	case "deposit":
+		if is(t.UserID) && t.Currency == currency {
+			change += t.Amount
+		}
+	case "withdrawal":
+		if is(t.UserID) && t.Currency == currency {
+			change -= t.Amount
+		}
+	case "transfer", "reversal", "adjustment":
+		if is(t.SenderID) && t.Currency == currency {
+			change -= t.Amount
+		}
+		receiverCurrency, receiverAmount := t.Currency, t.Amount
+		if t.ReceiverCurrency != nil && t.ReceiverAmount != nil {
+			receiverCurrency, receiverAmount = *t.ReceiverCurrency, *t.ReceiverAmount
+		}
+		if is(t.ReceiverID) && receiverCurrency == currency {
+			change += receiverAmount
+		}
+	}
+	if t.Fee != nil && is(t.UserID) && t.Currency == currency {
+		change -= *t.Fee
+	}
+	return change
+}
+
+// Читает выписку по счёту пользователя в валюте за период [from, to) и передаёт её w, не загружая
+// историю в память целиком. Все запросы видят один снимок данных, поэтому начальный баланс, транзакции
+// и конечный баланс согласованы между собой.
+func (r *RepositoryImpl) StreamStatement(ctx context.Context, userID int64, currency money.Currency, from *time.Time, to time.Time, w StatementWriter) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	s := &Statement{UserID: userID, Currency: currency, To: to.UTC()}
+	if from != nil {
+		f := from.UTC()
+		s.From = &f
+	}
+
+	// Начальный баланс — текущий баланс за вычетом эффекта транзакций с начала периода
+	// (без начала периода — всех транзакций, то есть начальный остаток счёта)
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE(a.balance, 0) - COALESCE((
+			SELECT SUM(e.amount) FROM (`+transactionEffectsSQL+`) e
+			WHERE e.user_id = $1 AND e.currency = $2 AND ($3::timestamp IS NULL OR e.created_at >= $3)
+		), 0)
+		FROM users u
+		LEFT JOIN accounts a ON a.user_id = u.id AND a.currency = $2
+		WHERE u.id = $1
+	`, userID, currency, s.From).Scan(&s.OpeningBalance)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get opening balance: %w", err)
+	}
+	if err = w.Begin(s); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT `+transactionColumns+`
+		FROM transactions
+		WHERE (user_id = $1 OR sender_id = $1 OR receiver_id = $1)
+			AND (currency = $2 OR receiver_currency = $2)
+			AND ($3::timestamp IS NULL OR created_at >= $3) AND created_at < $4
+		ORDER BY created_at, id
+	`, userID, currency, s.From, s.To)
+	if err != nil {
+		return fmt.Errorf("failed to query statement transactions: %w", err)
+	}
+	defer rows.Close()
+
+	balance := s.OpeningBalance
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		change := t.balanceChange(userID, currency)
+		balance += change
+		s.Transactions++
+		if err = w.Line(&StatementLine{Transaction: *t, Change: change, Balance: balance}); err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	s.ClosingBalance = balance
+	return w.End(s)
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Запоминает выписку, переданную StreamStatement
+type recordingStatementWriter struct {
+	begin *Statement
+	lines []StatementLine
+	end   *Statement
+}
+
+func (w *recordingStatementWriter) Begin(s *Statement) error {
+	c := *s
+	w.begin = &c
+	return nil
+}
+
+func (w *recordingStatementWriter) Line(l *StatementLine) error {
+	w.lines = append(w.lines, *l)
+	return nil
+}
+
+func (w *recordingStatementWriter) End(s *Statement) error {
+	w.end = s
+	return nil
+}
+
+func TestStreamStatement(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	user := createFundedUser(t, r, "100.00")
+	other := createFundedUser(t, r, "50.00")
+
+	out, err := r.Transfer(ctx, user, other, money.MustParse("30.00"), rub, nil)
+	require.NoError(t, err)
+	_, err = r.Transfer(ctx, other, user, money.MustParse("5.00"), rub, nil)
+	require.NoError(t, err)
+	_, err = r.Withdraw(ctx, user, money.MustParse("10.00"), rub, nil)
+	require.NoError(t, err)
+
+	// Вся история: от нулевого остатка до текущего баланса
+	var all recordingStatementWriter
+	require.NoError(t, r.StreamStatement(ctx, user, rub, nil, time.Now().Add(time.Minute), &all))
+	assert.Zero(t, all.begin.OpeningBalance)
+	require.Len(t, all.lines, 4)
+	var balances []string
+	for _, l := range all.lines {
+		balances = append(balances, l.Balance.String())
+	}
+	assert.Equal(t, []string{"100.00", "70.00", "75.00", "65.00"}, balances)
+	assert.Equal(t, balanceOf(t, r, user), all.end.ClosingBalance)
+	assert.Equal(t, 4, all.end.Transactions)
+
+	// Период с первого перевода: начальный баланс — остаток после пополнения
+	var period recordingStatementWriter
+	require.NoError(t, r.StreamStatement(ctx, user, rub, &out.CreatedAt, time.Now().Add(time.Minute), &period))
+	assert.Equal(t, money.MustParse("100.00"), period.begin.OpeningBalance)
+	require.Len(t, period.lines, 3)
+	assert.Equal(t, out.ID, period.lines[0].ID)
+	assert.Equal(t, money.MustParse("-30.00"), period.lines[0].Change)
+	assert.Equal(t, all.end.ClosingBalance, period.end.ClosingBalance)
+
+	// Конец периода не включается
+	var before recordingStatementWriter
+	require.NoError(t, r.StreamStatement(ctx, user, rub, nil, out.CreatedAt, &before))
+	assert.Len(t, before.lines, 1)
+	assert.Equal(t, money.MustParse("100.00"), before.end.ClosingBalance)
+}
+
+func TestStreamStatement_UserNotFound(t *testing.T) {
+	r := newTestRepository(t)
+
+	var w recordingStatementWriter
+	err := r.StreamStatement(context.Background(), 999999, rub, nil, time.Now(), &w)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	assert.Nil(t, w.begin)
+}
@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor — позиция в истории транзакций, упорядоченной по (created_at, id)
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// Encode возвращает непрозрачное представление курсора для передачи клиенту
+func (c Cursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + strconv.FormatInt(c.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor разбирает курсор, полученный от клиента
+func DecodeCursor(s string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if c.CreatedAt, err = time.Parse(time.RFC3339Nano, ts); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.ID, err = strconv.ParseInt(id, 10, 64); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// TransactionFilter — параметры выборки истории транзакций. Нулевой UserID — транзакции всех пользователей
+// (поиск в административном API).
+// Before и After взаимоисключающие: Before листает к более старым транзакциям, After — к более новым.
+type TransactionFilter struct {
+	UserID    int64
+	Limit     int
+	Before    *Cursor
+	After     *Cursor
+	Type      string
+	Currency  money.Currency // пусто — любая валюта; перевод с конвертацией попадает в выборку по обеим валютам
+	From      *time.Time     // включительно
+	To        *time.Time     // не включительно
+	MinAmount *money.Amount
+	MaxAmount *money.Amount
+}
+
+// TransactionPage — страница истории, транзакции упорядочены от новых к старым.
+// NextCursor передаётся в том же параметре (before или after), что и текущий курсор; пуст, если страница последняя.
+type TransactionPage struct {
+	Transactions []Transaction `json:"transactions"`
+	NextCursor   string        `json:"next_cursor,omitempty"`
+}
+
+// Получает страницу транзакций пользователя (или всех пользователей) с фильтрами, используя keyset-пагинацию по (created_at, id)
+func (r *RepositoryImpl) GetTransactions(ctx context.Context, filter TransactionFilter) (*TransactionPage, error) {
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	conditions := []string{"TRUE"}
+	if filter.UserID != 0 {
+		user := arg(filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("(user_id = %s OR sender_id = %s OR receiver_id = %s)", user, user, user))
+	}
+	order := "DESC"
+	if filter.Before != nil {
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) < (%s, %s)", arg(filter.Before.CreatedAt), arg(filter.Before.ID)))
+	}
+	if filter.After != nil {
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) > (%s, %s)", arg(filter.After.CreatedAt), arg(filter.After.ID)))
+		order = "ASC"
+	}
+	if filter.Type != "" {
+		conditions = append(conditions, "transaction_type = "+arg(filter.Type))
+	}
+	if filter.Currency != "" {
+		currency := arg(filter.Currency)
+		conditions = append(conditions, fmt.Sprintf("(currency = %s OR receiver_currency = %s)", currency, currency))
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "created_at >= "+arg(filter.From.UTC()))
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "created_at < "+arg(filter.To.UTC()))
+	}
+	if filter.MinAmount != nil {
+		conditions = append(conditions, "amount >= "+arg(*filter.MinAmount))
+	}
+	if filter.MaxAmount != nil {
+		conditions = append(conditions, "amount <= "+arg(*filter.MaxAmount))
+	}
+
+	// Запрашиваем на одну строку больше, чтобы понять, есть ли следующая страница
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY created_at ` + order + `, id ` + order + `
+		LIMIT ` + arg(filter.Limit+1)
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transactions: %w", err)
+	}
+	defer rows.Close()
+
+	page := &TransactionPage{Transactions: []Transaction{}}
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		page.Transactions = append(page.Transactions, *t)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(page.Transactions) > filter.Limit {
+		page.Transactions = page.Transactions[:filter.Limit]
+		last := page.Transactions[len(page.Transactions)-1]
+		page.NextCursor = Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	if filter.After != nil {
+		slices.Reverse(page.Transactions)
+	}
+	if err = loadReversals(ctx, r.pool, page.Transactions); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
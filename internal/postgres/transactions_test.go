@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor_EncodeDecode(t *testing.T) {
+	c := Cursor{CreatedAt: time.Date(2025, 2, 1, 12, 30, 0, 123456000, time.UTC), ID: 42}
+
+	decoded, err := DecodeCursor(c.Encode())
+	require.NoError(t, err)
+	assert.Equal(t, c, *decoded)
+
+	for _, s := range []string{"", "!!!", "MTIz", c.Encode() + "x"} {
+		_, err := DecodeCursor(s)
+		assert.ErrorIs(t, err, ErrInvalidCursor, s)
+	}
+}
+
+func TestGetTransactions_Pagination(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	userID := createFundedUser(t, r, "0")
+	for i := 1; i <= 5; i++ {
+		_, err := r.Deposit(ctx, userID, money.Amount(i*100), rub, nil)
+		require.NoError(t, err)
+	}
+	_, err := r.Withdraw(ctx, userID, money.MustParse("1.00"), rub, nil)
+	require.NoError(t, err)
+
+	// Листаем к старым транзакциям страницами по 2
+	var amounts []money.Amount
+	filter := TransactionFilter{UserID: userID, Limit: 2}
+	for {
+		page, err := r.GetTransactions(ctx, filter)
+		require.NoError(t, err)
+		for _, tx := range page.Transactions {
+			amounts = append(amounts, tx.Amount)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Before, err = DecodeCursor(page.NextCursor)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []money.Amount{100, 500, 400, 300, 200, 100}, amounts)
+
+	// Фильтры по типу и сумме
+	minAmount, maxAmount := money.MustParse("2.00"), money.MustParse("4.00")
+	page, err := r.GetTransactions(ctx, TransactionFilter{UserID: userID, Limit: 10, Type: "deposit", MinAmount: &minAmount, MaxAmount: &maxAmount})
+	require.NoError(t, err)
+	assert.Len(t, page.Transactions, 3)
+	assert.Empty(t, page.NextCursor)
+
+	// after возвращает более новые транзакции, упорядоченные так же — от новых к старым
+	oldest := page.Transactions[len(page.Transactions)-1]
+	page, err = r.GetTransactions(ctx, TransactionFilter{UserID: userID, Limit: 2, After: &Cursor{CreatedAt: oldest.CreatedAt, ID: oldest.ID}})
+	require.NoError(t, err)
+	require.Len(t, page.Transactions, 2)
+	assert.Equal(t, []money.Amount{400, 300}, []money.Amount{page.Transactions[0].Amount, page.Transactions[1].Amount})
+	assert.NotEmpty(t, page.NextCursor)
+}
@@ -0,0 +1,241 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Статусы счёта пользователя
+const (
+	UserStatusActive = "active"
+	UserStatusFrozen = "frozen"
+	UserStatusClosed = "closed"
+)
+
+// User — пользователь и его счета в разных валютах.
+// Accounts заполняется при выдаче пользователя наружу (GetUser, ListUsers и т.п.).
+type User struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"username"`
+	Status    string    `json:"status" example:"active"`
+	Tier      string    `json:"tier" example:"standard"`
+	Accounts  []Account `json:"accounts"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Общие колонки для выборки пользователей, порядок совпадает с scanUser
+const userColumns = `id, username, status, tier, created_at`
+
+func scanUser(row pgx.Row) (*User, error) {
+	var u User
+	if err := row.Scan(&u.ID, &u.Username, &u.Status, &u.Tier, &u.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// Блокирует строки пользователей до конца транзакции (SELECT ... FOR UPDATE).
+// Строки всегда блокируются в порядке возрастания id, поэтому транзакции,
+// затрагивающие одних и тех же пользователей, не могут взаимно заблокироваться.
+// Отсутствующие пользователи в результат не попадают.
+func lockUsers(ctx context.Context, tx pgx.Tx, userIDs ...int64) (map[int64]*User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = ANY($1) ORDER BY id FOR UPDATE`
+	rows, err := tx.Query(ctx, query, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock users: %w", err)
+	}
+	defer rows.Close()
+
+	users := make(map[int64]*User, len(userIDs))
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users[u.ID] = u
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to lock users: %w", err)
+	}
+
+	return users, nil
+}
+
+// Проверяет, что пользователь найден и его счёт активен. Если пользователь не найден, возвращает notFound.
+func checkActive(u *User, notFound error) error {
+	if u == nil {
+		return notFound
+	}
+	switch u.Status {
+	case UserStatusFrozen:
+		return ErrAccountFrozen
+	case UserStatusClosed:
+		return ErrAccountClosed
+	}
+	return nil
+}
+
+// Блокирует строку пользователя до конца транзакции и проверяет, что счёт активен.
+// Если пользователь не найден, возвращает notFound.
+func lockActiveUser(ctx context.Context, tx pgx.Tx, userID int64, notFound error) (*User, error) {
+	users, err := lockUsers(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	u := users[userID]
+	if err = checkActive(u, notFound); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Создаёт пользователя с нулевым счётом в валюте по умолчанию
+func (r *RepositoryImpl) CreateUser(ctx context.Context, username string) (u *User, err error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	query := `INSERT INTO users (username) VALUES ($1) RETURNING ` + userColumns
+	u, err = scanUser(tx.QueryRow(ctx, query, username))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrUsernameTaken
+		}
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	a, _, err := openAccount(ctx, tx, u.ID, money.DefaultCurrency)
+	if err != nil {
+		return nil, err
+	}
+	u.Accounts = []Account{*a}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit user: %w", err)
+	}
+
+	return u, nil
+}
+
+func (r *RepositoryImpl) GetUser(ctx context.Context, userID int64) (*User, error) {
+	u, err := scanUser(r.pool.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if err = loadAccounts(ctx, r.pool, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Возвращает пользователя по имени
+func (r *RepositoryImpl) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	u, err := scanUser(r.pool.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE username = $1`, username))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if err = loadAccounts(ctx, r.pool, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Возвращает страницу пользователей, упорядоченных по id
+func (r *RepositoryImpl) ListUsers(ctx context.Context, limit, offset int) ([]User, error) {
+	query := `SELECT ` + userColumns + ` FROM users ORDER BY id LIMIT $1 OFFSET $2`
+	rows, err := r.pool.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	var page []*User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		page = append(page, u)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err = loadAccounts(ctx, r.pool, page...); err != nil {
+		return nil, err
+	}
+	users := make([]User, len(page))
+	for i, u := range page {
+		users[i] = *u
+	}
+	return users, nil
+}
+
+// Меняет статус счёта. Закрытый счёт нельзя открыть снова,
+// а закрыть можно только пользователя с нулевыми балансами во всех валютах.
+func (r *RepositoryImpl) SetUserStatus(ctx context.Context, userID int64, status string) (u *User, err error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	u, err = scanUser(tx.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1 FOR UPDATE`, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock user: %w", err)
+	}
+	if u.Status == UserStatusClosed {
+		return nil, ErrAccountClosed
+	}
+	if status == UserStatusClosed {
+		var nonEmpty bool
+		err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM accounts WHERE user_id = $1 AND balance <> 0)`, userID).Scan(&nonEmpty)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check balances: %w", err)
+		}
+		if nonEmpty {
+			return nil, ErrAccountNotEmpty
+		}
+	}
+
+	query := `UPDATE users SET status = $1 WHERE id = $2 RETURNING ` + userColumns
+	u, err = scanUser(tx.QueryRow(ctx, query, status, userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user status: %w", err)
+	}
+	if err = loadAccounts(ctx, tx, u); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit user status: %w", err)
+	}
+
+	return u, nil
+}
@@ -0,0 +1,377 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/jackc/pgx/v5"
+)
+
+// Статусы доставки вебхука
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusDead      = "dead"
+)
+
+// Событие вебхука об изменении баланса пользователя; о самой транзакции сообщает событие
+// <тип транзакции>.completed
+const webhookEventBalanceUpdated = "balance.updated"
+
+// WebhookSubscription — подписка на события сервиса. Secret (ключ HMAC-подписи запросов)
+// возвращается только при создании подписки.
+type WebhookSubscription struct {
+	ID         int64     `json:"id"`
+	URL        string    `json:"url" example:"https://example.com/hooks/fin"`
+	EventTypes []string  `json:"event_types" example:"transfer.completed,transfer.failed"`
+	Secret     string    `json:"secret,omitempty" example:"whsec_3f1c..."`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WebhookDelivery — доставка события подписчику и результат последней попытки
+type WebhookDelivery struct {
+	ID             int64      `json:"id"`
+	EventID        int64      `json:"event_id"`
+	EventType      string     `json:"event_type" example:"transfer.completed"`
+	SubscriptionID int64      `json:"subscription_id"`
+	Status         string     `json:"status" example:"pending"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`
+	LastStatusCode *int       `json:"last_status_code,omitempty"`
+	LastError      *string    `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// WebhookMessage — доставка, взятая в работу: куда и с каким секретом отправить событие.
+// Attempts — число уже сделанных попыток.
+type WebhookMessage struct {
+	DeliveryID     int64
+	Attempts       int
+	URL            string
+	Secret         string
+	EventID        int64
+	EventType      string
+	Payload        json.RawMessage
+	EventCreatedAt time.Time
+}
+
+// WebhookAttempt — результат попытки доставки. Без Error доставка успешна; с Error и RetryAfter
+// следующая попытка будет через RetryAfter, без RetryAfter доставка переходит в статус dead.
+type WebhookAttempt struct {
+	StatusCode *int
+	Error      *string
+	RetryAfter *time.Duration
+}
+
+const webhookSubscriptionColumns = `id, url, secret, event_types, created_at`
+
+func scanWebhookSubscription(row pgx.Row) (*WebhookSubscription, error) {
+	var s WebhookSubscription
+	if err := row.Scan(&s.ID, &s.URL, &s.Secret, &s.EventTypes, &s.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Колонки доставки; выбираются из webhook_deliveries d, соединённой с webhook_events e
+const webhookDeliveryColumns = `d.id, d.event_id, e.event_type, d.subscription_id, d.status, d.attempts,
+	d.next_attempt_at, d.last_attempt_at, d.last_status_code, d.last_error, d.delivered_at, d.created_at`
+
+func scanWebhookDelivery(row pgx.Row) (*WebhookDelivery, error) {
+	var d WebhookDelivery
+	err := row.Scan(&d.ID, &d.EventID, &d.EventType, &d.SubscriptionID, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &d.LastAttemptAt, &d.LastStatusCode, &d.LastError, &d.DeliveredAt, &d.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Создаёт подписку на события
+func (r *RepositoryImpl) CreateWebhookSubscription(ctx context.Context, s *WebhookSubscription) (*WebhookSubscription, error) {
+	query := `
+		INSERT INTO webhook_subscriptions (url, secret, event_types)
+		VALUES ($1, $2, $3)
+		RETURNING ` + webhookSubscriptionColumns
+	created, err := scanWebhookSubscription(r.pool.QueryRow(ctx, query, s.URL, s.Secret, s.EventTypes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+	return created, nil
+}
+
+// Возвращает все подписки без секретов
+func (r *RepositoryImpl) ListWebhookSubscriptions(ctx context.Context) ([]WebhookSubscription, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subscriptions := []WebhookSubscription{}
+	for rows.Next() {
+		s, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook subscription: %w", err)
+		}
+		s.Secret = ""
+		subscriptions = append(subscriptions, *s)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// Удаляет подписку вместе с её доставками
+func (r *RepositoryImpl) DeleteWebhookSubscription(ctx context.Context, id int64) error {
+	ct, err := r.pool.Exec(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrWebhookSubscriptionNotFound
+	}
+	return nil
+}
+
+// Сообщает, есть ли подписчики на события типа eventType
+func hasWebhookSubscribers(ctx context.Context, q querier, eventType string) (bool, error) {
+	var exists bool
+	err := q.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM webhook_subscriptions WHERE $1 = ANY(event_types))`, eventType).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check webhook subscribers: %w", err)
+	}
+	return exists, nil
+}
+
+// Сохраняет событие и ставит его в очередь доставки каждому подписчику на eventType.
+// Если подписчиков нет, событие не сохраняется. Возвращает число созданных доставок.
+func (r *RepositoryImpl) EnqueueWebhookEvent(ctx context.Context, eventType string, payload []byte) (int64, error) {
+	return enqueueWebhookEvent(ctx, r.pool, eventType, payload)
+}
+
+func enqueueWebhookEvent(ctx context.Context, q querier, eventType string, payload []byte) (int64, error) {
+	query := `
+		WITH subscriptions AS (
+			SELECT id FROM webhook_subscriptions WHERE $1 = ANY(event_types)
+		), event AS (
+			INSERT INTO webhook_events (event_type, payload)
+			SELECT $1, $2 WHERE EXISTS (SELECT 1 FROM subscriptions)
+			RETURNING id
+		)
+		INSERT INTO webhook_deliveries (event_id, subscription_id, next_attempt_at)
+		SELECT event.id, subscriptions.id, LOCALTIMESTAMP FROM event, subscriptions`
+	ct, err := q.Exec(ctx, query, eventType, payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue webhook event: %w", err)
+	}
+	return ct.RowsAffected(), nil
+}
+
+// Берёт в работу до limit доставок, время очередной попытки которых наступило. Взятая доставка
+// откладывается на lease: если экземпляр сервиса упадёт, не записав результат, её повторит другой.
+// Несколько экземпляров могут брать доставки одновременно — каждая достанется только одному.
+func (r *RepositoryImpl) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]WebhookMessage, error) {
+	query := `
+		WITH due AS (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= LOCALTIMESTAMP
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), claimed AS (
+			UPDATE webhook_deliveries d SET next_attempt_at = LOCALTIMESTAMP + make_interval(secs => $2)
+			FROM due WHERE d.id = due.id
+			RETURNING d.id, d.attempts, d.event_id, d.subscription_id
+		)
+		SELECT c.id, c.attempts, s.url, s.secret, e.id, e.event_type, e.payload, e.created_at
+		FROM claimed c
+		JOIN webhook_subscriptions s ON s.id = c.subscription_id
+		JOIN webhook_events e ON e.id = c.event_id
+		ORDER BY c.id`
+	rows, err := r.pool.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []WebhookMessage
+	for rows.Next() {
+		var m WebhookMessage
+		err := rows.Scan(&m.DeliveryID, &m.Attempts, &m.URL, &m.Secret, &m.EventID, &m.EventType, &m.Payload, &m.EventCreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		messages = append(messages, m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// Записывает результат попытки доставки, взятой в работу через ClaimWebhookDeliveries
+func (r *RepositoryImpl) RecordWebhookAttempt(ctx context.Context, deliveryID int64, attempt WebhookAttempt) error {
+	var retryAfter *float64
+	if attempt.RetryAfter != nil {
+		secs := attempt.RetryAfter.Seconds()
+		retryAfter = &secs
+	}
+	_, err := r.pool.Exec(ctx, `
+		UPDATE webhook_deliveries SET
+			attempts = attempts + 1,
+			last_attempt_at = LOCALTIMESTAMP,
+			last_status_code = $2,
+			last_error = $3::text,
+			status = CASE
+				WHEN $3::text IS NULL THEN 'delivered'
+				WHEN $4::float8 IS NULL THEN 'dead'
+				ELSE 'pending'
+			END,
+			next_attempt_at = CASE
+				WHEN $3::text IS NOT NULL AND $4::float8 IS NOT NULL THEN LOCALTIMESTAMP + make_interval(secs => $4::float8)
+			END,
+			delivered_at = CASE WHEN $3::text IS NULL THEN LOCALTIMESTAMP END
+		WHERE id = $1 AND status = 'pending'
+	`, deliveryID, attempt.StatusCode, attempt.Error, retryAfter)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", err)
+	}
+	return nil
+}
+
+// Возвращает последние limit доставок подписки, от новых к старым; status (если не пуст) — фильтр по статусу
+func (r *RepositoryImpl) ListWebhookDeliveries(ctx context.Context, subscriptionID int64, status string, limit int) ([]WebhookDelivery, error) {
+	var exists bool
+	err := r.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM webhook_subscriptions WHERE id = $1)`, subscriptionID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+	if !exists {
+		return nil, ErrWebhookSubscriptionNotFound
+	}
+
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries d JOIN webhook_events e ON e.id = d.event_id
+		WHERE d.subscription_id = $1 AND ($2 = '' OR d.status = $2)
+		ORDER BY d.id DESC
+		LIMIT $3`
+	rows, err := r.pool.Query(ctx, query, subscriptionID, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, *d)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// Ставит доставленное или исчерпавшее попытки событие в очередь заново, с полным набором попыток
+func (r *RepositoryImpl) RedeliverWebhook(ctx context.Context, deliveryID int64) (*WebhookDelivery, error) {
+	query := `
+		WITH d AS (
+			UPDATE webhook_deliveries SET status = 'pending', attempts = 0, next_attempt_at = LOCALTIMESTAMP
+			WHERE id = $1 AND status <> 'pending'
+			RETURNING *
+		)
+		SELECT ` + webhookDeliveryColumns + ` FROM d JOIN webhook_events e ON e.id = d.event_id`
+	d, err := scanWebhookDelivery(r.pool.QueryRow(ctx, query, deliveryID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		var exists bool
+		err = r.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM webhook_deliveries WHERE id = $1)`, deliveryID).Scan(&exists)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+		}
+		if !exists {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, ErrWebhookDeliveryPending
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeliver webhook: %w", err)
+	}
+	return d, nil
+}
+
+// Ставит в очередь вебхуков события о проведённой транзакции t: <тип транзакции>.completed и balance.updated
+// для каждого затронутого пользователя. Вызывается в транзакции операции после изменения балансов, поэтому
+// события фиксируются вместе с операцией, а баланс в событии — тот, что оставила именно она.
+func recordWebhookEvents(ctx context.Context, tx pgx.Tx, t *Transaction) error {
+	payload, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook event: %w", err)
+	}
+	if _, err = enqueueWebhookEvent(ctx, tx, t.TransactionType+".completed", payload); err != nil {
+		return err
+	}
+
+	has, err := hasWebhookSubscribers(ctx, tx, webhookEventBalanceUpdated)
+	if err != nil || !has {
+		return err
+	}
+	for _, b := range affectedBalances(t) {
+		err = tx.QueryRow(ctx, `SELECT balance FROM accounts WHERE user_id = $1 AND currency = $2`,
+			b.UserID, b.Currency).Scan(&b.Balance)
+		if err != nil {
+			return fmt.Errorf("failed to get balance: %w", err)
+		}
+		held, err := heldAmount(ctx, tx, b.UserID, b.Currency)
+		if err != nil {
+			return err
+		}
+		available := b.Balance - held
+		b.Held, b.Available, b.LastTransactionAt = &held, &available, &t.CreatedAt
+
+		if payload, err = json.Marshal(b); err != nil {
+			return fmt.Errorf("failed to marshal webhook event: %w", err)
+		}
+		if _, err = enqueueWebhookEvent(ctx, tx, webhookEventBalanceUpdated, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Балансы, изменённые транзакцией: по пользователю и валюте его счёта
+func affectedBalances(t *Transaction) []Balance {
+	var balances []Balance
+	add := func(userID *int64, currency money.Currency) {
+		if userID == nil {
+			return
+		}
+		for _, b := range balances {
+			if b.UserID == *userID && b.Currency == currency {
+				return
+			}
+		}
+		balances = append(balances, Balance{UserID: *userID, Currency: currency})
+	}
+
+	add(t.UserID, t.Currency)
+	add(t.SenderID, t.Currency)
+	receiverCurrency := t.Currency
+	if t.ReceiverCurrency != nil {
+		receiverCurrency = *t.ReceiverCurrency
+	}
+	add(t.ReceiverID, receiverCurrency)
+	return balances
+}
@@ -0,0 +1,177 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookDelivery_Lifecycle(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	sub, err := r.CreateWebhookSubscription(ctx, &WebhookSubscription{
+		URL: "https://example.com/hook", Secret: "whsec_test", EventTypes: []string{"transfer.completed"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "whsec_test", sub.Secret)
+
+	subs, err := r.ListWebhookSubscriptions(ctx)
+	require.NoError(t, err)
+	require.Len(t, subs, 1)
+	assert.Empty(t, subs[0].Secret, "секрет не должен отдаваться в списке")
+
+	// Без подписчиков событие не сохраняется
+	n, err := r.EnqueueWebhookEvent(ctx, "deposit.completed", []byte(`{"id":1}`))
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	n, err = r.EnqueueWebhookEvent(ctx, "transfer.completed", []byte(`{"id":2}`))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	claimed, err := r.ClaimWebhookDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	m := claimed[0]
+	assert.Equal(t, "https://example.com/hook", m.URL)
+	assert.Equal(t, "whsec_test", m.Secret)
+	assert.JSONEq(t, `{"id":2}`, string(m.Payload))
+
+	// Взятая в работу доставка не выдаётся повторно до истечения аренды
+	again, err := r.ClaimWebhookDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, again)
+
+	status, msg := 500, "receiver responded with status 500"
+	retry := time.Duration(0)
+	require.NoError(t, r.RecordWebhookAttempt(ctx, m.DeliveryID, WebhookAttempt{StatusCode: &status, Error: &msg, RetryAfter: &retry}))
+	claimed, err = r.ClaimWebhookDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, 1, claimed[0].Attempts)
+
+	// Без повтора доставка переходит в dead
+	require.NoError(t, r.RecordWebhookAttempt(ctx, m.DeliveryID, WebhookAttempt{StatusCode: &status, Error: &msg}))
+	deliveries, err := r.ListWebhookDeliveries(ctx, sub.ID, DeliveryStatusDead, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, 2, deliveries[0].Attempts)
+	assert.Equal(t, "transfer.completed", deliveries[0].EventType)
+	assert.Equal(t, msg, *deliveries[0].LastError)
+	assert.Nil(t, deliveries[0].NextAttemptAt)
+
+	d, err := r.RedeliverWebhook(ctx, m.DeliveryID)
+	require.NoError(t, err)
+	assert.Equal(t, DeliveryStatusPending, d.Status)
+	assert.Zero(t, d.Attempts)
+	_, err = r.RedeliverWebhook(ctx, m.DeliveryID)
+	assert.ErrorIs(t, err, ErrWebhookDeliveryPending)
+	_, err = r.RedeliverWebhook(ctx, 999999)
+	assert.ErrorIs(t, err, ErrWebhookDeliveryNotFound)
+
+	claimed, err = r.ClaimWebhookDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	ok := 200
+	require.NoError(t, r.RecordWebhookAttempt(ctx, m.DeliveryID, WebhookAttempt{StatusCode: &ok}))
+	deliveries, err = r.ListWebhookDeliveries(ctx, sub.ID, "", 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, DeliveryStatusDelivered, deliveries[0].Status)
+	assert.NotNil(t, deliveries[0].DeliveredAt)
+
+	require.NoError(t, r.DeleteWebhookSubscription(ctx, sub.ID))
+	assert.ErrorIs(t, r.DeleteWebhookSubscription(ctx, sub.ID), ErrWebhookSubscriptionNotFound)
+	_, err = r.ListWebhookDeliveries(ctx, sub.ID, "", 10)
+	assert.ErrorIs(t, err, ErrWebhookSubscriptionNotFound)
+}
+
+func TestTransfer_RecordsWebhookEvents(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	sender := createFundedUser(t, r, "100.00")
+	receiver := createFundedUser(t, r, "0")
+	_, err := r.CreateWebhookSubscription(ctx, &WebhookSubscription{
+		URL: "https://example.com/hook", Secret: "whsec_test", EventTypes: []string{"transfer.completed", "balance.updated"},
+	})
+	require.NoError(t, err)
+
+	key := &IdempotencyKey{Key: "transfer-1", RequestHash: "h1"}
+	transfer, err := r.Transfer(ctx, sender, receiver, money.MustParse("40.00"), rub, key)
+	require.NoError(t, err)
+	// Повтор запроса и отклонённая операция событий не добавляют
+	_, err = r.Transfer(ctx, sender, receiver, money.MustParse("40.00"), rub, key)
+	require.NoError(t, err)
+	_, err = r.Transfer(ctx, sender, receiver, money.MustParse("1000.00"), rub, nil)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// Баланс в событии — на момент операции, а не доставки
+	_, err = r.Deposit(ctx, sender, money.MustParse("5.00"), rub, nil)
+	require.NoError(t, err)
+
+	claimed, err := r.ClaimWebhookDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	var types []string
+	balances := map[int64]money.Amount{}
+	for _, m := range claimed {
+		types = append(types, m.EventType)
+		switch m.EventType {
+		case "transfer.completed":
+			var got Transaction
+			require.NoError(t, json.Unmarshal(m.Payload, &got))
+			assert.Equal(t, transfer.ID, got.ID)
+		case "balance.updated":
+			var got Balance
+			require.NoError(t, json.Unmarshal(m.Payload, &got))
+			if _, seen := balances[got.UserID]; !seen {
+				balances[got.UserID] = got.Balance
+			}
+		}
+	}
+	assert.Equal(t, []string{"transfer.completed", "balance.updated", "balance.updated", "balance.updated"}, types)
+	assert.Equal(t, map[int64]money.Amount{sender: money.MustParse("60.00"), receiver: money.MustParse("40.00")}, balances)
+}
+
+func TestClaimWebhookDeliveries_Concurrent(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	_, err := r.CreateWebhookSubscription(ctx, &WebhookSubscription{
+		URL: "https://example.com/hook", Secret: "whsec_test", EventTypes: []string{"deposit.completed"},
+	})
+	require.NoError(t, err)
+	for range 20 {
+		_, err := r.EnqueueWebhookEvent(ctx, "deposit.completed", []byte(`{}`))
+		require.NoError(t, err)
+	}
+
+	var (
+		mu   sync.Mutex
+		seen = map[int64]int{}
+	)
+	errs := runConcurrently(4, func(int) error {
+		claimed, err := r.ClaimWebhookDeliveries(ctx, 10, time.Minute)
+		mu.Lock()
+		defer mu.Unlock()
+		for _, m := range claimed {
+			seen[m.DeliveryID]++
+		}
+		return err
+	})
+
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	assert.Len(t, seen, 20)
+	for id, n := range seen {
+		assert.Equal(t, 1, n, "доставка %d взята несколько раз", id)
+	}
+}
@@ -0,0 +1,101 @@
+// Пакет ratelimit — ограничение частоты запросов по алгоритму token bucket.
+//
+// Каждому ключу (клиенту, адресу или паре клиент–роут) соответствует корзина ёмкостью Limit.Requests токенов,
+// которая равномерно пополняется и наполняется полностью за Limit.Period. Запрос забирает один токен; если
+// токенов нет, запрос отклоняется. Так клиент может сделать до Requests запросов подряд, а в среднем — не больше
+// Requests за Period. Корзины хранятся в Store: в памяти процесса (MemoryStore) или, при нескольких экземплярах
+// сервиса, в общем хранилище с той же семантикой.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit — ёмкость корзины и время её полного пополнения. Нулевой лимит отключает ограничение.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Enabled сообщает, ограничивает ли лимит запросы
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// Скорость пополнения корзины в токенах в секунду
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// ParseLimit разбирает лимит в виде "<запросов>/<период>", например "100/1m" или "5/1s"; "off" отключает лимит
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "off" {
+		return Limit{}, nil
+	}
+	requests, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected <requests>/<period>", s)
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: requests must be a positive integer", s)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: period must be a positive duration", s)
+	}
+	return Limit{Requests: n, Period: d}, nil
+}
+
+// ParseRouteLimits разбирает лимиты роутов в виде "POST /transfer=10/1m,POST /withdraw=20/1m".
+// Роут задаётся методом и шаблоном пути, как он объявлен в роутере (например, "POST /holds/:id/capture").
+func ParseRouteLimits(s string) (map[string]Limit, error) {
+	limits := map[string]Limit{}
+	for _, entry := range strings.Split(s, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		route, limit, ok := strings.Cut(entry, "=")
+		method, path, hasPath := strings.Cut(strings.TrimSpace(route), " ")
+		if !ok || !hasPath || method == "" || !strings.HasPrefix(strings.TrimSpace(path), "/") {
+			return nil, fmt.Errorf("invalid route rate limit %q, expected METHOD /path=<requests>/<period>", entry)
+		}
+		l, err := ParseLimit(limit)
+		if err != nil {
+			return nil, err
+		}
+		limits[strings.ToUpper(method)+" "+strings.TrimSpace(path)] = l
+	}
+	return limits, nil
+}
+
+// Policy — лимиты сервиса: на адрес клиента до аутентификации, на клиента (пользователя или API-ключ)
+// для всех роутов и дополнительные, обычно более строгие, лимиты клиента на отдельные роуты.
+type Policy struct {
+	IP     Limit
+	Client Limit
+	Routes map[string]Limit
+}
+
+// Result — результат попытки забрать токен из корзины
+type Result struct {
+	Allowed   bool
+	Limit     Limit
+	Remaining int // сколько запросов ещё можно сделать подряд
+	// Через сколько появится следующий токен; ноль, если токены есть
+	RetryAfter time.Duration
+	// Через сколько корзина наполнится полностью
+	Reset time.Duration
+}
+
+// Store хранит корзины. Take пополняет корзину key по лимиту limit с момента прошлого обращения и забирает
+// из неё один токен, если он есть. Реализация должна быть безопасна для конкурентного использования;
+// общее хранилище для нескольких экземпляров сервиса должно выполнять Take атомарно.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
@@ -0,0 +1,151 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	l, err := ParseLimit(" 100/1m ")
+	require.NoError(t, err)
+	assert.Equal(t, Limit{Requests: 100, Period: time.Minute}, l)
+
+	l, err = ParseLimit("off")
+	require.NoError(t, err)
+	assert.False(t, l.Enabled())
+
+	for _, in := range []string{"", "100", "0/1m", "-1/1m", "x/1m", "10/0s", "10/m", "10/-1s"} {
+		_, err := ParseLimit(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestParseRouteLimits(t *testing.T) {
+	limits, err := ParseRouteLimits("post /transfer=10/1m, POST /holds/:id/capture=off")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Limit{
+		"POST /transfer":          {Requests: 10, Period: time.Minute},
+		"POST /holds/:id/capture": {},
+	}, limits)
+
+	limits, err = ParseRouteLimits("")
+	require.NoError(t, err)
+	assert.Empty(t, limits)
+
+	for _, in := range []string{"/transfer=10/1m", "POST transfer=10/1m", "POST /transfer", "POST /transfer=10"} {
+		_, err := ParseRouteLimits(in)
+		assert.Error(t, err, in)
+	}
+}
+
+// Хранилище с часами, которые передвигает тест
+func newTestStore() (*MemoryStore, *time.Time) {
+	now := time.Date(2025, 5, 10, 12, 0, 0, 0, time.UTC)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func TestMemoryStore_Take(t *testing.T) {
+	s, now := newTestStore()
+	ctx := context.Background()
+	limit := Limit{Requests: 3, Period: 3 * time.Second}
+
+	// Полная корзина позволяет сделать Requests запросов подряд
+	for i := 2; i >= 0; i-- {
+		res, err := s.Take(ctx, "user:1", limit)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, i, res.Remaining)
+	}
+	res, err := s.Take(ctx, "user:1", limit)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+	assert.Equal(t, time.Second, res.RetryAfter)
+	assert.Equal(t, 3*time.Second, res.Reset)
+
+	// У другого ключа своя корзина
+	res, err = s.Take(ctx, "user:2", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	// Токены возвращаются равномерно, но не больше ёмкости корзины
+	*now = now.Add(1500 * time.Millisecond)
+	res, err = s.Take(ctx, "user:1", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+	res, err = s.Take(ctx, "user:1", limit)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 500*time.Millisecond, res.RetryAfter)
+
+	*now = now.Add(time.Hour)
+	res, err = s.Take(ctx, "user:1", limit)
+	require.NoError(t, err)
+	assert.Equal(t, 2, res.Remaining)
+
+	// Нулевой лимит не ограничивает запросы
+	for range 10 {
+		res, err = s.Take(ctx, "user:3", Limit{})
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+	}
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	s, now := newTestStore()
+	ctx := context.Background()
+	limit := Limit{Requests: 10, Period: 10 * time.Minute}
+
+	// Пять токенов возвращаются за пять минут
+	for range 5 {
+		_, err := s.Take(ctx, "idle", limit)
+		require.NoError(t, err)
+	}
+	*now = now.Add(2 * time.Minute)
+	_, err := s.Take(ctx, "busy", Limit{Requests: 1, Period: time.Hour})
+	require.NoError(t, err)
+	assert.Len(t, s.buckets, 2)
+
+	// Корзина "idle" ещё не пополнилась и сохраняется
+	*now = now.Add(2 * time.Minute)
+	_, err = s.Take(ctx, "busy", Limit{Requests: 1, Period: time.Hour})
+	require.NoError(t, err)
+	assert.Len(t, s.buckets, 2)
+
+	*now = now.Add(10 * time.Minute)
+	_, err = s.Take(ctx, "busy", Limit{Requests: 1, Period: time.Hour})
+	require.NoError(t, err)
+	assert.Len(t, s.buckets, 1)
+}
+
+func TestMemoryStore_Concurrent(t *testing.T) {
+	s := NewMemoryStore()
+	limit := Limit{Requests: 50, Period: time.Hour}
+
+	var mu sync.Mutex
+	allowed := 0
+	var wg sync.WaitGroup
+	for range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := s.Take(context.Background(), "shared", limit)
+			assert.NoError(t, err)
+			if res.Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 50, allowed)
+}
@@ -0,0 +1,134 @@
+// Package recurrence описывает расписания повторяющихся операций в подмножестве формата
+// RRULE (RFC 5545): FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL, COUNT и UNTIL, например
+// "FREQ=MONTHLY;INTERVAL=1;COUNT=12".
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+// Частота повторения
+type Freq string
+
+const (
+	Daily   Freq = "DAILY"
+	Weekly  Freq = "WEEKLY"
+	Monthly Freq = "MONTHLY"
+)
+
+// Формат UNTIL: дата и время в UTC
+const untilLayout = "20060102T150405Z"
+
+// Rule — правило повторения. Вхождения отсчитываются от момента первого выполнения:
+// n-е вхождение — start плюс n*Interval дней, недель или месяцев. Если в месяце нет дня
+// первого выполнения (31-е число в апреле), используется последний день месяца.
+// Count ограничивает общее число вхождений, Until — момент последнего из них.
+type Rule struct {
+	Freq     Freq
+	Interval int
+	Count    int
+	Until    *time.Time
+}
+
+// Parse разбирает правило вида "FREQ=WEEKLY;INTERVAL=2;UNTIL=20251231T000000Z".
+// Ключи не зависят от регистра; FREQ обязателен, INTERVAL по умолчанию равен 1.
+func Parse(s string) (Rule, error) {
+	r := Rule{Interval: 1}
+	for _, part := range strings.Split(strings.TrimPrefix(strings.TrimSpace(s), "RRULE:"), ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return Rule{}, fmt.Errorf("%w: %q", ErrInvalidRule, part)
+		}
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			switch f := Freq(strings.ToUpper(value)); f {
+			case Daily, Weekly, Monthly:
+				r.Freq = f
+			default:
+				return Rule{}, fmt.Errorf("%w: unsupported FREQ %q", ErrInvalidRule, value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return Rule{}, fmt.Errorf("%w: INTERVAL must be a positive integer", ErrInvalidRule)
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return Rule{}, fmt.Errorf("%w: COUNT must be a positive integer", ErrInvalidRule)
+			}
+			r.Count = n
+		case "UNTIL":
+			t, err := time.Parse(untilLayout, strings.ToUpper(value))
+			if err != nil {
+				return Rule{}, fmt.Errorf("%w: UNTIL must look like 20251231T000000Z", ErrInvalidRule)
+			}
+			r.Until = &t
+		default:
+			return Rule{}, fmt.Errorf("%w: unsupported part %q", ErrInvalidRule, key)
+		}
+	}
+	if r.Freq == "" {
+		return Rule{}, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+	if r.Count > 0 && r.Until != nil {
+		return Rule{}, fmt.Errorf("%w: COUNT and UNTIL cannot be used together", ErrInvalidRule)
+	}
+	return r, nil
+}
+
+// String возвращает правило в каноническом виде
+func (r Rule) String() string {
+	s := fmt.Sprintf("FREQ=%s;INTERVAL=%d", r.Freq, r.Interval)
+	if r.Count > 0 {
+		s += fmt.Sprintf(";COUNT=%d", r.Count)
+	}
+	if r.Until != nil {
+		s += ";UNTIL=" + r.Until.UTC().Format(untilLayout)
+	}
+	return s
+}
+
+// Occurrence возвращает n-е вхождение (с нуля) для расписания, начинающегося в start
+func (r Rule) Occurrence(start time.Time, n int) time.Time {
+	step := n * r.Interval
+	switch r.Freq {
+	case Daily:
+		return start.AddDate(0, 0, step)
+	case Weekly:
+		return start.AddDate(0, 0, 7*step)
+	default:
+		// AddDate переносит 31 января + 1 месяц на 3 марта, поэтому день ограничивается длиной месяца
+		y, m, d := start.Date()
+		first := time.Date(y, m+time.Month(step), 1, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+		if last := daysIn(first); d > last {
+			d = last
+		}
+		return first.AddDate(0, 0, d-1)
+	}
+}
+
+// Next возвращает первое вхождение строго после after. ok = false, если вхождения закончились.
+func (r Rule) Next(start, after time.Time) (next time.Time, ok bool) {
+	for n := 0; r.Count == 0 || n < r.Count; n++ {
+		t := r.Occurrence(start, n)
+		if r.Until != nil && t.After(*r.Until) {
+			return time.Time{}, false
+		}
+		if t.After(after) {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func daysIn(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+}
@@ -0,0 +1,81 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	r, err := Parse("freq=weekly;interval=2;until=20251231t000000z")
+	require.NoError(t, err)
+	assert.Equal(t, Weekly, r.Freq)
+	assert.Equal(t, 2, r.Interval)
+	assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;UNTIL=20251231T000000Z", r.String())
+
+	r, err = Parse("RRULE:FREQ=MONTHLY;COUNT=12")
+	require.NoError(t, err)
+	assert.Equal(t, "FREQ=MONTHLY;INTERVAL=1;COUNT=12", r.String())
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=-1",
+		"FREQ=DAILY;UNTIL=2025-12-31",
+		"FREQ=DAILY;COUNT=2;UNTIL=20251231T000000Z",
+		"FREQ=DAILY;BYDAY=MO",
+		"FREQ",
+	} {
+		_, err := Parse(in)
+		assert.ErrorIs(t, err, ErrInvalidRule, in)
+	}
+}
+
+func TestRule_Occurrence_MonthEnd(t *testing.T) {
+	r, err := Parse("FREQ=MONTHLY")
+	require.NoError(t, err)
+	start := time.Date(2025, time.January, 31, 9, 0, 0, 0, time.UTC)
+
+	// Платёж 31-го числа в коротких месяцах переносится на последний день месяца, но не сдвигает следующие
+	assert.Equal(t, time.Date(2025, time.February, 28, 9, 0, 0, 0, time.UTC), r.Occurrence(start, 1))
+	assert.Equal(t, time.Date(2025, time.March, 31, 9, 0, 0, 0, time.UTC), r.Occurrence(start, 2))
+	assert.Equal(t, time.Date(2025, time.April, 30, 9, 0, 0, 0, time.UTC), r.Occurrence(start, 3))
+	assert.Equal(t, time.Date(2026, time.January, 31, 9, 0, 0, 0, time.UTC), r.Occurrence(start, 12))
+}
+
+func TestRule_Next(t *testing.T) {
+	start := time.Date(2025, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+	daily, err := Parse("FREQ=DAILY;INTERVAL=3")
+	require.NoError(t, err)
+	next, ok := daily.Next(start, start)
+	require.True(t, ok)
+	assert.Equal(t, start.AddDate(0, 0, 3), next)
+
+	// Момент до начала расписания — первым вхождением будет само начало
+	next, ok = daily.Next(start, start.Add(-time.Hour))
+	require.True(t, ok)
+	assert.Equal(t, start, next)
+
+	counted, err := Parse("FREQ=WEEKLY;COUNT=2")
+	require.NoError(t, err)
+	next, ok = counted.Next(start, start)
+	require.True(t, ok)
+	assert.Equal(t, start.AddDate(0, 0, 7), next)
+	_, ok = counted.Next(start, next)
+	assert.False(t, ok)
+
+	until, err := Parse("FREQ=DAILY;UNTIL=20250302T100000Z")
+	require.NoError(t, err)
+	next, ok = until.Next(start, start)
+	require.True(t, ok)
+	assert.Equal(t, start.AddDate(0, 0, 1), next)
+	_, ok = until.Next(start, next)
+	assert.False(t, ok)
+}
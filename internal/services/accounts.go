@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	repo "github.com/EugeneKrivoshein/fin_service/internal/postgres"
+)
+
+// OpenAccount открывает пользователю счёт в новой валюте
+func (s *Service) OpenAccount(ctx context.Context, userID int64, currency money.Currency) (*repo.Account, error) {
+	currency, err := money.ParseCurrency(string(currency))
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.OpenAccount(ctx, userID, currency)
+}
+
+// ListAccounts возвращает счета пользователя во всех валютах
+func (s *Service) ListAccounts(ctx context.Context, userID int64) ([]repo.Account, error) {
+	return s.repo.ListAccounts(ctx, userID)
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	repo "github.com/EugeneKrivoshein/fin_service/internal/postgres"
+)
+
+// MaxAdjustmentReasonLength — максимальная длина причины ручной корректировки в символах
+const MaxAdjustmentReasonLength = 500
+
+// AdjustBalance вносит ручную корректировку баланса пользователя в валюте currency (пустая — валюта
+// по умолчанию): положительная сумма зачисляется, отрицательная списывается. Причина обязательна и
+// сохраняется в транзакции. Непустой idempotencyKey защищает от повторной корректировки при ретраях клиента.
+func (s *Service) AdjustBalance(ctx context.Context, userID int64, amount money.Amount, currency money.Currency, reason, idempotencyKey string) (*repo.Transaction, error) {
+	if amount == 0 {
+		return nil, ErrZeroAdjustment
+	}
+	currency, err := resolveCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	if err := currency.CheckAmount(amount); err != nil {
+		return nil, err
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" || utf8.RuneCountInString(reason) > MaxAdjustmentReasonLength {
+		return nil, ErrInvalidReason
+	}
+
+	key := newIdempotencyKey(ctx, idempotencyKey, "adjustment", userID, amount, currency, reason)
+	t, err := s.repo.CreateAdjustment(ctx, userID, amount, currency, reason, key)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetUserRoles возвращает роли пользователя в административном API
+func (s *Service) GetUserRoles(ctx context.Context, userID int64) ([]string, error) {
+	return s.repo.GetUserRoles(ctx, userID)
+}
+
+// GrantRole выдаёт пользователю роль
+func (s *Service) GrantRole(ctx context.Context, userID int64, role string) error {
+	if !slices.Contains(repo.Roles, role) {
+		return ErrUnknownRole
+	}
+	return s.repo.GrantRole(ctx, userID, role)
+}
+
+// EnsureAdmin выдаёт роль admin пользователю username, создавая его, если такого ещё нет. Так при запуске
+// назначается первый администратор: создавать пользователей могут только администраторы и сервисы.
+func (s *Service) EnsureAdmin(ctx context.Context, username string) (*repo.User, error) {
+	u, err := s.repo.CreateUser(ctx, username)
+	if errors.Is(err, repo.ErrUsernameTaken) {
+		u, err = s.repo.GetUserByUsername(ctx, username)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = s.repo.GrantRole(ctx, u.ID, repo.RoleAdmin); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// RevokeRole отзывает роль у пользователя. Администратор callerID не может отозвать роль admin
+// у себя, чтобы не лишить сервис последнего администратора по ошибке.
+func (s *Service) RevokeRole(ctx context.Context, callerID, userID int64, role string) error {
+	if !slices.Contains(repo.Roles, role) {
+		return ErrUnknownRole
+	}
+	if role == repo.RoleAdmin && callerID == userID {
+		return ErrRevokeOwnAdminRole
+	}
+	return s.repo.RevokeRole(ctx, userID, role)
+}
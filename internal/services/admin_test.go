@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdjustBalance(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	userID := int64(1)
+	amount := money.MustParse("-25.00")
+	expected := &postgres.Transaction{ID: 7, TransactionType: "adjustment", SenderID: &userID}
+	mockRepo.On("CreateAdjustment", mock.Anything, userID, amount, money.Currency("USD"), "duplicate deposit", mock.AnythingOfType("*postgres.IdempotencyKey")).
+		Return(expected, nil)
+
+	// Причина сохраняется без пробелов по краям, код валюты приводится к верхнему регистру
+	tx, err := service.AdjustBalance(context.Background(), userID, amount, "usd", "  duplicate deposit ", "key-1")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, tx)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAdjustBalance_Validation(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+	ctx := context.Background()
+
+	_, err := service.AdjustBalance(ctx, 1, 0, "", "reason", "")
+	assert.ErrorIs(t, err, ErrZeroAdjustment)
+
+	_, err = service.AdjustBalance(ctx, 1, money.MustParse("10.00"), "", "   ", "")
+	assert.ErrorIs(t, err, ErrInvalidReason)
+
+	_, err = service.AdjustBalance(ctx, 1, money.MustParse("10.00"), "", strings.Repeat("я", MaxAdjustmentReasonLength+1), "")
+	assert.ErrorIs(t, err, ErrInvalidReason)
+
+	_, err = service.AdjustBalance(ctx, 1, money.MustParse("-10.50"), "JPY", "reason", "")
+	assert.ErrorIs(t, err, money.ErrTooManyFractionDigits)
+
+	mockRepo.AssertNotCalled(t, "CreateAdjustment", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRoles(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("GrantRole", mock.Anything, int64(2), postgres.RoleSupport).Return(nil)
+	mockRepo.On("RevokeRole", mock.Anything, int64(2), postgres.RoleAdmin).Return(postgres.ErrRoleNotGranted)
+
+	assert.NoError(t, service.GrantRole(ctx, 2, postgres.RoleSupport))
+	assert.ErrorIs(t, service.GrantRole(ctx, 2, "root"), ErrUnknownRole)
+	assert.ErrorIs(t, service.RevokeRole(ctx, 1, 2, postgres.RoleAdmin), postgres.ErrRoleNotGranted)
+
+	// Администратор не может лишить себя роли admin, но может отказаться от других ролей
+	assert.ErrorIs(t, service.RevokeRole(ctx, 1, 1, postgres.RoleAdmin), ErrRevokeOwnAdminRole)
+	mockRepo.On("RevokeRole", mock.Anything, int64(1), postgres.RoleSupport).Return(nil)
+	assert.NoError(t, service.RevokeRole(ctx, 1, 1, postgres.RoleSupport))
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestEnsureAdmin(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("CreateUser", mock.Anything, "root").Return(&postgres.User{ID: 1, Username: "root"}, nil).Once()
+	mockRepo.On("GrantRole", mock.Anything, int64(1), postgres.RoleAdmin).Return(nil)
+	u, err := service.EnsureAdmin(ctx, "root")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), u.ID)
+
+	// При повторном запуске пользователь уже есть: роль выдаётся ему же
+	mockRepo.On("CreateUser", mock.Anything, "root").Return(nil, postgres.ErrUsernameTaken).Once()
+	mockRepo.On("GetUserByUsername", mock.Anything, "root").Return(&postgres.User{ID: 1, Username: "root"}, nil)
+	u, err = service.EnsureAdmin(ctx, "root")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), u.ID)
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNumberOfCalls(t, "GrantRole", 2)
+}
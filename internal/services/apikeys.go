@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+	"unicode/utf8"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/auth"
+	repo "github.com/EugeneKrivoshein/fin_service/internal/postgres"
+)
+
+// Параметры ротации API-ключей
+const (
+	// DefaultAPIKeyRotationGrace — сколько старый ключ действует после ротации, если не указано иное
+	DefaultAPIKeyRotationGrace = 24 * time.Hour
+	maxAPIKeyRotationGrace     = 30 * 24 * time.Hour
+	maxAPIKeyNameLength        = 100
+)
+
+// CreateAPIKey выпускает API-ключ сервиса name с областями доступа scopes, действующий до expiresAt
+// (nil — бессрочно). Возвращённый ключ содержит сам ключ (Key) — он показывается только один раз.
+func (s *Service) CreateAPIKey(ctx context.Context, name string, scopes []string, expiresAt *time.Time) (*repo.APIKey, error) {
+	if name == "" || utf8.RuneCountInString(name) > maxAPIKeyNameLength {
+		return nil, ErrInvalidAPIKeyName
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrUnknownScope)
+	}
+	unique := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !slices.Contains(auth.Scopes, scope) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownScope, scope)
+		}
+		if !slices.Contains(unique, scope) {
+			unique = append(unique, scope)
+		}
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, ErrExpiresInPast
+	}
+
+	key, prefix, err := auth.NewAPIKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+	created, err := s.repo.CreateAPIKey(ctx, &repo.APIKey{Name: name, Prefix: prefix, Scopes: unique, ExpiresAt: expiresAt}, auth.HashAPIKey(key))
+	if err != nil {
+		return nil, err
+	}
+	created.Key = key
+	return created, nil
+}
+
+// ListAPIKeys возвращает все ключи без самих ключей
+func (s *Service) ListAPIKeys(ctx context.Context) ([]repo.APIKey, error) {
+	return s.repo.ListAPIKeys(ctx)
+}
+
+// RotateAPIKey выпускает новый ключ взамен действующего ключа id. Старый ключ действует ещё grace
+// (nil — DefaultAPIKeyRotationGrace, ноль — отзывается сразу).
+func (s *Service) RotateAPIKey(ctx context.Context, id int64, grace *time.Duration) (*repo.APIKey, error) {
+	g := DefaultAPIKeyRotationGrace
+	if grace != nil {
+		g = *grace
+	}
+	if g < 0 || g > maxAPIKeyRotationGrace {
+		return nil, ErrInvalidGracePeriod
+	}
+
+	key, prefix, err := auth.NewAPIKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+	rotated, err := s.repo.RotateAPIKey(ctx, id, prefix, auth.HashAPIKey(key), g)
+	if err != nil {
+		return nil, err
+	}
+	rotated.Key = key
+	return rotated, nil
+}
+
+// RevokeAPIKey отзывает ключ: запросы с ним сразу перестают приниматься
+func (s *Service) RevokeAPIKey(ctx context.Context, id int64) (*repo.APIKey, error) {
+	return s.repo.RevokeAPIKey(ctx, id)
+}
+
+// AuthenticateAPIKey возвращает действующий ключ по его значению; неизвестный, отозванный
+// и истёкший ключи отклоняются с ErrInvalidAPIKey
+func (s *Service) AuthenticateAPIKey(ctx context.Context, key string) (*repo.APIKey, error) {
+	if !auth.IsAPIKey(key) {
+		return nil, ErrInvalidAPIKey
+	}
+	k, err := s.repo.GetActiveAPIKey(ctx, auth.HashAPIKey(key))
+	if errors.Is(err, repo.ErrAPIKeyNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	return k, err
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/auth"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	var hash []byte
+	mockRepo.On("CreateAPIKey", mock.Anything, mock.MatchedBy(func(k *postgres.APIKey) bool {
+		return k.Name == "billing" && len(k.Scopes) == 2 && k.Key == ""
+	}), mock.Anything).Run(func(args mock.Arguments) {
+		hash = args.Get(2).([]byte)
+	}).Return(&postgres.APIKey{ID: 1, Name: "billing"}, nil).Once()
+
+	// Повторяющиеся области доступа сохраняются один раз
+	key, err := service.CreateAPIKey(context.Background(), "billing",
+		[]string{auth.ScopeDepositWrite, auth.ScopeTransactionsRead, auth.ScopeDepositWrite}, nil)
+	require.NoError(t, err)
+	assert.True(t, auth.IsAPIKey(key.Key))
+	assert.Equal(t, auth.HashAPIKey(key.Key), hash, "в базу передаётся хеш выданного ключа")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateAPIKey_Validation(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+	ctx := context.Background()
+	past := time.Now().Add(-time.Minute)
+
+	_, err := service.CreateAPIKey(ctx, "", []string{auth.ScopeDepositWrite}, nil)
+	assert.ErrorIs(t, err, ErrInvalidAPIKeyName)
+	_, err = service.CreateAPIKey(ctx, "billing", []string{"admin"}, nil)
+	assert.ErrorIs(t, err, ErrUnknownScope)
+	_, err = service.CreateAPIKey(ctx, "billing", nil, nil)
+	assert.ErrorIs(t, err, ErrUnknownScope)
+	_, err = service.CreateAPIKey(ctx, "billing", []string{auth.ScopeDepositWrite}, &past)
+	assert.ErrorIs(t, err, ErrExpiresInPast)
+	mockRepo.AssertNotCalled(t, "CreateAPIKey")
+}
+
+func TestRotateAPIKey(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("RotateAPIKey", mock.Anything, int64(1), mock.Anything, mock.Anything, DefaultAPIKeyRotationGrace).
+		Return(&postgres.APIKey{ID: 2}, nil).Once()
+	key, err := service.RotateAPIKey(ctx, 1, nil)
+	require.NoError(t, err)
+	assert.True(t, auth.IsAPIKey(key.Key))
+
+	immediately := time.Duration(0)
+	mockRepo.On("RotateAPIKey", mock.Anything, int64(1), mock.Anything, mock.Anything, immediately).
+		Return(nil, postgres.ErrAPIKeyInactive).Once()
+	_, err = service.RotateAPIKey(ctx, 1, &immediately)
+	assert.ErrorIs(t, err, postgres.ErrAPIKeyInactive)
+
+	tooLong := 31 * 24 * time.Hour
+	_, err = service.RotateAPIKey(ctx, 1, &tooLong)
+	assert.ErrorIs(t, err, ErrInvalidGracePeriod)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+	ctx := context.Background()
+
+	valid, _, err := auth.NewAPIKey()
+	require.NoError(t, err)
+	revoked, _, err := auth.NewAPIKey()
+	require.NoError(t, err)
+	mockRepo.On("GetActiveAPIKey", mock.Anything, auth.HashAPIKey(valid)).Return(&postgres.APIKey{ID: 1}, nil)
+	mockRepo.On("GetActiveAPIKey", mock.Anything, auth.HashAPIKey(revoked)).Return(nil, postgres.ErrAPIKeyNotFound)
+
+	k, err := service.AuthenticateAPIKey(ctx, valid)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), k.ID)
+
+	_, err = service.AuthenticateAPIKey(ctx, revoked)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	// Строка не в формате ключа отклоняется без запроса к базе
+	_, err = service.AuthenticateAPIKey(ctx, "not-a-key")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	mockRepo.AssertNumberOfCalls(t, "GetActiveAPIKey", 2)
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+
+	repo "github.com/EugeneKrivoshein/fin_service/internal/postgres"
+)
+
+// Ограничения размера страницы журнала аудита
+const (
+	DefaultAuditLimit = 50
+	MaxAuditLimit     = 200
+)
+
+// RecordAudit добавляет запись в журнал аудита
+func (s *Service) RecordAudit(ctx context.Context, e *repo.AuditEntry) error {
+	return s.repo.RecordAudit(ctx, e)
+}
+
+// ListAuditLog возвращает страницу журнала аудита от новых записей к старым
+func (s *Service) ListAuditLog(ctx context.Context, filter repo.AuditFilter) (*repo.AuditPage, error) {
+	switch filter.ActorType {
+	case "", repo.ActorUser, repo.ActorAPIKey:
+	default:
+		return nil, ErrInvalidActorType
+	}
+	switch filter.Result {
+	case "", repo.AuditSuccess, repo.AuditFailure:
+	default:
+		return nil, ErrInvalidAuditResult
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, ErrInvalidDateRange
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultAuditLimit
+	}
+	if filter.Limit > MaxAuditLimit {
+		filter.Limit = MaxAuditLimit
+	}
+	return s.repo.ListAuditLog(ctx, filter)
+}
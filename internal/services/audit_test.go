@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestListAuditLog(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	expected := &postgres.AuditPage{Entries: []postgres.AuditEntry{{ID: 3, Action: "POST /deposit"}}}
+	// Размер страницы по умолчанию и ограничение сверху
+	mockRepo.On("ListAuditLog", mock.Anything, postgres.AuditFilter{ActorType: postgres.ActorUser, Limit: DefaultAuditLimit}).
+		Return(expected, nil).Once()
+	mockRepo.On("ListAuditLog", mock.Anything, postgres.AuditFilter{Result: postgres.AuditFailure, Limit: MaxAuditLimit}).
+		Return(expected, nil).Once()
+
+	page, err := service.ListAuditLog(context.Background(), postgres.AuditFilter{ActorType: postgres.ActorUser})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, page)
+
+	_, err = service.ListAuditLog(context.Background(), postgres.AuditFilter{Result: postgres.AuditFailure, Limit: 1000})
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListAuditLog_Validation(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+	ctx := context.Background()
+
+	_, err := service.ListAuditLog(ctx, postgres.AuditFilter{ActorType: "robot"})
+	assert.ErrorIs(t, err, ErrInvalidActorType)
+
+	_, err = service.ListAuditLog(ctx, postgres.AuditFilter{Result: "ok"})
+	assert.ErrorIs(t, err, ErrInvalidAuditResult)
+
+	from := time.Date(2025, 5, 2, 0, 0, 0, 0, time.UTC)
+	to := from.Add(-time.Hour)
+	_, err = service.ListAuditLog(ctx, postgres.AuditFilter{From: &from, To: &to})
+	assert.ErrorIs(t, err, ErrInvalidDateRange)
+
+	mockRepo.AssertNotCalled(t, "ListAuditLog", mock.Anything, mock.Anything)
+}
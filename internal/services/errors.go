@@ -0,0 +1,39 @@
+package service
+
+import "errors"
+
+// Ошибки проверки входных данных на уровне сервиса, до обращения к репозиторию.
+// Ошибки хранилища (нехватка средств, несуществующий пользователь и т.п.) объявлены в пакете postgres.
+var (
+	ErrNonPositiveAmount = errors.New("amount must be positive")
+	ErrSameAccount       = errors.New("sender and receiver must be different")
+	ErrSameCurrency      = errors.New("currencies must be different")
+	ErrInvalidHoldTTL    = errors.New("hold ttl must be between 1 second and 30 days")
+	ErrStartInPast       = errors.New("start_at must be in the future")
+	ErrInvalidTier       = errors.New("tier must be 1-32 lowercase letters, digits, '_' or '-'")
+	ErrInvalidFeeRule    = errors.New("invalid fee rule")
+
+	ErrInvalidWebhookURL     = errors.New("webhook url must be an absolute http or https url")
+	ErrUnknownEventType      = errors.New("unknown webhook event type")
+	ErrInvalidDeliveryStatus = errors.New("status must be one of pending, delivered, dead")
+
+	ErrInvalidStatementFormat = errors.New("format must be one of csv, jsonl, pdf")
+
+	ErrInvalidAPIKey      = errors.New("invalid api key")
+	ErrInvalidAPIKeyName  = errors.New("api key name must be 1-100 characters")
+	ErrUnknownScope       = errors.New("unknown api key scope")
+	ErrExpiresInPast      = errors.New("expires_at must be in the future")
+	ErrInvalidGracePeriod = errors.New("grace period must be between 0 and 30 days")
+
+	ErrZeroAdjustment     = errors.New("adjustment amount must not be zero")
+	ErrInvalidReason      = errors.New("reason must be 1-500 characters")
+	ErrUnknownRole        = errors.New("unknown role")
+	ErrRevokeOwnAdminRole = errors.New("admins cannot revoke their own admin role")
+
+	ErrInvalidActorType   = errors.New("actor_type must be one of user, api_key")
+	ErrInvalidAuditResult = errors.New("result must be one of success, failure")
+
+	ErrConflictingCursors = errors.New("before and after cannot be used together")
+	ErrInvalidDateRange   = errors.New("from must be earlier than to")
+	ErrInvalidAmountRange = errors.New("min_amount must not exceed max_amount")
+)
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	repo "github.com/EugeneKrivoshein/fin_service/internal/postgres"
+)
+
+// Наибольший процент комиссии
+var maxFeePercent = money.MustParseRate("100")
+
+// ListFeeRules возвращает все правила комиссий
+func (s *Service) ListFeeRules(ctx context.Context) ([]repo.FeeRule, error) {
+	return s.repo.ListFeeRules(ctx)
+}
+
+// CreateFeeRule создаёт правило комиссии за переводы или снятия в валюте rule.Currency
+// (пустая — валюта по умолчанию), для всех пользователей или для тарифа rule.Tier
+func (s *Service) CreateFeeRule(ctx context.Context, rule repo.FeeRule) (*repo.FeeRule, error) {
+	if rule.TransactionType != "transfer" && rule.TransactionType != "withdrawal" {
+		return nil, fmt.Errorf("%w: transaction_type must be transfer or withdrawal", ErrInvalidFeeRule)
+	}
+	if rule.Tier != nil && !tierPattern.MatchString(*rule.Tier) {
+		return nil, ErrInvalidTier
+	}
+	currency, err := resolveCurrency(rule.Currency)
+	if err != nil {
+		return nil, err
+	}
+	rule.Currency = currency
+
+	for _, a := range []*money.Amount{&rule.Flat, rule.MinFee, rule.MaxFee} {
+		if a == nil {
+			continue
+		}
+		if *a < 0 {
+			return nil, fmt.Errorf("%w: fee amounts must not be negative", ErrInvalidFeeRule)
+		}
+		if err := currency.CheckAmount(*a); err != nil {
+			return nil, err
+		}
+	}
+	if rule.Percent != nil && rule.Percent.Cmp(maxFeePercent) > 0 {
+		return nil, fmt.Errorf("%w: percent must not exceed 100", ErrInvalidFeeRule)
+	}
+	if rule.MinFee != nil && rule.MaxFee != nil && *rule.MinFee > *rule.MaxFee {
+		return nil, fmt.Errorf("%w: min_fee must not exceed max_fee", ErrInvalidFeeRule)
+	}
+
+	return s.repo.CreateFeeRule(ctx, &rule)
+}
+
+// DeleteFeeRule удаляет правило комиссии
+func (s *Service) DeleteFeeRule(ctx context.Context, id int64) error {
+	return s.repo.DeleteFeeRule(ctx, id)
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateFeeRule(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	percent := money.MustParseRate("1.5")
+	mockRepo.On("CreateFeeRule", mock.Anything, mock.MatchedBy(func(r *postgres.FeeRule) bool {
+		return r.Currency == money.DefaultCurrency && r.TransactionType == "transfer"
+	})).Return(&postgres.FeeRule{ID: 1}, nil)
+
+	rule, err := service.CreateFeeRule(context.Background(), postgres.FeeRule{TransactionType: "transfer", Percent: &percent})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), rule.ID)
+
+	minFee, maxFee := money.MustParse("100.00"), money.MustParse("10.00")
+	tooHigh := money.MustParseRate("100.5")
+	tier := "VIP"
+	for _, invalid := range []postgres.FeeRule{
+		{TransactionType: "deposit"},
+		{TransactionType: "transfer", Flat: money.MustParse("-1.00")},
+		{TransactionType: "transfer", Percent: &tooHigh},
+		{TransactionType: "transfer", MinFee: &minFee, MaxFee: &maxFee},
+	} {
+		_, err := service.CreateFeeRule(context.Background(), invalid)
+		assert.ErrorIs(t, err, ErrInvalidFeeRule, "%+v", invalid)
+	}
+	_, err = service.CreateFeeRule(context.Background(), postgres.FeeRule{TransactionType: "withdrawal", Tier: &tier})
+	assert.ErrorIs(t, err, ErrInvalidTier)
+
+	mockRepo.AssertNumberOfCalls(t, "CreateFeeRule", 1)
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/fx"
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	repo "github.com/EugeneKrivoshein/fin_service/internal/postgres"
+)
+
+// DefaultFXQuoteTTL — время, в течение которого курс котировки гарантирован
+const DefaultFXQuoteTTL = time.Minute
+
+// WithFXProvider подключает источник курсов для котировок. Если ttl не положителен,
+// используется DefaultFXQuoteTTL. Без провайдера котировки недоступны.
+func WithFXProvider(p fx.Provider, ttl time.Duration) Option {
+	return func(s *Service) {
+		s.fx = p
+		if ttl > 0 {
+			s.fxQuoteTTL = ttl
+		}
+	}
+}
+
+// CreateFXQuote фиксирует текущий курс обмена from на to на время жизни котировки
+func (s *Service) CreateFXQuote(ctx context.Context, from, to money.Currency) (*repo.FXQuote, error) {
+	from, err := money.ParseCurrency(string(from))
+	if err != nil {
+		return nil, err
+	}
+	to, err = money.ParseCurrency(string(to))
+	if err != nil {
+		return nil, err
+	}
+	if from == to {
+		return nil, ErrSameCurrency
+	}
+	if s.fx == nil {
+		return nil, fx.ErrRateUnavailable
+	}
+
+	rate, err := s.fx.Rate(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.CreateFXQuote(ctx, from, to, rate, s.fxQuoteTTL)
+}
+
+// TransferWithQuote переводит деньги с конвертацией по курсу котировки quoteID: отправитель платит amount
+// в исходной валюте котировки, получатель получает сумму в валюте назначения.
+// Непустой idempotencyKey защищает от повторного перевода при ретраях клиента.
+func (s *Service) TransferWithQuote(ctx context.Context, senderID, receiverID int64, amount money.Amount, quoteID, idempotencyKey string) (*repo.Transaction, error) {
+	if amount <= 0 {
+		return nil, ErrNonPositiveAmount
+	}
+	if senderID == receiverID {
+		return nil, ErrSameAccount
+	}
+	key := newIdempotencyKey(ctx, idempotencyKey, "transfer", senderID, receiverID, amount, quoteID)
+	t, err := s.repo.TransferFX(ctx, senderID, receiverID, amount, quoteID, key)
+	if err != nil {
+		op := FailedOperation{Operation: "transfer", SenderID: &senderID, ReceiverID: &receiverID, Amount: amount, FXQuoteID: &quoteID}
+		s.notifyFailed(ctx, EventTransferFailed, op, err)
+		return nil, err
+	}
+	return t, nil
+}
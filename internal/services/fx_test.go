@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/fx"
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Провайдер с фиксированными курсами
+type staticRates map[string]money.Rate
+
+func (r staticRates) Rate(_ context.Context, from, to money.Currency) (money.Rate, error) {
+	rate, ok := r[string(from)+"/"+string(to)]
+	if !ok {
+		return money.Rate{}, fx.ErrRateUnavailable
+	}
+	return rate, nil
+}
+
+func TestCreateFXQuote(t *testing.T) {
+	mockRepo := new(MockRepository)
+	rate := money.MustParseRate("0.0108")
+	service := NewService(mockRepo, WithFXProvider(staticRates{"RUB/USD": rate}, 30*time.Second))
+
+	expected := &postgres.FXQuote{ID: "q1", FromCurrency: "RUB", ToCurrency: "USD", Rate: rate}
+	mockRepo.On("CreateFXQuote", mock.Anything, money.Currency("RUB"), money.Currency("USD"), rate, 30*time.Second).
+		Return(expected, nil)
+
+	quote, err := service.CreateFXQuote(context.Background(), "rub", "usd")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, quote)
+
+	_, err = service.CreateFXQuote(context.Background(), "USD", "EUR")
+	assert.ErrorIs(t, err, fx.ErrRateUnavailable)
+
+	_, err = service.CreateFXQuote(context.Background(), "USD", "usd")
+	assert.ErrorIs(t, err, ErrSameCurrency)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateFXQuote_NoProvider(t *testing.T) {
+	service := NewService(new(MockRepository))
+
+	_, err := service.CreateFXQuote(context.Background(), "RUB", "USD")
+	assert.ErrorIs(t, err, fx.ErrRateUnavailable)
+}
+
+func TestTransferWithQuote(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	amount := money.MustParse("1000.00")
+	mockRepo.On("TransferFX", mock.Anything, int64(1), int64(2), amount, "q1", mock.AnythingOfType("*postgres.IdempotencyKey")).
+		Return(&postgres.Transaction{ID: 5}, nil)
+
+	_, err := service.TransferWithQuote(context.Background(), 1, 2, amount, "q1", "key-1")
+	assert.NoError(t, err)
+
+	_, err = service.TransferWithQuote(context.Background(), 1, 1, amount, "q1", "")
+	assert.ErrorIs(t, err, ErrSameAccount)
+
+	mockRepo.AssertNumberOfCalls(t, "TransferFX", 1)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	repo "github.com/EugeneKrivoshein/fin_service/internal/postgres"
+)
+
+// Ограничения срока жизни холда
+const (
+	DefaultHoldTTL = 7 * 24 * time.Hour
+	MaxHoldTTL     = 30 * 24 * time.Hour
+)
+
+// CreateHold резервирует amount на счёте пользователя в валюте currency (пустая — валюта по умолчанию)
+// на время ttl (ноль — DefaultHoldTTL). Зарезервированная сумма перестаёт входить в доступный остаток.
+func (s *Service) CreateHold(ctx context.Context, userID int64, amount money.Amount, currency money.Currency, ttl time.Duration) (*repo.Hold, error) {
+	currency, err := checkAmount(amount, currency)
+	if err != nil {
+		return nil, err
+	}
+	if ttl == 0 {
+		ttl = DefaultHoldTTL
+	}
+	if ttl < 0 || ttl > MaxHoldTTL {
+		return nil, ErrInvalidHoldTTL
+	}
+	return s.repo.CreateHold(ctx, userID, amount, currency, ttl)
+}
+
+// GetHold возвращает холд по id
+func (s *Service) GetHold(ctx context.Context, holdID int64) (*repo.Hold, error) {
+	return s.repo.GetHold(ctx, holdID)
+}
+
+// CaptureHold списывает холд: amount (ноль — вся сумма холда) переводится пользователю receiverID,
+// а если он не задан — списывается со счёта. Получатель не может совпадать с владельцем холда.
+// Остаток резерва освобождается. Непустой idempotencyKey защищает от повторного списания при ретраях клиента.
+func (s *Service) CaptureHold(ctx context.Context, holdID int64, amount money.Amount, receiverID *int64, idempotencyKey string) (*repo.Hold, *repo.Transaction, error) {
+	if amount < 0 {
+		return nil, nil, ErrNonPositiveAmount
+	}
+	var receiver int64
+	if receiverID != nil {
+		receiver = *receiverID
+		// Владелец холда не меняется, поэтому его можно проверить до блокировки холда в репозитории
+		h, err := s.repo.GetHold(ctx, holdID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if h.UserID == receiver {
+			return nil, nil, ErrSameAccount
+		}
+	}
+	key := newIdempotencyKey(ctx, idempotencyKey, "capture", holdID, amount, receiver)
+	h, t, err := s.repo.CaptureHold(ctx, holdID, amount, receiverID, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return h, t, nil
+}
+
+// ReleaseHold освобождает холд, не списывая деньги
+func (s *Service) ReleaseHold(ctx context.Context, holdID int64) (*repo.Hold, error) {
+	return s.repo.ReleaseHold(ctx, holdID)
+}
+
+// RunHoldExpiry каждые interval переводит истёкшие холды в статус expired, пока не отменён ctx.
+// Деньги истёкших холдов становятся доступны сразу по наступлении срока, фоновая задача
+// лишь обновляет их статус.
+func (s *Service) RunHoldExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.repo.ExpireHolds(ctx)
+			if err != nil {
+				log.Printf("Ошибка истечения холдов: %v", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("Истекло холдов: %d", n)
+			}
+		}
+	}
+}
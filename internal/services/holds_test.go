@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateHold(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	amount := money.MustParse("500.00")
+	expected := &postgres.Hold{ID: 1, UserID: 1, Amount: amount, Currency: "USD", Status: postgres.HoldStatusActive}
+	mockRepo.On("CreateHold", mock.Anything, int64(1), amount, money.Currency("USD"), DefaultHoldTTL).Return(expected, nil)
+	mockRepo.On("CreateHold", mock.Anything, int64(1), amount, money.DefaultCurrency, time.Hour).Return(expected, nil)
+
+	// Без срока используется срок по умолчанию, валюта приводится к каноническому виду
+	hold, err := service.CreateHold(context.Background(), 1, amount, "usd", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, hold)
+
+	_, err = service.CreateHold(context.Background(), 1, amount, "", time.Hour)
+	assert.NoError(t, err)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateHold_Validation(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	_, err := service.CreateHold(context.Background(), 1, 0, "", 0)
+	assert.ErrorIs(t, err, ErrNonPositiveAmount)
+
+	_, err = service.CreateHold(context.Background(), 1, money.MustParse("1.00"), "", -time.Second)
+	assert.ErrorIs(t, err, ErrInvalidHoldTTL)
+
+	_, err = service.CreateHold(context.Background(), 1, money.MustParse("1.00"), "", MaxHoldTTL+time.Second)
+	assert.ErrorIs(t, err, ErrInvalidHoldTTL)
+
+	_, err = service.CreateHold(context.Background(), 1, money.MustParse("1.00"), "XXX", 0)
+	assert.ErrorIs(t, err, money.ErrUnsupportedCurrency)
+
+	mockRepo.AssertNotCalled(t, "CreateHold", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCaptureHold(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	receiverID := int64(2)
+	amount := money.MustParse("450.00")
+	hold := &postgres.Hold{ID: 7, Status: postgres.HoldStatusCaptured}
+	transfer := &postgres.Transaction{ID: 10, TransactionType: "transfer"}
+	mockRepo.On("GetHold", mock.Anything, int64(7)).Return(&postgres.Hold{ID: 7, UserID: 1}, nil)
+	mockRepo.On("CaptureHold", mock.Anything, int64(7), amount, &receiverID, mock.AnythingOfType("*postgres.IdempotencyKey")).
+		Return(hold, transfer, nil)
+	mockRepo.On("CaptureHold", mock.Anything, int64(8), money.Amount(0), (*int64)(nil), (*postgres.IdempotencyKey)(nil)).
+		Return(hold, &postgres.Transaction{ID: 11, TransactionType: "withdrawal"}, nil)
+
+	h, tx, err := service.CaptureHold(context.Background(), 7, amount, &receiverID, "key-1")
+	assert.NoError(t, err)
+	assert.Equal(t, hold, h)
+	assert.Equal(t, transfer, tx)
+
+	// Нулевая сумма — списание всего холда
+	_, tx, err = service.CaptureHold(context.Background(), 8, 0, nil, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "withdrawal", tx.TransactionType)
+
+	_, _, err = service.CaptureHold(context.Background(), 7, money.MustParse("-1.00"), nil, "")
+	assert.ErrorIs(t, err, ErrNonPositiveAmount)
+
+	// Перевод холда самому владельцу отклоняется, как и обычный перевод самому себе
+	owner := int64(1)
+	_, _, err = service.CaptureHold(context.Background(), 7, amount, &owner, "")
+	assert.ErrorIs(t, err, ErrSameAccount)
+
+	mockRepo.AssertNumberOfCalls(t, "CaptureHold", 2)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCaptureHold_IdempotencyKeyDependsOnReceiver(t *testing.T) {
+	receiverID := int64(2)
+	withdrawal := newIdempotencyKey(context.Background(), "key-1", "capture", int64(7), money.MustParse("1.00"), int64(0))
+	transfer := newIdempotencyKey(context.Background(), "key-1", "capture", int64(7), money.MustParse("1.00"), receiverID)
+	assert.NotEqual(t, withdrawal.RequestHash, transfer.RequestHash)
+}
+
+func TestRunHoldExpiry(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mockRepo.On("ExpireHolds", mock.Anything).Return(int64(2), nil).Once().Run(func(mock.Arguments) { cancel() })
+
+	done := make(chan struct{})
+	go func() {
+		service.RunHoldExpiry(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("RunHoldExpiry did not stop after context cancellation")
+	}
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	repo "github.com/EugeneKrivoshein/fin_service/internal/postgres"
+)
+
+// Допустимое имя тарифа: строчные латинские буквы, цифры, "_" и "-"
+var tierPattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// GetSpendingStatus возвращает действующие лимиты расходов пользователя в валюте
+// (пустая — валюта по умолчанию), потраченные за сутки и месяц суммы и остаток лимита
+func (s *Service) GetSpendingStatus(ctx context.Context, userID int64, currency money.Currency) (*repo.SpendingStatus, error) {
+	currency, err := resolveCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetSpendingStatus(ctx, userID, currency)
+}
+
+// ListTierSpendingLimits возвращает лимиты тарифа во всех валютах
+func (s *Service) ListTierSpendingLimits(ctx context.Context, tier string) ([]repo.SpendingLimits, error) {
+	if !tierPattern.MatchString(tier) {
+		return nil, ErrInvalidTier
+	}
+	return s.repo.ListTierSpendingLimits(ctx, tier)
+}
+
+// SetTierSpendingLimits задаёт лимиты тарифа в валюте limits.Currency (пустая — валюта по умолчанию).
+// Они действуют для всех пользователей тарифа, кроме тех, кому заданы собственные лимиты.
+func (s *Service) SetTierSpendingLimits(ctx context.Context, tier string, limits repo.SpendingLimits) (*repo.SpendingLimits, error) {
+	if !tierPattern.MatchString(tier) {
+		return nil, ErrInvalidTier
+	}
+	limits, err := checkSpendingLimits(limits)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.SetTierSpendingLimits(ctx, tier, limits)
+}
+
+// SetUserSpendingLimits задаёт пользователю собственные лимиты в валюте limits.Currency
+// (пустая — валюта по умолчанию); они полностью заменяют лимиты его тарифа в этой валюте
+func (s *Service) SetUserSpendingLimits(ctx context.Context, userID int64, limits repo.SpendingLimits) (*repo.SpendingLimits, error) {
+	limits, err := checkSpendingLimits(limits)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.SetUserSpendingLimits(ctx, userID, limits)
+}
+
+// DeleteUserSpendingLimits удаляет собственные лимиты пользователя в валюте: снова действуют лимиты тарифа
+func (s *Service) DeleteUserSpendingLimits(ctx context.Context, userID int64, currency money.Currency) error {
+	currency, err := resolveCurrency(currency)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteUserSpendingLimits(ctx, userID, currency)
+}
+
+// SetUserTier переводит пользователя на тариф tier. Тариф без заданных лимитов расходы не ограничивает.
+func (s *Service) SetUserTier(ctx context.Context, userID int64, tier string) (*repo.User, error) {
+	if !tierPattern.MatchString(tier) {
+		return nil, ErrInvalidTier
+	}
+	return s.repo.SetUserTier(ctx, userID, tier)
+}
+
+// Проверяет лимиты: каждый заданный лимит положителен и записан с допустимой для валюты точностью
+func checkSpendingLimits(limits repo.SpendingLimits) (repo.SpendingLimits, error) {
+	currency, err := resolveCurrency(limits.Currency)
+	if err != nil {
+		return limits, err
+	}
+	limits.Currency = currency
+	for _, l := range []*money.Amount{limits.PerTransaction, limits.Daily, limits.Monthly} {
+		if l == nil {
+			continue
+		}
+		if _, err := checkAmount(*l, currency); err != nil {
+			return limits, err
+		}
+	}
+	return limits, nil
+}
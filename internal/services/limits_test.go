@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSetTierSpendingLimits(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	daily := money.MustParse("300000.00")
+	expected := postgres.SpendingLimits{Currency: money.DefaultCurrency, Daily: &daily}
+	mockRepo.On("SetTierSpendingLimits", mock.Anything, "premium", expected).Return(&expected, nil)
+
+	// Пустая валюта заменяется валютой по умолчанию
+	limits, err := service.SetTierSpendingLimits(context.Background(), "premium", postgres.SpendingLimits{Daily: &daily})
+	assert.NoError(t, err)
+	assert.Equal(t, &expected, limits)
+
+	_, err = service.SetTierSpendingLimits(context.Background(), "Premium Plus", expected)
+	assert.ErrorIs(t, err, ErrInvalidTier)
+
+	zero := money.Amount(0)
+	_, err = service.SetTierSpendingLimits(context.Background(), "premium", postgres.SpendingLimits{Monthly: &zero})
+	assert.ErrorIs(t, err, ErrNonPositiveAmount)
+
+	mockRepo.AssertNumberOfCalls(t, "SetTierSpendingLimits", 1)
+}
+
+func TestSetUserTier(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	mockRepo.On("SetUserTier", mock.Anything, int64(1), "premium").Return(&postgres.User{ID: 1, Tier: "premium"}, nil)
+
+	u, err := service.SetUserTier(context.Background(), 1, "premium")
+	assert.NoError(t, err)
+	assert.Equal(t, "premium", u.Tier)
+
+	_, err = service.SetUserTier(context.Background(), 1, "")
+	assert.ErrorIs(t, err, ErrInvalidTier)
+	mockRepo.AssertNumberOfCalls(t, "SetUserTier", 1)
+}
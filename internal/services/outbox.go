@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/outbox"
+)
+
+// Сколько событий outbox ретранслятор публикует за один проход
+const outboxBatchSize = 100
+
+// WithOutboxPublisher задаёт получателя событий outbox для RunOutboxRelay
+func WithOutboxPublisher(p outbox.Publisher) Option {
+	return func(s *Service) {
+		s.outbox = p
+	}
+}
+
+// RunOutboxRelay каждые interval публикует события outbox, пока не отменён ctx. За один тик публикуется
+// всё накопленное. Если публикатор не задан, ретранслятор не запускается и события копятся в outbox.
+func (s *Service) RunOutboxRelay(ctx context.Context, interval time.Duration) {
+	if s.outbox == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.relayOutbox(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Ошибка публикации событий outbox: %v", err)
+			}
+		}
+	}
+}
+
+// Публикует события outbox проходами по outboxBatchSize, пока они не закончатся или публикация
+// не завершится ошибкой; возвращает число опубликованных событий
+func (s *Service) relayOutbox(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		n, err := s.repo.RelayOutbox(ctx, outboxBatchSize, s.outbox.Publish)
+		total += n
+		if err != nil || n < outboxBatchSize {
+			return total, err
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/outbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRelayOutbox(t *testing.T) {
+	mockRepo := new(MockRepository)
+	publisher := &outbox.MemoryPublisher{}
+	service := NewService(mockRepo, WithOutboxPublisher(publisher))
+
+	// Полная пачка — ретранслятор сразу берёт следующую, неполная завершает проход
+	full := make([]outbox.Event, outboxBatchSize)
+	for i := range full {
+		full[i] = outbox.Event{ID: int64(i + 1), UserID: 1, Type: "deposit.completed"}
+	}
+	rest := []outbox.Event{{ID: int64(outboxBatchSize + 1), UserID: 2, Type: "transfer.completed"}}
+	mockRepo.On("RelayOutbox", mock.Anything, outboxBatchSize).Return(full, nil).Once()
+	mockRepo.On("RelayOutbox", mock.Anything, outboxBatchSize).Return(rest, nil).Once()
+
+	n, err := service.relayOutbox(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, outboxBatchSize+1, n)
+	assert.Len(t, publisher.Events(), outboxBatchSize+1)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRelayOutbox_Error(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, WithOutboxPublisher(&outbox.MemoryPublisher{}))
+
+	mockRepo.On("RelayOutbox", mock.Anything, outboxBatchSize).Return([]outbox.Event{}, errors.New("connection refused")).Once()
+
+	_, err := service.relayOutbox(context.Background())
+	assert.Error(t, err)
+	mockRepo.AssertNumberOfCalls(t, "RelayOutbox", 1)
+}
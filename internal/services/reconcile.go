@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	repo "github.com/EugeneKrivoshein/fin_service/internal/postgres"
+)
+
+// Reconcile пересчитывает балансы всех счетов пользователей по истории транзакций и сообщает о расхождениях.
+// Если fix истинно, каждое расхождение исправляется корректирующей проводкой (см. Repository.AdjustBalance).
+func (s *Service) Reconcile(ctx context.Context, fix bool) (*repo.ReconciliationReport, error) {
+	report, err := s.repo.Reconcile(ctx)
+	if err != nil || !fix {
+		return report, err
+	}
+
+	for i, m := range report.Mismatches {
+		line, err := s.repo.AdjustBalance(ctx, m.UserID, m.Currency)
+		if err != nil {
+			return report, fmt.Errorf("failed to adjust %s balance of user %d: %w", m.Currency, m.UserID, err)
+		}
+		report.Mismatches[i] = *line
+	}
+	return report, nil
+}
+
+// WriteReconciliationCSV выводит расхождения из отчёта сверки в формате CSV с заголовком
+func WriteReconciliationCSV(w io.Writer, report *repo.ReconciliationReport) error {
+	cw := csv.NewWriter(w)
+	header := []string{
+		"user_id", "username", "currency", "balance", "opening_balance", "expected_balance", "ledger_balance", "difference", "adjusted",
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, m := range report.Mismatches {
+		record := []string{
+			strconv.FormatInt(m.UserID, 10),
+			m.Username,
+			m.Currency.String(),
+			m.Balance.String(),
+			m.Opening.String(),
+			m.Expected.String(),
+			m.LedgerBalance.String(),
+			m.Difference.String(),
+			strconv.FormatBool(m.Adjusted),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
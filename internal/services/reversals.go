@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	repo "github.com/EugeneKrivoshein/fin_service/internal/postgres"
+)
+
+// ReverseTransaction сторнирует перевод transactionID: получатель возвращает отправителю amount
+// (ноль — всю сумму перевода). Непустой idempotencyKey защищает от повторного сторно при ретраях клиента.
+func (s *Service) ReverseTransaction(ctx context.Context, transactionID int64, amount money.Amount, idempotencyKey string) (*repo.Transaction, error) {
+	if amount < 0 {
+		return nil, ErrNonPositiveAmount
+	}
+	key := newIdempotencyKey(ctx, idempotencyKey, "reversal", transactionID, amount)
+	t, err := s.repo.ReverseTransaction(ctx, transactionID, amount, key)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
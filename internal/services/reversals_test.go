@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestReverseTransaction(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	originalID := int64(5)
+	expected := &postgres.Transaction{ID: 6, TransactionType: "reversal", ReversalOf: &originalID}
+	mockRepo.On("ReverseTransaction", mock.Anything, originalID, money.Amount(0), (*postgres.IdempotencyKey)(nil)).
+		Return(expected, nil)
+	mockRepo.On("ReverseTransaction", mock.Anything, originalID, money.MustParse("10.00"), mock.AnythingOfType("*postgres.IdempotencyKey")).
+		Return(nil, postgres.ErrAlreadyReversed)
+
+	// Нулевая сумма — сторно всего перевода
+	tx, err := service.ReverseTransaction(context.Background(), originalID, 0, "")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, tx)
+
+	_, err = service.ReverseTransaction(context.Background(), originalID, money.MustParse("10.00"), "key-1")
+	assert.ErrorIs(t, err, postgres.ErrAlreadyReversed)
+
+	_, err = service.ReverseTransaction(context.Background(), originalID, money.MustParse("-1.00"), "")
+	assert.ErrorIs(t, err, ErrNonPositiveAmount)
+
+	mockRepo.AssertNumberOfCalls(t, "ReverseTransaction", 2)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	repo "github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	"github.com/EugeneKrivoshein/fin_service/internal/recurrence"
+)
+
+// Ограничения размера страницы истории выполнений запланированного перевода
+const (
+	DefaultScheduledRunsLimit = 20
+	MaxScheduledRunsLimit     = 100
+)
+
+// Сколько наступивших переводов планировщик выбирает за один проход
+const schedulerBatchSize = 100
+
+// CreateScheduledTransfer планирует перевод в валюте currency (пустая — валюта по умолчанию) на момент startAt.
+// Если задано правило rule (RRULE, например "FREQ=MONTHLY"), перевод повторяется по нему, начиная со startAt.
+func (s *Service) CreateScheduledTransfer(ctx context.Context, senderID, receiverID int64, amount money.Amount, currency money.Currency, startAt time.Time, rule string) (*repo.ScheduledTransfer, error) {
+	currency, err := checkAmount(amount, currency)
+	if err != nil {
+		return nil, err
+	}
+	if senderID == receiverID {
+		return nil, ErrSameAccount
+	}
+	startAt = startAt.UTC()
+	if !startAt.After(time.Now()) {
+		return nil, ErrStartInPast
+	}
+
+	st := &repo.ScheduledTransfer{
+		SenderID:   senderID,
+		ReceiverID: receiverID,
+		Amount:     amount,
+		Currency:   currency,
+		StartAt:    startAt,
+	}
+	if rule != "" {
+		r, err := recurrence.Parse(rule)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := r.Next(startAt, startAt.Add(-time.Nanosecond)); !ok {
+			return nil, fmt.Errorf("%w: UNTIL is before start_at", recurrence.ErrInvalidRule)
+		}
+		canonical := r.String()
+		st.Recurrence = &canonical
+	}
+
+	return s.repo.CreateScheduledTransfer(ctx, st)
+}
+
+// GetScheduledTransfer возвращает запланированный перевод по id
+func (s *Service) GetScheduledTransfer(ctx context.Context, id int64) (*repo.ScheduledTransfer, error) {
+	return s.repo.GetScheduledTransfer(ctx, id)
+}
+
+// ListScheduledTransfers возвращает запланированные переводы пользователя-отправителя
+func (s *Service) ListScheduledTransfers(ctx context.Context, userID int64) ([]repo.ScheduledTransfer, error) {
+	return s.repo.ListScheduledTransfers(ctx, userID)
+}
+
+// ListScheduledTransferRuns возвращает последние выполнения запланированного перевода
+func (s *Service) ListScheduledTransferRuns(ctx context.Context, id int64, limit int) ([]repo.ScheduledTransferRun, error) {
+	if limit <= 0 {
+		limit = DefaultScheduledRunsLimit
+	}
+	if limit > MaxScheduledRunsLimit {
+		limit = MaxScheduledRunsLimit
+	}
+	return s.repo.ListScheduledTransferRuns(ctx, id, limit)
+}
+
+// PauseScheduledTransfer приостанавливает выполнение запланированного перевода
+func (s *Service) PauseScheduledTransfer(ctx context.Context, id int64) (*repo.ScheduledTransfer, error) {
+	return s.repo.SetScheduledTransferStatus(ctx, id, repo.ScheduleStatusPaused, nil)
+}
+
+// ResumeScheduledTransfer возобновляет приостановленный перевод. Вхождения, пропущенные за время паузы,
+// не выполняются: следующим станет ближайшее будущее вхождение, а просроченный разовый перевод
+// выполнится сразу. Если вхождений больше нет, перевод завершается.
+func (s *Service) ResumeScheduledTransfer(ctx context.Context, id int64) (*repo.ScheduledTransfer, error) {
+	st, err := s.repo.GetScheduledTransfer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if st.Status == repo.ScheduleStatusActive {
+		return st, nil
+	}
+
+	now := time.Now().UTC()
+	next := now
+	if st.NextRunAt != nil && st.NextRunAt.After(now) {
+		next = *st.NextRunAt
+	} else if st.Recurrence != nil {
+		n, err := nextScheduledRun(st, now)
+		if err != nil {
+			return nil, err
+		}
+		if n == nil {
+			return s.repo.SetScheduledTransferStatus(ctx, id, repo.ScheduleStatusCompleted, nil)
+		}
+		next = *n
+	}
+	return s.repo.SetScheduledTransferStatus(ctx, id, repo.ScheduleStatusActive, &next)
+}
+
+// CancelScheduledTransfer отменяет запланированный перевод; отменённый перевод возобновить нельзя
+func (s *Service) CancelScheduledTransfer(ctx context.Context, id int64) (*repo.ScheduledTransfer, error) {
+	return s.repo.SetScheduledTransferStatus(ctx, id, repo.ScheduleStatusCancelled, nil)
+}
+
+// RunScheduler каждые interval выполняет наступившие запланированные переводы, пока не отменён ctx.
+// Несколько экземпляров сервиса могут работать одновременно: каждый перевод выполняется под
+// рекомендательной блокировкой Postgres, поэтому одно вхождение не выполнится дважды.
+func (s *Service) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.runDueScheduledTransfers(ctx, time.Now().UTC()); err != nil && ctx.Err() == nil {
+				log.Printf("Ошибка планировщика переводов: %v", err)
+			}
+		}
+	}
+}
+
+// Выполняет переводы, время которых наступило к моменту now, и возвращает число выполненных.
+// Ошибка одного перевода не мешает выполнению остальных.
+func (s *Service) runDueScheduledTransfers(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.repo.ListDueScheduledTransfers(ctx, now, schedulerBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	executed := 0
+	for _, st := range due {
+		ok, err := s.executeScheduledTransfer(ctx, st.ID, now)
+		if err != nil {
+			if ctx.Err() != nil {
+				return executed, ctx.Err()
+			}
+			log.Printf("Ошибка выполнения запланированного перевода %d: %v", st.ID, err)
+			continue
+		}
+		if ok {
+			executed++
+		}
+	}
+	return executed, nil
+}
+
+// Выполняет наступившее вхождение перевода через Transfer и записывает результат.
+// Возвращает false, если перевод выполняет другой экземпляр или он уже не ждёт выполнения.
+func (s *Service) executeScheduledTransfer(ctx context.Context, id int64, now time.Time) (executed bool, err error) {
+	_, err = s.repo.WithScheduledTransferLock(ctx, id, func(ctx context.Context) error {
+		// Пока перевод ждал в очереди, его могли выполнить, приостановить или отменить
+		st, err := s.repo.GetScheduledTransfer(ctx, id)
+		if err != nil {
+			return err
+		}
+		if st.Status != repo.ScheduleStatusActive || st.NextRunAt == nil || st.NextRunAt.After(now) {
+			return nil
+		}
+
+		// Ключ идемпотентности привязан к вхождению: если экземпляр упадёт после перевода,
+		// но до записи результата, повторное выполнение вернёт уже созданную транзакцию
+		key := fmt.Sprintf("scheduled-transfer:%d:%d", st.ID, st.NextRunAt.Unix())
+		run := &repo.ScheduledTransferRun{ScheduledTransferID: st.ID, ScheduledFor: *st.NextRunAt}
+		t, err := s.Transfer(WithPrincipal(ctx, PrincipalScheduler), st.SenderID, st.ReceiverID, st.Amount, st.Currency, key)
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil:
+			msg := err.Error()
+			run.Status, run.Error = repo.RunStatusFailed, &msg
+		default:
+			run.Status, run.TransactionID = repo.RunStatusSucceeded, &t.ID
+		}
+
+		next, err := nextScheduledRun(st, now)
+		if err != nil {
+			return err
+		}
+		if _, err = s.repo.RecordScheduledTransferRun(ctx, run, next); err != nil {
+			return err
+		}
+		executed = true
+		return nil
+	})
+	return executed, err
+}
+
+// Возвращает следующее вхождение перевода после текущего; nil — вхождений больше нет.
+// Вхождения, время которых уже прошло (сервис не работал), пропускаются.
+func nextScheduledRun(st *repo.ScheduledTransfer, now time.Time) (*time.Time, error) {
+	if st.Recurrence == nil {
+		return nil, nil
+	}
+	rule, err := recurrence.Parse(*st.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	after := now
+	if st.NextRunAt != nil && st.NextRunAt.After(after) {
+		after = *st.NextRunAt
+	}
+	next, ok := rule.Next(st.StartAt, after)
+	if !ok {
+		return nil, nil
+	}
+	return &next, nil
+}
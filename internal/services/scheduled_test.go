@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	"github.com/EugeneKrivoshein/fin_service/internal/recurrence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateScheduledTransfer(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	startAt := time.Now().Add(time.Hour)
+	amount := money.MustParse("25000.00")
+	mockRepo.On("CreateScheduledTransfer", mock.Anything, mock.MatchedBy(func(st *postgres.ScheduledTransfer) bool {
+		// Правило сохраняется в каноническом виде, время — в UTC
+		return st.Recurrence != nil && *st.Recurrence == "FREQ=MONTHLY;INTERVAL=1" &&
+			st.Currency == money.DefaultCurrency && st.StartAt.Location() == time.UTC
+	})).Return(&postgres.ScheduledTransfer{ID: 1}, nil)
+
+	st, err := service.CreateScheduledTransfer(context.Background(), 1, 2, amount, "", startAt, "freq=monthly")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), st.ID)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateScheduledTransfer_Validation(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+	ctx := context.Background()
+	amount := money.MustParse("100.00")
+	future := time.Now().Add(time.Hour)
+
+	_, err := service.CreateScheduledTransfer(ctx, 1, 1, amount, "", future, "")
+	assert.ErrorIs(t, err, ErrSameAccount)
+
+	_, err = service.CreateScheduledTransfer(ctx, 1, 2, amount, "", time.Now().Add(-time.Minute), "")
+	assert.ErrorIs(t, err, ErrStartInPast)
+
+	_, err = service.CreateScheduledTransfer(ctx, 1, 2, amount, "", future, "FREQ=YEARLY")
+	assert.ErrorIs(t, err, recurrence.ErrInvalidRule)
+
+	_, err = service.CreateScheduledTransfer(ctx, 1, 2, amount, "", future, "FREQ=DAILY;UNTIL=20200101T000000Z")
+	assert.ErrorIs(t, err, recurrence.ErrInvalidRule)
+
+	mockRepo.AssertNotCalled(t, "CreateScheduledTransfer", mock.Anything, mock.Anything)
+}
+
+func TestRunDueScheduledTransfers(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	now := time.Date(2025, time.April, 1, 9, 0, 30, 0, time.UTC)
+	scheduledFor := time.Date(2025, time.April, 1, 9, 0, 0, 0, time.UTC)
+	rule := "FREQ=MONTHLY;INTERVAL=1"
+	amount := money.MustParse("25000.00")
+	monthly := postgres.ScheduledTransfer{
+		ID: 1, SenderID: 1, ReceiverID: 2, Amount: amount, Currency: "RUB",
+		StartAt: scheduledFor, Recurrence: &rule, NextRunAt: &scheduledFor, Status: postgres.ScheduleStatusActive,
+	}
+	oneOff := postgres.ScheduledTransfer{
+		ID: 2, SenderID: 3, ReceiverID: 2, Amount: amount, Currency: "RUB",
+		StartAt: scheduledFor, NextRunAt: &scheduledFor, Status: postgres.ScheduleStatusActive,
+	}
+	// Третий перевод выполняет другой экземпляр сервиса
+	busy := postgres.ScheduledTransfer{ID: 3}
+
+	mockRepo.On("ListDueScheduledTransfers", mock.Anything, now, schedulerBatchSize).
+		Return([]postgres.ScheduledTransfer{monthly, oneOff, busy}, nil)
+	mockRepo.On("WithScheduledTransferLock", mock.Anything, int64(1)).Return(true, nil)
+	mockRepo.On("WithScheduledTransferLock", mock.Anything, int64(2)).Return(true, nil)
+	mockRepo.On("WithScheduledTransferLock", mock.Anything, int64(3)).Return(false, nil)
+	mockRepo.On("GetScheduledTransfer", mock.Anything, int64(1)).Return(&monthly, nil)
+	mockRepo.On("GetScheduledTransfer", mock.Anything, int64(2)).Return(&oneOff, nil)
+
+	// Перевод выполняется через Transfer с ключом идемпотентности, привязанным к вхождению
+	mockRepo.On("Transfer", mock.Anything, int64(1), int64(2), amount, money.Currency("RUB"),
+		mock.MatchedBy(func(k *postgres.IdempotencyKey) bool {
+			return k.Principal == PrincipalScheduler && k.Key == "scheduled-transfer:1:1743498000"
+		})).
+		Return(&postgres.Transaction{ID: 10}, nil)
+	mockRepo.On("Transfer", mock.Anything, int64(3), int64(2), amount, money.Currency("RUB"), mock.Anything).
+		Return(nil, postgres.ErrInsufficientFunds)
+
+	nextMonth := time.Date(2025, time.May, 1, 9, 0, 0, 0, time.UTC)
+	mockRepo.On("RecordScheduledTransferRun", mock.Anything, mock.MatchedBy(func(r *postgres.ScheduledTransferRun) bool {
+		return r.ScheduledTransferID == 1 && r.Status == postgres.RunStatusSucceeded && *r.TransactionID == 10
+	}), &nextMonth).Return(&postgres.ScheduledTransferRun{}, nil)
+	mockRepo.On("RecordScheduledTransferRun", mock.Anything, mock.MatchedBy(func(r *postgres.ScheduledTransferRun) bool {
+		return r.ScheduledTransferID == 2 && r.Status == postgres.RunStatusFailed && *r.Error == "insufficient funds"
+	}), (*time.Time)(nil)).Return(&postgres.ScheduledTransferRun{}, nil)
+
+	executed, err := service.runDueScheduledTransfers(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, executed)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRunDueScheduledTransfers_SkipsAlreadyExecuted(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	now := time.Date(2025, time.April, 1, 9, 0, 30, 0, time.UTC)
+	due := time.Date(2025, time.April, 1, 9, 0, 0, 0, time.UTC)
+	next := due.AddDate(0, 1, 0)
+
+	// Пока перевод ждал блокировку, его выполнил другой экземпляр
+	mockRepo.On("ListDueScheduledTransfers", mock.Anything, now, schedulerBatchSize).
+		Return([]postgres.ScheduledTransfer{{ID: 1, NextRunAt: &due, Status: postgres.ScheduleStatusActive}}, nil)
+	mockRepo.On("WithScheduledTransferLock", mock.Anything, int64(1)).Return(true, nil)
+	mockRepo.On("GetScheduledTransfer", mock.Anything, int64(1)).
+		Return(&postgres.ScheduledTransfer{ID: 1, NextRunAt: &next, Status: postgres.ScheduleStatusActive}, nil)
+
+	executed, err := service.runDueScheduledTransfers(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 0, executed)
+
+	mockRepo.AssertNotCalled(t, "Transfer", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestNextScheduledRun_SkipsMissedOccurrences(t *testing.T) {
+	rule := "FREQ=DAILY;INTERVAL=1"
+	start := time.Date(2025, time.March, 1, 9, 0, 0, 0, time.UTC)
+	st := &postgres.ScheduledTransfer{StartAt: start, Recurrence: &rule, NextRunAt: &start}
+
+	// Сервис не работал пять дней: следующим будет ближайшее будущее вхождение
+	now := time.Date(2025, time.March, 6, 12, 0, 0, 0, time.UTC)
+	next, err := nextScheduledRun(st, now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, time.March, 7, 9, 0, 0, 0, time.UTC), *next)
+
+	next, err = nextScheduledRun(&postgres.ScheduledTransfer{StartAt: start, NextRunAt: &start}, now)
+	require.NoError(t, err)
+	assert.Nil(t, next)
+}
+
+func TestResumeScheduledTransfer(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	// Разовый перевод, просроченный за время паузы, выполняется сразу
+	past := time.Now().Add(-time.Hour)
+	mockRepo.On("GetScheduledTransfer", mock.Anything, int64(1)).
+		Return(&postgres.ScheduledTransfer{ID: 1, StartAt: past, NextRunAt: &past, Status: postgres.ScheduleStatusPaused}, nil)
+	mockRepo.On("SetScheduledTransferStatus", mock.Anything, int64(1), postgres.ScheduleStatusActive,
+		mock.MatchedBy(func(next *time.Time) bool { return next.After(past) })).
+		Return(&postgres.ScheduledTransfer{ID: 1, Status: postgres.ScheduleStatusActive}, nil)
+
+	// Повторяющийся перевод без оставшихся вхождений завершается
+	rule := "FREQ=DAILY;COUNT=1"
+	mockRepo.On("GetScheduledTransfer", mock.Anything, int64(2)).
+		Return(&postgres.ScheduledTransfer{ID: 2, StartAt: past, Recurrence: &rule, NextRunAt: &past, Status: postgres.ScheduleStatusPaused}, nil)
+	mockRepo.On("SetScheduledTransferStatus", mock.Anything, int64(2), postgres.ScheduleStatusCompleted, (*time.Time)(nil)).
+		Return(&postgres.ScheduledTransfer{ID: 2, Status: postgres.ScheduleStatusCompleted}, nil)
+
+	st, err := service.ResumeScheduledTransfer(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, postgres.ScheduleStatusActive, st.Status)
+
+	st, err = service.ResumeScheduledTransfer(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, postgres.ScheduleStatusCompleted, st.Status)
+
+	mockRepo.AssertExpectations(t)
+}
@@ -2,28 +2,206 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
 
+	"github.com/EugeneKrivoshein/fin_service/internal/fx"
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/EugeneKrivoshein/fin_service/internal/outbox"
 	repo "github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	"github.com/EugeneKrivoshein/fin_service/internal/webhook"
 )
 
 type Service struct {
 	repo repo.Repository
+
+	fx         fx.Provider
+	fxQuoteTTL time.Duration
+
+	// Отправитель вебхуков; nil — события не записываются
+	webhooks *webhook.Sender
+	// Получатель событий outbox; nil — ретранслятор не запускается
+	outbox outbox.Publisher
 }
 
-func NewService(r repo.Repository) *Service {
-	return &Service{
-		repo: r,
+// Option настраивает необязательные зависимости сервиса
+type Option func(*Service)
+
+func NewService(r repo.Repository, opts ...Option) *Service {
+	s := &Service{
+		repo:       r,
+		fxQuoteTTL: DefaultFXQuoteTTL,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
-func (s *Service) Deposit(ctx context.Context, userID int64, amount float64) error {
-	return s.repo.Deposit(ctx, userID, amount)
+// Deposit пополняет счёт в валюте currency (пустая — валюта по умолчанию).
+// Непустой idempotencyKey защищает от повторного зачисления при ретраях клиента.
+func (s *Service) Deposit(ctx context.Context, userID int64, amount money.Amount, currency money.Currency, idempotencyKey string) (*repo.Transaction, error) {
+	currency, err := checkAmount(amount, currency)
+	if err != nil {
+		return nil, err
+	}
+	key := newIdempotencyKey(ctx, idempotencyKey, "deposit", userID, amount, currency)
+	t, err := s.repo.Deposit(ctx, userID, amount, currency, key)
+	if err != nil {
+		s.notifyFailed(ctx, EventDepositFailed, FailedOperation{Operation: "deposit", UserID: &userID, Amount: amount, Currency: currency}, err)
+		return nil, err
+	}
+	return t, nil
 }
 
-func (s *Service) Transfer(ctx context.Context, senderID, receiverID int64, amount float64) error {
-	return s.repo.Transfer(ctx, senderID, receiverID, amount)
+// Transfer переводит деньги в валюте currency (пустая — валюта по умолчанию).
+// Непустой idempotencyKey защищает от повторного перевода при ретраях клиента.
+func (s *Service) Transfer(ctx context.Context, senderID, receiverID int64, amount money.Amount, currency money.Currency, idempotencyKey string) (*repo.Transaction, error) {
+	currency, err := checkAmount(amount, currency)
+	if err != nil {
+		return nil, err
+	}
+	if senderID == receiverID {
+		return nil, ErrSameAccount
+	}
+	key := newIdempotencyKey(ctx, idempotencyKey, "transfer", senderID, receiverID, amount, currency)
+	t, err := s.repo.Transfer(ctx, senderID, receiverID, amount, currency, key)
+	if err != nil {
+		op := FailedOperation{Operation: "transfer", SenderID: &senderID, ReceiverID: &receiverID, Amount: amount, Currency: currency}
+		s.notifyFailed(ctx, EventTransferFailed, op, err)
+		return nil, err
+	}
+	return t, nil
+}
+
+// Withdraw списывает деньги со счёта в валюте currency (пустая — валюта по умолчанию).
+// Непустой idempotencyKey защищает от повторного списания при ретраях клиента.
+func (s *Service) Withdraw(ctx context.Context, userID int64, amount money.Amount, currency money.Currency, idempotencyKey string) (*repo.Transaction, error) {
+	currency, err := checkAmount(amount, currency)
+	if err != nil {
+		return nil, err
+	}
+	key := newIdempotencyKey(ctx, idempotencyKey, "withdrawal", userID, amount, currency)
+	t, err := s.repo.Withdraw(ctx, userID, amount, currency, key)
+	if err != nil {
+		s.notifyFailed(ctx, EventWithdrawalFailed, FailedOperation{Operation: "withdrawal", UserID: &userID, Amount: amount, Currency: currency}, err)
+		return nil, err
+	}
+	return t, nil
+}
+
+// Проверяет сумму операции и её валюту; возвращает валюту в каноническом виде
+func checkAmount(amount money.Amount, currency money.Currency) (money.Currency, error) {
+	if amount <= 0 {
+		return "", ErrNonPositiveAmount
+	}
+	currency, err := resolveCurrency(currency)
+	if err != nil {
+		return "", err
+	}
+	if err := currency.CheckAmount(amount); err != nil {
+		return "", err
+	}
+	return currency, nil
+}
+
+// Пустая валюта означает валюту по умолчанию; код приводится к верхнему регистру
+func resolveCurrency(currency money.Currency) (money.Currency, error) {
+	if currency == "" {
+		return money.DefaultCurrency, nil
+	}
+	return money.ParseCurrency(string(currency))
+}
+
+// Ограничения размера страницы истории транзакций
+const (
+	DefaultTransactionsLimit = 10
+	MaxTransactionsLimit     = 100
+)
+
+// GetTransactions возвращает страницу истории транзакций от новых к старым: пользователя filter.UserID
+// или, если он не задан, всех пользователей
+func (s *Service) GetTransactions(ctx context.Context, filter repo.TransactionFilter) (*repo.TransactionPage, error) {
+	if filter.Before != nil && filter.After != nil {
+		return nil, ErrConflictingCursors
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, ErrInvalidDateRange
+	}
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount {
+		return nil, ErrInvalidAmountRange
+	}
+	if filter.Currency != "" {
+		currency, err := money.ParseCurrency(string(filter.Currency))
+		if err != nil {
+			return nil, err
+		}
+		filter.Currency = currency
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultTransactionsLimit
+	}
+	if filter.Limit > MaxTransactionsLimit {
+		filter.Limit = MaxTransactionsLimit
+	}
+	return s.repo.GetTransactions(ctx, filter)
+}
+
+// Клиенты сервиса, от имени которых выполняются внутренние операции. Ключи идемпотентности клиентов API
+// («user:<id>», «api_key:<id>») не пересекаются с ключами внутренних операций.
+const (
+	PrincipalSystem    = "system"
+	PrincipalScheduler = "system:scheduler"
+)
+
+type principalKey struct{}
+
+// WithPrincipal возвращает контекст операции, выполняемой от имени клиента principal
+func WithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// Клиент, от имени которого выполняется операция; без него — PrincipalSystem
+func principalFrom(ctx context.Context) string {
+	if p, ok := ctx.Value(principalKey{}).(string); ok && p != "" {
+		return p
+	}
+	return PrincipalSystem
+}
+
+// Привязывает ключ к клиенту из ctx и хэшу параметров операции: одинаковые по смыслу запросы
+// ("100.5" и "100.50") дают одинаковый хэш независимо от форматирования JSON.
+func newIdempotencyKey(ctx context.Context, key, operation string, params ...any) *repo.IdempotencyKey {
+	if key == "" {
+		return nil
+	}
+
+	h := sha256.New()
+	fmt.Fprint(h, operation)
+	for _, p := range params {
+		fmt.Fprintf(h, "|%v", p)
+	}
+	return &repo.IdempotencyKey{
+		Principal:   principalFrom(ctx),
+		Key:         key,
+		RequestHash: hex.EncodeToString(h.Sum(nil)),
+	}
+}
+
+// GetBalance возвращает текущий баланс пользователя в валюте (пустая — валюта по умолчанию)
+// или, если asOf задан, баланс на этот момент
+func (s *Service) GetBalance(ctx context.Context, userID int64, currency money.Currency, asOf *time.Time) (*repo.Balance, error) {
+	currency, err := resolveCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetBalance(ctx, userID, currency, asOf)
 }
 
-func (s *Service) GetTransactions(ctx context.Context, userID int64) ([]repo.Transaction, error) {
-	return s.repo.GetTransactions(ctx, userID)
+// VerifyLedger сверяет кэшированные балансы пользователей с главной книгой
+func (s *Service) VerifyLedger(ctx context.Context) (*repo.LedgerReport, error) {
+	return s.repo.VerifyLedger(ctx)
 }
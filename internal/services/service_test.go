@@ -1,10 +1,14 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/EugeneKrivoshein/fin_service/internal/outbox"
 	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -14,19 +18,373 @@ type MockRepository struct {
 	mock.Mock
 }
 
-func (m *MockRepository) Deposit(ctx context.Context, userID int64, amount float64) error {
-	args := m.Called(ctx, userID, amount)
+func (m *MockRepository) Deposit(ctx context.Context, userID int64, amount money.Amount, currency money.Currency, key *postgres.IdempotencyKey) (*postgres.Transaction, error) {
+	args := m.Called(ctx, userID, amount, currency, key)
+	return transactionArg(args, 0), args.Error(1)
+}
+
+func (m *MockRepository) Transfer(ctx context.Context, senderID, receiverID int64, amount money.Amount, currency money.Currency, key *postgres.IdempotencyKey) (*postgres.Transaction, error) {
+	args := m.Called(ctx, senderID, receiverID, amount, currency, key)
+	return transactionArg(args, 0), args.Error(1)
+}
+
+func (m *MockRepository) Withdraw(ctx context.Context, userID int64, amount money.Amount, currency money.Currency, key *postgres.IdempotencyKey) (*postgres.Transaction, error) {
+	args := m.Called(ctx, userID, amount, currency, key)
+	return transactionArg(args, 0), args.Error(1)
+}
+
+func (m *MockRepository) CreateFXQuote(ctx context.Context, from, to money.Currency, rate money.Rate, ttl time.Duration) (*postgres.FXQuote, error) {
+	args := m.Called(ctx, from, to, rate, ttl)
+	q, _ := args.Get(0).(*postgres.FXQuote)
+	return q, args.Error(1)
+}
+
+func (m *MockRepository) TransferFX(ctx context.Context, senderID, receiverID int64, amount money.Amount, quoteID string, key *postgres.IdempotencyKey) (*postgres.Transaction, error) {
+	args := m.Called(ctx, senderID, receiverID, amount, quoteID, key)
+	return transactionArg(args, 0), args.Error(1)
+}
+
+func (m *MockRepository) CreateHold(ctx context.Context, userID int64, amount money.Amount, currency money.Currency, ttl time.Duration) (*postgres.Hold, error) {
+	args := m.Called(ctx, userID, amount, currency, ttl)
+	return holdArg(args, 0), args.Error(1)
+}
+
+func (m *MockRepository) GetHold(ctx context.Context, holdID int64) (*postgres.Hold, error) {
+	args := m.Called(ctx, holdID)
+	return holdArg(args, 0), args.Error(1)
+}
+
+func (m *MockRepository) CaptureHold(ctx context.Context, holdID int64, amount money.Amount, receiverID *int64, key *postgres.IdempotencyKey) (*postgres.Hold, *postgres.Transaction, error) {
+	args := m.Called(ctx, holdID, amount, receiverID, key)
+	return holdArg(args, 0), transactionArg(args, 1), args.Error(2)
+}
+
+func (m *MockRepository) ReleaseHold(ctx context.Context, holdID int64) (*postgres.Hold, error) {
+	args := m.Called(ctx, holdID)
+	return holdArg(args, 0), args.Error(1)
+}
+
+func (m *MockRepository) ExpireHolds(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func holdArg(args mock.Arguments, i int) *postgres.Hold {
+	h, _ := args.Get(i).(*postgres.Hold)
+	return h
+}
+
+func (m *MockRepository) CreateScheduledTransfer(ctx context.Context, st *postgres.ScheduledTransfer) (*postgres.ScheduledTransfer, error) {
+	args := m.Called(ctx, st)
+	return scheduledTransferArg(args, 0), args.Error(1)
+}
+
+func (m *MockRepository) GetScheduledTransfer(ctx context.Context, id int64) (*postgres.ScheduledTransfer, error) {
+	args := m.Called(ctx, id)
+	return scheduledTransferArg(args, 0), args.Error(1)
+}
+
+func (m *MockRepository) ListScheduledTransfers(ctx context.Context, userID int64) ([]postgres.ScheduledTransfer, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]postgres.ScheduledTransfer), args.Error(1)
+}
+
+func (m *MockRepository) ListDueScheduledTransfers(ctx context.Context, now time.Time, limit int) ([]postgres.ScheduledTransfer, error) {
+	args := m.Called(ctx, now, limit)
+	return args.Get(0).([]postgres.ScheduledTransfer), args.Error(1)
+}
+
+func (m *MockRepository) SetScheduledTransferStatus(ctx context.Context, id int64, status string, nextRunAt *time.Time) (*postgres.ScheduledTransfer, error) {
+	args := m.Called(ctx, id, status, nextRunAt)
+	return scheduledTransferArg(args, 0), args.Error(1)
+}
+
+func (m *MockRepository) RecordScheduledTransferRun(ctx context.Context, run *postgres.ScheduledTransferRun, nextRunAt *time.Time) (*postgres.ScheduledTransferRun, error) {
+	args := m.Called(ctx, run, nextRunAt)
+	r, _ := args.Get(0).(*postgres.ScheduledTransferRun)
+	return r, args.Error(1)
+}
+
+func (m *MockRepository) ListScheduledTransferRuns(ctx context.Context, id int64, limit int) ([]postgres.ScheduledTransferRun, error) {
+	args := m.Called(ctx, id, limit)
+	return args.Get(0).([]postgres.ScheduledTransferRun), args.Error(1)
+}
+
+// Блокировка в моке: первый результат сообщает, захвачена ли она; если да, fn выполняется
+func (m *MockRepository) WithScheduledTransferLock(ctx context.Context, id int64, fn func(ctx context.Context) error) (bool, error) {
+	args := m.Called(ctx, id)
+	if !args.Bool(0) {
+		return false, args.Error(1)
+	}
+	return true, fn(ctx)
+}
+
+func scheduledTransferArg(args mock.Arguments, i int) *postgres.ScheduledTransfer {
+	st, _ := args.Get(i).(*postgres.ScheduledTransfer)
+	return st
+}
+
+func (m *MockRepository) ReverseTransaction(ctx context.Context, transactionID int64, amount money.Amount, key *postgres.IdempotencyKey) (*postgres.Transaction, error) {
+	args := m.Called(ctx, transactionID, amount, key)
+	return transactionArg(args, 0), args.Error(1)
+}
+
+func (m *MockRepository) GetTransactions(ctx context.Context, filter postgres.TransactionFilter) (*postgres.TransactionPage, error) {
+	args := m.Called(ctx, filter)
+	page, _ := args.Get(0).(*postgres.TransactionPage)
+	return page, args.Error(1)
+}
+
+// Выписка в моке: аргументы ответа — заголовок выписки, её строки и ошибка
+func (m *MockRepository) StreamStatement(ctx context.Context, userID int64, currency money.Currency, from *time.Time, to time.Time, w postgres.StatementWriter) error {
+	args := m.Called(ctx, userID, currency, from, to)
+	if err := args.Error(2); err != nil {
+		return err
+	}
+	s := *args.Get(0).(*postgres.Statement)
+	if err := w.Begin(&s); err != nil {
+		return err
+	}
+	for _, l := range args.Get(1).([]postgres.StatementLine) {
+		if err := w.Line(&l); err != nil {
+			return err
+		}
+	}
+	return w.End(&s)
+}
+
+func transactionArg(args mock.Arguments, i int) *postgres.Transaction {
+	t, _ := args.Get(i).(*postgres.Transaction)
+	return t
+}
+
+func (m *MockRepository) GetBalance(ctx context.Context, userID int64, currency money.Currency, asOf *time.Time) (*postgres.Balance, error) {
+	args := m.Called(ctx, userID, currency, asOf)
+	b, _ := args.Get(0).(*postgres.Balance)
+	return b, args.Error(1)
+}
+
+func (m *MockRepository) VerifyLedger(ctx context.Context) (*postgres.LedgerReport, error) {
+	args := m.Called(ctx)
+	report, _ := args.Get(0).(*postgres.LedgerReport)
+	return report, args.Error(1)
+}
+
+func (m *MockRepository) Reconcile(ctx context.Context) (*postgres.ReconciliationReport, error) {
+	args := m.Called(ctx)
+	report, _ := args.Get(0).(*postgres.ReconciliationReport)
+	return report, args.Error(1)
+}
+
+func (m *MockRepository) AdjustBalance(ctx context.Context, userID int64, currency money.Currency) (*postgres.ReconciliationLine, error) {
+	args := m.Called(ctx, userID, currency)
+	line, _ := args.Get(0).(*postgres.ReconciliationLine)
+	return line, args.Error(1)
+}
+
+func (m *MockRepository) ListFeeRules(ctx context.Context) ([]postgres.FeeRule, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]postgres.FeeRule), args.Error(1)
+}
+
+func (m *MockRepository) CreateFeeRule(ctx context.Context, rule *postgres.FeeRule) (*postgres.FeeRule, error) {
+	args := m.Called(ctx, rule)
+	r, _ := args.Get(0).(*postgres.FeeRule)
+	return r, args.Error(1)
+}
+
+func (m *MockRepository) DeleteFeeRule(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetSpendingStatus(ctx context.Context, userID int64, currency money.Currency) (*postgres.SpendingStatus, error) {
+	args := m.Called(ctx, userID, currency)
+	status, _ := args.Get(0).(*postgres.SpendingStatus)
+	return status, args.Error(1)
+}
+
+func (m *MockRepository) ListTierSpendingLimits(ctx context.Context, tier string) ([]postgres.SpendingLimits, error) {
+	args := m.Called(ctx, tier)
+	return args.Get(0).([]postgres.SpendingLimits), args.Error(1)
+}
+
+func (m *MockRepository) SetTierSpendingLimits(ctx context.Context, tier string, limits postgres.SpendingLimits) (*postgres.SpendingLimits, error) {
+	args := m.Called(ctx, tier, limits)
+	l, _ := args.Get(0).(*postgres.SpendingLimits)
+	return l, args.Error(1)
+}
+
+func (m *MockRepository) SetUserSpendingLimits(ctx context.Context, userID int64, limits postgres.SpendingLimits) (*postgres.SpendingLimits, error) {
+	args := m.Called(ctx, userID, limits)
+	l, _ := args.Get(0).(*postgres.SpendingLimits)
+	return l, args.Error(1)
+}
+
+func (m *MockRepository) DeleteUserSpendingLimits(ctx context.Context, userID int64, currency money.Currency) error {
+	args := m.Called(ctx, userID, currency)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateWebhookSubscription(ctx context.Context, sub *postgres.WebhookSubscription) (*postgres.WebhookSubscription, error) {
+	args := m.Called(ctx, sub)
+	r, _ := args.Get(0).(*postgres.WebhookSubscription)
+	return r, args.Error(1)
+}
+
+func (m *MockRepository) ListWebhookSubscriptions(ctx context.Context) ([]postgres.WebhookSubscription, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]postgres.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockRepository) DeleteWebhookSubscription(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
-func (m *MockRepository) Transfer(ctx context.Context, senderID, receiverID int64, amount float64) error {
-	args := m.Called(ctx, senderID, receiverID, amount)
+func (m *MockRepository) EnqueueWebhookEvent(ctx context.Context, eventType string, payload []byte) (int64, error) {
+	args := m.Called(ctx, eventType, payload)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]postgres.WebhookMessage, error) {
+	args := m.Called(ctx, limit, lease)
+	return args.Get(0).([]postgres.WebhookMessage), args.Error(1)
+}
+
+func (m *MockRepository) RecordWebhookAttempt(ctx context.Context, deliveryID int64, attempt postgres.WebhookAttempt) error {
+	args := m.Called(ctx, deliveryID, attempt)
 	return args.Error(0)
 }
 
-func (m *MockRepository) GetTransactions(ctx context.Context, userID int64) ([]postgres.Transaction, error) {
+func (m *MockRepository) ListWebhookDeliveries(ctx context.Context, subscriptionID int64, status string, limit int) ([]postgres.WebhookDelivery, error) {
+	args := m.Called(ctx, subscriptionID, status, limit)
+	return args.Get(0).([]postgres.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockRepository) RedeliverWebhook(ctx context.Context, deliveryID int64) (*postgres.WebhookDelivery, error) {
+	args := m.Called(ctx, deliveryID)
+	r, _ := args.Get(0).(*postgres.WebhookDelivery)
+	return r, args.Error(1)
+}
+
+// Ретрансляция в моке: первый результат — события, которые получит publish
+func (m *MockRepository) RelayOutbox(ctx context.Context, limit int, publish func(ctx context.Context, e outbox.Event) error) (int, error) {
+	args := m.Called(ctx, limit)
+	published := 0
+	for _, e := range args.Get(0).([]outbox.Event) {
+		if err := publish(ctx, e); err != nil {
+			return published, err
+		}
+		published++
+	}
+	return published, args.Error(1)
+}
+
+func (m *MockRepository) CreateAPIKey(ctx context.Context, k *postgres.APIKey, hash []byte) (*postgres.APIKey, error) {
+	args := m.Called(ctx, k, hash)
+	r, _ := args.Get(0).(*postgres.APIKey)
+	return r, args.Error(1)
+}
+
+func (m *MockRepository) ListAPIKeys(ctx context.Context) ([]postgres.APIKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]postgres.APIKey), args.Error(1)
+}
+
+func (m *MockRepository) GetActiveAPIKey(ctx context.Context, hash []byte) (*postgres.APIKey, error) {
+	args := m.Called(ctx, hash)
+	r, _ := args.Get(0).(*postgres.APIKey)
+	return r, args.Error(1)
+}
+
+func (m *MockRepository) RotateAPIKey(ctx context.Context, id int64, prefix string, hash []byte, grace time.Duration) (*postgres.APIKey, error) {
+	args := m.Called(ctx, id, prefix, hash, grace)
+	r, _ := args.Get(0).(*postgres.APIKey)
+	return r, args.Error(1)
+}
+
+func (m *MockRepository) RevokeAPIKey(ctx context.Context, id int64) (*postgres.APIKey, error) {
+	args := m.Called(ctx, id)
+	r, _ := args.Get(0).(*postgres.APIKey)
+	return r, args.Error(1)
+}
+
+func (m *MockRepository) CreateAdjustment(ctx context.Context, userID int64, amount money.Amount, currency money.Currency, reason string, key *postgres.IdempotencyKey) (*postgres.Transaction, error) {
+	args := m.Called(ctx, userID, amount, currency, reason, key)
+	return transactionArg(args, 0), args.Error(1)
+}
+
+func (m *MockRepository) GetUserRoles(ctx context.Context, userID int64) ([]string, error) {
 	args := m.Called(ctx, userID)
-	return args.Get(0).([]postgres.Transaction), args.Error(1)
+	roles, _ := args.Get(0).([]string)
+	return roles, args.Error(1)
+}
+
+func (m *MockRepository) GrantRole(ctx context.Context, userID int64, role string) error {
+	return m.Called(ctx, userID, role).Error(0)
+}
+
+func (m *MockRepository) RevokeRole(ctx context.Context, userID int64, role string) error {
+	return m.Called(ctx, userID, role).Error(0)
+}
+
+func (m *MockRepository) RecordAudit(ctx context.Context, e *postgres.AuditEntry) error {
+	return m.Called(ctx, e).Error(0)
+}
+
+func (m *MockRepository) ListAuditLog(ctx context.Context, filter postgres.AuditFilter) (*postgres.AuditPage, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*postgres.AuditPage), args.Error(1)
+}
+
+func (m *MockRepository) CreateUser(ctx context.Context, username string) (*postgres.User, error) {
+	args := m.Called(ctx, username)
+	return userArg(args, 0), args.Error(1)
+}
+
+func (m *MockRepository) GetUser(ctx context.Context, userID int64) (*postgres.User, error) {
+	args := m.Called(ctx, userID)
+	return userArg(args, 0), args.Error(1)
+}
+
+func (m *MockRepository) GetUserByUsername(ctx context.Context, username string) (*postgres.User, error) {
+	args := m.Called(ctx, username)
+	return userArg(args, 0), args.Error(1)
+}
+
+func (m *MockRepository) ListUsers(ctx context.Context, limit, offset int) ([]postgres.User, error) {
+	args := m.Called(ctx, limit, offset)
+	return args.Get(0).([]postgres.User), args.Error(1)
+}
+
+func (m *MockRepository) SetUserStatus(ctx context.Context, userID int64, status string) (*postgres.User, error) {
+	args := m.Called(ctx, userID, status)
+	return userArg(args, 0), args.Error(1)
+}
+
+func (m *MockRepository) SetUserTier(ctx context.Context, userID int64, tier string) (*postgres.User, error) {
+	args := m.Called(ctx, userID, tier)
+	return userArg(args, 0), args.Error(1)
+}
+
+func (m *MockRepository) OpenAccount(ctx context.Context, userID int64, currency money.Currency) (*postgres.Account, error) {
+	args := m.Called(ctx, userID, currency)
+	a, _ := args.Get(0).(*postgres.Account)
+	return a, args.Error(1)
+}
+
+func (m *MockRepository) ListAccounts(ctx context.Context, userID int64) ([]postgres.Account, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]postgres.Account), args.Error(1)
+}
+
+func userArg(args mock.Arguments, i int) *postgres.User {
+	u, _ := args.Get(i).(*postgres.User)
+	return u
 }
 
 func TestDeposit(t *testing.T) {
@@ -34,14 +392,16 @@ func TestDeposit(t *testing.T) {
 	service := NewService(mockRepo)
 
 	userID := int64(1)
-	amount := 100.0
+	amount := money.MustParse("100.00")
 
 	// Ожидаем, что метод Deposit будет вызван с заданными параметрами и не вернет ошибок
-	mockRepo.On("Deposit", mock.Anything, userID, amount).Return(nil)
+	expected := &postgres.Transaction{ID: 1, UserID: &userID, Amount: amount, TransactionType: "deposit"}
+	mockRepo.On("Deposit", mock.Anything, userID, amount, money.DefaultCurrency, (*postgres.IdempotencyKey)(nil)).Return(expected, nil)
 
-	err := service.Deposit(context.Background(), userID, amount)
+	transaction, err := service.Deposit(context.Background(), userID, amount, "", "")
 
 	assert.NoError(t, err)
+	assert.Equal(t, expected, transaction)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -51,12 +411,12 @@ func TestDeposit_Error(t *testing.T) {
 
 	// Входные данные
 	userID := int64(1)
-	amount := 100.0
+	amount := money.MustParse("100.00")
 
 	// Ожидаем, что метод Deposit вызовет ошибку
-	mockRepo.On("Deposit", mock.Anything, userID, amount).Return(errors.New("db error"))
+	mockRepo.On("Deposit", mock.Anything, userID, amount, money.DefaultCurrency, mock.Anything).Return(nil, errors.New("db error"))
 
-	err := service.Deposit(context.Background(), userID, amount)
+	_, err := service.Deposit(context.Background(), userID, amount, "", "")
 
 	// Проверяем, что ошибка возвращена и она соответствует ожидаемой
 	assert.Error(t, err)
@@ -71,12 +431,13 @@ func TestTransfer(t *testing.T) {
 	// Входные данные
 	senderID := int64(1)
 	receiverID := int64(2)
-	amount := 50.0
+	amount := money.MustParse("50.00")
 
 	// Ожидаем, что метод Transfer будет вызван с заданными параметрами и не вернет ошибок
-	mockRepo.On("Transfer", mock.Anything, senderID, receiverID, amount).Return(nil)
+	mockRepo.On("Transfer", mock.Anything, senderID, receiverID, amount, money.DefaultCurrency, (*postgres.IdempotencyKey)(nil)).
+		Return(&postgres.Transaction{ID: 2, TransactionType: "transfer"}, nil)
 
-	err := service.Transfer(context.Background(), senderID, receiverID, amount)
+	_, err := service.Transfer(context.Background(), senderID, receiverID, amount, "", "")
 
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
@@ -89,12 +450,12 @@ func TestTransfer_Error(t *testing.T) {
 	// Входные данные
 	senderID := int64(1)
 	receiverID := int64(2)
-	amount := 50.0
+	amount := money.MustParse("50.00")
 
 	// Ожидаем, что метод Transfer вызовет ошибку
-	mockRepo.On("Transfer", mock.Anything, senderID, receiverID, amount).Return(errors.New("transfer failed"))
+	mockRepo.On("Transfer", mock.Anything, senderID, receiverID, amount, money.DefaultCurrency, mock.Anything).Return(nil, errors.New("transfer failed"))
 
-	err := service.Transfer(context.Background(), senderID, receiverID, amount)
+	_, err := service.Transfer(context.Background(), senderID, receiverID, amount, "", "")
 
 	// Проверяем, что ошибка возвращена и она соответствует ожидаемой
 	assert.Error(t, err)
@@ -102,6 +463,119 @@ func TestTransfer_Error(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestDeposit_IdempotencyKey(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	userID := int64(1)
+	amount := money.MustParse("100.50")
+
+	var keys []*postgres.IdempotencyKey
+	mockRepo.On("Deposit", mock.Anything, userID, amount, money.DefaultCurrency, mock.Anything).
+		Run(func(args mock.Arguments) { keys = append(keys, args.Get(4).(*postgres.IdempotencyKey)) }).
+		Return(&postgres.Transaction{ID: 1}, nil)
+	mockRepo.On("Deposit", mock.Anything, userID, money.MustParse("100.51"), money.DefaultCurrency, mock.Anything).
+		Run(func(args mock.Arguments) { keys = append(keys, args.Get(4).(*postgres.IdempotencyKey)) }).
+		Return(nil, postgres.ErrIdempotencyKeyReused)
+
+	_, err := service.Deposit(context.Background(), userID, amount, "", "key-1")
+	assert.NoError(t, err)
+	_, err = service.Deposit(context.Background(), userID, amount, "", "key-1")
+	assert.NoError(t, err)
+	_, err = service.Deposit(context.Background(), userID, money.MustParse("100.51"), "", "key-1")
+	assert.ErrorIs(t, err, postgres.ErrIdempotencyKeyReused)
+
+	// Повтор с теми же параметрами даёт тот же хэш, изменённое тело — другой
+	assert.Len(t, keys, 3)
+	assert.Equal(t, "key-1", keys[0].Key)
+	assert.Equal(t, keys[0].RequestHash, keys[1].RequestHash)
+	assert.NotEqual(t, keys[0].RequestHash, keys[2].RequestHash)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestNewIdempotencyKey_Principal(t *testing.T) {
+	// Ключ относится к клиенту из контекста; операции без клиента выполняются от имени системы
+	key := newIdempotencyKey(WithPrincipal(context.Background(), "user:7"), "key-1", "deposit", int64(7))
+	assert.Equal(t, "user:7", key.Principal)
+	assert.Equal(t, "key-1", key.Key)
+
+	key = newIdempotencyKey(context.Background(), "key-1", "deposit", int64(7))
+	assert.Equal(t, PrincipalSystem, key.Principal)
+
+	assert.Nil(t, newIdempotencyKey(context.Background(), "", "deposit", int64(7)))
+}
+
+func TestTransfer_Validation(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	// Некорректные переводы отклоняются без обращения к репозиторию
+	_, err := service.Transfer(context.Background(), 1, 1, money.MustParse("10.00"), "", "")
+	assert.ErrorIs(t, err, ErrSameAccount)
+
+	_, err = service.Transfer(context.Background(), 1, 2, 0, "", "")
+	assert.ErrorIs(t, err, ErrNonPositiveAmount)
+
+	mockRepo.AssertNotCalled(t, "Transfer", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOperations_Currency(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	userID := int64(1)
+	amount := money.MustParse("100.00")
+
+	// Код валюты приводится к верхнему регистру
+	mockRepo.On("Deposit", mock.Anything, userID, amount, money.Currency("USD"), (*postgres.IdempotencyKey)(nil)).
+		Return(&postgres.Transaction{ID: 1, Currency: "USD"}, nil)
+	_, err := service.Deposit(context.Background(), userID, amount, "usd", "")
+	assert.NoError(t, err)
+
+	// Неподдерживаемая валюта и дробная сумма в валюте без дробных единиц отклоняются без обращения к репозиторию
+	_, err = service.Deposit(context.Background(), userID, amount, "XXX", "")
+	assert.ErrorIs(t, err, money.ErrUnsupportedCurrency)
+	_, err = service.Transfer(context.Background(), 1, 2, money.MustParse("10.50"), "JPY", "")
+	assert.ErrorIs(t, err, money.ErrTooManyFractionDigits)
+
+	mockRepo.AssertNumberOfCalls(t, "Deposit", 1)
+	mockRepo.AssertNotCalled(t, "Transfer", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWithdraw(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	userID := int64(1)
+	amount := money.MustParse("30.00")
+
+	expected := &postgres.Transaction{ID: 3, UserID: &userID, Amount: amount, TransactionType: "withdrawal"}
+	mockRepo.On("Withdraw", mock.Anything, userID, amount, money.DefaultCurrency, mock.AnythingOfType("*postgres.IdempotencyKey")).Return(expected, nil)
+
+	transaction, err := service.Withdraw(context.Background(), userID, amount, "", "key-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, transaction)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWithdraw_Error(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	userID := int64(1)
+	amount := money.MustParse("5000.00")
+
+	// Ожидаем, что метод Withdraw вернёт ошибку нехватки средств
+	mockRepo.On("Withdraw", mock.Anything, userID, amount, money.DefaultCurrency, mock.Anything).Return(nil, postgres.ErrInsufficientFunds)
+
+	_, err := service.Withdraw(context.Background(), userID, amount, "", "")
+
+	assert.ErrorIs(t, err, postgres.ErrInsufficientFunds)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestGetTransactions(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := NewService(mockRepo)
@@ -109,19 +583,23 @@ func TestGetTransactions(t *testing.T) {
 	// Входные данные
 	userID := int64(1)
 	expectedTransactions := []postgres.Transaction{
-		{ID: 1, UserID: &userID, Amount: 100.0, TransactionType: "deposit"},
-		{ID: 2, SenderID: &userID, ReceiverID: new(int64), Amount: 50.0, TransactionType: "transfer"},
+		{ID: 1, UserID: &userID, Amount: money.MustParse("100.00"), TransactionType: "deposit"},
+		{ID: 2, SenderID: &userID, ReceiverID: new(int64), Amount: money.MustParse("50.00"), TransactionType: "transfer"},
 	}
 
-	// Ожидаем, что метод GetTransactions вернет список транзакций без ошибок
-	mockRepo.On("GetTransactions", mock.Anything, userID).Return(expectedTransactions, nil)
+	// Ожидаем, что метод GetTransactions вернет список транзакций без ошибок;
+	// без явного limit используется размер страницы по умолчанию
+	filter := postgres.TransactionFilter{UserID: userID, Limit: DefaultTransactionsLimit}
+	mockRepo.On("GetTransactions", mock.Anything, filter).
+		Return(&postgres.TransactionPage{Transactions: expectedTransactions, NextCursor: "abc"}, nil)
 
-	transactions, err := service.GetTransactions(context.Background(), userID)
+	page, err := service.GetTransactions(context.Background(), postgres.TransactionFilter{UserID: userID})
 
 	// Проверяем, что ошибок нет и транзакции совпадают с ожидаемыми
 	assert.NoError(t, err)
-	assert.Len(t, transactions, 2)
-	assert.Equal(t, expectedTransactions, transactions)
+	assert.Len(t, page.Transactions, 2)
+	assert.Equal(t, expectedTransactions, page.Transactions)
+	assert.Equal(t, "abc", page.NextCursor)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -132,12 +610,158 @@ func TestGetTransactions_Error(t *testing.T) {
 	userID := int64(1)
 
 	// Ожидаем, что метод GetTransactions вызовет ошибку
-	mockRepo.On("GetTransactions", mock.Anything, userID).Return([]postgres.Transaction{}, errors.New("db error"))
+	mockRepo.On("GetTransactions", mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
 
-	transactions, err := service.GetTransactions(context.Background(), userID)
+	page, err := service.GetTransactions(context.Background(), postgres.TransactionFilter{UserID: userID})
 
 	assert.Error(t, err)
 	assert.Equal(t, "db error", err.Error())
-	assert.Equal(t, []postgres.Transaction{}, transactions)
+	assert.Nil(t, page)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetTransactions_InvalidFilter(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	cursor := &postgres.Cursor{CreatedAt: time.Now(), ID: 1}
+	from := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(-time.Hour)
+	minAmount, maxAmount := money.MustParse("100.00"), money.MustParse("10.00")
+
+	_, err := service.GetTransactions(context.Background(), postgres.TransactionFilter{UserID: 1, Before: cursor, After: cursor})
+	assert.ErrorIs(t, err, ErrConflictingCursors)
+
+	_, err = service.GetTransactions(context.Background(), postgres.TransactionFilter{UserID: 1, From: &from, To: &to})
+	assert.ErrorIs(t, err, ErrInvalidDateRange)
+
+	_, err = service.GetTransactions(context.Background(), postgres.TransactionFilter{UserID: 1, MinAmount: &minAmount, MaxAmount: &maxAmount})
+	assert.ErrorIs(t, err, ErrInvalidAmountRange)
+
+	mockRepo.AssertNotCalled(t, "GetTransactions", mock.Anything, mock.Anything)
+}
+
+func TestGetBalance(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	userID := int64(1)
+	asOf := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+	expected := &postgres.Balance{UserID: userID, Currency: "USD", Balance: money.MustParse("1000.00"), AsOf: &asOf}
+
+	// Ожидаем, что момент времени передаётся в репозиторий без изменений, а код валюты приводится к верхнему регистру
+	mockRepo.On("GetBalance", mock.Anything, userID, money.Currency("USD"), &asOf).Return(expected, nil)
+
+	balance, err := service.GetBalance(context.Background(), userID, "usd", &asOf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, balance)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateUser_UsernameTaken(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	mockRepo.On("CreateUser", mock.Anything, "user1").Return(nil, postgres.ErrUsernameTaken)
+
+	_, err := service.CreateUser(context.Background(), "user1")
+
+	assert.ErrorIs(t, err, postgres.ErrUsernameTaken)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestListUsers_Pagination(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	// Некорректные параметры заменяются значениями по умолчанию и ограничиваются сверху
+	mockRepo.On("ListUsers", mock.Anything, DefaultUsersLimit, 0).Return([]postgres.User{{ID: 1}}, nil).Once()
+	mockRepo.On("ListUsers", mock.Anything, MaxUsersLimit, 40).Return([]postgres.User{}, nil).Once()
+
+	users, err := service.ListUsers(context.Background(), 0, -5)
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+
+	_, err = service.ListUsers(context.Background(), 1000, 40)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestChangeUserStatus(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	userID := int64(1)
+	mockRepo.On("SetUserStatus", mock.Anything, userID, postgres.UserStatusFrozen).
+		Return(&postgres.User{ID: userID, Status: postgres.UserStatusFrozen}, nil)
+	mockRepo.On("SetUserStatus", mock.Anything, userID, postgres.UserStatusActive).
+		Return(&postgres.User{ID: userID, Status: postgres.UserStatusActive}, nil)
+	mockRepo.On("SetUserStatus", mock.Anything, userID, postgres.UserStatusClosed).
+		Return(nil, postgres.ErrAccountNotEmpty)
+
+	user, err := service.FreezeUser(context.Background(), userID)
+	assert.NoError(t, err)
+	assert.Equal(t, postgres.UserStatusFrozen, user.Status)
+
+	user, err = service.UnfreezeUser(context.Background(), userID)
+	assert.NoError(t, err)
+	assert.Equal(t, postgres.UserStatusActive, user.Status)
+
+	_, err = service.CloseUser(context.Background(), userID)
+	assert.ErrorIs(t, err, postgres.ErrAccountNotEmpty)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestReconcile(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	mismatch := postgres.ReconciliationLine{
+		UserID:     2,
+		Username:   "user2",
+		Currency:   "RUB",
+		Balance:    money.MustParse("1500.50"),
+		Expected:   money.MustParse("1500.00"),
+		Difference: money.MustParse("-0.50"),
+	}
+	report := func() *postgres.ReconciliationReport {
+		return &postgres.ReconciliationReport{AccountsChecked: 3, Mismatches: []postgres.ReconciliationLine{mismatch}}
+	}
+	mockRepo.On("Reconcile", mock.Anything).Return(report(), nil).Once()
+	mockRepo.On("Reconcile", mock.Anything).Return(report(), nil).Once()
+
+	// Без fix расхождения только возвращаются
+	result, err := service.Reconcile(context.Background(), false)
+	assert.NoError(t, err)
+	assert.Equal(t, []postgres.ReconciliationLine{mismatch}, result.Mismatches)
+	mockRepo.AssertNotCalled(t, "AdjustBalance", mock.Anything, mock.Anything, mock.Anything)
+
+	// С fix каждое расхождение исправляется
+	adjusted := mismatch
+	adjusted.Adjusted = true
+	mockRepo.On("AdjustBalance", mock.Anything, int64(2), money.Currency("RUB")).Return(&adjusted, nil).Once()
+
+	result, err = service.Reconcile(context.Background(), true)
+	assert.NoError(t, err)
+	assert.True(t, result.Mismatches[0].Adjusted)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWriteReconciliationCSV(t *testing.T) {
+	report := &postgres.ReconciliationReport{Mismatches: []postgres.ReconciliationLine{{
+		UserID:        2,
+		Username:      "user2",
+		Currency:      "RUB",
+		Balance:       money.MustParse("1500.50"),
+		Opening:       money.MustParse("1500.50"),
+		Expected:      money.MustParse("1400.50"),
+		LedgerBalance: money.MustParse("1400.50"),
+		Difference:    money.MustParse("-100.00"),
+	}}}
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteReconciliationCSV(&buf, report))
+	assert.Equal(t, "user_id,username,currency,balance,opening_balance,expected_balance,ledger_balance,difference,adjusted\n"+
+		"2,user2,RUB,1500.50,1500.50,1400.50,1400.50,-100.00,false\n", buf.String())
+}
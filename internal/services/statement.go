@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/EugeneKrivoshein/fin_service/internal/pdf"
+	repo "github.com/EugeneKrivoshein/fin_service/internal/postgres"
+)
+
+// Форматы выписки
+const (
+	StatementFormatCSV   = "csv"
+	StatementFormatJSONL = "jsonl"
+	StatementFormatPDF   = "pdf"
+)
+
+// WriteStatement выводит в w выписку по счёту пользователя в валюте currency (пустая — валюта по умолчанию)
+// за период [from, to) в формате format: начальный баланс, все транзакции периода с балансом после каждой
+// и конечный баланс. Без from выписка начинается с первой транзакции, без to — заканчивается текущим моментом.
+// Ошибки проверки параметров и отсутствие пользователя возвращаются до первой записи в w.
+func (s *Service) WriteStatement(ctx context.Context, w io.Writer, format string, userID int64, currency money.Currency, from, to *time.Time) error {
+	currency, err := resolveCurrency(currency)
+	if err != nil {
+		return err
+	}
+	end := time.Now().UTC()
+	if to != nil {
+		end = *to
+	}
+	if from != nil && !from.Before(end) {
+		return ErrInvalidDateRange
+	}
+
+	var sw repo.StatementWriter
+	switch format {
+	case StatementFormatCSV:
+		sw = &csvStatementWriter{w: csv.NewWriter(w), userID: userID}
+	case StatementFormatJSONL:
+		sw = &jsonlStatementWriter{enc: json.NewEncoder(w)}
+	case StatementFormatPDF:
+		sw = &pdfStatementWriter{pdf: pdf.NewWriter(w), userID: userID}
+	default:
+		return ErrInvalidStatementFormat
+	}
+	return s.repo.StreamStatement(ctx, userID, currency, from, end, sw)
+}
+
+// Контрагент транзакции с точки зрения пользователя userID; nil — у операции его нет
+func counterparty(t *repo.Transaction, userID int64) *int64 {
+	switch {
+	case t.SenderID != nil && *t.SenderID == userID:
+		return t.ReceiverID
+	case t.ReceiverID != nil && *t.ReceiverID == userID:
+		return t.SenderID
+	}
+	return nil
+}
+
+func formatStatementTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// CSV: строка с заголовком, строка opening_balance, транзакции и строка closing_balance
+type csvStatementWriter struct {
+	w      *csv.Writer
+	userID int64
+}
+
+func (c *csvStatementWriter) Begin(s *repo.Statement) error {
+	header := []string{
+		"created_at", "transaction_id", "transaction_type", "counterparty_id", "amount", "currency", "fee", "change", "balance",
+	}
+	if err := c.w.Write(header); err != nil {
+		return err
+	}
+	var from string
+	if s.From != nil {
+		from = formatStatementTime(*s.From)
+	}
+	return c.w.Write([]string{from, "", "opening_balance", "", "", s.Currency.String(), "", "", s.OpeningBalance.String()})
+}
+
+func (c *csvStatementWriter) Line(l *repo.StatementLine) error {
+	var counterpartyID, fee string
+	if id := counterparty(&l.Transaction, c.userID); id != nil {
+		counterpartyID = strconv.FormatInt(*id, 10)
+	}
+	if l.Fee != nil && l.UserID != nil && *l.UserID == c.userID {
+		fee = l.Fee.String()
+	}
+	return c.w.Write([]string{
+		formatStatementTime(l.CreatedAt),
+		strconv.FormatInt(l.ID, 10),
+		l.TransactionType,
+		counterpartyID,
+		l.Amount.String(),
+		l.Currency.String(),
+		fee,
+		l.Change.String(),
+		l.Balance.String(),
+	})
+}
+
+func (c *csvStatementWriter) End(s *repo.Statement) error {
+	err := c.w.Write([]string{formatStatementTime(s.To), "", "closing_balance", "", "", s.Currency.String(), "", "", s.ClosingBalance.String()})
+	if err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// JSON Lines: запись opening с параметрами выписки, по записи transaction на транзакцию и запись closing
+type jsonlStatementWriter struct {
+	enc *json.Encoder
+}
+
+type statementRecord struct {
+	Record string `json:"record"`
+	*repo.Statement
+}
+
+type statementLineRecord struct {
+	Record string `json:"record"`
+	*repo.StatementLine
+}
+
+func (j *jsonlStatementWriter) Begin(s *repo.Statement) error {
+	return j.enc.Encode(statementRecord{Record: "opening", Statement: s})
+}
+
+func (j *jsonlStatementWriter) Line(l *repo.StatementLine) error {
+	return j.enc.Encode(statementLineRecord{Record: "transaction", StatementLine: l})
+}
+
+func (j *jsonlStatementWriter) End(s *repo.Statement) error {
+	return j.enc.Encode(statementRecord{Record: "closing", Statement: s})
+}
+
+// PDF: шапка с периодом и начальным балансом, таблица транзакций и итог. Стандартные шрифты PDF
+// не поддерживают кириллицу, поэтому документ на английском.
+type pdfStatementWriter struct {
+	pdf    *pdf.Writer
+	userID int64
+}
+
+const pdfStatementRow = "%-20s %10s %-10s %12s %18s %18s"
+
+func (p *pdfStatementWriter) Begin(s *repo.Statement) error {
+	from := "account opening"
+	if s.From != nil {
+		from = formatStatementTime(*s.From)
+	}
+	for _, line := range []string{
+		"ACCOUNT STATEMENT",
+		"",
+		fmt.Sprintf("User ID:          %d", s.UserID),
+		fmt.Sprintf("Currency:         %s", s.Currency),
+		fmt.Sprintf("Period:           %s - %s", from, formatStatementTime(s.To)),
+		fmt.Sprintf("Opening balance:  %s", s.OpeningBalance),
+		"",
+		fmt.Sprintf(pdfStatementRow, "Date (UTC)", "ID", "Type", "Counterparty", "Change", "Balance"),
+	} {
+		if err := p.pdf.Line(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *pdfStatementWriter) Line(l *repo.StatementLine) error {
+	var counterpartyID string
+	if id := counterparty(&l.Transaction, p.userID); id != nil {
+		counterpartyID = strconv.FormatInt(*id, 10)
+	}
+	return p.pdf.Line(fmt.Sprintf(pdfStatementRow,
+		formatStatementTime(l.CreatedAt), strconv.FormatInt(l.ID, 10), l.TransactionType, counterpartyID, l.Change, l.Balance))
+}
+
+func (p *pdfStatementWriter) End(s *repo.Statement) error {
+	for _, line := range []string{
+		"",
+		fmt.Sprintf("Transactions:     %d", s.Transactions),
+		fmt.Sprintf("Closing balance:  %s", s.ClosingBalance),
+	} {
+		if err := p.pdf.Line(line); err != nil {
+			return err
+		}
+	}
+	return p.pdf.Close()
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Выписка пользователя 1: пополнение на 100 и перевод 40 пользователю 2 с комиссией 1.50
+func statementFixture() (*postgres.Statement, []postgres.StatementLine) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	user, receiver := int64(1), int64(2)
+	fee := money.MustParse("1.50")
+	s := &postgres.Statement{
+		UserID:         user,
+		Currency:       money.DefaultCurrency,
+		From:           &from,
+		To:             from.AddDate(0, 1, 0),
+		OpeningBalance: money.MustParse("10.00"),
+		ClosingBalance: money.MustParse("68.50"),
+		Transactions:   2,
+	}
+	lines := []postgres.StatementLine{
+		{
+			Transaction: postgres.Transaction{
+				ID: 7, UserID: &user, Amount: money.MustParse("100.00"), Currency: money.DefaultCurrency,
+				TransactionType: "deposit", CreatedAt: from.Add(time.Hour),
+			},
+			Change:  money.MustParse("100.00"),
+			Balance: money.MustParse("110.00"),
+		},
+		{
+			Transaction: postgres.Transaction{
+				ID: 9, UserID: &user, SenderID: &user, ReceiverID: &receiver, Amount: money.MustParse("40.00"),
+				Currency: money.DefaultCurrency, TransactionType: "transfer", CreatedAt: from.Add(2 * time.Hour), Fee: &fee,
+			},
+			Change:  money.MustParse("-41.50"),
+			Balance: money.MustParse("68.50"),
+		},
+	}
+	return s, lines
+}
+
+func TestWriteStatement_CSV(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	s, lines := statementFixture()
+	mockRepo.On("StreamStatement", mock.Anything, int64(1), money.DefaultCurrency, s.From, s.To).Return(s, lines, nil)
+
+	var buf bytes.Buffer
+	err := service.WriteStatement(context.Background(), &buf, StatementFormatCSV, 1, "", s.From, &s.To)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{
+		"created_at,transaction_id,transaction_type,counterparty_id,amount,currency,fee,change,balance",
+		"2025-01-01T00:00:00Z,,opening_balance,,,RUB,,,10.00",
+		"2025-01-01T01:00:00Z,7,deposit,,100.00,RUB,,100.00,110.00",
+		"2025-01-01T02:00:00Z,9,transfer,2,40.00,RUB,1.50,-41.50,68.50",
+		"2025-02-01T00:00:00Z,,closing_balance,,,RUB,,,68.50",
+	}, "\n")+"\n", buf.String())
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWriteStatement_JSONL(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	s, lines := statementFixture()
+	mockRepo.On("StreamStatement", mock.Anything, int64(1), money.DefaultCurrency, s.From, s.To).Return(s, lines, nil)
+
+	var buf bytes.Buffer
+	err := service.WriteStatement(context.Background(), &buf, StatementFormatJSONL, 1, "", s.From, &s.To)
+	require.NoError(t, err)
+
+	records := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, records, 4)
+	var kinds []string
+	for _, r := range records {
+		var record struct {
+			Record string `json:"record"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(r), &record))
+		kinds = append(kinds, record.Record)
+	}
+	assert.Equal(t, []string{"opening", "transaction", "transaction", "closing"}, kinds)
+	assert.Contains(t, records[2], `"change":-41.50,"balance":68.50`)
+	assert.Contains(t, records[3], `"closing_balance":68.50,"transactions":2`)
+}
+
+func TestWriteStatement_PDF(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	s, lines := statementFixture()
+	mockRepo.On("StreamStatement", mock.Anything, int64(1), money.DefaultCurrency, s.From, s.To).Return(s, lines, nil)
+
+	var buf bytes.Buffer
+	err := service.WriteStatement(context.Background(), &buf, StatementFormatPDF, 1, "", s.From, &s.To)
+	require.NoError(t, err)
+	doc := buf.String()
+	assert.True(t, strings.HasPrefix(doc, "%PDF-1.4\n"))
+	assert.Contains(t, doc, "Opening balance:  10.00")
+	assert.Contains(t, doc, "Closing balance:  68.50")
+}
+
+func TestWriteStatement_Validation(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+	ctx := context.Background()
+	from := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, -1)
+
+	var buf bytes.Buffer
+	assert.ErrorIs(t, service.WriteStatement(ctx, &buf, "xlsx", 1, "", nil, nil), ErrInvalidStatementFormat)
+	assert.ErrorIs(t, service.WriteStatement(ctx, &buf, StatementFormatCSV, 1, "", &from, &to), ErrInvalidDateRange)
+	assert.ErrorIs(t, service.WriteStatement(ctx, &buf, StatementFormatCSV, 1, "XXX", nil, nil), money.ErrUnsupportedCurrency)
+	assert.Zero(t, buf.Len())
+	mockRepo.AssertNotCalled(t, "StreamStatement")
+}
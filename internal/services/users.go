@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+
+	repo "github.com/EugeneKrivoshein/fin_service/internal/postgres"
+)
+
+// Ограничения размера страницы при выводе списка пользователей
+const (
+	DefaultUsersLimit = 20
+	MaxUsersLimit     = 100
+)
+
+func (s *Service) CreateUser(ctx context.Context, username string) (*repo.User, error) {
+	return s.repo.CreateUser(ctx, username)
+}
+
+func (s *Service) GetUser(ctx context.Context, userID int64) (*repo.User, error) {
+	return s.repo.GetUser(ctx, userID)
+}
+
+// ListUsers возвращает страницу пользователей; некорректные limit и offset заменяются допустимыми
+func (s *Service) ListUsers(ctx context.Context, limit, offset int) ([]repo.User, error) {
+	if limit <= 0 {
+		limit = DefaultUsersLimit
+	}
+	if limit > MaxUsersLimit {
+		limit = MaxUsersLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.repo.ListUsers(ctx, limit, offset)
+}
+
+// FreezeUser временно запрещает операции по счёту
+func (s *Service) FreezeUser(ctx context.Context, userID int64) (*repo.User, error) {
+	return s.repo.SetUserStatus(ctx, userID, repo.UserStatusFrozen)
+}
+
+// UnfreezeUser снова разрешает операции по замороженному счёту
+func (s *Service) UnfreezeUser(ctx context.Context, userID int64) (*repo.User, error) {
+	return s.repo.SetUserStatus(ctx, userID, repo.UserStatusActive)
+}
+
+// CloseUser окончательно закрывает счёт с нулевым балансом
+func (s *Service) CloseUser(ctx context.Context, userID int64) (*repo.User, error) {
+	return s.repo.SetUserStatus(ctx, userID, repo.UserStatusClosed)
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	repo "github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	"github.com/EugeneKrivoshein/fin_service/internal/webhook"
+)
+
+// Типы событий вебхуков
+const (
+	EventDepositCompleted    = "deposit.completed"
+	EventTransferCompleted   = "transfer.completed"
+	EventWithdrawalCompleted = "withdrawal.completed"
+	EventReversalCompleted   = "reversal.completed"
+	EventAdjustmentCompleted = "adjustment.completed"
+	EventDepositFailed       = "deposit.failed"
+	EventTransferFailed      = "transfer.failed"
+	EventWithdrawalFailed    = "withdrawal.failed"
+	EventBalanceUpdated      = "balance.updated"
+)
+
+// WebhookEventTypes — все типы событий, на которые можно подписаться
+var WebhookEventTypes = []string{
+	EventDepositCompleted, EventTransferCompleted, EventWithdrawalCompleted, EventReversalCompleted, EventAdjustmentCompleted,
+	EventDepositFailed, EventTransferFailed, EventWithdrawalFailed,
+	EventBalanceUpdated,
+}
+
+// Параметры доставки вебхуков
+const (
+	// DefaultWebhookTimeout — время ожидания ответа получателя
+	DefaultWebhookTimeout = 10 * time.Second
+	// MaxWebhookAttempts — число попыток, после которого доставка переходит в статус dead
+	MaxWebhookAttempts = 10
+
+	webhookRetryBase = 30 * time.Second
+	webhookRetryMax  = 6 * time.Hour
+	webhookBatchSize = 50
+	// Доставка, взятая в работу, не достанется другому экземпляру в течение аренды
+	webhookLease = 2 * time.Minute
+	// Длина сохраняемого текста ошибки доставки, в символах
+	maxWebhookErrorLength = 500
+)
+
+// Ограничения размера страницы истории доставок
+const (
+	DefaultWebhookDeliveriesLimit = 50
+	MaxWebhookDeliveriesLimit     = 200
+)
+
+// Отказы в операции, о которых сообщают события *.failed. Остальные ошибки (валидация запроса,
+// сбои БД) событий не порождают.
+var declineErrors = []error{
+	repo.ErrInsufficientFunds,
+	repo.ErrLimitExceeded,
+	repo.ErrAccountFrozen,
+	repo.ErrAccountClosed,
+	repo.ErrUserNotFound,
+	repo.ErrSenderNotFound,
+	repo.ErrReceiverNotFound,
+	repo.ErrCurrencyAccountNotFound,
+	repo.ErrCurrencyMismatch,
+	repo.ErrQuoteNotFound,
+	repo.ErrQuoteExpired,
+	repo.ErrQuoteUsed,
+	repo.ErrConvertedAmountTooSmall,
+}
+
+// FailedOperation — данные события об отклонённой операции
+type FailedOperation struct {
+	Operation  string         `json:"operation" example:"transfer"`
+	UserID     *int64         `json:"user_id,omitempty"`
+	SenderID   *int64         `json:"sender_id,omitempty"`
+	ReceiverID *int64         `json:"receiver_id,omitempty"`
+	Amount     money.Amount   `json:"amount" swaggertype:"number" example:"100.50"`
+	Currency   money.Currency `json:"currency,omitempty" swaggertype:"string" example:"RUB"`
+	FXQuoteID  *string        `json:"fx_quote_id,omitempty"`
+	Error      string         `json:"error" example:"insufficient funds"`
+}
+
+// Конверт, в котором событие отправляется получателю
+type webhookEnvelope struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// WithWebhooks включает доставку вебхуков: RunWebhookWorker отправляет события через sender, а отклонённые
+// операции ставят в очередь события *.failed. События о проведённых транзакциях записывает хранилище
+// в транзакции операции, независимо от этой опции.
+func WithWebhooks(sender *webhook.Sender) Option {
+	return func(s *Service) {
+		s.webhooks = sender
+	}
+}
+
+// CreateWebhookSubscription подписывает адрес rawURL на события eventTypes.
+// Возвращённая подписка содержит секрет подписи запросов — он показывается только один раз.
+func (s *Service) CreateWebhookSubscription(ctx context.Context, rawURL string, eventTypes []string) (*repo.WebhookSubscription, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrInvalidWebhookURL
+	}
+	if len(eventTypes) == 0 {
+		return nil, fmt.Errorf("%w: at least one event type is required", ErrUnknownEventType)
+	}
+	types := make([]string, 0, len(eventTypes))
+	for _, t := range eventTypes {
+		if !slices.Contains(WebhookEventTypes, t) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownEventType, t)
+		}
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return s.repo.CreateWebhookSubscription(ctx, &repo.WebhookSubscription{URL: u.String(), EventTypes: types, Secret: secret})
+}
+
+// ListWebhookSubscriptions возвращает подписки без секретов
+func (s *Service) ListWebhookSubscriptions(ctx context.Context) ([]repo.WebhookSubscription, error) {
+	return s.repo.ListWebhookSubscriptions(ctx)
+}
+
+// DeleteWebhookSubscription удаляет подписку; недоставленные ей события больше не отправляются
+func (s *Service) DeleteWebhookSubscription(ctx context.Context, id int64) error {
+	return s.repo.DeleteWebhookSubscription(ctx, id)
+}
+
+// ListWebhookDeliveries возвращает последние доставки подписки; status (если не пуст) — фильтр по статусу
+func (s *Service) ListWebhookDeliveries(ctx context.Context, subscriptionID int64, status string, limit int) ([]repo.WebhookDelivery, error) {
+	switch status {
+	case "", repo.DeliveryStatusPending, repo.DeliveryStatusDelivered, repo.DeliveryStatusDead:
+	default:
+		return nil, ErrInvalidDeliveryStatus
+	}
+	if limit <= 0 {
+		limit = DefaultWebhookDeliveriesLimit
+	}
+	if limit > MaxWebhookDeliveriesLimit {
+		limit = MaxWebhookDeliveriesLimit
+	}
+	return s.repo.ListWebhookDeliveries(ctx, subscriptionID, status, limit)
+}
+
+// RedeliverWebhook повторно ставит в очередь доставленное или исчерпавшее попытки событие
+func (s *Service) RedeliverWebhook(ctx context.Context, deliveryID int64) (*repo.WebhookDelivery, error) {
+	return s.repo.RedeliverWebhook(ctx, deliveryID)
+}
+
+// Ставит в очередь событие eventType об отклонённой операции op, если err — отказ в операции
+func (s *Service) notifyFailed(ctx context.Context, eventType string, op FailedOperation, err error) {
+	if s.webhooks == nil || !slices.ContainsFunc(declineErrors, func(d error) bool { return errors.Is(err, d) }) {
+		return
+	}
+	op.Error = err.Error()
+	s.enqueueEvent(context.WithoutCancel(ctx), eventType, op)
+}
+
+func (s *Service) enqueueEvent(ctx context.Context, eventType string, data any) {
+	payload, err := json.Marshal(data)
+	if err == nil {
+		_, err = s.repo.EnqueueWebhookEvent(ctx, eventType, payload)
+	}
+	if err != nil {
+		log.Printf("Ошибка записи события вебхука %s: %v", eventType, err)
+	}
+}
+
+// RunWebhookWorker каждые interval отправляет события, время доставки которых наступило, пока не отменён ctx.
+// Несколько экземпляров сервиса могут работать одновременно: каждую доставку берёт только один из них.
+func (s *Service) RunWebhookWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.deliverWebhooks(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Ошибка доставки вебхуков: %v", err)
+			}
+		}
+	}
+}
+
+// Отправляет очередную пачку наступивших доставок и возвращает число успешных
+func (s *Service) deliverWebhooks(ctx context.Context) (int, error) {
+	if s.webhooks == nil {
+		return 0, nil
+	}
+	messages, err := s.repo.ClaimWebhookDeliveries(ctx, webhookBatchSize, webhookLease)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		delivered int
+	)
+	for _, m := range messages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := s.deliverWebhook(ctx, m)
+			if err != nil {
+				log.Printf("Ошибка записи результата доставки вебхука %d: %v", m.DeliveryID, err)
+			}
+			if ok {
+				mu.Lock()
+				delivered++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return delivered, nil
+}
+
+// Делает одну попытку доставки и записывает её результат: при неудаче назначает повтор
+// с экспоненциальной задержкой, а после MaxWebhookAttempts попыток переводит доставку в dead
+func (s *Service) deliverWebhook(ctx context.Context, m repo.WebhookMessage) (bool, error) {
+	body, err := json.Marshal(webhookEnvelope{ID: m.EventID, Type: m.EventType, CreatedAt: m.EventCreatedAt, Data: m.Payload})
+	if err != nil {
+		return false, err
+	}
+	status, sendErr := s.webhooks.Send(ctx, webhook.Request{
+		URL:        m.URL,
+		Secret:     m.Secret,
+		Event:      m.EventType,
+		DeliveryID: m.DeliveryID,
+		Body:       body,
+	})
+	if ctx.Err() != nil {
+		// Сервис останавливается: доставку повторит следующий запуск после истечения аренды
+		return false, nil
+	}
+
+	var attempt repo.WebhookAttempt
+	if status != 0 {
+		attempt.StatusCode = &status
+	}
+	if sendErr != nil {
+		msg := []rune(sendErr.Error())
+		if len(msg) > maxWebhookErrorLength {
+			msg = msg[:maxWebhookErrorLength]
+		}
+		text := string(msg)
+		attempt.Error = &text
+		if attempts := m.Attempts + 1; attempts < MaxWebhookAttempts {
+			delay := webhookRetryDelay(attempts)
+			attempt.RetryAfter = &delay
+		}
+	}
+	if err := s.repo.RecordWebhookAttempt(ctx, m.DeliveryID, attempt); err != nil {
+		return false, err
+	}
+	return sendErr == nil, nil
+}
+
+// Задержка перед повтором после attempts неудачных попыток: 30 секунд, минута, две и т.д., не больше 6 часов
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := webhookRetryBase
+	for i := 1; i < attempts && delay < webhookRetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, webhookRetryMax)
+}
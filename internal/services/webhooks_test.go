@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EugeneKrivoshein/fin_service/internal/money"
+	"github.com/EugeneKrivoshein/fin_service/internal/postgres"
+	"github.com/EugeneKrivoshein/fin_service/internal/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateWebhookSubscription(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	mockRepo.On("CreateWebhookSubscription", mock.Anything, mock.MatchedBy(func(s *postgres.WebhookSubscription) bool {
+		return s.URL == "https://example.com/hook" &&
+			assert.ObjectsAreEqual([]string{EventTransferCompleted, EventTransferFailed}, s.EventTypes) &&
+			strings.HasPrefix(s.Secret, "whsec_")
+	})).Return(&postgres.WebhookSubscription{ID: 1}, nil)
+
+	sub, err := service.CreateWebhookSubscription(context.Background(), "https://example.com/hook",
+		[]string{EventTransferCompleted, EventTransferFailed, EventTransferCompleted})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), sub.ID)
+
+	_, err = service.CreateWebhookSubscription(context.Background(), "ftp://example.com/hook", []string{EventTransferCompleted})
+	assert.ErrorIs(t, err, ErrInvalidWebhookURL)
+	_, err = service.CreateWebhookSubscription(context.Background(), "/hook", []string{EventTransferCompleted})
+	assert.ErrorIs(t, err, ErrInvalidWebhookURL)
+	_, err = service.CreateWebhookSubscription(context.Background(), "https://example.com/hook", []string{"transfer.created"})
+	assert.ErrorIs(t, err, ErrUnknownEventType)
+	_, err = service.CreateWebhookSubscription(context.Background(), "https://example.com/hook", nil)
+	assert.ErrorIs(t, err, ErrUnknownEventType)
+
+	mockRepo.AssertNumberOfCalls(t, "CreateWebhookSubscription", 1)
+}
+
+// События о проведённой операции записывает хранилище в её транзакции, сервис их не дублирует
+func TestDeposit_DoesNotEnqueueCompletedEvents(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, WithWebhooks(webhook.NewSender(time.Second)))
+
+	userID := int64(1)
+	amount := money.MustParse("100.00")
+	tx := &postgres.Transaction{ID: 7, UserID: &userID, Amount: amount, Currency: money.DefaultCurrency, TransactionType: "deposit"}
+
+	mockRepo.On("Deposit", mock.Anything, userID, amount, money.DefaultCurrency, (*postgres.IdempotencyKey)(nil)).Return(tx, nil)
+
+	got, err := service.Deposit(context.Background(), userID, amount, "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, tx, got)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "EnqueueWebhookEvent", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTransfer_DeclineEnqueuesFailedEvent(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, WithWebhooks(webhook.NewSender(time.Second)))
+
+	amount := money.MustParse("100.00")
+	mockRepo.On("Transfer", mock.Anything, int64(1), int64(2), amount, money.DefaultCurrency, (*postgres.IdempotencyKey)(nil)).
+		Return(nil, postgres.ErrInsufficientFunds).Once()
+	mockRepo.On("EnqueueWebhookEvent", mock.Anything, EventTransferFailed, mock.MatchedBy(func(p []byte) bool {
+		var got FailedOperation
+		return json.Unmarshal(p, &got) == nil && *got.SenderID == 1 && got.Error == "insufficient funds"
+	})).Return(int64(1), nil).Once()
+
+	_, err := service.Transfer(context.Background(), 1, 2, amount, "", "")
+	assert.ErrorIs(t, err, postgres.ErrInsufficientFunds)
+
+	// Сбой хранилища — не отказ в операции: события нет
+	mockRepo.On("Transfer", mock.Anything, int64(1), int64(2), amount, money.DefaultCurrency, (*postgres.IdempotencyKey)(nil)).
+		Return(nil, errors.New("connection refused")).Once()
+	_, err = service.Transfer(context.Background(), 1, 2, amount, "", "")
+	assert.Error(t, err)
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNumberOfCalls(t, "EnqueueWebhookEvent", 1)
+}
+
+func TestDeliverWebhooks(t *testing.T) {
+	const secret = "whsec_test"
+	var received struct {
+		header http.Header
+		body   []byte
+	}
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.header = r.Header.Clone()
+		received.body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, WithWebhooks(webhook.NewSender(time.Second)))
+
+	createdAt := time.Date(2025, 4, 15, 12, 0, 0, 0, time.UTC)
+	mockRepo.On("ClaimWebhookDeliveries", mock.Anything, webhookBatchSize, webhookLease).Return([]postgres.WebhookMessage{{
+		DeliveryID:     5,
+		URL:            receiver.URL,
+		Secret:         secret,
+		EventID:        3,
+		EventType:      EventTransferCompleted,
+		Payload:        json.RawMessage(`{"id":7}`),
+		EventCreatedAt: createdAt,
+	}}, nil)
+	status := http.StatusNoContent
+	mockRepo.On("RecordWebhookAttempt", mock.Anything, int64(5), postgres.WebhookAttempt{StatusCode: &status}).Return(nil)
+
+	delivered, err := service.deliverWebhooks(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	mockRepo.AssertExpectations(t)
+
+	assert.Equal(t, EventTransferCompleted, received.header.Get(webhook.HeaderEvent))
+	assert.Equal(t, "5", received.header.Get(webhook.HeaderDelivery))
+	assert.NoError(t, webhook.Verify(secret, received.header.Get(webhook.HeaderSignature), received.body, time.Now(), time.Minute))
+
+	var envelope webhookEnvelope
+	require.NoError(t, json.Unmarshal(received.body, &envelope))
+	assert.Equal(t, int64(3), envelope.ID)
+	assert.Equal(t, EventTransferCompleted, envelope.Type)
+	assert.True(t, createdAt.Equal(envelope.CreatedAt))
+	assert.JSONEq(t, `{"id":7}`, string(envelope.Data))
+}
+
+func TestDeliverWebhooks_Failure(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	status := http.StatusInternalServerError
+	tests := []struct {
+		name      string
+		attempts  int
+		wantRetry *time.Duration
+	}{
+		{"первая неудача", 0, durationPtr(30 * time.Second)},
+		{"третья неудача", 2, durationPtr(2 * time.Minute)},
+		{"последняя попытка", MaxWebhookAttempts - 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := NewService(mockRepo, WithWebhooks(webhook.NewSender(time.Second)))
+
+			mockRepo.On("ClaimWebhookDeliveries", mock.Anything, webhookBatchSize, webhookLease).Return([]postgres.WebhookMessage{{
+				DeliveryID: 5,
+				Attempts:   tt.attempts,
+				URL:        receiver.URL,
+				EventType:  EventTransferCompleted,
+				Payload:    json.RawMessage(`{}`),
+			}}, nil)
+			mockRepo.On("RecordWebhookAttempt", mock.Anything, int64(5), mock.MatchedBy(func(a postgres.WebhookAttempt) bool {
+				return a.StatusCode != nil && *a.StatusCode == status && a.Error != nil &&
+					assert.ObjectsAreEqual(tt.wantRetry, a.RetryAfter)
+			})).Return(nil)
+
+			delivered, err := service.deliverWebhooks(context.Background())
+			require.NoError(t, err)
+			assert.Zero(t, delivered)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWebhookRetryDelay(t *testing.T) {
+	assert.Equal(t, 30*time.Second, webhookRetryDelay(1))
+	assert.Equal(t, time.Minute, webhookRetryDelay(2))
+	assert.Equal(t, 4*time.Minute, webhookRetryDelay(4))
+	assert.Equal(t, webhookRetryMax, webhookRetryDelay(20))
+	assert.Equal(t, webhookRetryMax, webhookRetryDelay(1000))
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
@@ -0,0 +1,131 @@
+// Пакет webhook — подпись и отправка HTTP-уведомлений (вебхуков) о событиях сервиса.
+//
+// Каждый запрос подписывается HMAC-SHA256 секретом подписки. Заголовок подписи имеет вид
+// "t=<unix-время>,v1=<hex(HMAC(secret, "<t>.<тело запроса>"))>": время входит в подпись, поэтому
+// получатель может отклонять перехваченные и повторно отправленные запросы (см. Verify).
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Заголовки запроса вебхука
+const (
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrSignatureExpired = errors.New("webhook signature timestamp is outside the tolerance")
+)
+
+// NewSecret создаёт случайный секрет подписки
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Sign возвращает значение заголовка подписи тела body, отправленного в момент t
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + mac(secret, ts, body)
+}
+
+// Verify проверяет заголовок подписи header для тела body. Подпись, созданная раньше или позже now
+// более чем на tolerance, отклоняется с ErrSignatureExpired; нулевой tolerance отключает эту проверку.
+func Verify(secret, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(part, "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sig = v
+		}
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || sig == "" {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(sig), []byte(mac(secret, ts, body))) {
+		return ErrInvalidSignature
+	}
+	if tolerance > 0 {
+		if d := now.Sub(time.Unix(unix, 0)); d > tolerance || d < -tolerance {
+			return ErrSignatureExpired
+		}
+	}
+	return nil
+}
+
+func mac(secret, ts string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(ts))
+	h.Write([]byte("."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Request — одна попытка доставки события
+type Request struct {
+	URL        string
+	Secret     string
+	Event      string
+	DeliveryID int64
+	Body       []byte
+}
+
+// Sender отправляет подписанные запросы вебхуков
+type Sender struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewSender создаёт отправителя; запросы прерываются через timeout
+func NewSender(timeout time.Duration) *Sender {
+	return &Sender{client: &http.Client{Timeout: timeout}, now: time.Now}
+}
+
+// Send отправляет POST-запрос с телом req.Body и возвращает HTTP-статус ответа. Доставка считается
+// успешной только при статусе 2xx; иначе возвращается ошибка (вместе со статусом, если ответ получен).
+func (s *Sender) Send(ctx context.Context, req Request) (int, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return 0, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set(HeaderEvent, req.Event)
+	httpReq.Header.Set(HeaderDelivery, strconv.FormatInt(req.DeliveryID, 10))
+	httpReq.Header.Set(HeaderSignature, Sign(req.Secret, s.now(), req.Body))
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Тело ответа вычитывается, чтобы соединение можно было переиспользовать
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
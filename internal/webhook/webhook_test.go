@@ -0,0 +1,52 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignVerify(t *testing.T) {
+	now := time.Unix(1743500000, 0)
+	body := []byte(`{"type":"transfer.completed"}`)
+	header := Sign("secret", now, body)
+
+	assert.NoError(t, Verify("secret", header, body, now.Add(time.Minute), 5*time.Minute))
+	assert.ErrorIs(t, Verify("other", header, body, now, 0), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify("secret", header, []byte(`{}`), now, 0), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify("secret", "v1=abc", body, now, 0), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify("secret", header, body, now.Add(10*time.Minute), 5*time.Minute), ErrSignatureExpired)
+}
+
+func TestSender_Send(t *testing.T) {
+	var received *http.Request
+	var receivedBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		if r.Header.Get(HeaderEvent) == "fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	s := NewSender(time.Second)
+	body := []byte(`{"id":1}`)
+	status, err := s.Send(context.Background(), Request{URL: srv.URL, Secret: "secret", Event: "deposit.completed", DeliveryID: 7, Body: body})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, body, receivedBody)
+	assert.Equal(t, "7", received.Header.Get(HeaderDelivery))
+	assert.NoError(t, Verify("secret", received.Header.Get(HeaderSignature), receivedBody, time.Now(), time.Minute))
+
+	// Ответ не 2xx — неудачная доставка
+	status, err = s.Send(context.Background(), Request{URL: srv.URL, Secret: "secret", Event: "fail", Body: body})
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+}